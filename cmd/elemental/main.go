@@ -39,6 +39,8 @@ func main() {
 		cmd.NewInitCommand(appName, action.Init),
 		cmd.NewVersionCommand(appName),
 		cmd.NewReleaseInfoCommand(appName, action.ReleaseInfo),
+		cmd.NewReleaseDiffCommand(appName, action.ReleaseDiff),
+		cmd.NewBundleCommand(appName, action.BundleExport),
 	)
 
 	if err := application.Run(context.Background(), os.Args); err != nil {
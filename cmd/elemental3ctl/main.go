@@ -40,6 +40,27 @@ func main() {
 		cmd.NewUnpackImageCommand(appName, action.Unpack),
 		cmd.NewBuildInstallerCommand(appName, action.BuildInstaller),
 		cmd.NewResetCommand(appName, action.Reset),
+		cmd.NewSnapshotCommand(appName, cmd.SnapshotActions{
+			List:       action.SnapshotList,
+			Show:       action.SnapshotShow,
+			Rollback:   action.SnapshotRollback,
+			Delete:     action.SnapshotDelete,
+			SetDefault: action.SnapshotSetDefault,
+		}),
+		cmd.NewBootCommand(appName, cmd.BootActions{
+			MarkGood: action.BootMarkGood,
+		}),
+		cmd.NewStatusCommand(appName, action.Status),
+		cmd.NewExtensionCommand(appName, cmd.ExtensionActions{
+			List:   action.ExtensionList,
+			Add:    action.ExtensionAdd,
+			Update: action.ExtensionUpdate,
+			Remove: action.ExtensionRemove,
+		}),
+		cmd.NewKubernetesCommand(appName, cmd.KubernetesActions{
+			Upgrade:  action.KubernetesUpgrade,
+			Uncordon: action.KubernetesUncordon,
+		}),
 		cmd.NewVersionCommand(appName))
 
 	if err := application.Run(context.Background(), os.Args); err != nil {
@@ -95,6 +95,9 @@ func (b *Builder) Run(ctx context.Context, d *image.Definition, output config.Ou
 		logger.Error("Preparing installation setup failed")
 		return err
 	}
+	if md := rm.Metadata(); md != nil {
+		dep.Release = &deployment.Release{Name: md.Name, Version: md.Version}
+	}
 
 	boot, err := bootloader.New(dep.BootConfig.Bootloader, b.System)
 	if err != nil {
@@ -144,11 +147,16 @@ func newDeployment(
 		deploymentOpts = append(deploymentOpts, deployment.WithConfigPartition(deployment.MiB(configSize)))
 	}
 
+	if installation.BIOS {
+		deploymentOpts = append(deploymentOpts, deployment.WithBIOSPartition())
+	}
+
 	d := deployment.New(deploymentOpts...)
 
 	d.Disks[0].Device = installationDevice
 	d.BootConfig.Bootloader = installation.Bootloader
 	d.BootConfig.KernelCmdline = installation.KernelCmdLine
+	d.BootConfig.BIOS = installation.BIOS
 	d.Security.CryptoPolicy = installation.CryptoPolicy
 
 	if d.IsFipsEnabled() {
@@ -0,0 +1,75 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"context"
+	"fmt"
+	"os/signal"
+	"strconv"
+	"syscall"
+
+	"github.com/urfave/cli/v3"
+)
+
+// BootMarkGood clears the boot assessment of the current boot. If the system booted the fallback
+// snapshot because the assessment of the default snapshot failed, the fallback snapshot is set as
+// the default one.
+func BootMarkGood(ctx context.Context, cmd *cli.Command) error {
+	ctxCancel, stop := signal.NotifyContext(ctx, syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	setup, err := digestSnapshotSetup(ctxCancel, cmd)
+	if err != nil {
+		return err
+	}
+
+	fallback, err := setup.b.MarkGood(setup.espDir)
+	if err != nil {
+		setup.s.Logger().Error("Failed marking boot as good")
+		return err
+	} else if fallback == "" {
+		return nil
+	}
+
+	fallbackID, err := strconv.Atoi(fallback)
+	if err != nil {
+		return fmt.Errorf("invalid fallback boot entry '%s'", fallback)
+	}
+
+	snaps, err := setup.t.ListSnapshots()
+	if err != nil {
+		setup.s.Logger().Error("Failed to list snapshots")
+		return err
+	}
+
+	for _, snap := range snaps {
+		if snap.Active && snap.ID == fallbackID && !snap.Default {
+			setup.s.Logger().Warn("Default snapshot failed to boot, setting snapshot %d as default", fallbackID)
+			err = setDefaultSnapshot(setup, snaps, fallbackID)
+			if err != nil {
+				setup.s.Logger().Error("Failed falling back to snapshot %d", fallbackID)
+				return err
+			}
+			return nil
+		}
+	}
+
+	setup.s.Logger().Info("Boot marked as good")
+	return nil
+}
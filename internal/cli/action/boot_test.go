@@ -0,0 +1,147 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action_test
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/suse/elemental/v3/internal/cli/action"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+const snapperFallbackList = `{
+	"root": [
+	  {
+		"number": 2,
+		"default": false,
+		"active": true
+	  },{
+		"number": 3,
+		"default": true,
+		"active": false
+	  }
+	]
+  }
+`
+
+var _ = Describe("Boot actions", Label("boot"), func() {
+	var s *sys.System
+	var tfs vfs.FS
+	var cleanup func()
+	var err error
+	var cliCmd *cli.Command
+	var runner *sysmock.Runner
+	var snapperList string
+
+	BeforeEach(func() {
+		snapperList = snapperRootList
+		tfs, cleanup, err = sysmock.TestFS(map[string]string{
+			"/boot/grubenv":          "entries=active 3 2\nboot_tries_left=0\nboot_fallback=2",
+			"/boot/loader/entries/2": "display_name=OS (2)\ncmdline=snapshot2",
+			"/boot/loader/entries/3": "display_name=OS (3)\ncmdline=snapshot3",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		runner = sysmock.NewRunner()
+		mounter := sysmock.NewMounter()
+		s, err = sys.NewSystem(
+			sys.WithFS(tfs), sys.WithRunner(runner), sys.WithMounter(mounter),
+			sys.WithLogger(log.New(log.WithBuffer(&bytes.Buffer{}))),
+		)
+		Expect(err).NotTo(HaveOccurred())
+
+		d := deployment.DefaultDeployment()
+		d.Disks[0].Partitions[0].UUID = "c60d1845-7b04-4fc4-8639-8c49eb7277d5"
+		d.Disks[0].Partitions[1].UUID = "34a8abb8-ddb3-48a2-8ecc-2443e92c7510"
+		d.BootConfig.Bootloader = "grub"
+		d.SourceOS = deployment.NewOCISrc("registry.org/my/os:1.0")
+		Expect(d.WriteDeploymentFile(s, "/")).To(Succeed())
+
+		Expect(mounter.Mount("/dev/sda2", "/", "btrfs", []string{"ro", "subvol=@/.snapshots/2/snapshot"})).To(Succeed())
+		runner.SideEffect = func(command string, args ...string) ([]byte, error) {
+			switch command {
+			case "lsblk":
+				return []byte(snapshotsLsblkJson), nil
+			case "snapper":
+				if slices.Contains(args, "list") {
+					return []byte(snapperList), nil
+				}
+			case "grub2-editenv":
+				switch args[1] {
+				case "list":
+					return tfs.ReadFile(args[0])
+				case "set":
+					return nil, tfs.WriteFile(args[0], []byte(strings.Join(args[2:], "\n")), vfs.FilePerm)
+				case "unset":
+					return nil, tfs.WriteFile(args[0], []byte("entries=active 3 2"), vfs.FilePerm)
+				}
+			}
+			return []byte{}, nil
+		}
+
+		cliCmd = &cli.Command{
+			Metadata: map[string]any{
+				"system": s,
+			},
+		}
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+	It("fails if no sys.System instance is in metadata", func() {
+		cliCmd.Metadata["system"] = nil
+		Expect(action.BootMarkGood(context.Background(), cliCmd)).NotTo(Succeed())
+	})
+	It("clears the boot assessment of a healthy default snapshot", func() {
+		Expect(action.BootMarkGood(context.Background(), cliCmd)).To(Succeed())
+		Expect(runner.IncludesCmds([][]string{
+			{"grub2-editenv", "/boot/grubenv", "unset", "boot_tries_left", "boot_fallback"},
+		})).To(Succeed())
+		Expect(runner.IncludesCmds([][]string{
+			{"snapper", "--no-dbus", "modify", "--default"},
+		})).NotTo(Succeed())
+	})
+	It("sets the fallback snapshot as default after a failed boot assessment", func() {
+		snapperList = snapperFallbackList
+		Expect(action.BootMarkGood(context.Background(), cliCmd)).To(Succeed())
+		Expect(runner.MatchMilestones([][]string{
+			{"grub2-editenv", "/boot/grubenv", "unset", "boot_tries_left", "boot_fallback"},
+			{"snapper", "--no-dbus", "modify", "--default", "2"},
+			{"grub2-editenv", "/boot/loader/entries/active", "set"},
+		})).To(Succeed())
+		active, err := tfs.ReadFile("/boot/loader/entries/active")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(strings.Split(string(active), "\n")).To(ContainElements("cmdline=snapshot2", "target_id=2"))
+	})
+	It("does nothing if no boot assessment is in progress", func() {
+		Expect(tfs.WriteFile("/boot/grubenv", []byte("entries=active 3 2"), vfs.FilePerm)).To(Succeed())
+		Expect(action.BootMarkGood(context.Background(), cliCmd)).To(Succeed())
+		Expect(runner.IncludesCmds([][]string{{"grub2-editenv", "/boot/grubenv", "unset"}})).NotTo(Succeed())
+	})
+})
@@ -26,6 +26,7 @@ import (
 	"github.com/urfave/cli/v3"
 
 	cmdpkg "github.com/suse/elemental/v3/internal/cli/cmd"
+	"github.com/suse/elemental/v3/pkg/artifact"
 	"github.com/suse/elemental/v3/pkg/bootloader"
 	"github.com/suse/elemental/v3/pkg/deployment"
 	"github.com/suse/elemental/v3/pkg/installer"
@@ -51,6 +52,12 @@ func BuildInstaller(ctx context.Context, cmd *cli.Command) error {
 		stop()
 	}()
 
+	if args.Push != "" {
+		if _, err := artifact.ParseReference(args.Push, args.Verify); err != nil {
+			return fmt.Errorf("invalid push reference: %w", err)
+		}
+	}
+
 	media, err := digestInstallerMedia(ctxCancel, s, args)
 	if err != nil {
 		return fmt.Errorf("bad installer media setup: %w", err)
@@ -68,11 +75,43 @@ func BuildInstaller(ctx context.Context, cmd *cli.Command) error {
 		return fmt.Errorf("failed building installer media: %w", err)
 	}
 
+	if args.Push != "" {
+		err = pushInstallerMedia(ctxCancel, s, args, media, d)
+		if err != nil {
+			return fmt.Errorf("failed pushing installer media: %w", err)
+		}
+	}
+
 	s.Logger().Info("Build complete")
 
 	return nil
 }
 
+// pushInstallerMedia uploads the built installer media and its deployment description
+// as an OCI artifact
+func pushInstallerMedia(
+	ctx context.Context, s *sys.System, flags *cmdpkg.InstallerFlags, media *installer.Media, d *deployment.Deployment,
+) error {
+	desc, err := d.MarshalDescription()
+	if err != nil {
+		return err
+	}
+
+	s.Logger().Info("Pushing installer media to %s", flags.Push)
+	publisher := artifact.NewPublisher(s, artifact.WithContext(ctx), artifact.WithVerify(flags.Verify))
+	digest, err := publisher.Push(flags.Push, &artifact.Artifact{
+		Image:       media.OutputFile(),
+		Deployment:  desc,
+		Annotations: map[string]string{artifact.MediaTypeAnnotation: flags.Type},
+	})
+	if err != nil {
+		return err
+	}
+
+	s.Logger().Info("Installer media pushed as %s", digest)
+	return nil
+}
+
 func digestInstallerDeploymentSetup(s *sys.System, flags *cmdpkg.InstallerFlags) (*deployment.Deployment, error) {
 	// Recovery partition size will be determined during the build
 	d := deployment.New(deployment.WithRecoveryPartition(0))
@@ -115,9 +154,17 @@ func digestInstallerMedia(ctx context.Context, s *sys.System, flags *cmdpkg.Inst
 		return nil, err
 	}
 
+	verifier, err := setupSignatureVerifier(s, flags.SignaturePolicy, flags.Verify)
+	if err != nil {
+		return nil, err
+	}
+
 	media := installer.NewMedia(
 		ctx, s, mType,
-		installer.WithUnpackOpts(unpack.WithLocal(flags.Local), unpack.WithVerify(flags.Verify)),
+		installer.WithUnpackOpts(
+			unpack.WithLocal(flags.Local), unpack.WithVerify(flags.Verify), unpack.WithSignatureVerifier(verifier),
+		),
+		installer.WithNetbootURL(flags.NetbootURL),
 	)
 
 	if flags.Name != "" {
@@ -0,0 +1,115 @@
+/*
+Copyright © 2022-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"context"
+	"fmt"
+
+	containerregistry "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/urfave/cli/v3"
+
+	cmdpkg "github.com/suse/elemental/v3/internal/cli/cmd"
+	"github.com/suse/elemental/v3/internal/config"
+	"github.com/suse/elemental/v3/pkg/bundle"
+	"github.com/suse/elemental/v3/pkg/signature"
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/platform"
+	"github.com/suse/elemental/v3/pkg/unpack"
+)
+
+func BundleExport(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Root().Metadata == nil || cmd.Root().Metadata["system"] == nil {
+		return fmt.Errorf("error setting up initial configuration")
+	}
+	system := cmd.Root().Metadata["system"].(*sys.System)
+	logger := system.Logger()
+	fs := system.FS()
+	args := &cmdpkg.BundleExportArgs
+
+	logger.Debug("bundle export called with args: %+v", args)
+
+	p, err := platform.Parse(args.Platform)
+	if err != nil {
+		return fmt.Errorf("error parsing platform %s", args.Platform)
+	}
+
+	conf, err := config.Parse(fs, args.ConfigDir)
+	if err != nil {
+		return fmt.Errorf("parsing configuration directory %s: %w", args.ConfigDir, err)
+	}
+
+	output, err := config.NewOutput(fs, "", "")
+	if err != nil {
+		logger.Error("Creating working directory failed")
+		return err
+	}
+	defer func() {
+		logger.Debug("Cleaning up working directory")
+		if rmErr := output.Cleanup(fs); rmErr != nil {
+			logger.Error("Cleaning up working directory failed: %v", rmErr)
+		}
+	}()
+
+	b, err := bundle.Create(system, args.OutputPath, bundle.WithContext(ctx))
+	if err != nil {
+		logger.Error("Creating offline bundle failed")
+		return err
+	}
+
+	// record the manifests and signatures of verified images, so they are verified again on import
+	verifier, err := setupSignatureVerifier(
+		system, args.SignaturePolicy, true, signature.WithRegistry(b.Recorder(signature.NewRemoteRegistry())),
+	)
+	if err != nil {
+		_ = b.Discard()
+		return err
+	}
+
+	manager := config.NewManager(
+		system, nil,
+		config.WithLocal(args.Local),
+		config.WithSignatureVerifier(verifier),
+		config.WithPullImageFunc(func(ctx context.Context, imageRef string) (containerregistry.Image, error) {
+			unpacker := unpack.NewOCIUnpacker(
+				system, imageRef, unpack.WithLocalOCI(args.Local), unpack.WithSignatureVerifierOCI(verifier),
+				unpack.WithPlatformRefOCI(p.String()),
+			)
+			return unpacker.Image(ctx)
+		}),
+	)
+
+	rm, err := manager.ExportBundle(ctx, conf, output, b)
+	if err != nil {
+		logger.Error("Exporting offline bundle failed")
+		_ = b.Discard()
+		return err
+	}
+
+	if err = b.Close(); err != nil {
+		logger.Error("Writing offline bundle failed")
+		return err
+	}
+
+	if m := rm.Metadata(); m != nil {
+		logger.Info("Offline bundle of release %s %s written to %s", m.Name, m.Version, args.OutputPath)
+	} else {
+		logger.Info("Offline bundle written to %s", args.OutputPath)
+	}
+	return nil
+}
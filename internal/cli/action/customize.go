@@ -33,12 +33,17 @@ import (
 	v0 "github.com/suse/elemental/v3/internal/config/v0"
 	"github.com/suse/elemental/v3/internal/customize"
 	"github.com/suse/elemental/v3/internal/image"
+	"github.com/suse/elemental/v3/pkg/artifact"
+	"github.com/suse/elemental/v3/pkg/bundle"
+	"github.com/suse/elemental/v3/pkg/diskimage"
 	"github.com/suse/elemental/v3/pkg/extractor"
 	"github.com/suse/elemental/v3/pkg/helm"
 	"github.com/suse/elemental/v3/pkg/http"
+	"github.com/suse/elemental/v3/pkg/signature"
 	"github.com/suse/elemental/v3/pkg/sys"
 	"github.com/suse/elemental/v3/pkg/sys/platform"
 	"github.com/suse/elemental/v3/pkg/sys/vfs"
+	"github.com/suse/elemental/v3/pkg/unpack"
 )
 
 func Customize(ctx context.Context, cmd *cli.Command) error {
@@ -76,7 +81,21 @@ func Customize(ctx context.Context, cmd *cli.Command) error {
 	ctxCancel, cancelFunc := signal.NotifyContext(ctx, syscall.SIGTERM, syscall.SIGINT)
 	defer cancelFunc()
 
-	customizeRunner, err := setupCustomizeRunner(ctxCancel, system, args, output)
+	var b *bundle.Bundle
+	if args.Bundle != "" {
+		b, err = bundle.Open(system, args.Bundle, bundle.WithContext(ctxCancel))
+		if err != nil {
+			logger.Error("Opening offline bundle failed")
+			return err
+		}
+		defer func() {
+			if cErr := b.Close(); cErr != nil {
+				logger.Error("Closing offline bundle failed: %v", cErr)
+			}
+		}()
+	}
+
+	customizeRunner, err := setupCustomizeRunner(ctxCancel, system, args, output, b)
 	if err != nil {
 		logger.Error("Setting up customization runner failed")
 		return err
@@ -92,7 +111,14 @@ func Customize(ctx context.Context, cmd *cli.Command) error {
 
 func resolveOutputPaths(fs vfs.FS, args *cmdpkg.CustomizeFlags) (imagePath, configPath string) {
 	imagePath = args.OutputPath
-	imageName := fmt.Sprintf("image-%s.%s", time.Now().UTC().Format("2006-01-02T15-04-05"), args.MediaType)
+	ext := args.MediaType
+	if args.MediaType == diskimage.MediaType {
+		ext = string(diskimage.Raw)
+		if args.DiskFormat != "" {
+			ext = args.DiskFormat
+		}
+	}
+	imageName := fmt.Sprintf("image-%s.%s", time.Now().UTC().Format("2006-01-02T15-04-05"), ext)
 
 	if imagePath == "" {
 		imagePath = filepath.Join(args.ConfigDir, imageName)
@@ -115,34 +141,71 @@ func setupCustomizeRunner(
 	s *sys.System,
 	args *cmdpkg.CustomizeFlags,
 	output config.Output,
+	b *bundle.Bundle,
 ) (*customize.Runner, error) {
-	extr, err := setupFileExtractor(ctx, s, output, args.Local)
+	var source unpack.ImageSource
+	var verifier unpack.SignatureVerifier
+	var err error
+
+	var verifierOpts []signature.Opt
+	download, fetchChart := http.DownloadFile, helm.FetchChart
+	if b != nil {
+		// images of a bundle are verified against the manifests and signatures recorded on export
+		s.Logger().Info("Fetching all release manifests, images, files and Helm charts from bundle '%s'", args.Bundle)
+		source, download = b, b.DownloadFile
+		fetchChart = func(_ context.Context, repository, chart, version string, _ ...helm.FetchOpt) ([]byte, error) {
+			return b.Chart(repository, chart, version)
+		}
+		verifierOpts = append(verifierOpts, signature.WithRegistry(b.Registry()))
+	}
+
+	verifier, err = setupSignatureVerifier(s, args.SignaturePolicy, true, verifierOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	extr, err := setupFileExtractor(ctx, s, output, args.Local, verifier, source)
 	if err != nil {
 		return nil, fmt.Errorf("setting up file extractor: %w", err)
 	}
 
 	return &customize.Runner{
 		System:        s,
-		ConfigManager: setupConfigManager(s, args.ConfigDir, output, args.Local),
+		ConfigManager: setupConfigManager(s, args.ConfigDir, output, args.Local, verifier, source, download, fetchChart),
 		FileExtractor: extr,
+		Verifier:      verifier,
+		ImageSource:   source,
+		Local:         args.Local,
 	}, nil
 }
 
-func setupConfigManager(s *sys.System, configDir string, output config.Output, local bool) *config.Manager {
+func setupConfigManager(
+	s *sys.System, configDir string, output config.Output, local bool, verifier unpack.SignatureVerifier,
+	source unpack.ImageSource, download func(context.Context, vfs.FS, string, string) error,
+	fetchChart func(context.Context, string, string, string, ...helm.FetchOpt) ([]byte, error),
+) *config.Manager {
 	valuesResolver := &helm.ValuesResolver{
 		FS:        s.FS(),
 		ValuesDir: v0.Dir(configDir).HelmValuesDir(),
 	}
 
+	h := config.NewHelm(s.FS(), valuesResolver, s.Logger(), output.OverlaysDir())
+	h.FetchChart = fetchChart
+
 	return config.NewManager(
 		s,
-		config.NewHelm(s.FS(), valuesResolver, s.Logger(), output.OverlaysDir()),
-		config.WithDownloadFunc(http.DownloadFile),
+		h,
+		config.WithDownloadFunc(download),
 		config.WithLocal(local),
+		config.WithSignatureVerifier(verifier),
+		config.WithImageSource(source),
 	)
 }
 
-func setupFileExtractor(ctx context.Context, s *sys.System, outDir config.Output, local bool) (extr *extractor.OCIFileExtractor, err error) {
+func setupFileExtractor(
+	ctx context.Context, s *sys.System, outDir config.Output, local bool, verifier unpack.SignatureVerifier,
+	source unpack.ImageSource,
+) (extr *extractor.OCIFileExtractor, err error) {
 	const isoSearchGlob = "/iso/*default-iso*.iso"
 
 	if err := vfs.MkdirAll(s.FS(), outDir.ISOStoreDir(), vfs.DirPerm); err != nil {
@@ -155,6 +218,8 @@ func setupFileExtractor(ctx context.Context, s *sys.System, outDir config.Output
 		extractor.WithFS(s.FS()),
 		extractor.WithContext(ctx),
 		extractor.WithLocal(local),
+		extractor.WithSignatureVerifier(verifier),
+		extractor.WithImageSource(source),
 	)
 }
 
@@ -164,6 +229,12 @@ func digestCustomizeDefinition(f vfs.FS, args *cmdpkg.CustomizeFlags, imagePath
 		return nil, fmt.Errorf("error parsing platform %s", args.Platform)
 	}
 
+	if args.Push != "" {
+		if _, err = artifact.ParseReference(args.Push, true); err != nil {
+			return nil, err
+		}
+	}
+
 	conf, err := config.Parse(f, args.ConfigDir)
 	if err != nil {
 		return nil, fmt.Errorf("parsing configuration directory %s: %w", args.ConfigDir, err)
@@ -172,8 +243,11 @@ func digestCustomizeDefinition(f vfs.FS, args *cmdpkg.CustomizeFlags, imagePath
 	return &image.Definition{
 		Image: image.Image{
 			ImageType:       args.MediaType,
+			DiskFormat:      args.DiskFormat,
+			NetbootURL:      args.NetbootURL,
 			Platform:        p,
 			OutputImageName: imagePath,
+			PushReference:   args.Push,
 		},
 		Configuration: conf,
 	}, nil
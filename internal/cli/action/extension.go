@@ -0,0 +1,252 @@
+/*
+Copyright © 2022-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"context"
+	"fmt"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/urfave/cli/v3"
+
+	cmdpkg "github.com/suse/elemental/v3/internal/cli/cmd"
+	"github.com/suse/elemental/v3/internal/kmod"
+	"github.com/suse/elemental/v3/internal/sysext"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/manifest/api"
+	"github.com/suse/elemental/v3/pkg/manifest/resolver"
+	"github.com/suse/elemental/v3/pkg/snapper"
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/unpack"
+)
+
+// kernelModulesReloader reloads kernel modules for the running kernel, the kernel is only
+// looked up if there are modules to reload
+type kernelModulesReloader struct {
+	s *sys.System
+}
+
+func (r kernelModulesReloader) Reload(ctx context.Context, modules []string) error {
+	reloader, err := newKernelModulesReloader(r.s, kmod.NewConfig())
+	if err != nil {
+		return fmt.Errorf("finding kernel directory: %w", err)
+	}
+	return reloader.Reload(ctx, modules)
+}
+
+func ExtensionList(ctx context.Context, cmd *cli.Command) error {
+	s, err := extensionSystem(cmd)
+	if err != nil {
+		return err
+	}
+
+	exts, err := sysext.New(ctx, s).List()
+	if err != nil {
+		s.Logger().Error("Failed to list extensions")
+		return err
+	}
+
+	out := cmd.Writer
+	if out == nil {
+		out = cmd.Root().Writer
+	}
+
+	if cmdpkg.ExtensionArgs.JSON {
+		if exts == nil {
+			exts = []api.SystemdExtension{}
+		}
+		return printJSON(out, exts)
+	}
+
+	var data [][]string
+	for _, ext := range exts {
+		data = append(data, []string{ext.Name, ext.Image, strings.Join(ext.KernelModules, ", ")})
+	}
+	table := newTable(false, out)
+	table.Header([]string{"Name", "Image", "Kernel Modules"})
+	return printAndClearData(table, data, out)
+}
+
+func ExtensionAdd(ctx context.Context, cmd *cli.Command) error {
+	return changeExtension(ctx, cmd, func(m *sysext.Manager, ext api.SystemdExtension) error {
+		return m.Add(ext)
+	})
+}
+
+func ExtensionUpdate(ctx context.Context, cmd *cli.Command) error {
+	return changeExtension(ctx, cmd, func(m *sysext.Manager, ext api.SystemdExtension) error {
+		return m.Update(ext)
+	})
+}
+
+func ExtensionRemove(ctx context.Context, cmd *cli.Command) error {
+	ctxCancel, stop := signal.NotifyContext(ctx, syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	s, err := extensionSystem(cmd)
+	if err != nil {
+		return err
+	}
+
+	name, err := extensionNameArg(cmd)
+	if err != nil {
+		return err
+	}
+
+	m, err := setupExtensionManager(ctxCancel, s)
+	if err != nil {
+		return err
+	}
+
+	if err = m.Remove(name); err != nil {
+		s.Logger().Error("Removing extension '%s' failed", name)
+		return err
+	}
+
+	s.Logger().Info("Extension '%s' removed", name)
+	return nil
+}
+
+// changeExtension installs the extension named by the command argument with the given change function
+func changeExtension(ctx context.Context, cmd *cli.Command, change func(*sysext.Manager, api.SystemdExtension) error) error {
+	ctxCancel, stop := signal.NotifyContext(ctx, syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	args := &cmdpkg.ExtensionArgs
+
+	s, err := extensionSystem(cmd)
+	if err != nil {
+		return err
+	}
+
+	name, err := extensionNameArg(cmd)
+	if err != nil {
+		return err
+	}
+
+	ext, err := digestExtension(s, name, args)
+	if err != nil {
+		return err
+	}
+
+	verifier, err := setupSignatureVerifier(s, args.SignaturePolicy, true)
+	if err != nil {
+		return err
+	}
+
+	m, err := setupExtensionManager(
+		ctxCancel, s, sysext.WithUnpackOpts(unpack.WithLocalOCI(args.Local), unpack.WithSignatureVerifierOCI(verifier)),
+	)
+	if err != nil {
+		return err
+	}
+
+	if err = change(m, *ext); err != nil {
+		s.Logger().Error("Applying extension '%s' failed", name)
+		return err
+	}
+
+	s.Logger().Info("Extension '%s' applied from %s", name, ext.Image)
+	return nil
+}
+
+// digestExtension returns the extension of the given name as defined in the release manifest of the
+// installed system, if any, with the image and kernel modules given by the flags on top of it.
+func digestExtension(s *sys.System, name string, args *cmdpkg.ExtensionFlags) (*api.SystemdExtension, error) {
+	ext := &api.SystemdExtension{Name: name}
+
+	rm, err := resolver.ParseManifestFile(s, "/")
+	if err != nil {
+		return nil, fmt.Errorf("parsing release manifest: %w", err)
+	}
+	if rm != nil {
+		var all []api.SystemdExtension
+		if rm.CorePlatform != nil {
+			all = append(all, rm.CorePlatform.Components.Systemd.Extensions...)
+		}
+		if rm.SolutionExtension != nil {
+			all = append(all, rm.SolutionExtension.Components.Systemd.Extensions...)
+		}
+		for _, e := range all {
+			if e.Name == name {
+				*ext = e
+			}
+		}
+	}
+
+	if args.Image != "" {
+		ext.Image = args.Image
+	}
+	if len(args.KernelModules) > 0 {
+		ext.KernelModules = args.KernelModules
+	}
+
+	if ext.Image == "" {
+		return nil, fmt.Errorf("extension '%s' not found in the installed release manifest, set its image with --image", name)
+	}
+	return ext, nil
+}
+
+// setupExtensionManager returns the manager of the systemd extensions of the current host. Changes are
+// preceded by a snapshot of /etc if the host deployment uses snapper and /etc is a snapshotted volume.
+func setupExtensionManager(ctx context.Context, s *sys.System, opts ...sysext.Option) (*sysext.Manager, error) {
+	d, err := deployment.Parse(s, "/")
+	if err != nil {
+		return nil, fmt.Errorf("parsing deployment: %w", err)
+	}
+	if d != nil && (d.Snapshotter == nil || d.Snapshotter.Name == "" || d.Snapshotter.Name == "snapper") {
+		if isSnapshottedVolume(d, "/etc") {
+			opts = append(opts, sysext.WithSnapshotter(snapper.New(s), snapper.ConfigName("/etc")))
+		} else {
+			s.Logger().Warn("/etc is not a snapshotted volume, extension changes can't be rolled back")
+		}
+	}
+
+	opts = append(opts, sysext.WithKernelModulesReloader(kernelModulesReloader{s: s}))
+	return sysext.New(ctx, s, opts...), nil
+}
+
+// isSnapshottedVolume checks whether the given path is a snapshotted read-write volume of the deployment
+func isSnapshottedVolume(d *deployment.Deployment, path string) bool {
+	var partitions deployment.Partitions
+	for _, disk := range d.Disks {
+		partitions = append(partitions, disk.Partitions...)
+	}
+	for _, rwVol := range partitions.GetSnapshottedVolumes() {
+		if rwVol.Path == path {
+			return true
+		}
+	}
+	return false
+}
+
+func extensionSystem(cmd *cli.Command) (*sys.System, error) {
+	if cmd.Root().Metadata == nil || cmd.Root().Metadata["system"] == nil {
+		return nil, fmt.Errorf("error setting up initial configuration")
+	}
+	return cmd.Root().Metadata["system"].(*sys.System), nil
+}
+
+func extensionNameArg(cmd *cli.Command) (string, error) {
+	if cmd.Args() == nil || cmd.Args().Len() == 0 {
+		return "", fmt.Errorf("no extension name provided, refer usage: %s", cmd.UsageText)
+	}
+	return strings.TrimSpace(cmd.Args().Get(0)), nil
+}
@@ -0,0 +1,181 @@
+/*
+Copyright © 2022-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/suse/elemental/v3/internal/cli/action"
+	"github.com/suse/elemental/v3/internal/cli/cmd"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/extensions"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/manifest/api"
+	"github.com/suse/elemental/v3/pkg/manifest/api/core"
+	"github.com/suse/elemental/v3/pkg/manifest/resolver"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+var _ = Describe("Extension actions", Label("extension"), func() {
+	var s *sys.System
+	var tfs vfs.FS
+	var cleanup func()
+	var err error
+	var runner *sysmock.Runner
+	var cliCmd *cli.Command
+	var out *bytes.Buffer
+	var server *httptest.Server
+
+	BeforeEach(func() {
+		cmd.ExtensionArgs = cmd.ExtensionFlags{}
+		out = &bytes.Buffer{}
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("extension " + r.URL.Path))
+		}))
+
+		tfs, cleanup, err = sysmock.TestFS(map[string]string{
+			extensions.File:          "- name: foo\n  image: registry.org/foo:1.0\n",
+			extensions.OSReleaseFile: "ID=sl-micro\nVERSION_ID=6.2\n",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		runner = sysmock.NewRunner()
+		s, err = sys.NewSystem(
+			sys.WithFS(tfs), sys.WithRunner(runner), sys.WithLogger(log.New(log.WithBuffer(&bytes.Buffer{}))),
+		)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(deployment.DefaultDeployment().WriteDeploymentFile(s, "/")).To(Succeed())
+		rm := &resolver.ResolvedManifest{
+			CorePlatform: &core.ReleaseManifest{
+				Components: core.Components{
+					Systemd: api.Systemd{Extensions: []api.SystemdExtension{
+						{Name: "bar", Image: server.URL + "/bar.raw", Required: true},
+					}},
+				},
+			},
+		}
+		Expect(resolver.WriteManifestFile(s, "/", rm)).To(Succeed())
+
+		runner.SideEffect = func(command string, args ...string) ([]byte, error) {
+			if command == "snapper" && slices.Contains(args, "create") {
+				return []byte("5\n"), nil
+			}
+			if command == "systemd-dissect" {
+				Expect(vfs.MkdirAll(tfs, args[3], vfs.DirPerm)).To(Succeed())
+				release := filepath.Join(args[3], "extension-release.bar")
+				return []byte{}, tfs.WriteFile(release, []byte("ID=sl-micro\nVERSION_ID=6.2\n"), vfs.FilePerm)
+			}
+			return []byte{}, nil
+		}
+	})
+	AfterEach(func() {
+		server.Close()
+		cleanup()
+	})
+
+	run := func(a func(context.Context, *cli.Command) error, args ...string) error {
+		cliCmd = &cli.Command{
+			Metadata: map[string]any{"system": s},
+			Writer:   out,
+			Action:   a,
+		}
+		return cliCmd.Run(context.Background(), append([]string{""}, args...))
+	}
+
+	It("fails if no sys.System instance is in metadata", func() {
+		cliCmd = &cli.Command{Metadata: map[string]any{"system": nil}}
+		Expect(action.ExtensionList(context.Background(), cliCmd)).NotTo(Succeed())
+	})
+
+	It("lists the enabled extensions", func() {
+		Expect(run(action.ExtensionList)).To(Succeed())
+		Expect(out.String()).To(ContainSubstring("registry.org/foo:1.0"))
+		Expect(out.String()).To(ContainSubstring("KERNEL MODULES"))
+	})
+
+	It("lists the enabled extensions in JSON format", func() {
+		cmd.ExtensionArgs.JSON = true
+		Expect(run(action.ExtensionList)).To(Succeed())
+
+		var exts []map[string]any
+		Expect(json.Unmarshal(out.Bytes(), &exts)).To(Succeed())
+		Expect(exts).To(HaveLen(1))
+		Expect(exts[0]["Name"]).To(Equal("foo"))
+	})
+
+	It("adds an extension of the installed release manifest", func() {
+		Expect(run(action.ExtensionAdd, "bar")).To(Succeed())
+
+		data, err := tfs.ReadFile("/var/lib/extensions/bar.raw")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal("extension /bar.raw"))
+
+		data, err = tfs.ReadFile(extensions.File)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(ContainSubstring("name: bar"))
+		Expect(string(data)).NotTo(ContainSubstring("required"))
+
+		Expect(runner.IncludesCmds([][]string{{"systemd-sysext", "refresh"}})).To(Succeed())
+	})
+
+	It("fails to add an unknown extension without an image", func() {
+		err := run(action.ExtensionAdd, "baz")
+		Expect(err).To(MatchError(ContainSubstring("extension 'baz' not found in the installed release manifest")))
+	})
+
+	It("fails to add an extension without a name", func() {
+		err := run(action.ExtensionAdd)
+		Expect(err).To(MatchError(ContainSubstring("no extension name provided")))
+	})
+
+	It("removes an extension", func() {
+		Expect(run(action.ExtensionRemove, "foo")).To(Succeed())
+
+		data, err := tfs.ReadFile(extensions.File)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).NotTo(ContainSubstring("foo"))
+		Expect(runner.IncludesCmds([][]string{{"snapper"}, {"systemd-sysext", "refresh"}})).To(Succeed())
+	})
+
+	It("does not snapshot /etc if it is not a snapshotted volume", func() {
+		d := deployment.DefaultDeployment()
+		for _, part := range d.Disks[0].Partitions {
+			for i := range part.RWVolumes {
+				part.RWVolumes[i].Snapshotted = false
+			}
+		}
+		Expect(d.WriteDeploymentFile(s, "/")).To(Succeed())
+
+		Expect(run(action.ExtensionRemove, "foo")).To(Succeed())
+		Expect(runner.IncludesCmds([][]string{{"snapper"}})).NotTo(Succeed())
+		Expect(runner.IncludesCmds([][]string{{"systemd-sysext", "refresh"}})).To(Succeed())
+	})
+})
@@ -34,6 +34,7 @@ import (
 	"github.com/suse/elemental/v3/pkg/firmware"
 	"github.com/suse/elemental/v3/pkg/install"
 	"github.com/suse/elemental/v3/pkg/installer"
+	"github.com/suse/elemental/v3/pkg/signature"
 	"github.com/suse/elemental/v3/pkg/sys"
 	"github.com/suse/elemental/v3/pkg/sys/vfs"
 	"github.com/suse/elemental/v3/pkg/transaction"
@@ -85,7 +86,7 @@ func Install(ctx context.Context, cmd *cli.Command) error {
 }
 
 func initInstaller(ctx context.Context, s *sys.System, d *deployment.Deployment, args *cmdpkg.InstallFlags) (*install.Installer, error) {
-	bootloader, err := bootloader.New(d.BootConfig.Bootloader, s)
+	bootloader, err := bootloader.NewFromConfig(s, d.BootConfig)
 	if err != nil {
 		s.Logger().Error("Parsing boot config failed")
 		return nil, err
@@ -97,7 +98,14 @@ func initInstaller(ctx context.Context, s *sys.System, d *deployment.Deployment,
 		return nil, err
 	}
 
-	unpackOpts := []unpack.Opt{unpack.WithVerify(args.Verify), unpack.WithLocal(args.Local)}
+	verifier, err := setupSignatureVerifier(s, args.SignaturePolicy, args.Verify)
+	if err != nil {
+		return nil, err
+	}
+
+	unpackOpts := []unpack.Opt{
+		unpack.WithVerify(args.Verify), unpack.WithLocal(args.Local), unpack.WithSignatureVerifier(verifier),
+	}
 	manager := firmware.NewEfiBootManager(s)
 	upgrader := upgrade.New(
 		ctx, s, upgrade.WithBootManager(manager), upgrade.WithBootloader(bootloader),
@@ -112,6 +120,24 @@ func initInstaller(ctx context.Context, s *sys.System, d *deployment.Deployment,
 	return installer, nil
 }
 
+// setupSignatureVerifier returns the verifier of OCI image signatures for the given policy file,
+// or for the default policy file if none is given and it exists. It returns nil if there is no policy.
+func setupSignatureVerifier(s *sys.System, policyFile string, verify bool, opts ...signature.Opt) (unpack.SignatureVerifier, error) {
+	if policyFile == "" {
+		if ok, _ := vfs.Exists(s.FS(), signature.PolicyFile); !ok {
+			return nil, nil
+		}
+		policyFile = signature.PolicyFile
+	}
+
+	verifier, err := signature.NewVerifier(s, policyFile, append([]signature.Opt{signature.WithInsecure(!verify)}, opts...)...)
+	if err != nil {
+		return nil, fmt.Errorf("loading signature policy: %w", err)
+	}
+	s.Logger().Info("Verifying OCI image signatures according to policy '%s'", policyFile)
+	return verifier, nil
+}
+
 // loadDescriptionFile reads the given deployment description file into the given deployment object
 func loadDescriptionFile(s *sys.System, file string, d *deployment.Deployment) error {
 	data, err := s.FS().ReadFile(file)
@@ -127,14 +153,7 @@ func loadDescriptionFile(s *sys.System, file string, d *deployment.Deployment) e
 }
 
 // setBootloader configures the bootloader for the given deployment with the given flags
-func setBootloader(s *sys.System, d *deployment.Deployment, bootloaderType, cmdline string, createEntry bool) {
-	disk := d.GetSystemDisk()
-	if createEntry && disk != nil {
-		d.Firmware.BootEntries = []*firmware.EfiBootEntry{
-			firmware.DefaultBootEntry(s.Platform(), disk.Device),
-		}
-	}
-
+func setBootloader(s *sys.System, d *deployment.Deployment, bootloaderType, cmdline string) {
 	if d.BootConfig == nil {
 		d.BootConfig = &deployment.BootConfig{}
 	}
@@ -151,6 +170,86 @@ func setBootloader(s *sys.System, d *deployment.Deployment, bootloaderType, cmdl
 	}
 }
 
+// setBootEntry sets an EFI boot entry for the given disk pointing to the EFI application of the
+// bootloader of the given deployment
+func setBootEntry(s *sys.System, d *deployment.Deployment, disk string) error {
+	b, err := bootloader.NewFromConfig(s, d.BootConfig)
+	if err != nil {
+		return err
+	}
+
+	loader := b.EFILoader()
+	if loader == "" {
+		s.Logger().Warn("No EFI application to boot, skipping boot entry creation")
+		return nil
+	}
+
+	if d.Firmware == nil {
+		d.Firmware = &deployment.FirmwareConfig{}
+	}
+	d.Firmware.BootEntries = []*firmware.EfiBootEntry{
+		firmware.DefaultBootEntry(loader, disk),
+	}
+	return nil
+}
+
+// setUKI enables Unified Kernel Images for the given deployment and sets the signing keys, if any.
+// The signing keys are ignored if Unified Kernel Images are not enabled.
+func setUKI(s *sys.System, d *deployment.Deployment, enable bool, key, cert, pcrKey string) {
+	if enable {
+		if d.BootConfig.UKI == nil {
+			d.BootConfig.UKI = &deployment.UKIConfig{}
+		}
+		d.BootConfig.UKI.Enabled = true
+	}
+
+	if key == "" && cert == "" && pcrKey == "" {
+		return
+	}
+	if !d.BootConfig.IsUKIEnabled() {
+		s.Logger().Warn("Unified Kernel Images not enabled, ignoring signing keys")
+		return
+	}
+	if key != "" {
+		d.BootConfig.UKI.SigningKey = key
+	}
+	if cert != "" {
+		d.BootConfig.UKI.SigningCert = cert
+	}
+	if pcrKey != "" {
+		d.BootConfig.UKI.PCRKey = pcrKey
+	}
+}
+
+// checkUKIKeys checks the private keys matching the certificate and public key of the Unified Kernel
+// Images configuration are set. Private keys are not stored in the deployment file, hence they have
+// to be provided again on upgrades.
+func checkUKIKeys(d *deployment.Deployment) error {
+	if !d.BootConfig.IsUKIEnabled() {
+		return nil
+	}
+	uki := d.BootConfig.UKI
+	if uki.SigningCert != "" && uki.SigningKey == "" {
+		return fmt.Errorf("signing key for certificate '%s' is required to sign unified kernel images", uki.SigningCert)
+	}
+	if uki.PCRPublicKey != "" && uki.PCRKey == "" {
+		return fmt.Errorf("PCR private key for public key '%s' is required to sign unified kernel images", uki.PCRPublicKey)
+	}
+	return nil
+}
+
+// setBIOS enables legacy BIOS boot for the given deployment. A BIOS boot partition is added
+// at the beginning of the system disk if the deployment does not define any.
+func setBIOS(d *deployment.Deployment, enable bool) {
+	if !enable {
+		return
+	}
+	d.BootConfig.BIOS = true
+	if d.GetBIOSPartition() == nil {
+		deployment.WithBIOSPartition()(d)
+	}
+}
+
 // digestInstallSetup produces the Deployment object required to describe the installation parameters
 func digestInstallSetup(s *sys.System, flags *cmdpkg.InstallFlags) (*deployment.Deployment, error) {
 	d := deployment.DefaultDeployment()
@@ -211,7 +310,18 @@ func applyInstallFlags(s *sys.System, d *deployment.Deployment, flags *cmdpkg.In
 		}
 	}
 
-	setBootloader(s, d, flags.Bootloader, flags.KernelCmdline, flags.CreateBootEntry)
+	setBootloader(s, d, flags.Bootloader, flags.KernelCmdline)
+	setUKI(s, d, flags.UKI, flags.UKISigningKey, flags.UKISigningCert, flags.UKIPCRKey)
+	if err := checkUKIKeys(d); err != nil {
+		return err
+	}
+	setBIOS(d, flags.BIOS)
+
+	if flags.CreateBootEntry && disk != nil {
+		if err := setBootEntry(s, d, disk.Device); err != nil {
+			return fmt.Errorf("setting EFI boot entry: %w", err)
+		}
+	}
 
 	if flags.Snapshotter != "" {
 		d.Snapshotter.Name = flags.Snapshotter
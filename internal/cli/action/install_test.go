@@ -109,4 +109,11 @@ var _ = Describe("Install action", Label("install"), func() {
 		Expect(err).To(HaveOccurred())
 		Expect(err.Error()).To(ContainSubstring("image source type not supported"))
 	})
+	It("fails if the signature policy can't be loaded", func() {
+		cmd.InstallArgs.Target = "/dev/device"
+		cmd.InstallArgs.OperatingSystemImage = "my.registry.org/my/image:test"
+		cmd.InstallArgs.SignaturePolicy = "/etc/elemental/missing-policy.yaml"
+		err = action.Install(context.Background(), cliCmd)
+		Expect(err).To(MatchError(ContainSubstring("loading signature policy")))
+	})
 })
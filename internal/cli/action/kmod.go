@@ -65,14 +65,24 @@ func ManageKernelModules(ctx context.Context, cmd *cli.Command) error {
 		return unloader.Unload(ctxCancel, kernelModules)
 	}
 
-	kernel, _, err := vfs.FindKernel(system.FS(), "/")
+	reloader, err := newKernelModulesReloader(system, config)
 	if err != nil {
 		logger.Error("Finding kernel directory failed, unable to proceed")
 		return err
 	}
+
+	return reloader.Reload(ctxCancel, kernelModules)
+}
+
+// newKernelModulesReloader returns a reloader of kernel modules for the running kernel
+func newKernelModulesReloader(system *sys.System, config *kmod.Config) (*kmod.Reloader, error) {
+	kernel, _, err := vfs.FindKernel(system.FS(), "/")
+	if err != nil {
+		return nil, err
+	}
 	kernelDir := filepath.Dir(kernel)
 
-	reloader := &kmod.Reloader{
+	return &kmod.Reloader{
 		System: system,
 		Config: config,
 		ModuleCache: &kmod.ModuleCache{
@@ -81,7 +91,5 @@ func ManageKernelModules(ctx context.Context, cmd *cli.Command) error {
 			KernelDir: kernelDir,
 		},
 		KernelDir: kernelDir,
-	}
-
-	return reloader.Reload(ctxCancel, kernelModules)
+	}, nil
 }
@@ -0,0 +1,66 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"context"
+	"fmt"
+	"os/signal"
+	"syscall"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/suse/elemental/v3/internal/image/kubernetes"
+	"github.com/suse/elemental/v3/pkg/sys"
+)
+
+// KubernetesUpgrade installs the Kubernetes upgrade staged by the last OS upgrade
+func KubernetesUpgrade(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Root().Metadata == nil || cmd.Root().Metadata["system"] == nil {
+		return fmt.Errorf("error setting up initial configuration")
+	}
+	s := cmd.Root().Metadata["system"].(*sys.System)
+
+	ctxCancel, stop := signal.NotifyContext(ctx, syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	err := kubernetes.NewNodeUpgrader(s).Upgrade(ctxCancel)
+	if err != nil {
+		s.Logger().Error("Kubernetes upgrade failed")
+		return err
+	}
+	return nil
+}
+
+// KubernetesUncordon uncordons the node drained by an OS upgrade once Kubernetes is up
+func KubernetesUncordon(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Root().Metadata == nil || cmd.Root().Metadata["system"] == nil {
+		return fmt.Errorf("error setting up initial configuration")
+	}
+	s := cmd.Root().Metadata["system"].(*sys.System)
+
+	ctxCancel, stop := signal.NotifyContext(ctx, syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	err := kubernetes.NewNodeUpgrader(s).UncordonDrained(ctxCancel)
+	if err != nil {
+		s.Logger().Error("Uncordoning the node failed")
+		return err
+	}
+	return nil
+}
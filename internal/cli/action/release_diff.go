@@ -0,0 +1,136 @@
+/*
+Copyright © 2022-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	cmdpkg "github.com/suse/elemental/v3/internal/cli/cmd"
+	"github.com/suse/elemental/v3/pkg/manifest/diff"
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/urfave/cli/v3"
+)
+
+const (
+	oldHdr    = "Old"
+	newHdr    = "New"
+	fieldHdr  = "Field"
+	statusHdr = "Status"
+)
+
+func ReleaseDiff(_ context.Context, cmd *cli.Command) error {
+	if cmd.Root().Metadata == nil || cmd.Root().Metadata["system"] == nil {
+		return fmt.Errorf("error setting up initial configuration")
+	}
+	system := cmd.Root().Metadata["system"].(*sys.System)
+	args := &cmdpkg.ReleaseDiffArgs
+
+	system.Logger().Debug("release-diff called with args: %+v", args)
+
+	if cmd.Args() == nil || cmd.Args().Len() != 2 {
+		system.Logger().Error("two release manifests, old and new, must be provided")
+		return fmt.Errorf("refer usage: %s", cmd.UsageText)
+	}
+
+	oldURI, err := manifestURI(system, cmd.Args().Get(0))
+	if err != nil {
+		return err
+	}
+	newURI, err := manifestURI(system, cmd.Args().Get(1))
+	if err != nil {
+		return err
+	}
+
+	oldRM, err := resolveManifest(system, oldURI, args.Local)
+	if err != nil {
+		return fmt.Errorf("resolving release manifest '%s': %w", oldURI, err)
+	}
+	newRM, err := resolveManifest(system, newURI, args.Local)
+	if err != nil {
+		return fmt.Errorf("resolving release manifest '%s': %w", newURI, err)
+	}
+
+	report, err := diff.Compare(oldRM, newRM)
+	if err != nil {
+		return fmt.Errorf("comparing release manifests: %w", err)
+	}
+
+	out := cmd.Writer
+	if out == nil {
+		out = cmd.Root().Writer
+	}
+
+	if args.JSON {
+		return printJSON(out, report)
+	}
+
+	markdown = args.Markdown
+	return printReport(report, out)
+}
+
+func printReport(report *diff.Report, out io.Writer) error {
+	table := newTable(markdown, out)
+	table.Header([]string{"Release", oldHdr, newHdr})
+	data := [][]string{
+		{"Name", report.Old.Name, report.New.Name},
+		{versionHdr, report.Old.Version, report.New.Version},
+	}
+	if err := printAndClearData(table, data, out); err != nil {
+		return err
+	}
+
+	if report.IsEmpty() {
+		fmt.Fprintln(out, "No component differences found between the releases")
+		return nil
+	}
+
+	data = nil
+	for _, c := range report.OperatingSystem {
+		data = append(data, []string{"Operating System", c.Field, c.Old, c.New})
+	}
+	for _, c := range report.Kubernetes {
+		data = append(data, []string{"Kubernetes", c.Field, c.Old, c.New})
+	}
+	table = newTable(markdown, out)
+	table.Header([]string{"Infrastructure Components", fieldHdr, oldHdr, newHdr})
+	if err := printAndClearData(table, data, out); err != nil {
+		return err
+	}
+
+	table = newTable(markdown, out)
+	table.Header([]string{"Systemd Extensions", statusHdr, fieldHdr, oldHdr, newHdr})
+	if err := printAndClearData(table, componentDiffData(report.Extensions), out); err != nil {
+		return err
+	}
+
+	table = newTable(markdown, out)
+	table.Header([]string{"Helm Charts", statusHdr, fieldHdr, oldHdr, newHdr})
+	return printAndClearData(table, componentDiffData(report.HelmCharts), out)
+}
+
+func componentDiffData(diffs []diff.ComponentDiff) [][]string {
+	var data [][]string
+	for _, d := range diffs {
+		for _, c := range d.Changes {
+			data = append(data, []string{d.Name, string(d.Status), c.Field, c.Old, c.New})
+		}
+	}
+	return data
+}
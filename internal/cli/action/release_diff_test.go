@@ -0,0 +1,195 @@
+/*
+Copyright © 2022-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/suse/elemental/v3/internal/cli/action"
+	"github.com/suse/elemental/v3/internal/cli/cmd"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/manifest/diff"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+	"github.com/urfave/cli/v3"
+)
+
+var _ = Describe("Release diff tests", Label("release-diff"), func() {
+	var s *sys.System
+	var tfs vfs.FS
+	var cleanup func()
+	var err error
+	var cliCmd *cli.Command
+	var buffer *bytes.Buffer
+	var ctx context.Context
+	var oldPath, newPath string
+	var oldManifest = `metadata:
+  name: suse-core-test
+  version: 0.5.0
+components:
+  operatingSystem:
+    image:
+      base: registry.suse.com/elemental/base-os-kernel-default:16.0-2.2
+      iso: registry.suse.com/elemental/base-os-kernel-default-iso:16.0-2.2
+  kubernetes:
+    version: v1.34.2+rke2r1
+    image: registry.suse.com/elemental/rke2/rke2:1.34.2_rke2r1
+  systemd:
+    extensions:
+    - name: elemental3ctl
+      image: registry.suse.com/elemental/elemental3ctl:0.5
+      required: true
+  helm:
+    charts:
+    - name: MetalLB
+      chart: metallb
+      version: 0.15.0
+      namespace: metallb-system
+      repository: metallb
+    repositories:
+    - name: metallb
+      url: https://metallb.github.io/metallb`
+	var newManifest = `metadata:
+  name: suse-core-test
+  version: 0.6.0
+  upgradePathsFrom:
+  - 0.5.x
+components:
+  operatingSystem:
+    image:
+      base: registry.suse.com/elemental/base-os-kernel-default:16.0-2.4
+      iso: registry.suse.com/elemental/base-os-kernel-default-iso:16.0-2.4
+  kubernetes:
+    version: v1.35.5+rke2r1
+    image: registry.suse.com/elemental/rke2/rke2:1.35.5_rke2r1
+  systemd:
+    extensions:
+    - name: elemental3ctl
+      image: registry.suse.com/elemental/elemental3ctl:0.6
+      required: true
+    - name: nvidia
+      image: registry.suse.com/elemental/nvidia:580
+      kernelModules:
+      - nvidia
+  helm:
+    charts:
+    - name: MetalLB
+      chart: metallb
+      version: 0.15.2
+      namespace: metallb-system
+      repository: metallb
+    repositories:
+    - name: metallb
+      url: https://metallb.github.io/metallb`
+
+	BeforeEach(func() {
+		cmd.ReleaseDiffArgs = cmd.ReleaseDiffFlags{}
+		buffer = &bytes.Buffer{}
+		tfs, cleanup, err = sysmock.TestFS(map[string]string{
+			"/etc/elemental3/old.yaml": oldManifest,
+			"/etc/elemental3/new.yaml": newManifest,
+		})
+		Expect(err).ToNot(HaveOccurred())
+		s, err = sys.NewSystem(
+			sys.WithFS(tfs),
+			sys.WithLogger(log.New(log.WithBuffer(buffer))),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		cliCmd = &cli.Command{
+			Metadata: map[string]any{
+				"system": s,
+			},
+			Writer: buffer,
+		}
+		ctx = context.Background()
+		cmd.ReleaseDiffArgs.Local = true
+		cliCmd.Action = action.ReleaseDiff
+
+		oldPath, err = tfs.RawPath("/etc/elemental3/old.yaml")
+		Expect(err).ToNot(HaveOccurred())
+		oldPath = "file://" + oldPath
+		newPath, err = tfs.RawPath("/etc/elemental3/new.yaml")
+		Expect(err).ToNot(HaveOccurred())
+		newPath = "file://" + newPath
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+
+	It("fails if no sys.System instance is available", func() {
+		cliCmd.Metadata["system"] = nil
+		Expect(action.ReleaseDiff(ctx, cliCmd)).ToNot(Succeed())
+	})
+
+	It("fails if a single release manifest is passed to it", func() {
+		Expect(cliCmd.Run(ctx, []string{"", oldPath})).ToNot(Succeed())
+	})
+
+	It("prints the differences between two releases", func() {
+		Expect(cliCmd.Run(ctx, []string{"", oldPath, newPath})).To(Succeed())
+		Expect(buffer).To(ContainSubstring("0.5.0"))
+		Expect(buffer).To(ContainSubstring("0.6.0"))
+
+		Expect(buffer).To(ContainSubstring("INFRASTRUCTURE COMPONENTS"))
+		Expect(buffer).To(ContainSubstring("registry.suse.com/elemental/base-os-kernel-default:16.0-2.4"))
+		Expect(buffer).To(ContainSubstring("v1.35.5+rke2r1"))
+
+		Expect(buffer).To(ContainSubstring("SYSTEMD EXTENSIONS"))
+		Expect(buffer).To(ContainSubstring("registry.suse.com/elemental/elemental3ctl:0.6"))
+		Expect(buffer).To(ContainSubstring("registry.suse.com/elemental/nvidia:580"))
+		Expect(buffer).To(ContainSubstring("kernelModules"))
+
+		Expect(buffer).To(ContainSubstring("HELM CHARTS"))
+		Expect(buffer).To(ContainSubstring("0.15.2"))
+	})
+
+	It("prints no component differences for the same release", func() {
+		Expect(cliCmd.Run(ctx, []string{"", oldPath, oldPath})).To(Succeed())
+		Expect(buffer).To(ContainSubstring("No component differences found"))
+	})
+
+	It("prints the differences in JSON format", func() {
+		cmd.ReleaseDiffArgs.JSON = true
+		jsonBuffer := &bytes.Buffer{}
+		cliCmd.Writer = jsonBuffer
+		Expect(cliCmd.Run(ctx, []string{"", oldPath, newPath})).To(Succeed())
+
+		report := &diff.Report{}
+		Expect(json.Unmarshal(jsonBuffer.Bytes(), report)).To(Succeed())
+		Expect(report.New.Version).To(Equal("0.6.0"))
+		Expect(report.Extensions).To(ContainElement(diff.ComponentDiff{
+			Name:   "nvidia",
+			Status: diff.Added,
+			Changes: []diff.Change{
+				{Field: "image", New: "registry.suse.com/elemental/nvidia:580"},
+				{Field: "required", New: "false"},
+				{Field: "kernelModules", New: "nvidia"},
+			},
+		}))
+		Expect(report.HelmCharts).To(Equal([]diff.ComponentDiff{{
+			Name:    "metallb",
+			Status:  diff.Changed,
+			Changes: []diff.Change{{Field: "version", Old: "0.15.0", New: "0.15.2"}},
+		}}))
+	})
+})
@@ -70,24 +70,10 @@ func ReleaseInfo(_ context.Context, cmd *cli.Command) error {
 		system.Logger().Error("no file or OCI image provided")
 		return fmt.Errorf("refer usage: %s", cmd.UsageText)
 	}
-	arg := cmd.Args().Get(0)
-	srcType, err := argSourceType(system, arg)
+	uri, err := manifestURI(system, cmd.Args().Get(0))
 	if err != nil {
 		return err
 	}
-	system.Logger().Debug("found source type: %s", srcType)
-
-	uri := arg
-	if !strings.Contains(arg, "://") {
-		uri = fmt.Sprintf("%s://%s", srcType, arg)
-	}
-
-	if srcType == source.OCI {
-		// check if it's a valid OCI image before proceeding
-		if _, err := name.ParseReference(uri); err != nil {
-			return fmt.Errorf("invalid OCI image reference: %w", err)
-		}
-	}
 	resolved, err := resolveManifest(system, uri, args.Local)
 	if err != nil {
 		return err
@@ -102,6 +88,28 @@ func ReleaseInfo(_ context.Context, cmd *cli.Command) error {
 	return printManifest(resolved, uri, out)
 }
 
+// manifestURI returns the release manifest URI of the given file or OCI image argument
+func manifestURI(system *sys.System, arg string) (string, error) {
+	srcType, err := argSourceType(system, arg)
+	if err != nil {
+		return "", err
+	}
+	system.Logger().Debug("found source type: %s", srcType)
+
+	uri := arg
+	if !strings.Contains(arg, "://") {
+		uri = fmt.Sprintf("%s://%s", srcType, arg)
+	}
+
+	if srcType == source.OCI {
+		// check if it's a valid OCI image before proceeding
+		if _, err := name.ParseReference(uri); err != nil {
+			return "", fmt.Errorf("invalid OCI image reference: %w", err)
+		}
+	}
+	return uri, nil
+}
+
 func resolveManifest(system *sys.System, uri string, local bool) (*resolver.ResolvedManifest, error) {
 	output, err := config.NewOutput(system.FS(), "", "")
 	if err != nil {
@@ -199,6 +207,11 @@ func printManifest(manifest *resolver.ResolvedManifest, arg string, out io.Write
 		return err
 	}
 
+	// upgrade graph
+	if err := printUpgradeData(cm, sm, out); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -341,6 +354,38 @@ func printHelmChartsData(cm *core.ReleaseManifest, sm *solution.ReleaseManifest,
 	return printAndClearData(table, data, out)
 }
 
+// printUpgradeData prints the releases each manifest can be upgraded from. The
+// minimum version, if any, is listed as an additional lower bound constraint.
+func printUpgradeData(cm *core.ReleaseManifest, sm *solution.ReleaseManifest, out io.Writer) error {
+	var data [][]string
+
+	data = append(data, upgradePathsData(cm.Metadata, "")...)
+	if sm != nil {
+		data = append(data, upgradePathsData(sm.Metadata, "(*)")...)
+	}
+
+	table := newTable(markdown, out)
+	table.Header([]string{"Release", "Upgrades From", "Upgrades To"})
+	return printAndClearData(table, data, out)
+}
+
+func upgradePathsData(m *api.Metadata, suffix string) [][]string {
+	var data [][]string
+
+	if m == nil {
+		return data
+	}
+
+	if m.MinimumVersion != "" {
+		data = append(data, []string{m.Name + suffix, ">= " + m.MinimumVersion, m.Version})
+	}
+	for _, path := range m.UpgradePathsFrom {
+		data = append(data, []string{m.Name + suffix, path, m.Version})
+	}
+
+	return data
+}
+
 func coreManifestHelmChartsData(h *api.Helm) [][]string {
 	var data [][]string
 
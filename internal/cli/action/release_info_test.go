@@ -27,6 +27,10 @@ var _ = Describe("Release info tests", Label("release-info"), func() {
   name: suse-core-test
   version: 0.6-rc.20260317
   creationDate: '2026-03-17'
+  minimumVersion: 0.5.0
+  upgradePathsFrom:
+  - 0.5.x
+  - 0.6-rc.20260301
 components:
   operatingSystem:
     image:
@@ -113,5 +117,10 @@ components:
 		Expect(buffer).To(ContainSubstring("CHART NAME"))
 		Expect(buffer).To(ContainSubstring("https://metallb.github.io/metallb"))
 		Expect(buffer).To(ContainSubstring("https://suse-edge.github.io/charts"))
+
+		Expect(buffer).To(ContainSubstring("UPGRADES FROM"))
+		Expect(buffer).To(ContainSubstring(">= 0.5.0"))
+		Expect(buffer).To(ContainSubstring("0.5.x"))
+		Expect(buffer).To(ContainSubstring("0.6-rc.20260301"))
 	})
 })
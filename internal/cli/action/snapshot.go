@@ -0,0 +1,359 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/urfave/cli/v3"
+
+	cmdpkg "github.com/suse/elemental/v3/internal/cli/cmd"
+	"github.com/suse/elemental/v3/pkg/bootloader"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/transaction"
+)
+
+// snapshotSetup holds the runtime objects required to manage snapshots of the current system
+type snapshotSetup struct {
+	s      *sys.System
+	d      *deployment.Deployment
+	t      transaction.Interface
+	b      bootloader.Bootloader
+	espDir string
+	out    io.Writer
+}
+
+func SnapshotList(ctx context.Context, cmd *cli.Command) error {
+	ctxCancel, stop := signal.NotifyContext(ctx, syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	setup, err := digestSnapshotSetup(ctxCancel, cmd)
+	if err != nil {
+		return err
+	}
+
+	snaps, err := setup.t.ListSnapshots()
+	if err != nil {
+		setup.s.Logger().Error("Failed to list snapshots")
+		return err
+	}
+
+	if cmdpkg.SnapshotArgs.JSON {
+		return printJSON(setup.out, snaps)
+	}
+
+	var data [][]string
+	for _, snap := range snaps {
+		data = append(data, []string{
+			strconv.Itoa(snap.ID), snap.Date, boolMark(snap.Default), boolMark(snap.Active), snap.Description,
+		})
+	}
+	table := newTable(false, setup.out)
+	table.Header([]string{"ID", "Date", "Default", "Active", "Description"})
+	return printAndClearData(table, data, setup.out)
+}
+
+func SnapshotShow(ctx context.Context, cmd *cli.Command) error {
+	ctxCancel, stop := signal.NotifyContext(ctx, syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	id, err := snapshotIDArg(cmd)
+	if err != nil {
+		return err
+	}
+
+	setup, err := digestSnapshotSetup(ctxCancel, cmd)
+	if err != nil {
+		return err
+	}
+
+	snap, err := findSnapshot(setup.t, id)
+	if err != nil {
+		return err
+	}
+
+	if cmdpkg.SnapshotArgs.JSON {
+		return printJSON(setup.out, snap)
+	}
+
+	data := [][]string{
+		{"ID", strconv.Itoa(snap.ID)},
+		{"Date", snap.Date},
+		{"Description", snap.Description},
+		{"Default", boolMark(snap.Default)},
+		{"Active", boolMark(snap.Active)},
+	}
+	keys := make([]string, 0, len(snap.Metadata))
+	for k := range snap.Metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		data = append(data, []string{fmt.Sprintf("Metadata (%s)", k), snap.Metadata[k]})
+	}
+	table := newTable(false, setup.out)
+	table.Header([]string{"Attribute", "Value"})
+	return printAndClearData(table, data, setup.out)
+}
+
+func SnapshotRollback(ctx context.Context, cmd *cli.Command) error {
+	ctxCancel, stop := signal.NotifyContext(ctx, syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	setup, err := digestSnapshotSetup(ctxCancel, cmd)
+	if err != nil {
+		return err
+	}
+
+	snaps, err := setup.t.ListSnapshots()
+	if err != nil {
+		setup.s.Logger().Error("Failed to list snapshots")
+		return err
+	}
+
+	var id int
+	if cmd.Args() != nil && cmd.Args().Len() > 0 {
+		id, err = snapshotIDArg(cmd)
+		if err != nil {
+			return err
+		}
+	} else {
+		id, err = previousSnapshot(snaps)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, snap := range snaps {
+		if snap.ID == id && snap.Default {
+			return fmt.Errorf("snapshot '%d' is already the default snapshot", id)
+		}
+	}
+
+	err = setDefaultSnapshot(setup, snaps, id)
+	if err != nil {
+		setup.s.Logger().Error("Rollback failed")
+		return err
+	}
+
+	setup.s.Logger().Info("Rollback to snapshot %d completed, reboot to apply it", id)
+	return nil
+}
+
+func SnapshotSetDefault(ctx context.Context, cmd *cli.Command) error {
+	ctxCancel, stop := signal.NotifyContext(ctx, syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	id, err := snapshotIDArg(cmd)
+	if err != nil {
+		return err
+	}
+
+	setup, err := digestSnapshotSetup(ctxCancel, cmd)
+	if err != nil {
+		return err
+	}
+
+	snaps, err := setup.t.ListSnapshots()
+	if err != nil {
+		setup.s.Logger().Error("Failed to list snapshots")
+		return err
+	}
+
+	err = setDefaultSnapshot(setup, snaps, id)
+	if err != nil {
+		setup.s.Logger().Error("Setting default snapshot failed")
+		return err
+	}
+
+	setup.s.Logger().Info("Snapshot %d set as default", id)
+	return nil
+}
+
+func SnapshotDelete(ctx context.Context, cmd *cli.Command) error {
+	ctxCancel, stop := signal.NotifyContext(ctx, syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	id, err := snapshotIDArg(cmd)
+	if err != nil {
+		return err
+	}
+
+	setup, err := digestSnapshotSetup(ctxCancel, cmd)
+	if err != nil {
+		return err
+	}
+
+	err = setup.t.DeleteSnapshot(id)
+	if err != nil {
+		setup.s.Logger().Error("Failed to delete snapshot %d", id)
+		return err
+	}
+
+	snapIDs, err := setup.t.GetActiveSnapshotIDs()
+	if err != nil {
+		return fmt.Errorf("get active snapshots: %w", err)
+	}
+
+	err = setup.b.Prune("/", setup.espDir, snapIDs)
+	if err != nil {
+		return fmt.Errorf("pruning boot entries: %w", err)
+	}
+
+	setup.s.Logger().Info("Snapshot %d deleted", id)
+	return nil
+}
+
+// digestSnapshotSetup parses the deployment of the current host and initiates the snapshotter and
+// bootloader defined in it.
+func digestSnapshotSetup(ctx context.Context, cmd *cli.Command) (*snapshotSetup, error) {
+	if cmd.Root().Metadata == nil || cmd.Root().Metadata["system"] == nil {
+		return nil, fmt.Errorf("error setting up initial configuration")
+	}
+	s := cmd.Root().Metadata["system"].(*sys.System)
+
+	d, err := deployment.Parse(s, "/")
+	if err != nil {
+		return nil, fmt.Errorf("parsing deployment: %w", err)
+	} else if d == nil {
+		return nil, fmt.Errorf("deployment not found")
+	}
+
+	esp := d.GetEfiPartition()
+	if esp == nil {
+		return nil, fmt.Errorf("no %s partition defined in deployment", deployment.EfiLabel)
+	}
+
+	snapshotter := "snapper"
+	if d.Snapshotter != nil && d.Snapshotter.Name != "" {
+		snapshotter = d.Snapshotter.Name
+	}
+	t, err := transaction.New(ctx, s, d, snapshotter)
+	if err != nil {
+		return nil, fmt.Errorf("initializing snapshotter: %w", err)
+	}
+	_, err = t.Init(*d)
+	if err != nil {
+		return nil, fmt.Errorf("initializing snapshotter: %w", err)
+	}
+
+	b, err := bootloader.NewFromConfig(s, d.BootConfig)
+	if err != nil {
+		return nil, fmt.Errorf("initializing bootloader: %w", err)
+	}
+
+	out := cmd.Writer
+	if out == nil {
+		out = cmd.Root().Writer
+	}
+
+	return &snapshotSetup{s: s, d: d, t: t, b: b, espDir: esp.MountPoint, out: out}, nil
+}
+
+// setDefaultSnapshot sets the given snapshot as the default one and updates the default boot entry
+// accordingly. If the boot entry can't be updated the previous default snapshot is restored.
+func setDefaultSnapshot(setup *snapshotSetup, snaps []*transaction.Snapshot, id int) error {
+	var prevDefault int
+	for _, snap := range snaps {
+		if snap.Default {
+			prevDefault = snap.ID
+		}
+	}
+
+	err := setup.t.SetDefaultSnapshot(id)
+	if err != nil {
+		return err
+	}
+
+	err = setup.b.SetDefaultEntry(setup.espDir, strconv.Itoa(id))
+	if err != nil {
+		err = fmt.Errorf("setting default boot entry: %w", err)
+		if prevDefault > 0 && prevDefault != id {
+			err = errors.Join(err, setup.t.SetDefaultSnapshot(prevDefault))
+		}
+		return err
+	}
+	return nil
+}
+
+// previousSnapshot returns the most recent snapshot older than the current default snapshot
+func previousSnapshot(snaps []*transaction.Snapshot) (int, error) {
+	var defaultID, prevID int
+	for _, snap := range snaps {
+		if snap.Default {
+			defaultID = snap.ID
+		}
+	}
+	for _, snap := range snaps {
+		if snap.ID < defaultID && snap.ID > prevID {
+			prevID = snap.ID
+		}
+	}
+	if prevID == 0 {
+		return 0, fmt.Errorf("no snapshot found previous to the default snapshot '%d'", defaultID)
+	}
+	return prevID, nil
+}
+
+func findSnapshot(t transaction.Interface, id int) (*transaction.Snapshot, error) {
+	snaps, err := t.ListSnapshots()
+	if err != nil {
+		return nil, err
+	}
+	for _, snap := range snaps {
+		if snap.ID == id {
+			return snap, nil
+		}
+	}
+	return nil, fmt.Errorf("snapshot '%d' not found", id)
+}
+
+func snapshotIDArg(cmd *cli.Command) (int, error) {
+	if cmd.Args() == nil || cmd.Args().Len() == 0 {
+		return 0, fmt.Errorf("no snapshot ID provided, refer usage: %s", cmd.UsageText)
+	}
+	arg := strings.TrimSpace(cmd.Args().Get(0))
+	id, err := strconv.Atoi(arg)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid snapshot ID '%s'", arg)
+	}
+	return id, nil
+}
+
+func printJSON(out io.Writer, data any) error {
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(data)
+}
+
+func boolMark(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
@@ -0,0 +1,207 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/suse/elemental/v3/internal/cli/action"
+	"github.com/suse/elemental/v3/internal/cli/cmd"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+	"github.com/suse/elemental/v3/pkg/transaction"
+)
+
+const snapshotsLsblkJson = `{
+	"blockdevices": [
+	   {
+		  "label": "EFI",
+		  "partlabel": "efi",
+		  "partuuid": "c60d1845-7b04-4fc4-8639-8c49eb7277d5",
+		  "size": 272629760,
+		  "fstype": "vfat",
+		  "mountpoints": ["/boot"],
+		  "path": "/dev/sda1",
+		  "pkname": "/dev/sda",
+		  "type": "part"
+	   },{
+		  "label": "SYSTEM",
+		  "partlabel": "system",
+		  "partuuid": "34a8abb8-ddb3-48a2-8ecc-2443e92c7510",
+		  "size": 2726297600,
+		  "fstype": "btrfs",
+		  "mountpoints": ["/"],
+		  "path": "/dev/sda2",
+		  "pkname": "/dev/sda",
+		  "type": "part"
+	   }
+	]
+ }`
+
+const snapperRootList = `{
+	"root": [
+	  {
+		"number": 0,
+		"default": false,
+		"active": false
+	  },{
+		"number": 2,
+		"default": false,
+		"active": false,
+		"date": "2026-01-10 10:00:00",
+		"description": "snapshot created from parent snapshot 1"
+	  },{
+		"number": 3,
+		"default": true,
+		"active": true,
+		"date": "2026-01-12 10:00:00",
+		"description": "snapshot created from parent snapshot 2",
+		"userdata": {
+		    "update-in-progress": ""
+		}
+	  }
+	]
+  }
+`
+
+var _ = Describe("Snapshot actions", Label("snapshot"), func() {
+	var s *sys.System
+	var tfs vfs.FS
+	var cleanup func()
+	var err error
+	var cliCmd *cli.Command
+	var buffer, out *bytes.Buffer
+	var runner *sysmock.Runner
+	var mounter *sysmock.Mounter
+
+	BeforeEach(func() {
+		cmd.SnapshotArgs = cmd.SnapshotFlags{}
+		buffer = &bytes.Buffer{}
+		out = &bytes.Buffer{}
+		tfs, cleanup, err = sysmock.TestFS(map[string]string{
+			"/boot/loader/entries/2": "display_name=OS (2)\ncmdline=snapshot2",
+			"/boot/loader/entries/3": "display_name=OS (3)\ncmdline=snapshot3",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		runner = sysmock.NewRunner()
+		mounter = sysmock.NewMounter()
+		s, err = sys.NewSystem(
+			sys.WithFS(tfs), sys.WithRunner(runner), sys.WithMounter(mounter),
+			sys.WithLogger(log.New(log.WithBuffer(buffer))),
+		)
+		Expect(err).NotTo(HaveOccurred())
+
+		d := deployment.DefaultDeployment()
+		d.Disks[0].Partitions[0].UUID = "c60d1845-7b04-4fc4-8639-8c49eb7277d5"
+		d.Disks[0].Partitions[1].UUID = "34a8abb8-ddb3-48a2-8ecc-2443e92c7510"
+		d.BootConfig.Bootloader = "grub"
+		d.SourceOS = deployment.NewOCISrc("registry.org/my/os:1.0")
+		Expect(d.WriteDeploymentFile(s, "/")).To(Succeed())
+
+		Expect(mounter.Mount("/dev/sda2", "/", "btrfs", []string{"ro", "subvol=@/.snapshots/3/snapshot"})).To(Succeed())
+		runner.SideEffect = func(command string, args ...string) ([]byte, error) {
+			switch command {
+			case "lsblk":
+				return []byte(snapshotsLsblkJson), nil
+			case "snapper":
+				if slices.Contains(args, "list") {
+					return []byte(snapperRootList), nil
+				}
+			case "grub2-editenv":
+				switch args[1] {
+				case "list":
+					return tfs.ReadFile(args[0])
+				case "set":
+					return nil, tfs.WriteFile(args[0], []byte(strings.Join(args[2:], "\n")), vfs.FilePerm)
+				}
+			}
+			return []byte{}, nil
+		}
+
+		cliCmd = &cli.Command{
+			Writer: out,
+			Metadata: map[string]any{
+				"system": s,
+			},
+		}
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+	It("fails if no sys.System instance is in metadata", func() {
+		cliCmd.Metadata["system"] = nil
+		Expect(action.SnapshotList(context.Background(), cliCmd)).NotTo(Succeed())
+	})
+	It("fails if the deployment file does not exist", func() {
+		Expect(tfs.RemoveAll("/etc/elemental")).To(Succeed())
+		Expect(action.SnapshotList(context.Background(), cliCmd)).To(MatchError("deployment not found"))
+	})
+	It("lists snapshots in JSON format", func() {
+		cmd.SnapshotArgs.JSON = true
+		Expect(action.SnapshotList(context.Background(), cliCmd)).To(Succeed())
+		var snaps []*transaction.Snapshot
+		Expect(json.Unmarshal(out.Bytes(), &snaps)).To(Succeed())
+		Expect(snaps).To(HaveLen(2))
+		Expect(snaps[1].ID).To(Equal(3))
+		Expect(snaps[1].Default).To(BeTrue())
+		Expect(snaps[1].Description).To(Equal("snapshot created from parent snapshot 2"))
+	})
+	It("lists snapshots in a table", func() {
+		Expect(action.SnapshotList(context.Background(), cliCmd)).To(Succeed())
+		Expect(out.String()).To(ContainSubstring("2026-01-12 10:00:00"))
+	})
+	It("fails to show a snapshot if no ID is provided", func() {
+		Expect(action.SnapshotShow(context.Background(), cliCmd)).To(MatchError(ContainSubstring("no snapshot ID provided")))
+	})
+	It("fails to show a snapshot with an invalid ID", func() {
+		cliCmd.Action = action.SnapshotShow
+		Expect(cliCmd.Run(context.Background(), []string{"", "two"})).To(MatchError("invalid snapshot ID 'two'"))
+	})
+	It("shows a snapshot", func() {
+		cliCmd.Action = action.SnapshotShow
+		Expect(cliCmd.Run(context.Background(), []string{"", "2"})).To(Succeed())
+		Expect(out.String()).To(ContainSubstring("snapshot created from parent snapshot 1"))
+	})
+	It("rolls back to the previous snapshot", func() {
+		Expect(action.SnapshotRollback(context.Background(), cliCmd)).To(Succeed())
+		Expect(runner.MatchMilestones([][]string{
+			{"snapper", "--no-dbus", "modify", "--default", "2"},
+			{"grub2-editenv", "/boot/loader/entries/2", "list"},
+			{"grub2-editenv", "/boot/loader/entries/active", "set"},
+		})).To(Succeed())
+		active, err := tfs.ReadFile("/boot/loader/entries/active")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(strings.Split(string(active), "\n")).To(ContainElements("display_name=OS", "cmdline=snapshot2"))
+	})
+	It("fails to delete the default snapshot", func() {
+		cliCmd.Action = action.SnapshotDelete
+		Expect(cliCmd.Run(context.Background(), []string{"", "3"})).To(MatchError("cannot delete the active snapshot '3'"))
+	})
+})
@@ -0,0 +1,240 @@
+/*
+Copyright © 2022-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+
+	"github.com/urfave/cli/v3"
+
+	cmdpkg "github.com/suse/elemental/v3/internal/cli/cmd"
+	"github.com/suse/elemental/v3/pkg/extensions"
+	"github.com/suse/elemental/v3/pkg/manifest/api"
+	"github.com/suse/elemental/v3/pkg/manifest/resolver"
+	"github.com/suse/elemental/v3/pkg/upgrade"
+)
+
+// releaseStatus identifies a release or a component by name and version
+type releaseStatus struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// kubernetesStatus describes the Kubernetes distribution of the release and the last Kubernetes upgrade of the node
+type kubernetesStatus struct {
+	Distribution string                     `json:"distribution"`
+	Version      string                     `json:"version"`
+	Upgrade      *upgrade.KubernetesUpgrade `json:"upgrade,omitempty"`
+}
+
+type extensionStatus struct {
+	Name  string `json:"name"`
+	Image string `json:"image"`
+}
+
+// systemStatus describes the release, OS image, snapshot and boot state of the running system
+type systemStatus struct {
+	Release         *releaseStatus    `json:"release,omitempty"`
+	CorePlatform    *releaseStatus    `json:"corePlatform,omitempty"`
+	Solution        *releaseStatus    `json:"solution,omitempty"`
+	OSImage         string            `json:"osImage"`
+	OSImageDigest   string            `json:"osImageDigest,omitempty"`
+	ActiveSnapshot  int               `json:"activeSnapshot"`
+	DefaultSnapshot int               `json:"defaultSnapshot"`
+	Bootloader      string            `json:"bootloader"`
+	NextBootEntry   string            `json:"nextBootEntry,omitempty"`
+	NextBootPending bool              `json:"nextBootPending"`
+	Kubernetes      *kubernetesStatus `json:"kubernetes,omitempty"`
+	Extensions      []extensionStatus `json:"extensions"`
+	HelmCharts      []releaseStatus   `json:"helmCharts"`
+}
+
+// Status prints the status of the running system
+func Status(ctx context.Context, cmd *cli.Command) error {
+	ctxCancel, stop := signal.NotifyContext(ctx, syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	setup, err := digestSnapshotSetup(ctxCancel, cmd)
+	if err != nil {
+		return err
+	}
+
+	status, err := collectStatus(setup)
+	if err != nil {
+		setup.s.Logger().Error("Failed to collect system status")
+		return err
+	}
+
+	if cmdpkg.StatusArgs.JSON {
+		return printJSON(setup.out, status)
+	}
+
+	data := [][]string{}
+	if status.Release != nil {
+		data = append(data, []string{"Release", releaseString(status.Release)})
+	}
+	// the release is the core platform itself unless it is extended by a solution
+	if status.Solution != nil && status.CorePlatform != nil {
+		data = append(data, []string{"Core Platform", releaseString(status.CorePlatform)})
+	}
+	data = append(data, []string{"OS Image", status.OSImage})
+	if status.OSImageDigest != "" {
+		data = append(data, []string{"OS Image Digest", status.OSImageDigest})
+	}
+	data = append(data,
+		[]string{"Active Snapshot", strconv.Itoa(status.ActiveSnapshot)},
+		[]string{"Default Snapshot", strconv.Itoa(status.DefaultSnapshot)},
+		[]string{"Bootloader", status.Bootloader},
+	)
+	if status.NextBootEntry != "" {
+		next := status.NextBootEntry
+		if status.NextBootPending {
+			next += " (pending)"
+		}
+		data = append(data, []string{"Next Boot Entry", next})
+	}
+	if k := status.Kubernetes; k != nil {
+		data = append(data, []string{"Kubernetes", fmt.Sprintf("%s %s", k.Distribution, k.Version)})
+		if k.Upgrade != nil {
+			data = append(data, []string{"Kubernetes Upgrade", kubernetesUpgradeString(k.Upgrade)})
+		}
+	}
+	for _, ext := range status.Extensions {
+		data = append(data, []string{"Extension", fmt.Sprintf("%s (%s)", ext.Name, ext.Image)})
+	}
+	for _, chart := range status.HelmCharts {
+		data = append(data, []string{"Helm Chart", releaseString(&chart)})
+	}
+
+	table := newTable(false, setup.out)
+	table.Header([]string{"Attribute", "Value"})
+	return printAndClearData(table, data, setup.out)
+}
+
+// collectStatus gathers the status of the running system from its deployment, release manifest,
+// extensions, snapshots and bootloader.
+func collectStatus(setup *snapshotSetup) (*systemStatus, error) {
+	d := setup.d
+	status := &systemStatus{
+		Bootloader: "none",
+		Extensions: []extensionStatus{},
+		HelmCharts: []releaseStatus{},
+	}
+
+	if d.SourceOS != nil {
+		status.OSImage = d.SourceOS.String()
+		status.OSImageDigest = d.SourceOS.GetDigest()
+	}
+	if d.BootConfig != nil && d.BootConfig.Bootloader != "" {
+		status.Bootloader = d.BootConfig.Bootloader
+	}
+
+	rm, err := resolver.ParseManifestFile(setup.s, "/")
+	if err != nil {
+		return nil, fmt.Errorf("parsing release manifest: %w", err)
+	}
+	if rm != nil {
+		status.Release = metadataStatus(rm.Metadata())
+		if rm.CorePlatform != nil {
+			status.CorePlatform = metadataStatus(rm.CorePlatform.Metadata)
+			if k := rm.CorePlatform.Components.Kubernetes; k != nil {
+				status.Kubernetes = &kubernetesStatus{Distribution: k.GetDistribution(), Version: k.Version}
+			}
+			status.HelmCharts = append(status.HelmCharts, chartsStatus(rm.CorePlatform.Components.Helm)...)
+		}
+		if rm.SolutionExtension != nil {
+			status.Solution = metadataStatus(rm.SolutionExtension.Metadata)
+			status.HelmCharts = append(status.HelmCharts, chartsStatus(rm.SolutionExtension.Components.Helm)...)
+		}
+	}
+	if d.Release != nil {
+		status.Release = &releaseStatus{Name: d.Release.Name, Version: d.Release.Version}
+	}
+
+	if status.Kubernetes != nil {
+		status.Kubernetes.Upgrade, err = upgrade.ParseKubernetesUpgrade(setup.s, "/")
+		if err != nil {
+			return nil, fmt.Errorf("parsing kubernetes upgrade record: %w", err)
+		}
+	}
+
+	exts, err := extensions.Parse(setup.s, "/")
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("parsing extensions: %w", err)
+	}
+	for _, ext := range exts {
+		status.Extensions = append(status.Extensions, extensionStatus{Name: ext.Name, Image: ext.Image})
+	}
+
+	snaps, err := setup.t.ListSnapshots()
+	if err != nil {
+		return nil, fmt.Errorf("listing snapshots: %w", err)
+	}
+	for _, snap := range snaps {
+		if snap.Active {
+			status.ActiveSnapshot = snap.ID
+		}
+		if snap.Default {
+			status.DefaultSnapshot = snap.ID
+		}
+	}
+
+	status.NextBootEntry, err = setup.b.DefaultEntry(setup.espDir)
+	if err != nil {
+		return nil, fmt.Errorf("reading next boot entry: %w", err)
+	}
+	status.NextBootPending = status.NextBootEntry != "" && status.NextBootEntry != strconv.Itoa(status.ActiveSnapshot)
+
+	return status, nil
+}
+
+func metadataStatus(m *api.Metadata) *releaseStatus {
+	if m == nil {
+		return nil
+	}
+	return &releaseStatus{Name: m.Name, Version: m.Version}
+}
+
+func chartsStatus(h *api.Helm) []releaseStatus {
+	var charts []releaseStatus
+	if h == nil {
+		return charts
+	}
+	for _, c := range h.Charts {
+		charts = append(charts, releaseStatus{Name: c.GetName(), Version: c.Version})
+	}
+	return charts
+}
+
+func releaseString(r *releaseStatus) string {
+	return fmt.Sprintf("%s %s", r.Name, r.Version)
+}
+
+func kubernetesUpgradeString(k *upgrade.KubernetesUpgrade) string {
+	state := k.State
+	if k.Message != "" {
+		state += ": " + k.Message
+	}
+	return fmt.Sprintf("%s -> %s (%s)", k.FromVersion, k.ToVersion, state)
+}
@@ -0,0 +1,179 @@
+/*
+Copyright © 2022-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"slices"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/suse/elemental/v3/internal/cli/action"
+	"github.com/suse/elemental/v3/internal/cli/cmd"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/manifest/api"
+	"github.com/suse/elemental/v3/pkg/manifest/api/core"
+	"github.com/suse/elemental/v3/pkg/manifest/api/solution"
+	"github.com/suse/elemental/v3/pkg/manifest/resolver"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+	"github.com/suse/elemental/v3/pkg/upgrade"
+)
+
+var _ = Describe("Status action", Label("status"), func() {
+	var s *sys.System
+	var tfs vfs.FS
+	var cleanup func()
+	var err error
+	var cliCmd *cli.Command
+	var out *bytes.Buffer
+
+	BeforeEach(func() {
+		cmd.StatusArgs = cmd.StatusFlags{}
+		out = &bytes.Buffer{}
+		tfs, cleanup, err = sysmock.TestFS(map[string]string{
+			"/boot/loader/entries/2":         "display_name=OS (2)\ncmdline=snapshot2",
+			"/boot/loader/entries/3":         "display_name=OS (3)\ncmdline=snapshot3",
+			"/boot/loader/entries/active":    "display_name=OS\ncmdline=snapshot3\ntarget_id=3",
+			"/etc/elemental/extensions.yaml": "- name: elemental3ctl\n  image: registry.org/elemental3ctl:1.0\n",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		runner := sysmock.NewRunner()
+		mounter := sysmock.NewMounter()
+		s, err = sys.NewSystem(
+			sys.WithFS(tfs), sys.WithRunner(runner), sys.WithMounter(mounter),
+			sys.WithLogger(log.New(log.WithBuffer(&bytes.Buffer{}))),
+		)
+		Expect(err).NotTo(HaveOccurred())
+
+		d := deployment.DefaultDeployment()
+		d.Disks[0].Partitions[0].UUID = "c60d1845-7b04-4fc4-8639-8c49eb7277d5"
+		d.Disks[0].Partitions[1].UUID = "34a8abb8-ddb3-48a2-8ecc-2443e92c7510"
+		d.BootConfig.Bootloader = "grub"
+		d.SourceOS = deployment.NewOCISrc("registry.org/my/os:1.0")
+		d.SourceOS.SetDigest("sha256:0123456789")
+		d.Release = &deployment.Release{Name: "suse-edge", Version: "3.2.0"}
+		Expect(d.WriteDeploymentFile(s, "/")).To(Succeed())
+
+		rm := &resolver.ResolvedManifest{
+			CorePlatform: &core.ReleaseManifest{
+				Metadata: &api.Metadata{Name: "suse-core", Version: "1.0.0"},
+				Components: core.Components{
+					Kubernetes: &core.Kubernetes{Version: "v1.35.0+rke2r1"},
+					Helm:       &api.Helm{Charts: []*api.HelmChart{{Chart: "metallb", Version: "0.15.2"}}},
+				},
+			},
+			SolutionExtension: &solution.ReleaseManifest{
+				Metadata: &api.Metadata{Name: "suse-edge", Version: "3.2.0"},
+			},
+		}
+		Expect(resolver.WriteManifestFile(s, "/", rm)).To(Succeed())
+		Expect(upgrade.WriteKubernetesUpgrade(s, "/", &upgrade.KubernetesUpgrade{
+			Distribution: "rke2", FromVersion: "v1.34.2+rke2r1", ToVersion: "v1.35.0+rke2r1",
+			State: upgrade.KubernetesUpgradeStaged,
+		})).To(Succeed())
+
+		Expect(mounter.Mount("/dev/sda2", "/", "btrfs", []string{"ro", "subvol=@/.snapshots/2/snapshot"})).To(Succeed())
+		runner.SideEffect = func(command string, args ...string) ([]byte, error) {
+			switch command {
+			case "lsblk":
+				return []byte(snapshotsLsblkJson), nil
+			case "snapper":
+				if slices.Contains(args, "list") {
+					return []byte(snapperFallbackList), nil
+				}
+			case "grub2-editenv":
+				return tfs.ReadFile(args[0])
+			}
+			return []byte{}, nil
+		}
+
+		cliCmd = &cli.Command{
+			Metadata: map[string]any{
+				"system": s,
+			},
+			Writer: out,
+		}
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+	It("fails if no sys.System instance is in metadata", func() {
+		cliCmd.Metadata["system"] = nil
+		Expect(action.Status(context.Background(), cliCmd)).NotTo(Succeed())
+	})
+	It("prints the system status", func() {
+		Expect(action.Status(context.Background(), cliCmd)).To(Succeed())
+		Expect(out.String()).To(ContainSubstring("suse-edge 3.2.0"))
+		Expect(out.String()).To(ContainSubstring("suse-core 1.0.0"))
+		Expect(out.String()).To(ContainSubstring("sha256:0123456789"))
+		Expect(out.String()).To(ContainSubstring("elemental3ctl (registry.org/elemental3ctl:1.0)"))
+		Expect(out.String()).To(ContainSubstring("metallb 0.15.2"))
+		Expect(out.String()).To(ContainSubstring("3 (pending)"))
+		Expect(out.String()).To(ContainSubstring("rke2 v1.35.0+rke2r1"))
+		Expect(out.String()).To(ContainSubstring("v1.34.2+rke2r1 -> v1.35.0+rke2r1 (staged)"))
+	})
+	It("prints the system status in JSON format", func() {
+		cmd.StatusArgs.JSON = true
+		Expect(action.Status(context.Background(), cliCmd)).To(Succeed())
+
+		status := map[string]any{}
+		Expect(json.Unmarshal(out.Bytes(), &status)).To(Succeed())
+		Expect(status["release"]).To(Equal(map[string]any{"name": "suse-edge", "version": "3.2.0"}))
+		Expect(status["corePlatform"]).To(Equal(map[string]any{"name": "suse-core", "version": "1.0.0"}))
+		Expect(status["osImage"]).To(Equal("oci://registry.org/my/os:1.0"))
+		Expect(status["osImageDigest"]).To(Equal("sha256:0123456789"))
+		Expect(status["activeSnapshot"]).To(BeEquivalentTo(2))
+		Expect(status["defaultSnapshot"]).To(BeEquivalentTo(3))
+		Expect(status["bootloader"]).To(Equal("grub"))
+		Expect(status["nextBootEntry"]).To(Equal("3"))
+		Expect(status["nextBootPending"]).To(BeTrue())
+		Expect(status["extensions"]).To(HaveLen(1))
+		Expect(status["helmCharts"]).To(HaveLen(1))
+		Expect(status["kubernetes"]).To(Equal(map[string]any{
+			"distribution": "rke2",
+			"version":      "v1.35.0+rke2r1",
+			"upgrade": map[string]any{
+				"distribution": "rke2",
+				"fromVersion":  "v1.34.2+rke2r1",
+				"toVersion":    "v1.35.0+rke2r1",
+				"state":        "staged",
+			},
+		}))
+	})
+	It("reports the status of systems without release manifest nor extensions", func() {
+		Expect(tfs.Remove(resolver.ManifestFile)).To(Succeed())
+		Expect(tfs.Remove("/etc/elemental/extensions.yaml")).To(Succeed())
+		cmd.StatusArgs.JSON = true
+		Expect(action.Status(context.Background(), cliCmd)).To(Succeed())
+
+		status := map[string]any{}
+		Expect(json.Unmarshal(out.Bytes(), &status)).To(Succeed())
+		Expect(status["release"]).To(Equal(map[string]any{"name": "suse-edge", "version": "3.2.0"}))
+		Expect(status).NotTo(HaveKey("corePlatform"))
+		Expect(status).NotTo(HaveKey("kubernetes"))
+		Expect(status["extensions"]).To(BeEmpty())
+	})
+})
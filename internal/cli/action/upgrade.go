@@ -19,6 +19,7 @@ package action
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os/signal"
 	"syscall"
@@ -26,9 +27,12 @@ import (
 	"github.com/urfave/cli/v3"
 
 	cmdpkg "github.com/suse/elemental/v3/internal/cli/cmd"
+	"github.com/suse/elemental/v3/internal/image/kubernetes"
 	"github.com/suse/elemental/v3/pkg/bootloader"
 	"github.com/suse/elemental/v3/pkg/deployment"
 	"github.com/suse/elemental/v3/pkg/firmware"
+	"github.com/suse/elemental/v3/pkg/manifest/api"
+	"github.com/suse/elemental/v3/pkg/manifest/resolver"
 	"github.com/suse/elemental/v3/pkg/sys"
 	"github.com/suse/elemental/v3/pkg/unpack"
 	"github.com/suse/elemental/v3/pkg/upgrade"
@@ -44,7 +48,11 @@ func Upgrade(ctx context.Context, cmd *cli.Command) error {
 
 	s.Logger().Info("Starting upgrade action with args: %+v", args)
 
-	d, err := digestUpgradeSetup(s, args)
+	if args.DryRun {
+		return upgradeDryRun(ctx, cmd, s, args)
+	}
+
+	d, rm, err := digestUpgradeSetup(s, args)
 	if err != nil {
 		s.Logger().Error("Failed to collect upgrade setup")
 		return err
@@ -60,17 +68,29 @@ func Upgrade(ctx context.Context, cmd *cli.Command) error {
 		stop()
 	}()
 
-	bootloader, err := bootloader.New(d.BootConfig.Bootloader, s)
+	bootloader, err := bootloader.NewFromConfig(s, d.BootConfig)
 	if err != nil {
 		s.Logger().Error("Parsing boot config failed")
 		return err
 	}
 
+	verifier, err := setupSignatureVerifier(s, args.SignaturePolicy, args.Verify)
+	if err != nil {
+		return err
+	}
+
 	manager := firmware.NewEfiBootManager(s)
-	upgrader := upgrade.New(
-		ctxCancel, s, upgrade.WithBootloader(bootloader), upgrade.WithBootManager(manager),
-		upgrade.WithUnpackOpts(unpack.WithVerify(args.Verify), unpack.WithLocal(args.Local)),
-	)
+	opts := []upgrade.Option{
+		upgrade.WithBootloader(bootloader), upgrade.WithBootManager(manager),
+		upgrade.WithUnpackOpts(
+			unpack.WithVerify(args.Verify), unpack.WithLocal(args.Local), unpack.WithSignatureVerifier(verifier),
+		),
+		upgrade.WithDelta(args.Delta), upgrade.WithReleaseManifest(rm),
+	}
+	if args.Drain {
+		opts = append(opts, upgrade.WithKubernetesDrainer(kubernetes.NewNodeUpgrader(s)))
+	}
+	upgrader := upgrade.New(ctxCancel, s, opts...)
 
 	err = upgrader.Upgrade(d)
 	if err != nil {
@@ -83,24 +103,99 @@ func Upgrade(ctx context.Context, cmd *cli.Command) error {
 	return nil
 }
 
-func digestUpgradeSetup(s *sys.System, flags *cmdpkg.UpgradeFlags) (*deployment.Deployment, error) {
+// upgradeDryRun runs the upgrade pre-flight checks and prints the resulting plan. It fails
+// if any of the checks fails, the plan includes the reasons.
+func upgradeDryRun(ctx context.Context, cmd *cli.Command, s *sys.System, args *cmdpkg.UpgradeFlags) error {
+	var reasons []upgrade.Reason
+	d, _, err := parseUpgradeSetup(s, args, func(code string, err error) error {
+		reasons = append(reasons, upgrade.Reason{Code: code, Message: err.Error()})
+		return nil
+	})
+
+	var plan *upgrade.Plan
+	if err != nil {
+		plan = &upgrade.Plan{Image: args.OperatingSystemImage, MergedVolumes: []string{}}
+		reasons = append(reasons, upgrade.Reason{Code: upgrade.ReasonInvalidDeployment, Message: err.Error()})
+	} else {
+		verifier, err := setupSignatureVerifier(s, args.SignaturePolicy, args.Verify)
+		if err != nil {
+			return err
+		}
+
+		upgrader := upgrade.New(
+			ctx, s, upgrade.WithUnpackOpts(
+				unpack.WithVerify(args.Verify), unpack.WithLocal(args.Local), unpack.WithSignatureVerifier(verifier),
+			),
+		)
+		plan = upgrader.Plan(d, deployment.CheckDiskDevice)
+	}
+	plan.Reasons = append(reasons, plan.Reasons...)
+
+	out := cmd.Writer
+	if out == nil {
+		out = cmd.Root().Writer
+	}
+	err = printJSON(out, plan)
+	if err != nil {
+		return fmt.Errorf("printing upgrade plan: %w", err)
+	}
+	return plan.Error()
+}
+
+// setupCheck handles a failed upgrade setup check identified by the given reason code. A non nil
+// error aborts the setup.
+type setupCheck func(code string, err error) error
+
+func digestUpgradeSetup(s *sys.System, flags *cmdpkg.UpgradeFlags) (*deployment.Deployment, *resolver.ResolvedManifest, error) {
+	d, rm, err := parseUpgradeSetup(s, flags, func(_ string, err error) error { return err })
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = d.Sanitize(s, deployment.CheckDiskDevice)
+	if err != nil {
+		return nil, nil, fmt.Errorf("inconsistent deployment setup found: %w", err)
+	}
+	return d, rm, nil
+}
+
+// parseUpgradeSetup parses the current deployment and applies the given flags on top of it. It
+// also returns the release manifest of the target release, if any. Failures of the release, signing
+// keys and boot entry checks are passed to the given check, the setup only fails if it returns an error.
+func parseUpgradeSetup(
+	s *sys.System, flags *cmdpkg.UpgradeFlags, check setupCheck,
+) (*deployment.Deployment, *resolver.ResolvedManifest, error) {
 	d, err := deployment.Parse(s, "/")
 	if err != nil {
-		return nil, fmt.Errorf("parsing deployment: %w", err)
+		return nil, nil, fmt.Errorf("parsing deployment: %w", err)
 	} else if d == nil {
-		return nil, fmt.Errorf("deployment not found")
+		return nil, nil, fmt.Errorf("deployment not found")
 	}
 
 	srcOS, err := deployment.NewSrcFromURI(flags.OperatingSystemImage)
 	if err != nil {
-		return nil, fmt.Errorf("failed parsing OS source URI ('%s'): %w", flags.OperatingSystemImage, err)
+		return nil, nil, fmt.Errorf("failed parsing OS source URI ('%s'): %w", flags.OperatingSystemImage, err)
 	}
 	d.SourceOS = srcOS
 
+	var rm *resolver.ResolvedManifest
+	if flags.ReleaseManifest != "" {
+		rm, err = setUpgradeRelease(s, d, flags)
+		if err != nil {
+			code := upgrade.ReasonManifestUnavailable
+			if errors.Is(err, api.ErrUpgradeNotAllowed) {
+				code = upgrade.ReasonUpgradeNotAllowed
+			}
+			if err = check(code, err); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
 	if flags.Overlay != "" {
 		overlay, err := deployment.NewSrcFromURI(flags.Overlay)
 		if err != nil {
-			return nil, fmt.Errorf("failed parsing overlay source URI ('%s'): %w", flags.Overlay, err)
+			return nil, nil, fmt.Errorf("failed parsing overlay source URI ('%s'): %w", flags.Overlay, err)
 		}
 		d.OverlayTree = overlay
 	}
@@ -109,18 +204,61 @@ func digestUpgradeSetup(s *sys.System, flags *cmdpkg.UpgradeFlags) (*deployment.
 		d.CfgScript = flags.ConfigScript
 	}
 
-	if flags.CreateBootEntry {
-		if d.Firmware == nil {
-			d.Firmware = &deployment.FirmwareConfig{}
+	if d.BootConfig != nil {
+		setUKI(s, d, false, flags.UKISigningKey, flags.UKISigningCert, flags.UKIPCRKey)
+		if err = checkUKIKeys(d); err != nil {
+			if err = check(upgrade.ReasonMissingSigningKey, err); err != nil {
+				return nil, nil, err
+			}
 		}
-		d.Firmware.BootEntries = []*firmware.EfiBootEntry{
-			firmware.DefaultBootEntry(s.Platform(), d.Disks[0].Device),
+	}
+
+	if flags.CreateBootEntry {
+		if err = setBootEntry(s, d, d.Disks[0].Device); err != nil {
+			err = check(upgrade.ReasonBootEntryFailed, fmt.Errorf("setting EFI boot entry: %w", err))
+			if err != nil {
+				return nil, nil, err
+			}
 		}
 	}
+	return d, rm, nil
+}
 
-	err = d.Sanitize(s, deployment.CheckDiskDevice)
+// setUpgradeRelease checks the target release manifest defines an upgrade path from the installed
+// release and records the target release in the deployment. Upgrades not allowed by the release
+// manifest only proceed if forced. Returns the resolved release manifest.
+func setUpgradeRelease(s *sys.System, d *deployment.Deployment, flags *cmdpkg.UpgradeFlags) (*resolver.ResolvedManifest, error) {
+	uri, err := manifestURI(s, flags.ReleaseManifest)
+	if err != nil {
+		return nil, err
+	}
+
+	rm, err := resolveManifest(s, uri, flags.Local)
 	if err != nil {
-		return nil, fmt.Errorf("inconsistent deployment setup found: %w", err)
+		return nil, fmt.Errorf("resolving release manifest '%s': %w", flags.ReleaseManifest, err)
 	}
-	return d, nil
+
+	target := rm.Metadata()
+	if target == nil {
+		return nil, fmt.Errorf("release manifest '%s' does not include release metadata", flags.ReleaseManifest)
+	}
+
+	if d.Release == nil {
+		s.Logger().Warn("Installed release is unknown, skipping upgrade path checks")
+	} else {
+		err = target.CheckUpgradeFrom(d.Release.Name, d.Release.Version)
+		switch {
+		case err == nil:
+		case errors.Is(err, api.ErrUpgradeNotAllowed) && flags.Force:
+			s.Logger().Warn("Forcing upgrade: %v", err)
+		case errors.Is(err, api.ErrUpgradeNotAllowed):
+			return nil, fmt.Errorf("%w, use --force to upgrade anyway", err)
+		default:
+			return nil, fmt.Errorf("checking upgrade path: %w", err)
+		}
+	}
+
+	s.Logger().Info("Upgrading to release '%s' %s", target.Name, target.Version)
+	d.Release = &deployment.Release{Name: target.Name, Version: target.Version}
+	return rm, nil
 }
@@ -34,6 +34,19 @@ import (
 	"github.com/suse/elemental/v3/pkg/sys/vfs"
 )
 
+const upgradeManifest = `metadata:
+  name: suse-core
+  version: 1.2.0
+  minimumVersion: 1.1.0
+  upgradePathsFrom:
+  - 1.1.x
+components:
+  operatingSystem:
+    image:
+      base: registry.suse.com/elemental/base-os:1.2.0
+      iso: registry.suse.com/elemental/base-os-iso:1.2.0
+`
+
 var _ = Describe("Upgrade action", Label("upgrade"), func() {
 	var s *sys.System
 	var tfs vfs.FS
@@ -46,7 +59,8 @@ var _ = Describe("Upgrade action", Label("upgrade"), func() {
 		cmd.UpgradeArgs = cmd.UpgradeFlags{}
 		buffer = &bytes.Buffer{}
 		tfs, cleanup, err = sysmock.TestFS(map[string]string{
-			"/etc/elemental/deployment.yaml": badConfig,
+			"/etc/elemental/deployment.yaml":   badConfig,
+			"/manifests/release_manifest.yaml": upgradeManifest,
 		})
 		Expect(err).NotTo(HaveOccurred())
 		s, err = sys.NewSystem(
@@ -81,6 +95,103 @@ var _ = Describe("Upgrade action", Label("upgrade"), func() {
 		Expect(err).To(HaveOccurred())
 		Expect(err.Error()).To(ContainSubstring("inconsistent deployment"))
 	})
+	It("prints the failed pre-flight checks on dry runs", func() {
+		out := &bytes.Buffer{}
+		cliCmd.Writer = out
+		cmd.UpgradeArgs.OperatingSystemImage = "my.registry.org/my/image:test"
+		cmd.UpgradeArgs.DryRun = true
+		err = action.Upgrade(context.Background(), cliCmd)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("upgrade pre-flight checks failed: InvalidDeployment"))
+		Expect(out.String()).To(ContainSubstring(`"image": "oci://my.registry.org/my/image:test"`))
+		Expect(out.String()).To(ContainSubstring(`"code": "InvalidDeployment"`))
+	})
+	It("prints a disallowed upgrade path as a failed pre-flight check on dry runs", func() {
+		out := &bytes.Buffer{}
+		cliCmd.Writer = out
+		Expect(tfs.WriteFile(
+			"/etc/elemental/deployment.yaml", []byte("release:\n  name: suse-core\n  version: 1.0.3\n"+badConfig), vfs.FilePerm,
+		)).To(Succeed())
+		cmd.UpgradeArgs.OperatingSystemImage = "my.registry.org/my/image:test"
+		manifestPath, err := tfs.RawPath("/manifests/release_manifest.yaml")
+		Expect(err).NotTo(HaveOccurred())
+		cmd.UpgradeArgs.ReleaseManifest = "file://" + manifestPath
+		cmd.UpgradeArgs.Local = true
+		cmd.UpgradeArgs.DryRun = true
+		err = action.Upgrade(context.Background(), cliCmd)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("UpgradeNotAllowed: upgrade not allowed: 'suse-core' 1.2.0 requires at least version 1.1.0"))
+		Expect(out.String()).To(ContainSubstring(`"code": "UpgradeNotAllowed"`))
+		Expect(out.String()).To(ContainSubstring(`"code": "InvalidDeployment"`))
+	})
+	It("prints missing signing keys as a failed pre-flight check on dry runs", func() {
+		out := &bytes.Buffer{}
+		cliCmd.Writer = out
+		Expect(tfs.WriteFile(
+			"/etc/elemental/deployment.yaml",
+			[]byte("bootloader:\n  name: systemd-boot\n  uki:\n    enabled: true\n    signingCert: /keys/db.crt\n"+badConfig),
+			vfs.FilePerm,
+		)).To(Succeed())
+		cmd.UpgradeArgs.OperatingSystemImage = "my.registry.org/my/image:test"
+		cmd.UpgradeArgs.DryRun = true
+		err = action.Upgrade(context.Background(), cliCmd)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("MissingSigningKey: signing key for certificate '/keys/db.crt' is required"))
+		Expect(out.String()).To(ContainSubstring(`"code": "MissingSigningKey"`))
+	})
+	It("prints the plan on dry runs if the deployment file does not exist", func() {
+		out := &bytes.Buffer{}
+		cliCmd.Writer = out
+		Expect(tfs.RemoveAll("/etc/elemental")).To(Succeed())
+		cmd.UpgradeArgs.OperatingSystemImage = "my.registry.org/my/image:test"
+		cmd.UpgradeArgs.DryRun = true
+		err = action.Upgrade(context.Background(), cliCmd)
+		Expect(err).To(MatchError("upgrade pre-flight checks failed: InvalidDeployment: deployment not found"))
+		Expect(out.String()).To(ContainSubstring(`"code": "InvalidDeployment"`))
+	})
+	It("fails if the release manifest does not define an upgrade path from the installed release", func() {
+		Expect(tfs.WriteFile(
+			"/etc/elemental/deployment.yaml", []byte("release:\n  name: suse-core\n  version: 1.0.3\n"+badConfig), vfs.FilePerm,
+		)).To(Succeed())
+		cmd.UpgradeArgs.OperatingSystemImage = "my.registry.org/my/image:test"
+		manifestPath, err := tfs.RawPath("/manifests/release_manifest.yaml")
+		Expect(err).NotTo(HaveOccurred())
+		cmd.UpgradeArgs.ReleaseManifest = "file://" + manifestPath
+		cmd.UpgradeArgs.Local = true
+		err = action.Upgrade(context.Background(), cliCmd)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("upgrade not allowed: 'suse-core' 1.2.0 requires at least version 1.1.0"))
+		Expect(err.Error()).To(ContainSubstring("use --force"))
+	})
+	It("forces upgrades not allowed by the release manifest", func() {
+		Expect(tfs.WriteFile(
+			"/etc/elemental/deployment.yaml", []byte("release:\n  name: suse-core\n  version: 1.3.0\n"+badConfig), vfs.FilePerm,
+		)).To(Succeed())
+		cmd.UpgradeArgs.OperatingSystemImage = "my.registry.org/my/image:test"
+		manifestPath, err := tfs.RawPath("/manifests/release_manifest.yaml")
+		Expect(err).NotTo(HaveOccurred())
+		cmd.UpgradeArgs.ReleaseManifest = "file://" + manifestPath
+		cmd.UpgradeArgs.Local = true
+		cmd.UpgradeArgs.Force = true
+		err = action.Upgrade(context.Background(), cliCmd)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("inconsistent deployment"))
+		Expect(buffer.String()).To(ContainSubstring("Forcing upgrade: upgrade not allowed: downgrading 'suse-core' from 1.3.0 to 1.2.0"))
+	})
+	It("requires the unified kernel images signing key again on upgrades", func() {
+		Expect(tfs.WriteFile(
+			"/etc/elemental/deployment.yaml",
+			[]byte("bootloader:\n  name: systemd-boot\n  uki:\n    enabled: true\n    signingCert: /keys/db.crt\n"+badConfig),
+			vfs.FilePerm,
+		)).To(Succeed())
+		cmd.UpgradeArgs.OperatingSystemImage = "my.registry.org/my/image:test"
+		err = action.Upgrade(context.Background(), cliCmd)
+		Expect(err).To(MatchError("signing key for certificate '/keys/db.crt' is required to sign unified kernel images"))
+
+		cmd.UpgradeArgs.UKISigningKey = "/keys/db.key"
+		err = action.Upgrade(context.Background(), cliCmd)
+		Expect(err.Error()).To(ContainSubstring("inconsistent deployment"))
+	})
 	It("fails if the given OS uri is not valid", func() {
 		cmd.UpgradeArgs.OperatingSystemImage = "https://example.com/my/image"
 		err = action.Upgrade(context.Background(), cliCmd)
@@ -0,0 +1,46 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urfave/cli/v3"
+)
+
+// BootActions groups the actions of each boot sub command
+type BootActions struct {
+	MarkGood func(context.Context, *cli.Command) error
+}
+
+func NewBootCommand(appName string, actions BootActions) *cli.Command {
+	return &cli.Command{
+		Name:      "boot",
+		Usage:     "Manage the boot assessment of the installed system",
+		UsageText: fmt.Sprintf("%s boot <command>", appName),
+		Commands: []*cli.Command{
+			{
+				Name:      "mark-good",
+				Usage:     "Mark the current boot as successful and keep the booted snapshot",
+				UsageText: fmt.Sprintf("%s boot mark-good", appName),
+				Action:    actions.MarkGood,
+			},
+		},
+	}
+}
@@ -30,12 +30,15 @@ type InstallerFlags struct {
 	ConfigScript         string
 	Local                bool
 	Verify               bool
+	SignaturePolicy      string
 	Name                 string
 	OutputDir            string
 	Overlay              string
 	Label                string
 	KernelCmdLine        string
 	Type                 string
+	NetbootURL           string
+	Push                 string
 }
 
 var InstallerArgs InstallerFlags
@@ -84,6 +87,11 @@ func NewBuildInstallerCommand(appName string, action func(context.Context, *cli.
 				Usage:       verifyDesc,
 				Destination: &InstallerArgs.Verify,
 			},
+			&cli.StringFlag{
+				Name:        signaturePolicyFlg,
+				Usage:       signaturePolicyDesc,
+				Destination: &InstallerArgs.SignaturePolicy,
+			},
 			&cli.BoolFlag{
 				Name:        localFlg,
 				Usage:       localDesc,
@@ -123,10 +131,20 @@ func NewBuildInstallerCommand(appName string, action func(context.Context, *cli.
 			},
 			&cli.StringFlag{
 				Name:        "type",
-				Usage:       "Type of the installer media, 'iso' or 'raw'",
+				Usage:       "Type of the installer media, 'iso', 'raw' or 'netboot'",
 				Destination: &InstallerArgs.Type,
 				Required:    true,
 			},
+			&cli.StringFlag{
+				Name:        netbootURLFlg,
+				Usage:       netbootURLDesc,
+				Destination: &InstallerArgs.NetbootURL,
+			},
+			&cli.StringFlag{
+				Name:        pushFlg,
+				Usage:       pushDesc,
+				Destination: &InstallerArgs.Push,
+			},
 		},
 	}
 }
@@ -0,0 +1,89 @@
+/*
+Copyright © 2022-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+
+	"github.com/urfave/cli/v3"
+)
+
+type BundleExportFlags struct {
+	ConfigDir       string
+	OutputPath      string
+	Platform        string
+	SignaturePolicy string
+	Local           bool
+}
+
+var BundleExportArgs BundleExportFlags
+
+var bundleExportDescription = `bundle export resolves the release manifest of a configuration directory and stores all the
+release manifests, OS images, Kubernetes artifacts, systemd extensions, remote Kubernetes manifests and Helm charts
+its customization fetches into an OCI layout directory, or into a tarball if the output path has the .tar suffix.
+The bundle is then used with 'customize --bundle' on hosts without registry or network access.`
+
+func NewBundleCommand(appName string, exportAction func(context.Context, *cli.Command) error) *cli.Command {
+	return &cli.Command{
+		Name:      "bundle",
+		Usage:     "Manage offline bundles for air-gapped customizations",
+		UsageText: fmt.Sprintf("%s bundle <command> [OPTIONS]", appName),
+		Commands: []*cli.Command{
+			{
+				Name:        "export",
+				Usage:       "Export all the artifacts required to customize a configuration into an offline bundle",
+				Description: fmt.Sprintf("%s %s", appName, bundleExportDescription),
+				UsageText:   fmt.Sprintf("%s bundle export [OPTIONS] --output <bundle-dir|bundle.tar>", appName),
+				Action:      exportAction,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:        "config-dir",
+						Usage:       "Full path to the image configuration directory",
+						Destination: &BundleExportArgs.ConfigDir,
+						Value:       "/config",
+					},
+					&cli.StringFlag{
+						Name:        outputFlg,
+						Aliases:     []string{"o"},
+						Usage:       "Bundle directory, or bundle tarball if it has the .tar suffix",
+						Destination: &BundleExportArgs.OutputPath,
+						Required:    true,
+					},
+					&cli.StringFlag{
+						Name:        platformFlg,
+						Usage:       platformDesc,
+						Destination: &BundleExportArgs.Platform,
+						Value:       fmt.Sprintf("linux/%s", runtime.GOARCH),
+					},
+					&cli.BoolFlag{
+						Name:        localFlg,
+						Usage:       localDesc,
+						Destination: &BundleExportArgs.Local,
+					},
+					&cli.StringFlag{
+						Name:        signaturePolicyFlg,
+						Usage:       signaturePolicyDesc,
+						Destination: &BundleExportArgs.SignaturePolicy,
+					},
+				},
+			},
+		},
+	}
+}
@@ -22,6 +22,14 @@ const (
 	localFlg  = "local"
 	localDesc = "Load OCI images from the local container storage instead of a remote registry"
 
+	// --delta flag name and description
+	deltaFlg  = "delta"
+	deltaDesc = "Only fetch and apply the OS image layers not included in the current snapshot"
+
+	// --dry-run flag name and description
+	dryRunFlg  = "dry-run"
+	dryRunDesc = "Run the upgrade pre-flight checks and print the upgrade plan in JSON format without applying it"
+
 	// --verify flag name and description
 	verifyFlg  = "verify"
 	verifyDesc = "Verify OCI ssl"
@@ -42,6 +50,30 @@ const (
 	createBootFlg  = "create-boot-entry"
 	createBootDesc = "Create EFI boot entry"
 
+	// --bootloader flag name and description
+	bootloaderFlg  = "bootloader"
+	bootloaderDesc = "Bundled bootloader to install to ESP (grub, systemd-boot or none)"
+
+	// --uki flag name and description
+	ukiFlg  = "uki"
+	ukiDesc = "Boot Unified Kernel Images, requires systemd-boot bootloader"
+
+	// --bios flag name and description
+	biosFlg  = "bios"
+	biosDesc = "Enable legacy BIOS boot in addition to UEFI, requires grub bootloader"
+
+	// --uki-signing-key flag name and description
+	ukiKeyFlg  = "uki-signing-key"
+	ukiKeyDesc = "Path to the private key used to sign Unified Kernel Images and the bootloader"
+
+	// --uki-signing-cert flag name and description
+	ukiCertFlg  = "uki-signing-cert"
+	ukiCertDesc = "Path to the certificate used to sign Unified Kernel Images and the bootloader"
+
+	// --uki-pcr-key flag name and description
+	ukiPCRKeyFlg  = "uki-pcr-key"
+	ukiPCRKeyDesc = "Path to the private key used to sign the PCR 11 policy of Unified Kernel Images"
+
 	// --platform flag name and description
 	platformFlg  = "platform"
 	platformDesc = "Target platform"
@@ -53,4 +85,44 @@ const (
 	// --output flag name and description
 	outputFlg  = "output"
 	outputDesc = "File/Path for the generated files"
+
+	// --netboot-url flag name and description
+	netbootURLFlg  = "netboot-url"
+	netbootURLDesc = "Base HTTP(S) URL the netboot artifacts are served from, required for 'netboot' media"
+
+	// --signature-policy flag name and description
+	signaturePolicyFlg  = "signature-policy"
+	signaturePolicyDesc = "Path to the signature policy of OCI images, defaults to /etc/elemental/signature-policy.yaml if present"
+
+	// --push flag name and description
+	pushFlg  = "push"
+	pushDesc = "Push the resulting image, checksum and descriptions as an OCI artifact to the given 'oci://registry/repository:tag' reference"
+
+	// --release-manifest flag name and description
+	releaseManifestFlg  = "release-manifest"
+	releaseManifestDesc = "Release manifest file or OCI image of the target release, used to check the upgrade path from the installed release and to upgrade Kubernetes"
+
+	// --force flag name and description
+	forceFlg  = "force"
+	forceDesc = "Upgrade even if the release manifest does not define an upgrade path from the installed release"
+
+	// --bundle flag name and description
+	bundleFlg  = "bundle"
+	bundleDesc = "Offline bundle directory or tarball, created by 'bundle export', to fetch all release manifests, images, files and Helm charts from"
+
+	// --image flag name and description
+	imageFlg  = "image"
+	imageDesc = "OCI image or HTTP(S) URL of the extension image, defaults to the one of the installed release manifest"
+
+	// --kernel-modules flag name and description
+	kernelModulesFlg  = "kernel-modules"
+	kernelModulesDesc = "Kernel modules shipped by the extension to load once enabled, defaults to the ones of the installed release manifest"
+
+	// --drain flag name and description
+	drainFlg  = "drain"
+	drainDesc = "Drain the node before rebooting when the release manifest upgrades the Kubernetes version"
+
+	// --json flag name and description
+	jsonFlg  = "json"
+	jsonDesc = "Print the output in JSON format"
 )
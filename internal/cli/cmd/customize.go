@@ -23,17 +23,23 @@ import (
 	"runtime"
 	"slices"
 
+	"github.com/suse/elemental/v3/pkg/diskimage"
 	"github.com/suse/elemental/v3/pkg/installer"
 	"github.com/urfave/cli/v3"
 )
 
 type CustomizeFlags struct {
-	ConfigDir  string
-	OutputPath string
-	Mode       string
-	Platform   string
-	MediaType  string
-	Local      bool
+	ConfigDir       string
+	OutputPath      string
+	Mode            string
+	Platform        string
+	MediaType       string
+	DiskFormat      string
+	NetbootURL      string
+	Push            string
+	SignaturePolicy string
+	Bundle          string
+	Local           bool
 }
 
 var CustomizeArgs CustomizeFlags
@@ -49,16 +55,42 @@ func NewCustomizeCommand(appName string, action func(context.Context, *cli.Comma
 				return ctx, cli.Exit("Error: Unsupported --mode option.", 1)
 			}
 
+			types := []string{
+				installer.ISO.String(), installer.Disk.String(), installer.Netboot.String(), diskimage.MediaType,
+			}
+			if !slices.Contains(types, CustomizeArgs.MediaType) {
+				return ctx, cli.Exit("Error: Unsupported --type option.", 1)
+			}
+
+			if _, err := diskimage.ParseFormat(CustomizeArgs.DiskFormat); err != nil {
+				return ctx, cli.Exit("Error: Unsupported --disk-format option.", 1)
+			}
+
+			if CustomizeArgs.Bundle != "" && CustomizeArgs.Local {
+				return ctx, cli.Exit("Error: --bundle can't be combined with --local option.", 1)
+			}
+
 			return ctx, nil
 		},
 		Action: action,
 		Flags: []cli.Flag{
 			&cli.StringFlag{
 				Name:        "type",
-				Usage:       "Type of the installer media, 'iso' or 'raw'",
+				Usage:       "Type of the media, 'iso', 'raw' or 'netboot' installers, or a preinstalled 'disk' image",
 				Destination: &CustomizeArgs.MediaType,
 				Value:       installer.ISO.String(),
 			},
+			&cli.StringFlag{
+				Name:        "disk-format",
+				Usage:       "File format of preinstalled disk images, 'raw', 'qcow2', 'vmdk' or 'vhdx'",
+				Destination: &CustomizeArgs.DiskFormat,
+				Value:       string(diskimage.Raw),
+			},
+			&cli.StringFlag{
+				Name:        netbootURLFlg,
+				Usage:       netbootURLDesc,
+				Destination: &CustomizeArgs.NetbootURL,
+			},
 			&cli.StringFlag{
 				Name:        "config-dir",
 				Usage:       "Full path to the image configuration directory",
@@ -72,6 +104,11 @@ func NewCustomizeCommand(appName string, action func(context.Context, *cli.Comma
 				Destination: &CustomizeArgs.OutputPath,
 				DefaultText: "image-<timestamp>.<image-type>",
 			},
+			&cli.StringFlag{
+				Name:        pushFlg,
+				Usage:       pushDesc,
+				Destination: &CustomizeArgs.Push,
+			},
 			&cli.StringFlag{
 				Name: "mode",
 				Usage: "Customization mode, 'embedded' (config partition within image) or " +
@@ -90,6 +127,16 @@ func NewCustomizeCommand(appName string, action func(context.Context, *cli.Comma
 				Usage:       localDesc,
 				Destination: &CustomizeArgs.Local,
 			},
+			&cli.StringFlag{
+				Name:        signaturePolicyFlg,
+				Usage:       signaturePolicyDesc,
+				Destination: &CustomizeArgs.SignaturePolicy,
+			},
+			&cli.StringFlag{
+				Name:        bundleFlg,
+				Usage:       bundleDesc,
+				Destination: &CustomizeArgs.Bundle,
+			},
 		},
 	}
 }
@@ -0,0 +1,108 @@
+/*
+Copyright © 2022-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urfave/cli/v3"
+)
+
+type ExtensionFlags struct {
+	JSON            bool
+	Image           string
+	KernelModules   []string
+	Local           bool
+	SignaturePolicy string
+}
+
+var ExtensionArgs ExtensionFlags
+
+// ExtensionActions groups the actions of each extension sub command
+type ExtensionActions struct {
+	List   func(context.Context, *cli.Command) error
+	Add    func(context.Context, *cli.Command) error
+	Update func(context.Context, *cli.Command) error
+	Remove func(context.Context, *cli.Command) error
+}
+
+func NewExtensionCommand(appName string, actions ExtensionActions) *cli.Command {
+	return &cli.Command{
+		Name:      "extension",
+		Usage:     "Manage the systemd extensions of the installed system",
+		UsageText: fmt.Sprintf("%s extension <command> [OPTIONS]", appName),
+		Commands: []*cli.Command{
+			{
+				Name:      "list",
+				Usage:     "List the enabled systemd extensions",
+				UsageText: fmt.Sprintf("%s extension list [OPTIONS]", appName),
+				Action:    actions.List,
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:        jsonFlg,
+						Usage:       jsonDesc,
+						Destination: &ExtensionArgs.JSON,
+					},
+				},
+			}, {
+				Name:      "add",
+				Usage:     "Install and enable a systemd extension",
+				UsageText: fmt.Sprintf("%s extension add [OPTIONS] <name>", appName),
+				Action:    actions.Add,
+				Flags:     extensionImageFlags(),
+			}, {
+				Name:      "update",
+				Usage:     "Replace the image of an enabled systemd extension",
+				UsageText: fmt.Sprintf("%s extension update [OPTIONS] <name>", appName),
+				Action:    actions.Update,
+				Flags:     extensionImageFlags(),
+			}, {
+				Name:      "remove",
+				Usage:     "Disable and remove a systemd extension",
+				UsageText: fmt.Sprintf("%s extension remove <name>", appName),
+				Action:    actions.Remove,
+			},
+		},
+	}
+}
+
+func extensionImageFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:        imageFlg,
+			Usage:       imageDesc,
+			Destination: &ExtensionArgs.Image,
+		},
+		&cli.StringSliceFlag{
+			Name:        kernelModulesFlg,
+			Usage:       kernelModulesDesc,
+			Destination: &ExtensionArgs.KernelModules,
+		},
+		&cli.BoolFlag{
+			Name:        localFlg,
+			Usage:       localDesc,
+			Destination: &ExtensionArgs.Local,
+		},
+		&cli.StringFlag{
+			Name:        signaturePolicyFlg,
+			Usage:       signaturePolicyDesc,
+			Destination: &ExtensionArgs.SignaturePolicy,
+		},
+	}
+}
@@ -32,9 +32,15 @@ type InstallFlags struct {
 	Overlay              string
 	CreateBootEntry      bool
 	Bootloader           string
+	UKI                  bool
+	BIOS                 bool
+	UKISigningKey        string
+	UKISigningCert       string
+	UKIPCRKey            string
 	KernelCmdline        string
 	Verify               bool
 	Local                bool
+	SignaturePolicy      string
 	CryptoPolicy         string
 	Snapshotter          string
 }
@@ -81,12 +87,37 @@ func NewInstallCommand(appName string, action func(context.Context, *cli.Command
 				Destination: &InstallArgs.CreateBootEntry,
 			},
 			&cli.StringFlag{
-				Name:        "bootloader",
+				Name:        bootloaderFlg,
 				Aliases:     []string{"b"},
 				Value:       "grub",
-				Usage:       "Bundled bootloader to install to ESP",
+				Usage:       bootloaderDesc,
 				Destination: &InstallArgs.Bootloader,
 			},
+			&cli.BoolFlag{
+				Name:        ukiFlg,
+				Usage:       ukiDesc,
+				Destination: &InstallArgs.UKI,
+			},
+			&cli.BoolFlag{
+				Name:        biosFlg,
+				Usage:       biosDesc,
+				Destination: &InstallArgs.BIOS,
+			},
+			&cli.StringFlag{
+				Name:        ukiKeyFlg,
+				Usage:       ukiKeyDesc,
+				Destination: &InstallArgs.UKISigningKey,
+			},
+			&cli.StringFlag{
+				Name:        ukiCertFlg,
+				Usage:       ukiCertDesc,
+				Destination: &InstallArgs.UKISigningCert,
+			},
+			&cli.StringFlag{
+				Name:        ukiPCRKeyFlg,
+				Usage:       ukiPCRKeyDesc,
+				Destination: &InstallArgs.UKIPCRKey,
+			},
 			&cli.StringFlag{
 				Name:        cmdlineFlg,
 				Value:       "",
@@ -99,6 +130,11 @@ func NewInstallCommand(appName string, action func(context.Context, *cli.Command
 				Usage:       verifyDesc,
 				Destination: &InstallArgs.Verify,
 			},
+			&cli.StringFlag{
+				Name:        signaturePolicyFlg,
+				Usage:       signaturePolicyDesc,
+				Destination: &InstallArgs.SignaturePolicy,
+			},
 			&cli.BoolFlag{
 				Name:        localFlg,
 				Usage:       localDesc,
@@ -0,0 +1,53 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urfave/cli/v3"
+)
+
+// KubernetesActions groups the actions of each kubernetes sub command
+type KubernetesActions struct {
+	Upgrade  func(context.Context, *cli.Command) error
+	Uncordon func(context.Context, *cli.Command) error
+}
+
+func NewKubernetesCommand(appName string, actions KubernetesActions) *cli.Command {
+	return &cli.Command{
+		Name:      "kubernetes",
+		Usage:     "Manage the Kubernetes distribution of the installed system",
+		UsageText: fmt.Sprintf("%s kubernetes <command>", appName),
+		Commands: []*cli.Command{
+			{
+				Name:      "upgrade",
+				Usage:     "Install the Kubernetes upgrade staged by the last OS upgrade",
+				UsageText: fmt.Sprintf("%s kubernetes upgrade", appName),
+				Action:    actions.Upgrade,
+			},
+			{
+				Name:      "uncordon",
+				Usage:     "Uncordon the node drained by an OS upgrade once Kubernetes is up",
+				UsageText: fmt.Sprintf("%s kubernetes uncordon", appName),
+				Action:    actions.Uncordon,
+			},
+		},
+	}
+}
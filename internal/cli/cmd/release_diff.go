@@ -0,0 +1,64 @@
+/*
+Copyright © 2022-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urfave/cli/v3"
+)
+
+type ReleaseDiffFlags struct {
+	Local    bool
+	Markdown bool
+	JSON     bool
+}
+
+var ReleaseDiffArgs ReleaseDiffFlags
+
+var releaseDiffDescription = `release-diff takes as arguments two release manifests, each either an OCI image containing a release
+manifest file in it or a local release manifest file, and prints the component changes from the old to the new release.`
+
+func NewReleaseDiffCommand(appName string, releaseDiffAction func(context.Context, *cli.Command) error) *cli.Command {
+	return &cli.Command{
+		Name:        "release-diff",
+		Usage:       "Prints the component differences between two Core or Solution release manifests",
+		Description: fmt.Sprintf("%s %s", appName, releaseDiffDescription),
+		UsageText:   fmt.Sprintf("%s release-diff [options] <old-manifest> <new-manifest>", appName),
+		Action:      releaseDiffAction,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:        localFlg,
+				Usage:       localDesc,
+				Destination: &ReleaseDiffArgs.Local,
+			},
+			&cli.BoolFlag{
+				Name:        "markdown",
+				Aliases:     []string{"m"},
+				Usage:       "Generate markdown output that can be copy-paste into a markdown editor",
+				Destination: &ReleaseDiffArgs.Markdown,
+			},
+			&cli.BoolFlag{
+				Name:        jsonFlg,
+				Usage:       jsonDesc,
+				Destination: &ReleaseDiffArgs.JSON,
+			},
+		},
+	}
+}
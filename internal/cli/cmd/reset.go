@@ -59,10 +59,10 @@ func NewResetCommand(appName string, action func(context.Context, *cli.Command)
 				Value:       true,
 			},
 			&cli.StringFlag{
-				Name:        "bootloader",
+				Name:        bootloaderFlg,
 				Aliases:     []string{"b"},
 				Value:       "grub",
-				Usage:       "Bundled bootloader to install to ESP",
+				Usage:       bootloaderDesc,
 				Destination: &InstallArgs.Bootloader,
 			},
 			&cli.StringFlag{
@@ -0,0 +1,86 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urfave/cli/v3"
+)
+
+type SnapshotFlags struct {
+	JSON bool
+}
+
+var SnapshotArgs SnapshotFlags
+
+// SnapshotActions groups the actions of each snapshot sub command
+type SnapshotActions struct {
+	List       func(context.Context, *cli.Command) error
+	Show       func(context.Context, *cli.Command) error
+	Rollback   func(context.Context, *cli.Command) error
+	Delete     func(context.Context, *cli.Command) error
+	SetDefault func(context.Context, *cli.Command) error
+}
+
+func NewSnapshotCommand(appName string, actions SnapshotActions) *cli.Command {
+	return &cli.Command{
+		Name:      "snapshot",
+		Usage:     "Manage the snapshots of the installed system",
+		UsageText: fmt.Sprintf("%s snapshot <command> [OPTIONS]", appName),
+		Commands: []*cli.Command{
+			{
+				Name:      "list",
+				Usage:     "List the system snapshots",
+				UsageText: fmt.Sprintf("%s snapshot list [OPTIONS]", appName),
+				Action:    actions.List,
+				Flags:     []cli.Flag{jsonFlag()},
+			}, {
+				Name:      "show",
+				Usage:     "Show the details of a system snapshot",
+				UsageText: fmt.Sprintf("%s snapshot show [OPTIONS] <id>", appName),
+				Action:    actions.Show,
+				Flags:     []cli.Flag{jsonFlag()},
+			}, {
+				Name:      "rollback",
+				Usage:     "Boot the given snapshot from now on, defaults to the previous snapshot",
+				UsageText: fmt.Sprintf("%s snapshot rollback [<id>]", appName),
+				Action:    actions.Rollback,
+			}, {
+				Name:      "delete",
+				Usage:     "Delete a system snapshot and its boot entry",
+				UsageText: fmt.Sprintf("%s snapshot delete <id>", appName),
+				Action:    actions.Delete,
+			}, {
+				Name:      "set-default",
+				Usage:     "Set the given snapshot as the default boot snapshot",
+				UsageText: fmt.Sprintf("%s snapshot set-default <id>", appName),
+				Action:    actions.SetDefault,
+			},
+		},
+	}
+}
+
+func jsonFlag() cli.Flag {
+	return &cli.BoolFlag{
+		Name:        jsonFlg,
+		Usage:       jsonDesc,
+		Destination: &SnapshotArgs.JSON,
+	}
+}
@@ -29,8 +29,17 @@ type UpgradeFlags struct {
 	ConfigScript         string
 	Overlay              string
 	Verify               bool
+	SignaturePolicy      string
 	CreateBootEntry      bool
 	Local                bool
+	Delta                bool
+	DryRun               bool
+	UKISigningKey        string
+	UKISigningCert       string
+	UKIPCRKey            string
+	ReleaseManifest      string
+	Force                bool
+	Drain                bool
 }
 
 var UpgradeArgs UpgradeFlags
@@ -64,6 +73,11 @@ func NewUpgradeCommand(appName string, action func(context.Context, *cli.Command
 				Usage:       verifyDesc,
 				Destination: &UpgradeArgs.Verify,
 			},
+			&cli.StringFlag{
+				Name:        signaturePolicyFlg,
+				Usage:       signaturePolicyDesc,
+				Destination: &UpgradeArgs.SignaturePolicy,
+			},
 			&cli.BoolFlag{
 				Name:        createBootFlg,
 				Usage:       createBootDesc,
@@ -74,6 +88,46 @@ func NewUpgradeCommand(appName string, action func(context.Context, *cli.Command
 				Usage:       localDesc,
 				Destination: &UpgradeArgs.Local,
 			},
+			&cli.BoolFlag{
+				Name:        deltaFlg,
+				Usage:       deltaDesc,
+				Destination: &UpgradeArgs.Delta,
+			},
+			&cli.BoolFlag{
+				Name:        dryRunFlg,
+				Usage:       dryRunDesc,
+				Destination: &UpgradeArgs.DryRun,
+			},
+			&cli.StringFlag{
+				Name:        ukiKeyFlg,
+				Usage:       ukiKeyDesc,
+				Destination: &UpgradeArgs.UKISigningKey,
+			},
+			&cli.StringFlag{
+				Name:        ukiCertFlg,
+				Usage:       ukiCertDesc,
+				Destination: &UpgradeArgs.UKISigningCert,
+			},
+			&cli.StringFlag{
+				Name:        ukiPCRKeyFlg,
+				Usage:       ukiPCRKeyDesc,
+				Destination: &UpgradeArgs.UKIPCRKey,
+			},
+			&cli.StringFlag{
+				Name:        releaseManifestFlg,
+				Usage:       releaseManifestDesc,
+				Destination: &UpgradeArgs.ReleaseManifest,
+			},
+			&cli.BoolFlag{
+				Name:        forceFlg,
+				Usage:       forceDesc,
+				Destination: &UpgradeArgs.Force,
+			},
+			&cli.BoolFlag{
+				Name:        drainFlg,
+				Usage:       drainDesc,
+				Destination: &UpgradeArgs.Drain,
+			},
 		},
 	}
 }
@@ -0,0 +1,242 @@
+/*
+Copyright © 2022-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+
+	containerregistry "github.com/google/go-containerregistry/pkg/v1"
+
+	"github.com/suse/elemental/v3/internal/image"
+	"github.com/suse/elemental/v3/internal/image/auth"
+	"github.com/suse/elemental/v3/pkg/helm"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/manifest/resolver"
+	"github.com/suse/elemental/v3/pkg/manifest/source"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+type bundleWriter interface {
+	AddImage(ref string, img containerregistry.Image) error
+	AddFile(url string, data []byte) error
+	AddChart(repository, chart, version string, data []byte) error
+}
+
+type bundleChart struct {
+	repository string
+	name       string
+	version    string
+	auth       *auth.HelmAuth
+}
+
+// ExportBundle resolves the release manifest of the provided configuration and stores in the given
+// bundle all the release manifests, images, files and Helm charts its customization fetches.
+func (m *Manager) ExportBundle(ctx context.Context, conf *image.Configuration, output Output, b bundleWriter) (*resolver.ResolvedManifest, error) {
+	logger := m.system.Logger()
+
+	if m.rmResolver == nil {
+		defaultResolver, err := defaultManifestResolver(m.system.FS(), output, m.local, m.verifier, m.source)
+		if err != nil {
+			return nil, fmt.Errorf("using default release manifest resolver: %w", err)
+		}
+		m.rmResolver = defaultResolver
+	}
+	if m.fetchChart == nil {
+		m.fetchChart = helm.FetchChart
+	}
+
+	rm, err := m.rmResolver.Resolve(conf.Release.ManifestURI)
+	if err != nil {
+		return nil, fmt.Errorf("resolving release manifest at uri '%s': %w", conf.Release.ManifestURI, err)
+	}
+
+	images, files, err := bundleArtifacts(conf, rm, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ref := range images {
+		logger.Info("Adding image %s to bundle", ref)
+		img, err := m.pullImage(ctx, ref)
+		if err != nil {
+			return nil, fmt.Errorf("pulling image '%s': %w", ref, err)
+		}
+		if err = b.AddImage(ref, img); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, url := range files {
+		logger.Info("Adding file %s to bundle", url)
+		if err = m.addBundleFile(ctx, url, output, b); err != nil {
+			return nil, err
+		}
+	}
+
+	charts, err := bundleCharts(conf, rm)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range charts {
+		logger.Info("Adding Helm chart %s %s from %s to bundle", c.name, c.version, c.repository)
+
+		var opts []helm.FetchOpt
+		if c.auth != nil {
+			opts = append(opts,
+				helm.WithBasicAuth(c.auth.Credentials.Username, c.auth.Credentials.Password),
+				helm.WithInsecureSkipTLSVerify(c.auth.InsecureSkipTLSVerify),
+			)
+		}
+
+		data, err := m.fetchChart(ctx, c.repository, c.name, c.version, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("fetching helm chart '%s': %w", c.name, err)
+		}
+		if err = b.AddChart(c.repository, c.name, c.version, data); err != nil {
+			return nil, err
+		}
+	}
+
+	return rm, nil
+}
+
+// bundleArtifacts returns the images and the remote files fetched by the customization of the configuration
+func bundleArtifacts(conf *image.Configuration, rm *resolver.ResolvedManifest, logger log.Logger) (images, files []string, err error) {
+	add := func(list []string, item string) []string {
+		if item == "" || slices.Contains(list, item) {
+			return list
+		}
+		return append(list, item)
+	}
+
+	rmSrc, err := source.ParseFromURI(conf.Release.ManifestURI)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parsing release manifest uri '%s': %w", conf.Release.ManifestURI, err)
+	}
+	if rmSrc.Type() == source.OCI {
+		images = add(images, rmSrc.URI())
+	}
+	if rm.SolutionExtension != nil {
+		images = add(images, rm.SolutionExtension.CorePlatform.Image)
+	}
+
+	if osImage := rm.CorePlatform.Components.OperatingSystem; osImage != nil {
+		images = add(images, osImage.Image.Base)
+		images = add(images, osImage.Image.ISO)
+	}
+
+	if isKubernetesEnabled(conf) && rm.CorePlatform.Components.Kubernetes != nil {
+		images = add(images, rm.CorePlatform.Components.Kubernetes.Image)
+	}
+
+	if conf.Kubernetes.EmbedArtifacts && needsHelmChartsSetup(conf) {
+		chartImages, err := helmChartImages(conf, rm)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, ref := range chartImages {
+			images = add(images, ref)
+		}
+	}
+
+	extensions, err := enabledExtensions(rm, conf, logger)
+	if err != nil {
+		return nil, nil, fmt.Errorf("filtering enabled systemd extensions: %w", err)
+	}
+	for _, ext := range extensions {
+		if isRemoteURL(ext.Image) {
+			files = add(files, ext.Image)
+		} else {
+			images = add(images, ext.Image)
+		}
+	}
+
+	for _, manifest := range conf.Kubernetes.RemoteManifests {
+		files = add(files, manifest)
+	}
+
+	return images, files, nil
+}
+
+// bundleCharts returns the enabled release Helm charts and the user defined Helm charts of the configuration,
+// these are the charts stored in bundles and embedded in images
+func bundleCharts(conf *image.Configuration, rm *resolver.ResolvedManifest) ([]bundleChart, error) {
+	if !needsHelmChartsSetup(conf) {
+		return nil, nil
+	}
+
+	releaseCharts, repositories, err := enabledHelmCharts(rm, conf.Release.Components.HelmCharts, nil)
+	if err != nil {
+		return nil, fmt.Errorf("filtering enabled helm charts: %w", err)
+	}
+
+	authMap, err := createAuthMap(releaseCharts, repositories, conf)
+	if err != nil {
+		return nil, fmt.Errorf("creating helm chart auth map: %w", err)
+	}
+
+	var charts []bundleChart
+	for _, c := range releaseCharts {
+		repository, ok := repositories[c.Repository]
+		if !ok {
+			return nil, fmt.Errorf("repository not found for chart: %s", c.GetName())
+		}
+		charts = append(charts, bundleChart{repository: repository, name: c.Chart, version: c.Version, auth: authMap[c.Chart]})
+	}
+
+	if conf.Kubernetes.Helm != nil {
+		repositories = conf.Kubernetes.Helm.ChartRepositories()
+		for _, c := range conf.Kubernetes.Helm.Charts {
+			repository, ok := repositories[c.RepositoryName]
+			if !ok {
+				return nil, fmt.Errorf("repository not found for chart: %s", c.Name)
+			}
+			charts = append(charts, bundleChart{repository: repository, name: c.Name, version: c.Version, auth: authMap[c.Name]})
+		}
+	}
+
+	return charts, nil
+}
+
+func (m *Manager) addBundleFile(ctx context.Context, url string, output Output, b bundleWriter) error {
+	fs := m.system.FS()
+
+	tempDir, err := vfs.TempDir(fs, output.RootPath, "bundle-file-")
+	if err != nil {
+		return fmt.Errorf("creating temp directory: %w", err)
+	}
+	defer func() {
+		_ = fs.RemoveAll(tempDir)
+	}()
+
+	path := filepath.Join(tempDir, filepath.Base(url))
+	if err = m.downloadFile(ctx, fs, url, path); err != nil {
+		return fmt.Errorf("downloading file '%s': %w", url, err)
+	}
+
+	data, err := fs.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading downloaded file '%s': %w", path, err)
+	}
+
+	return b.AddFile(url, data)
+}
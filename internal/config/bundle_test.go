@@ -0,0 +1,199 @@
+/*
+Copyright © 2022-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	containerregistry "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+
+	"github.com/suse/elemental/v3/internal/image"
+	"github.com/suse/elemental/v3/internal/image/auth"
+	"github.com/suse/elemental/v3/internal/image/kubernetes"
+	"github.com/suse/elemental/v3/internal/image/release"
+	"github.com/suse/elemental/v3/pkg/helm"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/manifest/api"
+	"github.com/suse/elemental/v3/pkg/manifest/api/core"
+	"github.com/suse/elemental/v3/pkg/manifest/api/solution"
+	"github.com/suse/elemental/v3/pkg/manifest/resolver"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+type bundleWriterMock struct {
+	images []string
+	files  map[string]string
+	charts []string
+}
+
+func (b *bundleWriterMock) AddImage(ref string, _ containerregistry.Image) error {
+	b.images = append(b.images, ref)
+	return nil
+}
+
+func (b *bundleWriterMock) AddFile(url string, data []byte) error {
+	b.files[url] = string(data)
+	return nil
+}
+
+func (b *bundleWriterMock) AddChart(repository, chart, version string, _ []byte) error {
+	b.charts = append(b.charts, fmt.Sprintf("%s/%s:%s", repository, chart, version))
+	return nil
+}
+
+var _ = Describe("Bundle export", func() {
+	var output = Output{
+		RootPath: "/_out",
+	}
+	var fs vfs.FS
+	var cleanup func()
+	var system *sys.System
+	var writer *bundleWriterMock
+	var chartAuth []string
+	var rm = &resolver.ResolvedManifest{
+		CorePlatform: &core.ReleaseManifest{
+			Components: core.Components{
+				OperatingSystem: &core.OperatingSystem{
+					Image: core.Image{Base: "registry.example.com/os:1.0", ISO: "registry.example.com/os-iso:1.0"},
+				},
+				Kubernetes: &core.Kubernetes{
+					Version: "v1.35.0+rke2r1",
+					Image:   "registry.example.com/rke2:1.35_1.0",
+				},
+				Systemd: api.Systemd{
+					Extensions: []api.SystemdExtension{
+						{Name: "remote", Image: "https://foo.bar/remote-ext.raw", Required: true},
+						{Name: "oci", Image: "registry.example.com/ext:1.0"},
+						{Name: "unused", Image: "registry.example.com/unused:1.0"},
+					},
+				},
+			},
+		},
+		SolutionExtension: &solution.ReleaseManifest{
+			CorePlatform: &solution.CorePlatform{Image: "registry.example.com/core-manifest:1.0"},
+			Components: solution.Components{
+				Helm: &api.Helm{
+					Charts: []*api.HelmChart{{
+						Chart: "bar", Version: "1.0.0", Repository: "suse",
+						Images: []api.HelmChartImage{{Name: "bar", Image: "registry.example.com/bar:1.0.0"}},
+					}},
+					Repositories: []*api.HelmRepository{{Name: "suse", URL: "https://charts.suse.com"}},
+				},
+			},
+		},
+	}
+	var conf = &image.Configuration{
+		Kubernetes: kubernetes.Kubernetes{
+			RemoteManifests: []string{"https://foo.bar/manifest.yaml"},
+			Helm: &kubernetes.Helm{
+				Charts: []*kubernetes.HelmChart{{Name: "baz", RepositoryName: "private", Version: "2.0.0", TargetNamespace: "baz"}},
+				Repositories: []*kubernetes.HelmRepository{{
+					Name: "private", URL: "oci://registry.example.com/charts",
+					Credentials: &auth.Credentials{Username: "user", Password: "pass"},
+				}},
+			},
+		},
+		Release: release.Release{
+			ManifestURI: "oci://registry.example.com/solution-manifest:1.0",
+			Components: release.Components{
+				SystemdExtensions: []release.SystemdExtension{{Name: "oci"}},
+				HelmCharts:        []release.HelmChart{{Name: "bar"}},
+			},
+		},
+	}
+
+	BeforeEach(func() {
+		var err error
+		fs, cleanup, err = sysmock.TestFS(nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(vfs.MkdirAll(fs, output.RootPath, vfs.DirPerm)).To(Succeed())
+		system, err = sys.NewSystem(sys.WithFS(fs), sys.WithLogger(log.New(log.WithDiscardAll())))
+		Expect(err).NotTo(HaveOccurred())
+		writer = &bundleWriterMock{files: map[string]string{}}
+		chartAuth = nil
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+
+	newManager := func(pullErr error) *Manager {
+		return NewManager(system, nil,
+			WithManifestResolver(&resolverMock{resolveFunc: func(string) (*resolver.ResolvedManifest, error) {
+				return rm, nil
+			}}),
+			WithPullImageFunc(func(_ context.Context, _ string) (containerregistry.Image, error) {
+				return empty.Image, pullErr
+			}),
+			WithDownloadFunc(func(_ context.Context, fs vfs.FS, url, path string) error {
+				return fs.WriteFile(path, []byte("content of "+url), vfs.FilePerm)
+			}),
+			WithFetchChartFunc(func(_ context.Context, _, chart, _ string, opts ...helm.FetchOpt) ([]byte, error) {
+				if len(opts) > 0 {
+					chartAuth = append(chartAuth, chart)
+				}
+				return []byte("chart"), nil
+			}),
+		)
+	}
+
+	It("adds all the artifacts fetched by the customization to the bundle", func() {
+		resolved, err := newManager(nil).ExportBundle(context.Background(), conf, output, writer)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resolved).To(Equal(rm))
+
+		Expect(writer.images).To(Equal([]string{
+			"registry.example.com/solution-manifest:1.0",
+			"registry.example.com/core-manifest:1.0",
+			"registry.example.com/os:1.0",
+			"registry.example.com/os-iso:1.0",
+			"registry.example.com/rke2:1.35_1.0",
+			"registry.example.com/ext:1.0",
+		}))
+		Expect(writer.files).To(Equal(map[string]string{
+			"https://foo.bar/remote-ext.raw": "content of https://foo.bar/remote-ext.raw",
+			"https://foo.bar/manifest.yaml":  "content of https://foo.bar/manifest.yaml",
+		}))
+		Expect(writer.charts).To(Equal([]string{
+			"https://charts.suse.com/bar:1.0.0",
+			"oci://registry.example.com/charts/baz:2.0.0",
+		}))
+		Expect(chartAuth).To(Equal([]string{"baz"}))
+	})
+
+	It("adds the images of the Helm charts to embed to the bundle", func() {
+		embedConf := *conf
+		embedConf.Kubernetes.EmbedArtifacts = true
+
+		_, err := newManager(nil).ExportBundle(context.Background(), &embedConf, output, writer)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(writer.images).To(ContainElement("registry.example.com/bar:1.0.0"))
+		Expect(writer.charts).To(HaveLen(2))
+	})
+
+	It("fails if an image can't be pulled", func() {
+		_, err := newManager(fmt.Errorf("pull failed")).ExportBundle(context.Background(), conf, output, writer)
+		Expect(err).To(MatchError(ContainSubstring("pulling image 'registry.example.com/solution-manifest:1.0': pull failed")))
+	})
+})
@@ -18,6 +18,7 @@ limitations under the License.
 package config
 
 import (
+	"context"
 	"encoding/base64"
 	"fmt"
 	"net/url"
@@ -55,6 +56,9 @@ type Helm struct {
 	DestinationDir string
 	ValuesResolver helmValuesResolver
 	Logger         log.Logger
+	// FetchChart fetches the chart archives embedded in the Helm chart resources,
+	// it defaults to fetching them from their repositories
+	FetchChart fetchChartFunc
 }
 
 func NewHelm(fs vfs.FS, valuesResolver helmValuesResolver, logger log.Logger, destinationDir string) *Helm {
@@ -64,10 +68,11 @@ func NewHelm(fs vfs.FS, valuesResolver helmValuesResolver, logger log.Logger, de
 		DestinationDir: destinationDir,
 		ValuesResolver: valuesResolver,
 		Logger:         logger,
+		FetchChart:     helm.FetchChart,
 	}
 }
 
-func (h *Helm) Configure(conf *image.Configuration, rm *resolver.ResolvedManifest) ([]string, map[string][]byte, error) {
+func (h *Helm) Configure(ctx context.Context, conf *image.Configuration, rm *resolver.ResolvedManifest) ([]string, map[string][]byte, error) {
 	if len(conf.Release.Components.HelmCharts) > 0 {
 		var charts []string
 		for _, c := range conf.Release.Components.HelmCharts {
@@ -82,6 +87,14 @@ func (h *Helm) Configure(conf *image.Configuration, rm *resolver.ResolvedManifes
 		return nil, nil, fmt.Errorf("retrieving helm charts: %w", err)
 	}
 
+	if conf.Kubernetes.EmbedArtifacts {
+		if err = h.embedHelmCharts(ctx, conf, rm, charts); err != nil {
+			return nil, nil, fmt.Errorf("embedding helm charts: %w", err)
+		}
+		// embedded charts are not fetched from their repositories, hence do not need credentials
+		secrets = nil
+	}
+
 	chartFiles, err := h.writeHelmCharts(charts)
 	if err != nil {
 		return nil, nil, fmt.Errorf("writing helm chart resources: %w", err)
@@ -177,6 +190,50 @@ func (h *Helm) retrieveHelmCharts(rm *resolver.ResolvedManifest, conf *image.Con
 	return crds, generateHelmSecrets(authMap), nil
 }
 
+// embedHelmCharts fetches the archive of each of the given chart resources and inlines it
+func (h *Helm) embedHelmCharts(ctx context.Context, conf *image.Configuration, rm *resolver.ResolvedManifest, crds []*helm.CRD) error {
+	charts, err := bundleCharts(conf, rm)
+	if err != nil {
+		return err
+	}
+
+	fetchChart := h.FetchChart
+	if fetchChart == nil {
+		fetchChart = helm.FetchChart
+	}
+
+	var size int64
+	for _, crd := range crds {
+		i := slices.IndexFunc(charts, func(c bundleChart) bool {
+			return c.name == crd.Metadata.Name
+		})
+		if i < 0 {
+			return fmt.Errorf("repository not found for chart: %s", crd.Metadata.Name)
+		}
+		c := charts[i]
+
+		var opts []helm.FetchOpt
+		if c.auth != nil {
+			opts = append(opts,
+				helm.WithBasicAuth(c.auth.Credentials.Username, c.auth.Credentials.Password),
+				helm.WithInsecureSkipTLSVerify(c.auth.InsecureSkipTLSVerify),
+			)
+		}
+
+		h.Logger.Info("Embedding Helm chart %s %s from %s", c.name, c.version, c.repository)
+		data, err := fetchChart(ctx, c.repository, c.name, c.version, opts...)
+		if err != nil {
+			return fmt.Errorf("fetching helm chart '%s': %w", c.name, err)
+		}
+
+		crd.EmbedChart(data)
+		size += int64(len(data))
+	}
+
+	h.Logger.Info("Embedded %d Helm charts, %dMiB in total", len(crds), size>>20)
+	return nil
+}
+
 func createAuthMap(charts []*api.HelmChart, repositories map[string]string, conf *image.Configuration) (map[string]*auth.HelmAuth, error) {
 	authMap := make(map[string]*auth.HelmAuth)
 	if conf.Release.Components.HelmCharts != nil {
@@ -18,6 +18,7 @@ limitations under the License.
 package config
 
 import (
+	"context"
 	"fmt"
 	"path/filepath"
 
@@ -154,7 +155,7 @@ var _ = Describe("Helm tests", Label("helm"), func() {
 
 			h := &Helm{ValuesResolver: resolver, Logger: logger}
 
-			charts, secrets, err := h.Configure(conf, rm)
+			charts, secrets, err := h.Configure(context.Background(), conf, rm)
 			Expect(err).To(HaveOccurred())
 			Expect(err).To(MatchError("retrieving helm charts: collecting helm charts: resolving values for chart metallb: resolving failed"))
 			Expect(charts).To(BeNil())
@@ -177,7 +178,7 @@ var _ = Describe("Helm tests", Label("helm"), func() {
 
 			h := &Helm{ValuesResolver: resolver, Logger: logger}
 
-			charts, secrets, err := h.Configure(conf, rm)
+			charts, secrets, err := h.Configure(context.Background(), conf, rm)
 			Expect(err).To(HaveOccurred())
 			Expect(err).To(MatchError("retrieving helm charts: collecting helm charts: resolving values for chart neuvector-crd: resolving failed"))
 			Expect(charts).To(BeNil())
@@ -210,7 +211,7 @@ var _ = Describe("Helm tests", Label("helm"), func() {
 
 			h := &Helm{ValuesResolver: resolver}
 
-			charts, secrets, err := h.Configure(conf, rm)
+			charts, secrets, err := h.Configure(context.Background(), conf, rm)
 			Expect(err).To(HaveOccurred())
 			Expect(err).To(MatchError("retrieving helm charts: collecting user helm charts: resolving values for chart apache: resolving failed"))
 			Expect(charts).To(BeNil())
@@ -236,7 +237,7 @@ var _ = Describe("Helm tests", Label("helm"), func() {
 			}
 
 			h := &Helm{ValuesResolver: resolver}
-			charts, secrets, err := h.Configure(conf, rm)
+			charts, secrets, err := h.Configure(context.Background(), conf, rm)
 			Expect(err).To(HaveOccurred())
 			Expect(err).To(MatchError("retrieving helm charts: collecting user helm charts: repository not found for chart: apache"))
 			Expect(charts).To(BeNil())
@@ -272,7 +273,7 @@ var _ = Describe("Helm tests", Label("helm"), func() {
 			}
 
 			h := &Helm{ValuesResolver: resolver}
-			charts, secrets, err := h.Configure(conf, rm)
+			charts, secrets, err := h.Configure(context.Background(), conf, rm)
 			Expect(err).To(HaveOccurred())
 			Expect(err).To(MatchError("retrieving helm charts: creating helm chart auth map: helm repository 'apache-repo' defined multiple times"))
 			Expect(charts).To(BeNil())
@@ -295,7 +296,7 @@ var _ = Describe("Helm tests", Label("helm"), func() {
 
 			h := &Helm{ValuesResolver: resolver, Logger: logger}
 
-			charts, secrets, err := h.Configure(conf, rm)
+			charts, secrets, err := h.Configure(context.Background(), conf, rm)
 			Expect(err).To(HaveOccurred())
 			Expect(err).To(MatchError("retrieving helm charts: filtering enabled helm charts: adding helm chart 'rancher': helm chart does not exist"))
 			Expect(charts).To(BeNil())
@@ -319,7 +320,7 @@ var _ = Describe("Helm tests", Label("helm"), func() {
 				RelativePath:   helmPath,
 			}
 
-			charts, secrets, err := h.Configure(conf, rm)
+			charts, secrets, err := h.Configure(context.Background(), conf, rm)
 			Expect(err).To(HaveOccurred())
 			Expect(err).To(MatchError("writing helm chart resources: creating directory: Mkdir /etc/overlays/helm: operation not permitted"))
 			Expect(charts).To(BeNil())
@@ -389,7 +390,7 @@ var _ = Describe("Helm tests", Label("helm"), func() {
 				Logger:         logger,
 			}
 
-			charts, secrets, err := h.Configure(conf, rm)
+			charts, secrets, err := h.Configure(context.Background(), conf, rm)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(secrets).To(BeEmpty())
 			Expect(charts).To(ConsistOf(
@@ -624,7 +625,7 @@ spec:
 				Logger:         logger,
 			}
 
-			charts, secrets, err := h.Configure(conf, rm)
+			charts, secrets, err := h.Configure(context.Background(), conf, rm)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(charts).To(ConsistOf(
 				"/helm/metallb.yaml",
@@ -741,6 +742,123 @@ spec:
 			Expect(string(b)).To(Equal(contents))
 
 		})
+
+		It("Embeds the archives of core and user Helm charts in the resources", func() {
+			fs, cleanup, err := sysmock.TestFS(nil)
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(cleanup)
+
+			conf := &image.Configuration{
+				Release: release.Release{
+					Components: release.Components{
+						HelmCharts: []release.HelmChart{{Name: "endpoint-copier-operator"}},
+					},
+				},
+				Kubernetes: kubernetes.Kubernetes{
+					EmbedArtifacts: true,
+					Helm: &kubernetes.Helm{
+						Charts: []*kubernetes.HelmChart{{
+							Name: "apache", RepositoryName: "apache", TargetNamespace: "web", Version: "10.7.0",
+						}},
+						Repositories: []*kubernetes.HelmRepository{{
+							Name: "apache", URL: "https://example.com/apache",
+							Credentials: &auth.Credentials{Username: "apache-user", Password: "apache-pass"},
+						}},
+					},
+				},
+			}
+
+			var fetched, authenticated []string
+			h := &Helm{
+				FS:             fs,
+				ValuesResolver: &valuesResolverMock{},
+				DestinationDir: overlaysPath,
+				RelativePath:   helmPath,
+				Logger:         logger,
+				FetchChart: func(_ context.Context, repository, chart, version string, opts ...helm.FetchOpt) ([]byte, error) {
+					fetched = append(fetched, fmt.Sprintf("%s/%s:%s", repository, chart, version))
+					if len(opts) > 0 {
+						authenticated = append(authenticated, chart)
+					}
+					return []byte(chart + " archive"), nil
+				},
+			}
+
+			charts, secrets, err := h.Configure(context.Background(), conf, rm)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(charts).To(ConsistOf("/helm/endpoint-copier-operator.yaml", "/helm/apache.yaml"))
+			Expect(secrets).To(BeEmpty())
+			Expect(fetched).To(ConsistOf(
+				"oci://example-1.com/charts/endpoint-copier-operator:0.3.0",
+				"https://example.com/apache/apache:10.7.0",
+			))
+			Expect(authenticated).To(Equal([]string{"apache"}))
+
+			contents := `apiVersion: helm.cattle.io/v1
+kind: HelmChart
+metadata:
+    name: endpoint-copier-operator
+    namespace: kube-system
+spec:
+    chart: endpoint-copier-operator
+    version: 0.3.0
+    chartContent: ZW5kcG9pbnQtY29waWVyLW9wZXJhdG9yIGFyY2hpdmU=
+    targetNamespace: endpoint-copier-operator
+    createNamespace: true
+    backOffLimit: 20
+`
+			b, err := fs.ReadFile(filepath.Join(overlaysPath, helmPath, "endpoint-copier-operator.yaml"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(b)).To(Equal(contents))
+
+			contents = `apiVersion: helm.cattle.io/v1
+kind: HelmChart
+metadata:
+    name: apache
+    namespace: kube-system
+spec:
+    chart: apache
+    version: 10.7.0
+    chartContent: YXBhY2hlIGFyY2hpdmU=
+    targetNamespace: web
+    createNamespace: true
+    backOffLimit: 20
+`
+			b, err = fs.ReadFile(filepath.Join(overlaysPath, helmPath, "apache.yaml"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(b)).To(Equal(contents))
+		})
+
+		It("Fails to fetch a Helm chart to embed", func() {
+			fs, cleanup, err := sysmock.TestFS(nil)
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(cleanup)
+
+			conf := &image.Configuration{
+				Release: release.Release{
+					Components: release.Components{
+						HelmCharts: []release.HelmChart{{Name: "metallb"}},
+					},
+				},
+				Kubernetes: kubernetes.Kubernetes{EmbedArtifacts: true},
+			}
+
+			h := &Helm{
+				FS:             fs,
+				ValuesResolver: &valuesResolverMock{},
+				DestinationDir: overlaysPath,
+				RelativePath:   helmPath,
+				Logger:         logger,
+				FetchChart: func(context.Context, string, string, string, ...helm.FetchOpt) ([]byte, error) {
+					return nil, fmt.Errorf("not found")
+				},
+			}
+
+			charts, secrets, err := h.Configure(context.Background(), conf, rm)
+			Expect(err).To(MatchError("embedding helm charts: fetching helm chart 'metallb': not found"))
+			Expect(charts).To(BeNil())
+			Expect(secrets).To(BeNil())
+		})
 	})
 
 	Describe("Filtering", func() {
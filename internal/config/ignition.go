@@ -68,7 +68,9 @@ var (
 // * Kubernetes configuration and deployment files
 // * Systemd extensions
 // * Kubernetes distribution installation
-func (m *Manager) configureIgnition(conf *image.Configuration, output Output, k8sScript, k8sConfScript string, ext []api.SystemdExtension) error {
+func (m *Manager) configureIgnition(
+	conf *image.Configuration, output Output, dist kubernetes.Distribution, k8sScript, k8sConfScript string, ext []api.SystemdExtension,
+) error {
 	if len(conf.ButaneConfig) == 0 &&
 		k8sScript == "" &&
 		k8sConfScript == "" &&
@@ -111,7 +113,7 @@ func (m *Manager) configureIgnition(conf *image.Configuration, output Output, k8
 			}
 		}
 
-		k8sResourcesUnit, err := generateK8sResourcesUnit(k8sScript, initHostname)
+		k8sResourcesUnit, err := generateK8sResourcesUnit(k8sScript, initHostname, dist)
 		if err != nil {
 			return err
 		}
@@ -120,9 +122,9 @@ func (m *Manager) configureIgnition(conf *image.Configuration, output Output, k8
 	}
 
 	if k8sConfScript != "" {
-		err := appendRke2Configuration(m.system, &config, &conf.Kubernetes, k8sConfScript)
+		err := appendKubernetesConfiguration(m.system, &config, &conf.Kubernetes, dist, k8sConfScript)
 		if err != nil {
-			return fmt.Errorf("failed appending rke2 configuration: %w", err)
+			return fmt.Errorf("failed appending %s configuration: %w", dist, err)
 		}
 	}
 
@@ -146,15 +148,17 @@ func (m *Manager) configureIgnition(conf *image.Configuration, output Output, k8
 	return butane.WriteIgnitionFile(m.system, config, ignitionFile)
 }
 
-func generateK8sResourcesUnit(deployScript, initHostname string) (string, error) {
+func generateK8sResourcesUnit(deployScript, initHostname string, dist kubernetes.Distribution) (string, error) {
 	values := struct {
 		KubernetesDir        string
 		ManifestDeployScript string
 		InitHostname         string
+		ServerService        string
 	}{
 		KubernetesDir:        filepath.Dir(deployScript),
 		ManifestDeployScript: deployScript,
 		InitHostname:         initHostname,
+		ServerService:        dist.Service(kubernetes.NodeTypeServer),
 	}
 
 	data, err := template.Parse(k8sResourcesUnitName, k8sResourceUnitTpl, &values)
@@ -164,11 +168,13 @@ func generateK8sResourcesUnit(deployScript, initHostname string) (string, error)
 	return data, nil
 }
 
-func generateK8sConfigUnit(deployScript string) (string, error) {
+func generateK8sConfigUnit(deployScript string, dist kubernetes.Distribution) (string, error) {
 	values := struct {
 		ConfigDeployScript string
+		SELinuxPolicy      string
 	}{
 		ConfigDeployScript: deployScript,
+		SELinuxPolicy:      dist.SELinuxPolicy(),
 	}
 
 	data, err := template.Parse(k8sConfigUnitName, k8sConfigUnitTpl, &values)
@@ -178,25 +184,37 @@ func generateK8sConfigUnit(deployScript string) (string, error) {
 	return data, nil
 }
 
-func kubernetesVIPManifest(k *kubernetes.Kubernetes) (string, error) {
+func kubernetesVIPManifest(k *kubernetes.Kubernetes, dist kubernetes.Distribution) (string, error) {
+	const apiPort = 6443
+
 	vars := struct {
-		APIAddress4 string
-		APIAddress6 string
+		APIAddress4    string
+		APIAddress6    string
+		Distribution   kubernetes.Distribution
+		SupervisorPort uint16
 	}{
-		APIAddress4: k.Network.APIVIP4,
-		APIAddress6: k.Network.APIVIP6,
+		APIAddress4:  k.Network.APIVIP4,
+		APIAddress6:  k.Network.APIVIP6,
+		Distribution: dist,
+	}
+
+	// K3s serves the supervisor on the API server port, which is always exposed
+	if port := dist.SupervisorPort(); port != apiPort {
+		vars.SupervisorPort = port
 	}
 
 	return template.Parse("k8s-vip", k8sVIPManifestTpl, &vars)
 }
 
-func appendRke2Configuration(s *sys.System, config *butane.Config, k *kubernetes.Kubernetes, configScript string) error {
-	c, err := kubernetes.NewCluster(s, k)
+func appendKubernetesConfiguration(
+	s *sys.System, config *butane.Config, k *kubernetes.Kubernetes, dist kubernetes.Distribution, configScript string,
+) error {
+	c, err := kubernetes.NewCluster(s, k, dist)
 	if err != nil {
 		return fmt.Errorf("failed parsing cluster: %w", err)
 	}
 
-	k8sConfigUnit, err := generateK8sConfigUnit(configScript)
+	k8sConfigUnit, err := generateK8sConfigUnit(configScript, dist)
 	if err != nil {
 		return fmt.Errorf("failed generating k8s config unit: %w", err)
 	}
@@ -254,7 +272,7 @@ func appendRke2Configuration(s *sys.System, config *butane.Config, k *kubernetes
 	if k.Network.APIVIP4 != "" || k.Network.APIVIP6 != "" {
 		manifestsPath := filepath.Join("/", image.KubernetesManifestsPath())
 
-		vip, err := kubernetesVIPManifest(k)
+		vip, err := kubernetesVIPManifest(k, dist)
 		if err != nil {
 			return fmt.Errorf("failed marshaling agent config: %w", err)
 		}
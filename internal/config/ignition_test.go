@@ -74,7 +74,7 @@ var _ = Describe("Ignition configuration", func() {
 
 		ignitionFile := filepath.Join(output.FirstbootConfigDir(), image.IgnitionFilePath())
 
-		Expect(m.configureIgnition(conf, output, "", "", nil)).To(Succeed())
+		Expect(m.configureIgnition(conf, output, kubernetes.RKE2, "", "", nil)).To(Succeed())
 		ok, err := vfs.Exists(system.FS(), ignitionFile)
 		Expect(err).NotTo(HaveOccurred())
 		Expect(ok).To(BeFalse())
@@ -102,7 +102,7 @@ passwd:
 
 		ignitionFile := filepath.Join(output.FirstbootConfigDir(), image.IgnitionFilePath())
 
-		Expect(m.configureIgnition(conf, output, "", "", nil)).To(Succeed())
+		Expect(m.configureIgnition(conf, output, kubernetes.RKE2, "", "", nil)).To(Succeed())
 		ok, err := vfs.Exists(system.FS(), ignitionFile)
 		Expect(err).NotTo(HaveOccurred())
 		Expect(ok).To(BeTrue())
@@ -125,7 +125,7 @@ passwd:
 		k8sScript := filepath.Join(output.OverlaysDir(), "path/to/k8s/script.sh")
 		k8sConfScript := filepath.Join(output.OverlaysDir(), "path/to/k8s/conf_script.sh")
 
-		Expect(m.configureIgnition(conf, output, k8sScript, k8sConfScript, nil)).To(Succeed())
+		Expect(m.configureIgnition(conf, output, kubernetes.RKE2, k8sScript, k8sConfScript, nil)).To(Succeed())
 		ok, err := vfs.Exists(system.FS(), ignitionFile)
 		Expect(err).NotTo(HaveOccurred())
 		Expect(ok).To(BeTrue())
@@ -138,12 +138,34 @@ passwd:
 		Expect(ignition).To(ContainSubstring("/var/lib/elemental/kubernetes/registries.yaml"))
 	})
 
+	It("Configures K3s services and supervisor port via Ignition", func() {
+		conf := &image.Configuration{
+			Kubernetes: kubernetes.Kubernetes{
+				Network: kubernetes.Network{
+					APIVIP4: "192.168.122.100",
+				},
+			},
+		}
+		ignitionFile := filepath.Join(output.FirstbootConfigDir(), image.IgnitionFilePath())
+
+		k8sScript := filepath.Join(output.OverlaysDir(), "path/to/k8s/script.sh")
+		k8sConfScript := filepath.Join(output.OverlaysDir(), "path/to/k8s/conf_script.sh")
+
+		Expect(m.configureIgnition(conf, output, kubernetes.K3s, k8sScript, k8sConfScript, nil)).To(Succeed())
+		ignition, err := system.FS().ReadFile(ignitionFile)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ignition).To(ContainSubstring("k3s.service"))
+		Expect(ignition).To(ContainSubstring("/usr/share/selinux/packages/k3s.pp"))
+		Expect(ignition).To(ContainSubstring("/var/lib/elemental/kubernetes/manifests/k8s-vip.yaml"))
+		Expect(ignition).NotTo(ContainSubstring("rke2"))
+	})
+
 	It("Writes systemd extension via Ignition", func() {
 		conf := &image.Configuration{}
 		ext := []api.SystemdExtension{{Name: "ext1", Image: "ext1-image"}}
 		ignitionFile := filepath.Join(output.FirstbootConfigDir(), image.IgnitionFilePath())
 
-		Expect(m.configureIgnition(conf, output, "", "", ext)).To(Succeed())
+		Expect(m.configureIgnition(conf, output, kubernetes.RKE2, "", "", ext)).To(Succeed())
 
 		ok, err := vfs.Exists(system.FS(), ignitionFile)
 		Expect(err).NotTo(HaveOccurred())
@@ -184,7 +206,7 @@ passwd:
 
 		ignitionFile := filepath.Join(output.FirstbootConfigDir(), image.IgnitionFilePath())
 
-		Expect(m.configureIgnition(conf, output, k8sScript, k8sConfScript, nil)).To(MatchError(
+		Expect(m.configureIgnition(conf, output, kubernetes.RKE2, k8sScript, k8sConfScript, nil)).To(MatchError(
 			ContainSubstring("No translator exists for variant unknown with version"),
 		))
 		ok, err := vfs.Exists(system.FS(), ignitionFile)
@@ -209,7 +231,7 @@ passwd:
 		}
 
 		ignitionFile := filepath.Join(output.FirstbootConfigDir(), image.IgnitionFilePath())
-		Expect(m.configureIgnition(conf, output, "", "", nil)).To(Succeed())
+		Expect(m.configureIgnition(conf, output, kubernetes.RKE2, "", "", nil)).To(Succeed())
 		ok, err := vfs.Exists(system.FS(), ignitionFile)
 		Expect(err).NotTo(HaveOccurred())
 		Expect(ok).To(BeTrue())
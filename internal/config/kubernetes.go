@@ -22,6 +22,11 @@ import (
 	_ "embed"
 	"fmt"
 	"path/filepath"
+	"slices"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	containerregistry "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
 
 	"github.com/suse/elemental/v3/internal/image"
 	"github.com/suse/elemental/v3/internal/image/kubernetes"
@@ -33,6 +38,7 @@ import (
 const (
 	k8sResDeployScriptName  = "k8s_res_deploy.sh"
 	k8sConfDeployScriptName = "k8s_conf_deploy.sh"
+	k8sImagesArchiveName    = "elemental-helm-images.tar"
 )
 
 //go:embed templates/k8s_res_deploy.sh.tpl
@@ -67,17 +73,28 @@ func (m *Manager) configureKubernetes(
 		return "", "", fmt.Errorf("kubernetes release not found")
 	}
 
+	dist, err := kubernetes.NewDistribution(manifest.CorePlatform.Components.Kubernetes)
+	if err != nil {
+		return "", "", err
+	}
+
 	var runtimeHelmCharts []string
 	var additionalManifests map[string][]byte
 	if needsHelmChartsSetup(conf) {
 		m.system.Logger().Info("Configuring Helm charts")
 
-		runtimeHelmCharts, additionalManifests, err = m.helm.Configure(conf, manifest)
+		runtimeHelmCharts, additionalManifests, err = m.helm.Configure(ctx, conf, manifest)
 		if err != nil {
 			return "", "", fmt.Errorf("configuring helm charts: %w", err)
 		}
 	}
 
+	if conf.Kubernetes.EmbedArtifacts {
+		if err = m.embedHelmChartImages(ctx, conf, manifest, dist, output); err != nil {
+			return "", "", fmt.Errorf("embedding helm chart images: %w", err)
+		}
+	}
+
 	var runtimeManifestsDir string
 	if needsManifestsSetup(conf, additionalManifests) {
 		m.system.Logger().Info("Configuring Kubernetes manifests")
@@ -89,7 +106,7 @@ func (m *Manager) configureKubernetes(
 	}
 
 	if len(runtimeHelmCharts) > 0 || runtimeManifestsDir != "" {
-		k8sResourceScript, err = writeK8sResDeployScript(m.system.FS(), output, runtimeManifestsDir, runtimeHelmCharts)
+		k8sResourceScript, err = writeK8sResDeployScript(m.system.FS(), output, dist, runtimeManifestsDir, runtimeHelmCharts)
 		if err != nil {
 			return "", "", fmt.Errorf("writing kubernetes resource deployment script: %w", err)
 		}
@@ -100,7 +117,7 @@ func (m *Manager) configureKubernetes(
 		return "", "", fmt.Errorf("unpacking kubernetes artifacts: %w", err)
 	}
 
-	k8sConfScript, err = writeK8sConfigDeployScript(m.system.FS(), output, conf.Kubernetes, artifactsDir, installScript)
+	k8sConfScript, err = writeK8sConfigDeployScript(m.system.FS(), output, dist, conf.Kubernetes, artifactsDir, installScript)
 	if err != nil {
 		return "", "", fmt.Errorf("writing kubernetes config deployment script: %w", err)
 	}
@@ -108,6 +125,83 @@ func (m *Manager) configureKubernetes(
 	return k8sResourceScript, k8sConfScript, nil
 }
 
+// embedHelmChartImages pulls the images of the enabled release Helm charts and stores them as a single
+// archive in the images directory of the Kubernetes agent, which imports them at startup.
+func (m *Manager) embedHelmChartImages(
+	ctx context.Context, conf *image.Configuration, manifest *resolver.ResolvedManifest, dist kubernetes.Distribution, output Output,
+) error {
+	if !needsHelmChartsSetup(conf) {
+		return nil
+	}
+
+	refs, err := helmChartImages(conf, manifest)
+	if err != nil {
+		return err
+	} else if len(refs) == 0 {
+		return nil
+	}
+
+	images := make(map[name.Reference]containerregistry.Image, len(refs))
+	for _, ref := range refs {
+		m.system.Logger().Info("Embedding image %s", ref)
+
+		r, err := name.ParseReference(ref)
+		if err != nil {
+			return fmt.Errorf("parsing image reference '%s': %w", ref, err)
+		}
+
+		img, err := m.pullImage(ctx, ref)
+		if err != nil {
+			return fmt.Errorf("pulling image '%s': %w", ref, err)
+		}
+		images[r] = img
+	}
+
+	fs := m.system.FS()
+	imagesDir := filepath.Join(output.OverlaysDir(), dist.ImagesPath())
+	if err = vfs.MkdirAll(fs, imagesDir, vfs.DirPerm); err != nil {
+		return fmt.Errorf("creating images directory '%s': %w", imagesDir, err)
+	}
+
+	archive := filepath.Join(imagesDir, k8sImagesArchiveName)
+	f, err := fs.Create(archive)
+	if err != nil {
+		return fmt.Errorf("creating images archive '%s': %w", archive, err)
+	}
+	defer f.Close()
+
+	if err = tarball.MultiRefWrite(images, f); err != nil {
+		return fmt.Errorf("writing images archive '%s': %w", archive, err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("inspecting images archive '%s': %w", archive, err)
+	}
+
+	m.system.Logger().Info("Embedded %d images, %dMiB in total", len(images), info.Size()>>20)
+	return nil
+}
+
+// helmChartImages returns the images listed by the enabled release Helm charts
+func helmChartImages(conf *image.Configuration, manifest *resolver.ResolvedManifest) ([]string, error) {
+	charts, _, err := enabledHelmCharts(manifest, conf.Release.Components.HelmCharts, nil)
+	if err != nil {
+		return nil, fmt.Errorf("filtering enabled helm charts: %w", err)
+	}
+
+	var refs []string
+	for _, c := range charts {
+		for _, i := range c.Images {
+			if !slices.Contains(refs, i.Image) {
+				refs = append(refs, i.Image)
+			}
+		}
+	}
+
+	return refs, nil
+}
+
 func (m *Manager) setupManifests(ctx context.Context, k *kubernetes.Kubernetes, additionalManifests map[string][]byte, output Output) (string, error) {
 	fs := m.system.FS()
 
@@ -143,13 +237,23 @@ func (m *Manager) setupManifests(ctx context.Context, k *kubernetes.Kubernetes,
 	return relativeManifestsPath, nil
 }
 
-func writeK8sResDeployScript(fs vfs.FS, output Output, runtimeManifestsDir string, runtimeHelmCharts []string) (string, error) {
+func writeK8sResDeployScript(
+	fs vfs.FS, output Output, dist kubernetes.Distribution, runtimeManifestsDir string, runtimeHelmCharts []string,
+) (string, error) {
 	values := struct {
-		HelmCharts   []string
-		ManifestsDir string
+		HelmCharts       []string
+		ManifestsDir     string
+		DistributionName string
+		Kubeconfig       string
+		Kubectl          string
+		CoreManifestsDir string
 	}{
-		HelmCharts:   runtimeHelmCharts,
-		ManifestsDir: runtimeManifestsDir,
+		HelmCharts:       runtimeHelmCharts,
+		ManifestsDir:     runtimeManifestsDir,
+		DistributionName: dist.Name(),
+		Kubeconfig:       dist.Kubeconfig(),
+		Kubectl:          dist.Kubectl(),
+		CoreManifestsDir: dist.ManifestsDir(),
 	}
 
 	data, err := template.Parse(k8sResDeployScriptName, k8sResDeployScriptTpl, &values)
@@ -174,7 +278,9 @@ func writeK8sResDeployScript(fs vfs.FS, output Output, runtimeManifestsDir strin
 	return relativePath, nil
 }
 
-func writeK8sConfigDeployScript(fs vfs.FS, output Output, k kubernetes.Kubernetes, artifactsDir, installScript string) (string, error) {
+func writeK8sConfigDeployScript(
+	fs vfs.FS, output Output, dist kubernetes.Distribution, k kubernetes.Kubernetes, artifactsDir, installScript string,
+) (string, error) {
 	relativeK8sPath := filepath.Join("/", image.KubernetesPath())
 
 	var (
@@ -190,23 +296,35 @@ func writeK8sConfigDeployScript(fs vfs.FS, output Output, k kubernetes.Kubernete
 	}
 
 	values := struct {
-		Nodes         kubernetes.Nodes
-		APIVIP4       string
-		APIVIP6       string
-		APIHost       string
-		KubernetesDir string
-		InitNode      kubernetes.Node
-		InstallPath   string
-		InstallScript string
+		Nodes            kubernetes.Nodes
+		APIVIP4          string
+		APIVIP6          string
+		APIHost          string
+		KubernetesDir    string
+		InitNode         kubernetes.Node
+		InstallPath      string
+		InstallScript    string
+		DistributionName string
+		K3s              bool
+		ConfigDir        string
+		InstallPrefix    string
+		BinDir           string
+		ImagesDir        string
 	}{
-		Nodes:         k.Nodes,
-		APIVIP4:       k.Network.APIVIP4,
-		APIVIP6:       k.Network.APIVIP6,
-		APIHost:       k.Network.APIHost,
-		KubernetesDir: relativeK8sPath,
-		InitNode:      kubernetes.Node{},
-		InstallPath:   artifactsDir,
-		InstallScript: installScript,
+		Nodes:            k.Nodes,
+		APIVIP4:          k.Network.APIVIP4,
+		APIVIP6:          k.Network.APIVIP6,
+		APIHost:          k.Network.APIHost,
+		KubernetesDir:    relativeK8sPath,
+		InitNode:         kubernetes.Node{},
+		InstallPath:      artifactsDir,
+		InstallScript:    installScript,
+		DistributionName: dist.Name(),
+		K3s:              dist == kubernetes.K3s,
+		ConfigDir:        dist.ConfigDir(),
+		InstallPrefix:    dist.InstallPrefix(),
+		BinDir:           dist.BinDir(),
+		ImagesDir:        filepath.Join("/", dist.ImagesPath()),
 	}
 
 	if initNode != nil {
@@ -22,6 +22,10 @@ import (
 	"fmt"
 	"path/filepath"
 
+	"github.com/google/go-containerregistry/pkg/name"
+	containerregistry "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/suse/elemental/v3/internal/image"
@@ -29,6 +33,7 @@ import (
 	"github.com/suse/elemental/v3/internal/image/kubernetes"
 	"github.com/suse/elemental/v3/internal/image/release"
 	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/manifest/api"
 	"github.com/suse/elemental/v3/pkg/manifest/api/core"
 	"github.com/suse/elemental/v3/pkg/manifest/resolver"
 	"github.com/suse/elemental/v3/pkg/sys"
@@ -345,6 +350,118 @@ var _ = Describe("Kubernetes", func() {
 			Expect(confScript).ToNot(BeEmpty())
 		})
 
+		It("Embeds the images of the enabled Helm charts", func() {
+			helmMock := &helmConfiguratorMock{
+				configureFunc: func(conf *image.Configuration, manifest *resolver.ResolvedManifest) ([]string, map[string][]byte, error) {
+					return []string{"metallb.yaml"}, nil, nil
+				},
+			}
+
+			unpackFunc := func(ctx context.Context, imageRef, destDir string) error {
+				installSh := filepath.Join(destDir, "install.sh")
+				return fs.WriteFile(installSh, []byte("#!/bin/sh\necho test"), 0755)
+			}
+
+			var pulled []string
+			pullFunc := func(_ context.Context, ref string) (containerregistry.Image, error) {
+				pulled = append(pulled, ref)
+				return random.Image(16, 1)
+			}
+
+			m := NewManager(system, helmMock, WithUnpackFunc(unpackFunc), WithPullImageFunc(pullFunc))
+
+			manifest := &resolver.ResolvedManifest{
+				CorePlatform: &core.ReleaseManifest{
+					Components: core.Components{
+						Kubernetes: &core.Kubernetes{
+							Version: "v1.35.0+rke2r1",
+							Image:   "registry.example.com/rke2:1.35_1.0",
+						},
+						Helm: &api.Helm{
+							Charts: []*api.HelmChart{
+								{
+									Chart: "metallb", Version: "0.14.9", Repository: "suse",
+									Images: []api.HelmChartImage{
+										{Name: "controller", Image: "registry.example.com/metallb/controller:0.14.9"},
+										{Name: "speaker", Image: "registry.example.com/metallb/speaker:0.14.9"},
+									},
+								},
+								{
+									Chart: "unused", Version: "1.0.0", Repository: "suse",
+									Images: []api.HelmChartImage{{Name: "unused", Image: "registry.example.com/unused:1.0.0"}},
+								},
+							},
+							Repositories: []*api.HelmRepository{{Name: "suse", URL: "https://charts.suse.com"}},
+						},
+					},
+				},
+			}
+			conf := &image.Configuration{
+				Kubernetes: kubernetes.Kubernetes{EmbedArtifacts: true},
+				Release: release.Release{
+					Components: release.Components{
+						HelmCharts: []release.HelmChart{{Name: "metallb"}},
+					},
+				},
+			}
+
+			_, _, err := m.configureKubernetes(context.Background(), conf, manifest, output)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pulled).To(Equal([]string{
+				"registry.example.com/metallb/controller:0.14.9",
+				"registry.example.com/metallb/speaker:0.14.9",
+			}))
+
+			archive, err := fs.RawPath(filepath.Join(output.OverlaysDir(), "var/lib/rancher/rke2/agent/images/elemental-helm-images.tar"))
+			Expect(err).NotTo(HaveOccurred())
+			for _, ref := range pulled {
+				tag, err := name.NewTag(ref)
+				Expect(err).NotTo(HaveOccurred())
+				_, err = tarball.ImageFromPath(archive, &tag)
+				Expect(err).NotTo(HaveOccurred())
+			}
+		})
+
+		It("Fails to pull an image of an enabled Helm chart", func() {
+			helmMock := &helmConfiguratorMock{
+				configureFunc: func(conf *image.Configuration, manifest *resolver.ResolvedManifest) ([]string, map[string][]byte, error) {
+					return nil, nil, nil
+				},
+			}
+
+			pullFunc := func(context.Context, string) (containerregistry.Image, error) {
+				return nil, fmt.Errorf("pull error")
+			}
+
+			m := NewManager(system, helmMock, WithPullImageFunc(pullFunc))
+
+			manifest := &resolver.ResolvedManifest{
+				CorePlatform: &core.ReleaseManifest{
+					Components: core.Components{
+						Kubernetes: &core.Kubernetes{Version: "v1.35.0+rke2r1"},
+						Helm: &api.Helm{
+							Charts: []*api.HelmChart{{
+								Chart: "metallb", Version: "0.14.9", Repository: "suse",
+								Images: []api.HelmChartImage{{Name: "controller", Image: "registry.example.com/metallb/controller:0.14.9"}},
+							}},
+							Repositories: []*api.HelmRepository{{Name: "suse", URL: "https://charts.suse.com"}},
+						},
+					},
+				},
+			}
+			conf := &image.Configuration{
+				Kubernetes: kubernetes.Kubernetes{EmbedArtifacts: true},
+				Release: release.Release{
+					Components: release.Components{
+						HelmCharts: []release.HelmChart{{Name: "metallb"}},
+					},
+				},
+			}
+
+			_, _, err := m.configureKubernetes(context.Background(), conf, manifest, output)
+			Expect(err).To(MatchError("embedding helm chart images: pulling image 'registry.example.com/metallb/controller:0.14.9': pull error"))
+		})
+
 		It("Uses server config for a single explicitly configured server node", func() {
 			conf := kubernetes.Kubernetes{
 				Nodes: kubernetes.Nodes{
@@ -355,6 +472,7 @@ var _ = Describe("Kubernetes", func() {
 			confScript, err := writeK8sConfigDeployScript(
 				fs,
 				output,
+				kubernetes.RKE2,
 				conf,
 				"/opt/k8s/install",
 				"/opt/k8s/install/install.sh",
@@ -378,6 +496,7 @@ var _ = Describe("Kubernetes", func() {
 			confScript, err := writeK8sConfigDeployScript(
 				fs,
 				output,
+				kubernetes.RKE2,
 				conf,
 				"/opt/k8s/install",
 				"/opt/k8s/install/install.sh",
@@ -390,6 +509,46 @@ var _ = Describe("Kubernetes", func() {
 			Expect(string(b)).To(ContainSubstring("CONFIGFILE=/var/lib/elemental/kubernetes/init.yaml"))
 		})
 
+		It("Installs K3s with its own paths and services", func() {
+			conf := kubernetes.Kubernetes{
+				Nodes: kubernetes.Nodes{
+					{Hostname: "server01", Type: kubernetes.NodeTypeServer},
+					{Hostname: "agent01", Type: kubernetes.NodeTypeAgent},
+				},
+			}
+
+			confScript, err := writeK8sConfigDeployScript(
+				fs,
+				output,
+				kubernetes.K3s,
+				conf,
+				"/opt/k8s/install",
+				"/opt/k8s/install/install.sh",
+			)
+			Expect(err).NotTo(HaveOccurred())
+
+			b, err := fs.ReadFile(filepath.Join(output.OverlaysDir(), confScript))
+			Expect(err).NotTo(HaveOccurred())
+			script := string(b)
+			Expect(script).To(ContainSubstring("/etc/rancher/k3s/config.yaml"))
+			Expect(script).To(ContainSubstring("INSTALL_K3S_SKIP_DOWNLOAD=true"))
+			Expect(script).To(ContainSubstring("INSTALL_K3S_BIN_DIR=/opt/k3s/bin"))
+			Expect(script).To(ContainSubstring("/var/lib/rancher/k3s/agent/images"))
+			Expect(script).ToNot(ContainSubstring("rke2"))
+		})
+
+		It("Deploys resources with the K3s kubeconfig and kubectl", func() {
+			script, err := writeK8sResDeployScript(fs, output, kubernetes.K3s, "/var/lib/elemental/kubernetes/manifests", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			b, err := fs.ReadFile(filepath.Join(output.OverlaysDir(), script))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(b)).To(ContainSubstring("/etc/rancher/k3s/k3s.yaml"))
+			Expect(string(b)).To(ContainSubstring("/opt/k3s/bin/kubectl"))
+			Expect(string(b)).To(ContainSubstring("/var/lib/rancher/k3s/server/manifests"))
+			Expect(string(b)).ToNot(ContainSubstring("rke2"))
+		})
+
 		It("Succeeds to configure RKE2 with additional resources and auth", func() {
 			additionalManifests := make(map[string][]byte)
 			additionalManifests["example-auth-priority.yaml"] = []byte("apiVersion: v1\nkind: Secret\nmetadata:\n    namespace: kube-system\n    name: example-auth\ntype: kubernetes.io/dockerconfigjson\ndata:\n    .dockerconfigjson: eyJhdXRocyI6eyJleGFtcGxlLmlvIjp7InVzZXJuYW1lIjoiZXhhbXBsZS11c2VyIiwicGFzc3dvcmQiOiJleGFtcGxlLXBhc3MiLCJhdXRoIjoiWlhoaGJYQnNaUzExYzJWeU9tVjRZVzF3YkdVdGNHRnpjdz09In19fQ==\n")
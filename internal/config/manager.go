@@ -22,8 +22,12 @@ import (
 	"fmt"
 	"path/filepath"
 
+	containerregistry "github.com/google/go-containerregistry/pkg/v1"
+
 	"github.com/suse/elemental/v3/internal/image"
+	"github.com/suse/elemental/v3/internal/image/kubernetes"
 	"github.com/suse/elemental/v3/pkg/extractor"
+	"github.com/suse/elemental/v3/pkg/helm"
 	"github.com/suse/elemental/v3/pkg/http"
 	"github.com/suse/elemental/v3/pkg/manifest/resolver"
 	"github.com/suse/elemental/v3/pkg/manifest/source"
@@ -34,9 +38,11 @@ import (
 
 type downloadFunc func(ctx context.Context, fs vfs.FS, url, path string) error
 type unpackFunc func(ctx context.Context, imageRef, destDir string) error
+type pullImageFunc func(ctx context.Context, imageRef string) (containerregistry.Image, error)
+type fetchChartFunc func(ctx context.Context, repository, chart, version string, opts ...helm.FetchOpt) ([]byte, error)
 
 type helmConfigurator interface {
-	Configure(conf *image.Configuration, manifest *resolver.ResolvedManifest) ([]string, map[string][]byte, error)
+	Configure(ctx context.Context, conf *image.Configuration, manifest *resolver.ResolvedManifest) ([]string, map[string][]byte, error)
 }
 
 type releaseManifestResolver interface {
@@ -44,12 +50,16 @@ type releaseManifestResolver interface {
 }
 
 type Manager struct {
-	system *sys.System
-	local  bool
+	system   *sys.System
+	local    bool
+	verifier unpack.SignatureVerifier
+	source   unpack.ImageSource
 
 	rmResolver   releaseManifestResolver
 	downloadFile downloadFunc
 	unpackImage  unpackFunc
+	pullImage    pullImageFunc
+	fetchChart   fetchChartFunc
 	helm         helmConfigurator
 }
 
@@ -79,6 +89,33 @@ func WithLocal(local bool) Opts {
 	}
 }
 
+// WithSignatureVerifier sets the verifier of the signatures of release manifests
+// and systemd extension images
+func WithSignatureVerifier(v unpack.SignatureVerifier) Opts {
+	return func(m *Manager) {
+		m.verifier = v
+	}
+}
+
+// WithImageSource sets the source all OCI images are fetched from, such as an offline bundle
+func WithImageSource(src unpack.ImageSource) Opts {
+	return func(m *Manager) {
+		m.source = src
+	}
+}
+
+func WithPullImageFunc(p pullImageFunc) Opts {
+	return func(m *Manager) {
+		m.pullImage = p
+	}
+}
+
+func WithFetchChartFunc(f fetchChartFunc) Opts {
+	return func(m *Manager) {
+		m.fetchChart = f
+	}
+}
+
 func NewManager(sys *sys.System, helm helmConfigurator, opts ...Opts) *Manager {
 	m := &Manager{
 		system: sys,
@@ -95,12 +132,25 @@ func NewManager(sys *sys.System, helm helmConfigurator, opts ...Opts) *Manager {
 
 	if m.unpackImage == nil {
 		m.unpackImage = func(ctx context.Context, imageRef, destDir string) error {
-			unpacker := unpack.NewOCIUnpacker(sys, imageRef, unpack.WithLocalOCI(m.local))
+			unpacker := unpack.NewOCIUnpacker(
+				sys, imageRef, unpack.WithLocalOCI(m.local), unpack.WithSignatureVerifierOCI(m.verifier),
+				unpack.WithImageSourceOCI(m.source),
+			)
 			_, err := unpacker.Unpack(ctx, destDir)
 			return err
 		}
 	}
 
+	if m.pullImage == nil {
+		m.pullImage = func(ctx context.Context, imageRef string) (containerregistry.Image, error) {
+			unpacker := unpack.NewOCIUnpacker(
+				sys, imageRef, unpack.WithLocalOCI(m.local), unpack.WithSignatureVerifierOCI(m.verifier),
+				unpack.WithImageSourceOCI(m.source),
+			)
+			return unpacker.Image(ctx)
+		}
+	}
+
 	return m
 }
 
@@ -108,7 +158,7 @@ func NewManager(sys *sys.System, helm helmConfigurator, opts ...Opts) *Manager {
 // and returns the resolved release manifest from said configuration.
 func (m *Manager) ConfigureComponents(ctx context.Context, conf *image.Configuration, output Output) (rm *resolver.ResolvedManifest, err error) {
 	if m.rmResolver == nil {
-		defaultResolver, err := defaultManifestResolver(m.system.FS(), output, m.local)
+		defaultResolver, err := defaultManifestResolver(m.system.FS(), output, m.local, m.verifier, m.source)
 		if err != nil {
 			return nil, fmt.Errorf("using default release manifest resolver: %w", err)
 		}
@@ -120,6 +170,11 @@ func (m *Manager) ConfigureComponents(ctx context.Context, conf *image.Configura
 		return nil, fmt.Errorf("resolving release manifest at uri '%s': %w", conf.Release.ManifestURI, err)
 	}
 
+	// keep a record of the applied release manifest on the installed system
+	if err = resolver.WriteManifestFile(m.system, output.OverlaysDir(), rm); err != nil {
+		return nil, fmt.Errorf("storing release manifest: %w", err)
+	}
+
 	if err = m.configureNetworkOnFirstboot(conf, output); err != nil {
 		return nil, fmt.Errorf("configuring network: %w", err)
 	}
@@ -139,19 +194,26 @@ func (m *Manager) ConfigureComponents(ctx context.Context, conf *image.Configura
 	}
 
 	if len(extensions) != 0 {
-		if err = m.downloadSystemExtensions(ctx, extensions, output); err != nil {
+		if err = m.downloadSystemExtensions(ctx, rm, extensions, output); err != nil {
 			return nil, fmt.Errorf("downloading system extensions: %w", err)
 		}
 	}
 
-	if err = m.configureIgnition(conf, output, k8sScript, k8sConfScript, extensions); err != nil {
+	dist, err := kubernetes.NewDistribution(rm.CorePlatform.Components.Kubernetes)
+	if err != nil {
+		return nil, fmt.Errorf("resolving kubernetes distribution: %w", err)
+	}
+
+	if err = m.configureIgnition(conf, output, dist, k8sScript, k8sConfScript, extensions); err != nil {
 		return nil, fmt.Errorf("configuring ignition: %w", err)
 	}
 
 	return rm, nil
 }
 
-func defaultManifestResolver(fs vfs.FS, out Output, local bool) (res *resolver.Resolver, err error) {
+func defaultManifestResolver(
+	fs vfs.FS, out Output, local bool, verifier unpack.SignatureVerifier, src unpack.ImageSource,
+) (res *resolver.Resolver, err error) {
 	const (
 		globPattern = "release_manifest*.yaml"
 	)
@@ -166,7 +228,10 @@ func defaultManifestResolver(fs vfs.FS, out Output, local bool) (res *resolver.R
 		return nil, fmt.Errorf("creating release manifest store '%s': %w", manifestsDir, err)
 	}
 
-	extr, err := extractor.New(searchPaths, extractor.WithStore(manifestsDir), extractor.WithLocal(local))
+	extr, err := extractor.New(
+		searchPaths, extractor.WithStore(manifestsDir), extractor.WithLocal(local),
+		extractor.WithSignatureVerifier(verifier), extractor.WithImageSource(src),
+	)
 	if err != nil {
 		return nil, fmt.Errorf("initializing OCI release manifest extractor: %w", err)
 	}
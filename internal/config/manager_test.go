@@ -49,7 +49,7 @@ type helmConfiguratorMock struct {
 	configureFunc func(*image.Configuration, *resolver.ResolvedManifest) ([]string, map[string][]byte, error)
 }
 
-func (h *helmConfiguratorMock) Configure(conf *image.Configuration, manifest *resolver.ResolvedManifest) ([]string, map[string][]byte, error) {
+func (h *helmConfiguratorMock) Configure(_ context.Context, conf *image.Configuration, manifest *resolver.ResolvedManifest) ([]string, map[string][]byte, error) {
 	if h.configureFunc != nil {
 		return h.configureFunc(conf, manifest)
 	}
@@ -223,6 +223,10 @@ passwd:
 		Expect(r).ToNot(BeNil())
 		Expect(r).To(Equal(activeReleaseManifest))
 
+		stored, err := resolver.ParseManifestFile(system, output.OverlaysDir())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(stored.CorePlatform.Components.Kubernetes).To(Equal(activeReleaseManifest.CorePlatform.Components.Kubernetes))
+
 		_, err = fs.Stat(filepath.Join(output.OverlaysDir(), image.HelmPath(), "bar"))
 		Expect(err).ToNot(HaveOccurred())
 		_, err = fs.Stat(filepath.Join(output.OverlaysDir(), image.KubernetesInstallPath(), "install.sh"))
@@ -18,24 +18,35 @@ limitations under the License.
 package config
 
 import (
+	"archive/tar"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	iofs "io/fs"
 	"net/url"
 	"path/filepath"
 	"slices"
+	"strings"
+
+	containerregistry "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/joho/godotenv"
 
 	"github.com/suse/elemental/v3/internal/image"
 	"github.com/suse/elemental/v3/internal/image/release"
+	"github.com/suse/elemental/v3/pkg/bootloader"
+	"github.com/suse/elemental/v3/pkg/extensions"
 	"github.com/suse/elemental/v3/pkg/log"
 	"github.com/suse/elemental/v3/pkg/manifest/api"
 	"github.com/suse/elemental/v3/pkg/manifest/resolver"
-	"github.com/suse/elemental/v3/pkg/rsync"
 	"github.com/suse/elemental/v3/pkg/sys/vfs"
 	"github.com/suse/elemental/v3/pkg/unpack"
 )
 
-func (m *Manager) downloadSystemExtensions(ctx context.Context, extensions []api.SystemdExtension, output Output) error {
+func (m *Manager) downloadSystemExtensions(
+	ctx context.Context, rm *resolver.ResolvedManifest, extensions []api.SystemdExtension, output Output,
+) error {
 	logger := m.system.Logger()
 	fs := m.system.FS()
 	extensionsDir := filepath.Join(output.OverlaysDir(), image.ExtensionsPath())
@@ -44,99 +55,143 @@ func (m *Manager) downloadSystemExtensions(ctx context.Context, extensions []api
 		return fmt.Errorf("creating extensions directory: %w", err)
 	}
 
+	artifacts := map[string][]string{}
 	for _, extension := range extensions {
 		logger.Info("Pulling extension %s from %s...",
 			extension.Name, extension.Image)
 
+		existing, err := fs.ReadDir(extensionsDir)
+		if err != nil {
+			return fmt.Errorf("reading extensions directory: %w", err)
+		}
+
 		if isRemoteURL(extension.Image) {
 			extensionPath := filepath.Join(extensionsDir, filepath.Base(extension.Image))
-			if err := m.downloadFile(ctx, fs, extension.Image, extensionPath); err != nil {
+			if err = m.downloadFile(ctx, fs, extension.Image, extensionPath); err != nil {
 				return fmt.Errorf("downloading systemd extension %s: %w", extension.Name, err)
 			}
-
-			continue
+		} else if err = m.unpackExtension(ctx, extension, extensionsDir); err != nil {
+			return fmt.Errorf("unpacking systemd extension %s: %w", extension.Name, err)
 		}
 
-		if err := m.unpackExtension(ctx, extension, extensionsDir); err != nil {
-			return fmt.Errorf("unpacking systemd extension %s: %w", extension.Name, err)
+		if artifacts[extension.Name], err = newEntries(fs, extensionsDir, existing); err != nil {
+			return fmt.Errorf("reading extensions directory: %w", err)
 		}
 	}
 
-	return nil
+	return m.validateSystemExtensions(ctx, rm, extensions, artifacts)
 }
 
-func isRemoteURL(s string) bool {
-	u, err := url.Parse(s)
+// newEntries returns the paths of the entries of the given directory not included in the given list
+func newEntries(fs vfs.FS, dir string, existing []iofs.DirEntry) ([]string, error) {
+	entries, err := fs.ReadDir(dir)
 	if err != nil {
-		return false
+		return nil, err
 	}
 
-	return u.Scheme == "http" || u.Scheme == "https"
+	var paths []string
+	for _, e := range entries {
+		if !slices.ContainsFunc(existing, func(x iofs.DirEntry) bool { return x.Name() == e.Name() }) {
+			paths = append(paths, filepath.Join(dir, e.Name()))
+		}
+	}
+	return paths, nil
 }
 
-func (m *Manager) unpackExtension(ctx context.Context, extension api.SystemdExtension, extensionsDir string) error {
-	fs := m.system.FS()
+// validateSystemExtensions checks the extension-release file of each downloaded extension matches
+// the os-release of the operating system image from the release manifest
+func (m *Manager) validateSystemExtensions(
+	ctx context.Context, rm *resolver.ResolvedManifest, exts []api.SystemdExtension, artifacts map[string][]string,
+) error {
+	logger := m.system.Logger()
 
-	tempDir, err := vfs.TempDir(fs, "", fmt.Sprintf("%s-", extension.Name))
-	if err != nil {
-		return fmt.Errorf("creating temp directory: %w", err)
+	osImage := rm.CorePlatform.Components.OperatingSystem
+	if osImage == nil || osImage.Image.Base == "" {
+		logger.Warn("Release manifest does not define an operating system image, skipping systemd extensions validation")
+		return nil
 	}
-	defer func() {
-		_ = fs.RemoveAll(tempDir)
-	}()
 
-	unpacker := unpack.NewOCIUnpacker(m.system, extension.Image, unpack.WithLocalOCI(m.local))
-	if _, err = unpacker.Unpack(ctx, tempDir); err != nil {
-		return fmt.Errorf("unpacking extension: %w", err)
+	img, err := m.pullImage(ctx, osImage.Image.Base)
+	if err != nil {
+		return fmt.Errorf("pulling operating system image '%s': %w", osImage.Image.Base, err)
 	}
 
-	entries, err := fs.ReadDir(tempDir)
+	osRelease, err := imageOSRelease(img)
 	if err != nil {
-		return fmt.Errorf("reading unpacked directory: %w", err)
+		return fmt.Errorf("reading os-release of '%s': %w", osImage.Image.Base, err)
 	}
 
-	if len(entries) == 1 {
-		entry := entries[0]
-		if !entry.IsDir() {
-			file := filepath.Join(tempDir, entry.Name())
-			if err = vfs.CopyFile(fs, file, extensionsDir); err != nil {
-				return fmt.Errorf("copying extension file %s: %w", file, err)
-			}
+	arch := extensions.Architecture(m.system.Platform())
 
-			return nil
+	var errs []error
+	for _, ext := range exts {
+		if err = extensions.Check(m.system, artifacts[ext.Name], osRelease, arch); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", ext.Name, err))
 		}
 	}
 
-	if !slices.ContainsFunc(entries, func(entry iofs.DirEntry) bool {
-		return entry.Name() == "usr" && entry.IsDir()
-	}) {
-		return fmt.Errorf("invalid extension: either a single image file or a /usr directory is required")
+	if len(errs) > 0 {
+		return fmt.Errorf("systemd extensions incompatible with '%s':\n%w", osImage.Image.Base, errors.Join(errs...))
+	}
+
+	logger.Info("Validated %d systemd extensions against '%s'", len(exts), osImage.Image.Base)
+	return nil
+}
+
+// imageOSRelease reads the os-release file of the given image
+func imageOSRelease(img containerregistry.Image) (extensions.Release, error) {
+	reader := mutate.Extract(img)
+	defer reader.Close()
+
+	candidates := []string{
+		strings.TrimPrefix(extensions.OSReleaseFile, "/"),
+		strings.TrimPrefix(bootloader.OsReleasePath, "/"),
 	}
 
-	sync := rsync.NewRsync(m.system, rsync.WithContext(ctx))
-	syncDirectory := func(dirName string) error {
-		sourcePath := filepath.Join(tempDir, dirName)
-		if exists, _ := vfs.Exists(fs, sourcePath); !exists {
-			return nil
+	found := map[string]map[string]string{}
+	tr := tar.NewReader(reader)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return extensions.Release{}, err
 		}
 
-		targetPath := filepath.Join(extensionsDir, extension.Name, dirName)
-		if err = vfs.MkdirAll(fs, targetPath, 0755); err != nil {
-			return fmt.Errorf("creating extension directory /%s: %w", dirName, err)
+		name := strings.TrimPrefix(filepath.Clean(hdr.Name), "/")
+		if hdr.Typeflag != tar.TypeReg || !slices.Contains(candidates, name) {
+			continue
 		}
 
-		if err = sync.SyncData(sourcePath, targetPath); err != nil {
-			return fmt.Errorf("syncing extension directory /%s: %w", dirName, err)
+		vars, err := godotenv.Parse(tr)
+		if err != nil {
+			return extensions.Release{}, fmt.Errorf("parsing '%s': %w", name, err)
 		}
+		found[name] = vars
+	}
 
-		return nil
+	for _, c := range candidates {
+		if vars, ok := found[c]; ok {
+			return extensions.NewRelease(vars), nil
+		}
 	}
+	return extensions.Release{}, fmt.Errorf("os-release file not found")
+}
 
-	if err = syncDirectory("usr"); err != nil {
-		return err
+func isRemoteURL(s string) bool {
+	u, err := url.Parse(s)
+	if err != nil {
+		return false
 	}
 
-	return syncDirectory("opt")
+	return u.Scheme == "http" || u.Scheme == "https"
+}
+
+func (m *Manager) unpackExtension(ctx context.Context, extension api.SystemdExtension, extensionsDir string) error {
+	return extensions.Unpack(
+		ctx, m.system, extension, extensionsDir,
+		unpack.WithLocalOCI(m.local), unpack.WithSignatureVerifierOCI(m.verifier), unpack.WithImageSourceOCI(m.source),
+	)
 }
 
 func isExtensionExplicitlyEnabled(name string, conf *image.Configuration) bool {
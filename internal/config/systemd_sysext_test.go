@@ -18,6 +18,14 @@ limitations under the License.
 package config
 
 import (
+	"context"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/crane"
+	containerregistry "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
@@ -28,6 +36,9 @@ import (
 	"github.com/suse/elemental/v3/pkg/manifest/api/core"
 	"github.com/suse/elemental/v3/pkg/manifest/api/solution"
 	"github.com/suse/elemental/v3/pkg/manifest/resolver"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
 )
 
 var _ = Describe("Systemd extensions", func() {
@@ -168,4 +179,109 @@ var _ = Describe("Systemd extensions", func() {
 			Expect(extensions).To(ContainElement(api.SystemdExtension{Name: "nvidia-toolkit", Image: "https://example.com/nvidia-toolkit.raw"}), "Explicitly requested")
 		})
 	})
+
+	Describe("Compatibility", func() {
+		var system *sys.System
+		var fs vfs.FS
+		var cleanup func()
+		var runner *sysmock.Runner
+		var releases map[string]string
+		var rm *resolver.ResolvedManifest
+		var m *Manager
+		var output = Output{RootPath: "/_out"}
+
+		exts := []api.SystemdExtension{
+			{Name: "foo", Image: "https://example.com/foo.raw"},
+			{Name: "bar", Image: "https://example.com/bar.raw"},
+		}
+
+		BeforeEach(func() {
+			var err error
+			fs, cleanup, err = sysmock.TestFS(nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			releases = map[string]string{
+				"foo": "ID=sl-micro\nVERSION_ID=6.2\n",
+				"bar": "ID=sl-micro\nSYSEXT_LEVEL=1.0\nARCHITECTURE=x86-64\n",
+			}
+
+			runner = sysmock.NewRunner()
+			runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+				if cmd == "systemd-dissect" {
+					name := strings.TrimSuffix(filepath.Base(args[1]), ".raw")
+					Expect(vfs.MkdirAll(fs, args[3], vfs.DirPerm)).To(Succeed())
+					return nil, fs.WriteFile(filepath.Join(args[3], "extension-release."+name), []byte(releases[name]), vfs.FilePerm)
+				}
+				return nil, nil
+			}
+
+			system, err = sys.NewSystem(
+				sys.WithFS(fs), sys.WithRunner(runner), sys.WithLogger(logger),
+				sys.WithPlatform("linux/amd64"),
+			)
+			Expect(err).NotTo(HaveOccurred())
+
+			rm = &resolver.ResolvedManifest{
+				CorePlatform: &core.ReleaseManifest{
+					Components: core.Components{
+						OperatingSystem: &core.OperatingSystem{
+							Image: core.Image{Base: "registry.example.com/os-base:6.2"},
+						},
+					},
+				},
+			}
+
+			osRelease, err := crane.Layer(map[string][]byte{
+				"usr/lib/os-release": []byte("ID=sl-micro\nVERSION_ID=6.2\nSYSEXT_LEVEL=1.0\n"),
+			})
+			Expect(err).NotTo(HaveOccurred())
+			osImage, err := mutate.AppendLayers(empty.Image, osRelease)
+			Expect(err).NotTo(HaveOccurred())
+
+			m = NewManager(
+				system, nil,
+				WithDownloadFunc(func(ctx context.Context, fs vfs.FS, url, path string) error {
+					return fs.WriteFile(path, []byte(url), vfs.FilePerm)
+				}),
+				WithPullImageFunc(func(ctx context.Context, ref string) (containerregistry.Image, error) {
+					Expect(ref).To(Equal("registry.example.com/os-base:6.2"))
+					return osImage, nil
+				}),
+			)
+		})
+
+		AfterEach(func() {
+			cleanup()
+		})
+
+		It("Validates extensions against the OS image", func() {
+			Expect(m.downloadSystemExtensions(context.Background(), rm, exts, output)).To(Succeed())
+			Expect(runner.CmdsMatch([][]string{
+				{"systemd-dissect", "--copy-from", filepath.Join(output.OverlaysDir(), image.ExtensionsPath(), "foo.raw")},
+				{"systemd-dissect", "--copy-from", filepath.Join(output.OverlaysDir(), image.ExtensionsPath(), "bar.raw")},
+			})).To(Succeed())
+		})
+
+		It("Reports all incompatible extensions", func() {
+			releases["foo"] = "ID=sl-micro\nVERSION_ID=6.1\n"
+			releases["bar"] = "ID=sl-micro\nSYSEXT_LEVEL=1.0\nARCHITECTURE=arm64\n"
+
+			err := m.downloadSystemExtensions(context.Background(), rm, exts, output)
+			Expect(err).To(MatchError(ContainSubstring("systemd extensions incompatible with 'registry.example.com/os-base:6.2'")))
+			Expect(err).To(MatchError(ContainSubstring(
+				"foo: 'foo.raw' is not compatible: VERSION_ID '6.1' does not match the OS VERSION_ID '6.2'",
+			)))
+			Expect(err).To(MatchError(ContainSubstring(
+				"bar: 'bar.raw' is not compatible: ARCHITECTURE 'arm64' does not match the OS architecture 'x86-64'",
+			)))
+		})
+
+		It("Skips the validation if the release manifest has no OS image", func() {
+			releases["foo"] = "ID=other\n"
+			rm.CorePlatform.Components.OperatingSystem = nil
+
+			Expect(m.downloadSystemExtensions(context.Background(), rm, exts, output)).To(Succeed())
+			Expect(runner.GetCmds()).To(BeEmpty())
+		})
+	})
 })
@@ -48,10 +48,12 @@ spec:
     - IPv6
   {{- end }}
   ports:
-  - name: rke2-api
-    port: 9345
+  {{- if .SupervisorPort }}
+  - name: {{ .Distribution }}-api
+    port: {{ .SupervisorPort }}
     protocol: TCP
-    targetPort: 9345
+    targetPort: {{ .SupervisorPort }}
+  {{- end }}
   - name: k8s-api
     port: 6443
     protocol: TCP
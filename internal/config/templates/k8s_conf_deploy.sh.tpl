@@ -26,12 +26,12 @@ fi
 
 # Better to append if a file exist
 # Useful if some custom configuration are done at boot
-mkdir -p /etc/rancher/rke2
-echo "Copying RKE2 config file ${CONFIGFILE}"
-cat ${CONFIGFILE} >> /etc/rancher/rke2/config.yaml
+mkdir -p {{ .ConfigDir }}
+echo "Copying {{ .DistributionName }} config file ${CONFIGFILE}"
+cat ${CONFIGFILE} >> {{ .ConfigDir }}/config.yaml
 
 if [[ -e "${REGFILE}" ]]; then
-  cp "${REGFILE}" /etc/rancher/rke2/registries.yaml
+  cp "${REGFILE}" {{ .ConfigDir }}/registries.yaml
 fi
 
 {{- if and .APIVIP4 .APIHost }}
@@ -44,14 +44,37 @@ grep -q "{{ .APIVIP6 }} {{ .APIHost }}" /etc/hosts \
   || echo "{{ .APIVIP6 }} {{ .APIHost }}" >> /etc/hosts
 {{- end }}
 
-echo "Installing RKE2 from embedded artifacts..."
+echo "Installing {{ .DistributionName }} from embedded artifacts..."
+{{- if .K3s }}
+
+mkdir -p {{ .BinDir }} {{ .ImagesDir }}
+install -m 0755 "{{ .InstallPath }}/k3s" {{ .BinDir }}/k3s
+for images in "{{ .InstallPath }}"/k3s-airgap-images-*; do
+  [[ -e "${images}" ]] && cp "${images}" {{ .ImagesDir }}/
+done
+
+export INSTALL_K3S_SKIP_DOWNLOAD=true
+export INSTALL_K3S_SKIP_ENABLE=true
+export INSTALL_K3S_SKIP_SELINUX_RPM=true
+export INSTALL_K3S_BIN_DIR={{ .BinDir }}
+export INSTALL_K3S_EXEC="${NODETYPE}"
+{{- else }}
 
 export INSTALL_RKE2_ARTIFACT_PATH="{{ .InstallPath }}"
-export INSTALL_RKE2_TAR_PREFIX=/opt/rke2
+export INSTALL_RKE2_TAR_PREFIX={{ .InstallPrefix }}
+{{- end }}
 
 if ! sh "{{ .InstallScript }}"; then
-  echo "Error: RKE2 installation failed" >&2
+  echo "Error: {{ .DistributionName }} installation failed" >&2
   exit 1
 fi
 
+{{- if .K3s }}
+
+SERVICE=k3s
+[[ "${NODETYPE}" = "agent" ]] && SERVICE=k3s-agent
+systemctl enable --now ${SERVICE}.service
+{{- else }}
+
 systemctl enable --now rke2-${NODETYPE}.service
+{{- end }}
@@ -5,7 +5,7 @@ set -uo pipefail
 KUBE_SYSTEM_NS="kube-system"
 
 kubectl_cmd() {
-  KUBECONFIG=/etc/rancher/rke2/rke2.yaml /var/lib/rancher/rke2/bin/kubectl "$@"
+  KUBECONFIG={{ .Kubeconfig }} {{ .Kubectl }} "$@"
 }
 
 retryKubectlCreate() {
@@ -137,35 +137,35 @@ deployPriorityManifests() {
 }
 {{- end }}
 
-waitForCoreRKE2Charts() {
-  # A running rke2-server.service does not mean that the Helm Controller is ready.
-  # Wait for the Helm Controller to start creating the core RKE2 HelmChart resources.
+waitForCoreCharts() {
+  # A running server service does not mean that the Helm Controller is ready.
+  # Wait for the Helm Controller to start creating the core HelmChart resources.
   until [[ $(kubectl_cmd get helmcharts -n "$KUBE_SYSTEM_NS" --no-headers 2>/dev/null | wc -l) -gt 0 ]]; do
     sleep 10
   done
 
-  local rke2_manifests_dir="/var/lib/rancher/rke2/server/manifests"
-  local rke2_chart_names=""
-  for rke2_file in $rke2_manifests_dir/*.yaml; do
+  local core_manifests_dir="{{ .CoreManifestsDir }}"
+  local core_chart_names=""
+  for core_file in $core_manifests_dir/*.yaml; do
     # Make sure file is a valid K8s resource
-    if kubectl_cmd create --dry-run=client -f "$rke2_file" > /dev/null 2>&1; then
-      kind=$(kubectl_cmd create --dry-run=client -f "$rke2_file" -o jsonpath="{.kind}" 2>&1)
-      name=$(kubectl_cmd create --dry-run=client -f "$rke2_file" -o jsonpath="{.metadata.name}" 2>&1)
+    if kubectl_cmd create --dry-run=client -f "$core_file" > /dev/null 2>&1; then
+      kind=$(kubectl_cmd create --dry-run=client -f "$core_file" -o jsonpath="{.kind}" 2>&1)
+      name=$(kubectl_cmd create --dry-run=client -f "$core_file" -o jsonpath="{.metadata.name}" 2>&1)
       if [ "$kind" = "HelmChart" ]; then
-          rke2_chart_names="$rke2_chart_names $name"
+          core_chart_names="$core_chart_names $name"
       fi
     fi
   done
 
-  echo "Waiting for RKE2 core helm charts"
-  for name in $rke2_chart_names; do
+  echo "Waiting for {{ .DistributionName }} core helm charts"
+  for name in $core_chart_names; do
     if ! waitForHelmChart "$name" "$KUBE_SYSTEM_NS"; then
       exit 1
     fi
   done
 }
 
-waitForCoreRKE2Charts
+waitForCoreCharts
 
 {{- if .ManifestsDir }}
 deployPriorityManifests
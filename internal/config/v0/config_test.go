@@ -286,7 +286,7 @@ raw:
 		_, err := Parse(fs, configDir)
 		Expect(err).To(HaveOccurred())
 		Expect(err.Error()).To(ContainSubstring("validating configuration"))
-		Expect(err.Error()).To(ContainSubstring("field \"Configuration.Installation.Bootloader\" must be one of [grub none], but got \"invalid\""))
+		Expect(err.Error()).To(ContainSubstring("field \"Configuration.Installation.Bootloader\" must be one of [grub systemd-boot none], but got \"invalid\""))
 		Expect(err.Error()).To(ContainSubstring("field \"Configuration.Installation.RAW.DiskSize\" must be a valid disk size (e.g., 10G, 500M), but got \"35X\""))
 	})
 
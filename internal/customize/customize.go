@@ -22,19 +22,26 @@ import (
 	"errors"
 	"fmt"
 	"path/filepath"
+	"strings"
 
 	_ "embed"
 
+	"go.yaml.in/yaml/v3"
+
 	"github.com/suse/elemental/v3/internal/config"
 	"github.com/suse/elemental/v3/internal/image"
 	"github.com/suse/elemental/v3/internal/image/install"
 	"github.com/suse/elemental/v3/internal/template"
+	"github.com/suse/elemental/v3/pkg/artifact"
+	"github.com/suse/elemental/v3/pkg/bootloader"
 	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/diskimage"
 	"github.com/suse/elemental/v3/pkg/fips"
 	"github.com/suse/elemental/v3/pkg/installer"
 	"github.com/suse/elemental/v3/pkg/manifest/resolver"
 	"github.com/suse/elemental/v3/pkg/sys"
 	"github.com/suse/elemental/v3/pkg/sys/vfs"
+	"github.com/suse/elemental/v3/pkg/unpack"
 )
 
 const (
@@ -56,11 +63,24 @@ type media interface {
 	Customize(d *deployment.Deployment) error
 }
 
+type diskImage interface {
+	Build(d *deployment.Deployment, output string) error
+}
+
+type publisher interface {
+	Push(uri string, a *artifact.Artifact) (digest string, err error)
+}
+
 type Runner struct {
 	System        *sys.System
 	ConfigManager configManager
 	FileExtractor ociFileExtractor
 	Media         media
+	DiskImage     diskImage
+	Publisher     publisher
+	Verifier      unpack.SignatureVerifier
+	ImageSource   unpack.ImageSource
+	Local         bool
 }
 
 func (r *Runner) Run(ctx context.Context, def *image.Definition, output config.Output) (err error) {
@@ -73,6 +93,10 @@ func (r *Runner) Run(ctx context.Context, def *image.Definition, output config.O
 		return err
 	}
 
+	if def.Image.ImageType == diskimage.MediaType {
+		return r.buildDiskImage(ctx, def, rm, output)
+	}
+
 	containerImage := rm.CorePlatform.Components.OperatingSystem.Image.ISO
 	logger.Info("Extracting ISO from container image %s", containerImage)
 	iso, err := r.FileExtractor.ExtractFrom(containerImage)
@@ -107,23 +131,20 @@ func (r *Runner) Run(ctx context.Context, def *image.Definition, output config.O
 		logger.Error("Parsing customization deployment failed")
 		return err
 	}
+	dep.Release = manifestRelease(rm)
 
 	mediaOpts := []installer.Option{
 		installer.WithOutputFile(def.Image.OutputImageName),
 	}
 	if mediaType == installer.Disk {
-		diskSizeStr := def.Configuration.Installation.RAW.DiskSize
-		if diskSizeStr == "" {
-			diskSizeStr = "12G"
-		}
-		if !diskSizeStr.IsValid() {
-			return fmt.Errorf("invalid disk size definition '%s'", diskSizeStr)
-		}
-		diskMiB, err := diskSizeStr.ToMiB()
+		diskMiB, err := parseDiskSize(def.Configuration.Installation.RAW.DiskSize)
 		if err != nil {
-			return fmt.Errorf("could not parse disk size '%s': %w", diskSizeStr, err)
+			return err
 		}
-		mediaOpts = append(mediaOpts, installer.WithRawDiskSize(deployment.MiB(diskMiB)))
+		mediaOpts = append(mediaOpts, installer.WithRawDiskSize(diskMiB))
+	}
+	if mediaType == installer.Netboot {
+		mediaOpts = append(mediaOpts, installer.WithNetbootURL(def.Image.NetbootURL))
 	}
 
 	// TODO(ipetrov117): Consider refactoring installer.Media, as right now
@@ -144,10 +165,198 @@ func (r *Runner) Run(ctx context.Context, def *image.Definition, output config.O
 		return err
 	}
 
+	if def.Image.PushReference != "" {
+		desc, err := installerDeployment.DeepCopy()
+		if err != nil {
+			return fmt.Errorf("copying installer deployment: %w", err)
+		}
+		if err = deployment.Merge(desc, dep); err != nil {
+			return fmt.Errorf("merging customized deployment: %w", err)
+		}
+		if err = r.push(ctx, def, rm, desc); err != nil {
+			logger.Error("Pushing customized image failed")
+			return err
+		}
+	}
+
 	logger.Info("Customize complete")
 	return nil
 }
 
+// buildDiskImage builds a disk image including the OS of the release already deployed, so it
+// boots straight into the OS without going through an installation.
+func (r *Runner) buildDiskImage(
+	ctx context.Context,
+	def *image.Definition,
+	rm *resolver.ResolvedManifest,
+	output config.Output,
+) error {
+	logger := r.System.Logger()
+
+	format := diskimage.Raw
+	if def.Image.DiskFormat != "" {
+		var err error
+		format, err = diskimage.ParseFormat(def.Image.DiskFormat)
+		if err != nil {
+			logger.Error("Parsing disk image format failed")
+			return err
+		}
+	}
+
+	diskMiB, err := parseDiskSize(def.Configuration.Installation.RAW.DiskSize)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Preparing disk image deployment")
+	dep, err := newDiskDeployment(
+		r.System,
+		rm.CorePlatform.Components.OperatingSystem.Image.Base,
+		&def.Configuration.Installation,
+		output,
+	)
+	if err != nil {
+		logger.Error("Preparing disk image deployment failed")
+		return err
+	}
+	dep.Release = manifestRelease(rm)
+
+	if r.DiskImage == nil {
+		boot, err := bootloader.New(dep.BootConfig.Bootloader, r.System)
+		if err != nil {
+			logger.Error("Parsing boot config failed")
+			return err
+		}
+		r.DiskImage = diskimage.New(
+			ctx, r.System, diskimage.WithFormat(format), diskimage.WithSize(diskMiB),
+			diskimage.WithBootloader(boot), diskimage.WithUnpackOpts(
+				unpack.WithLocal(r.Local), unpack.WithSignatureVerifier(r.Verifier), unpack.WithImageSource(r.ImageSource),
+			),
+		)
+	}
+
+	logger.Info("Building %s disk image", format)
+	if err = r.DiskImage.Build(dep, def.Image.OutputImageName); err != nil {
+		logger.Error("Building disk image failed")
+		return err
+	}
+
+	if def.Image.PushReference != "" {
+		if err = r.push(ctx, def, rm, dep); err != nil {
+			logger.Error("Pushing disk image failed")
+			return err
+		}
+	}
+
+	logger.Info("Customize complete")
+	return nil
+}
+
+// push uploads the built image to the registry as an OCI artifact including the
+// resolved release manifest and the deployment description of the image
+func (r *Runner) push(
+	ctx context.Context,
+	def *image.Definition,
+	rm *resolver.ResolvedManifest,
+	d *deployment.Deployment,
+) error {
+	manifest, err := yaml.Marshal(rm)
+	if err != nil {
+		return fmt.Errorf("marshalling release manifest: %w", err)
+	}
+
+	desc, err := d.MarshalDescription()
+	if err != nil {
+		return fmt.Errorf("marshalling deployment: %w", err)
+	}
+
+	if r.Publisher == nil {
+		r.Publisher = artifact.NewPublisher(r.System, artifact.WithContext(ctx))
+	}
+
+	r.System.Logger().Info("Pushing image to %s", def.Image.PushReference)
+	digest, err := r.Publisher.Push(def.Image.PushReference, &artifact.Artifact{
+		Image:           def.Image.OutputImageName,
+		ReleaseManifest: manifest,
+		Deployment:      desc,
+		Annotations:     map[string]string{artifact.MediaTypeAnnotation: def.Image.ImageType},
+	})
+	if err != nil {
+		return err
+	}
+
+	r.System.Logger().Info("Image pushed as %s", digest)
+	return nil
+}
+
+// newDiskDeployment returns the deployment of disk images for the given OS image
+func newDiskDeployment(
+	s *sys.System,
+	osImage string,
+	install *install.Installation,
+	output config.Output,
+) (*deployment.Deployment, error) {
+	fs := s.FS()
+	opts := []deployment.Opt{}
+
+	firstbootConfigExists, _ := vfs.Exists(fs, output.FirstbootConfigDir())
+	if firstbootConfigExists && output.ConfigPath == "" {
+		configSize, err := vfs.DirSizeMB(fs, output.FirstbootConfigDir())
+		if err != nil {
+			return nil, fmt.Errorf("computing configuration partition size: %w", err)
+		}
+		opts = append(opts, deployment.WithConfigPartition(deployment.MiB(configSize)))
+	}
+
+	if install.BIOS {
+		opts = append(opts, deployment.WithBIOSPartition())
+	}
+
+	d := deployment.New(opts...)
+	d.BootConfig.Bootloader = install.Bootloader
+	d.BootConfig.KernelCmdline = install.KernelCmdLine
+	d.BootConfig.BIOS = install.BIOS
+	d.Security.CryptoPolicy = install.CryptoPolicy
+
+	if d.IsFipsEnabled() {
+		d.BootConfig.KernelCmdline = fips.AppendCommandLine(d.BootConfig.KernelCmdline)
+	}
+
+	osURI := fmt.Sprintf("%s://%s", deployment.OCI, osImage)
+	osSource, err := deployment.NewSrcFromURI(osURI)
+	if err != nil {
+		return nil, fmt.Errorf("parsing OS source URI %q: %w", osURI, err)
+	}
+	d.SourceOS = osSource
+
+	overlaysDir := output.OverlaysDir()
+	if exists, _ := vfs.Exists(fs, overlaysDir); exists {
+		d.OverlayTree = deployment.NewDirSrc(overlaysDir)
+	}
+
+	// The target device is only known once the disk image is created
+	if err = d.Sanitize(s, deployment.CheckDiskDevice); err != nil {
+		return nil, fmt.Errorf("sanitizing deployment: %w", err)
+	}
+
+	return d, nil
+}
+
+// parseDiskSize returns the given disk size in MiB, it defaults to 12GiB if no size is given
+func parseDiskSize(diskSize install.DiskSize) (deployment.MiB, error) {
+	if diskSize == "" {
+		diskSize = "12G"
+	}
+	if !diskSize.IsValid() {
+		return 0, fmt.Errorf("invalid disk size definition '%s'", diskSize)
+	}
+	diskMiB, err := diskSize.ToMiB()
+	if err != nil {
+		return 0, fmt.Errorf("could not parse disk size '%s': %w", diskSize, err)
+	}
+	return deployment.MiB(diskMiB), nil
+}
+
 func loadISOInstallDesc(s *sys.System, iso, outputDir string) (dep *deployment.Deployment, err error) {
 	tempDir, err := vfs.TempDir(s.FS(), outputDir, "iso-desc-install")
 	if err != nil {
@@ -164,6 +373,15 @@ func loadISOInstallDesc(s *sys.System, iso, outputDir string) (dep *deployment.D
 	return installer.LoadISOInstallDesc(s, tempDir, iso)
 }
 
+// manifestRelease returns the release of the given manifest to record in the deployment
+func manifestRelease(rm *resolver.ResolvedManifest) *deployment.Release {
+	md := rm.Metadata()
+	if md == nil {
+		return nil
+	}
+	return &deployment.Release{Name: md.Name, Version: md.Version}
+}
+
 func parseDeployment(
 	fs vfs.FS,
 	mediaType installer.MediaType,
@@ -195,13 +413,21 @@ func parseDeployment(
 		additionalPartitions = append(additionalPartitions, configPart)
 	}
 
+	if install.BIOS && installerDep.GetBIOSPartition() == nil {
+		additionalPartitions = append(additionalPartitions, &deployment.Partition{
+			Label: deployment.BiosLabel,
+			Role:  deployment.BIOS,
+			Size:  deployment.BiosSize,
+		})
+	}
+
 	if len(additionalPartitions) > 0 {
 		customizeDisk.Partitions = prepareDeploymentPartitions(installerDep.Disks[0].Partitions, additionalPartitions)
 	}
 
-	if mediaType == installer.ISO {
+	if mediaType == installer.ISO || mediaType == installer.Netboot {
 		if install.ISO.Device == "" {
-			return nil, fmt.Errorf("missing device configuration for ISO image type")
+			return nil, fmt.Errorf("missing device configuration for %s image type", strings.ToUpper(mediaType.String()))
 		}
 
 		customizeDisk.Device = install.ISO.Device
@@ -210,6 +436,7 @@ func parseDeployment(
 	d.BootConfig = &deployment.BootConfig{
 		Bootloader:    install.Bootloader,
 		KernelCmdline: install.KernelCmdLine,
+		BIOS:          install.BIOS,
 	}
 
 	d.Security = &deployment.SecurityConfig{
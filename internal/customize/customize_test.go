@@ -30,8 +30,10 @@ import (
 	"github.com/suse/elemental/v3/internal/customize"
 	"github.com/suse/elemental/v3/internal/image"
 	"github.com/suse/elemental/v3/internal/image/install"
+	"github.com/suse/elemental/v3/pkg/artifact"
 	"github.com/suse/elemental/v3/pkg/crypto"
 	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/manifest/api"
 	"github.com/suse/elemental/v3/pkg/manifest/api/core"
 	"github.com/suse/elemental/v3/pkg/manifest/resolver"
 	"github.com/suse/elemental/v3/pkg/sys"
@@ -225,6 +227,41 @@ disks:
 
 	})
 
+	It("passes deployment object for netboot media with an installing auto-installer", func() {
+		customizeDeployment := &deployment.Deployment{}
+		customizeRunner.Media = &mediaMock{
+			customizeFunc: func(d *deployment.Deployment) error {
+				customizeDeployment = d
+				return nil
+			},
+		}
+		def := &image.Definition{
+			Image: image.Image{
+				ImageType:  "netboot",
+				NetbootURL: "http://10.0.0.1/elemental",
+			},
+			Configuration: &image.Configuration{
+				Installation: install.Installation{
+					ISO: install.ISO{
+						Device: "/dev/sda",
+					},
+				},
+			},
+		}
+
+		Expect(customizeRunner.Run(context.Background(), def, output)).To(Succeed())
+		Expect(customizeDeployment.Disks[0].Device).To(Equal("/dev/sda"))
+
+		script, err := fs.ReadFile(customizeDeployment.Installer.CfgScript)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(script)).To(ContainSubstring("ExecStart=/usr/bin/elemental3ctl --debug install"))
+		Expect(string(script)).NotTo(ContainSubstring("ConditionKernelCommandLine"))
+
+		def.Configuration.Installation.ISO.Device = ""
+		err = customizeRunner.Run(context.Background(), def, output)
+		Expect(err).To(MatchError("missing device configuration for NETBOOT image type"))
+	})
+
 	It("passes deployment object for RAW media without additional partitions", func() {
 		customizeRunner.FileExtractor = &fileExtractorMock{
 			extractFunc: func(uri string) (path string, err error) {
@@ -271,6 +308,169 @@ disks:
 		Expect(len(customizeDeployment.Disks[0].Partitions)).To(Equal(0))
 	})
 
+	It("builds a preinstalled disk image", func() {
+		customizeRunner.ConfigManager = &configManagerMock{
+			configFunc: func(ctx context.Context, conf *image.Configuration, output config.Output) (*resolver.ResolvedManifest, error) {
+				return &resolver.ResolvedManifest{
+					CorePlatform: &core.ReleaseManifest{
+						Metadata: &api.Metadata{Name: "suse-core", Version: "0.0.1"},
+						Components: core.Components{
+							OperatingSystem: &core.OperatingSystem{
+								Image: core.Image{
+									Base: "registry.foo.bar/base-os:0.0.1",
+									ISO:  expectedISO,
+								},
+							},
+						},
+					},
+				}, nil
+			},
+		}
+		customizeRunner.FileExtractor = &fileExtractorMock{}
+		Expect(vfs.MkdirAll(fs, output.FirstbootConfigDir(), vfs.DirPerm)).To(Succeed())
+
+		var diskDeployment *deployment.Deployment
+		customizeRunner.DiskImage = &diskImageMock{
+			buildFunc: func(d *deployment.Deployment, output string) error {
+				Expect(output).To(Equal("customized.qcow2"))
+				diskDeployment = d
+				return nil
+			},
+		}
+
+		def := &image.Definition{
+			Image: image.Image{
+				ImageType:       "disk",
+				DiskFormat:      "qcow2",
+				OutputImageName: "customized.qcow2",
+			},
+			Configuration: &image.Configuration{
+				Installation: install.Installation{
+					Bootloader:    "grub",
+					KernelCmdLine: "console=ttyS0",
+					CryptoPolicy:  crypto.FIPSPolicy,
+				},
+			},
+		}
+
+		Expect(customizeRunner.Run(context.Background(), def, output)).To(Succeed())
+		Expect(diskDeployment.SourceOS.String()).To(Equal("oci://registry.foo.bar/base-os:0.0.1"))
+		Expect(diskDeployment.OverlayTree).To(Equal(deployment.NewDirSrc("/_out/overlays")))
+		Expect(diskDeployment.GetConfigPartition()).NotTo(BeNil())
+		Expect(diskDeployment.Disks[0].Device).To(BeEmpty())
+		Expect(diskDeployment.BootConfig.Bootloader).To(Equal("grub"))
+		Expect(diskDeployment.BootConfig.KernelCmdline).To(ContainSubstring("fips=1"))
+		Expect(diskDeployment.Release).To(Equal(&deployment.Release{Name: "suse-core", Version: "0.0.1"}))
+	})
+
+	It("pushes the preinstalled disk image as an OCI artifact", func() {
+		customizeRunner.ConfigManager = &configManagerMock{
+			configFunc: func(ctx context.Context, conf *image.Configuration, output config.Output) (*resolver.ResolvedManifest, error) {
+				return &resolver.ResolvedManifest{
+					CorePlatform: &core.ReleaseManifest{
+						Components: core.Components{
+							OperatingSystem: &core.OperatingSystem{
+								Image: core.Image{
+									Base: "registry.foo.bar/base-os:0.0.1",
+									ISO:  expectedISO,
+								},
+							},
+						},
+					},
+				}, nil
+			},
+		}
+		customizeRunner.DiskImage = &diskImageMock{
+			buildFunc: func(d *deployment.Deployment, output string) error {
+				return nil
+			},
+		}
+
+		var pushed *artifact.Artifact
+		customizeRunner.Publisher = &publisherMock{
+			pushFunc: func(uri string, a *artifact.Artifact) (string, error) {
+				Expect(uri).To(Equal("oci://registry.foo.bar/images/disk:1.0"))
+				pushed = a
+				return "registry.foo.bar/images/disk@sha256:1234", nil
+			},
+		}
+
+		def := &image.Definition{
+			Image: image.Image{
+				ImageType:       "disk",
+				OutputImageName: "customized.raw",
+				PushReference:   "oci://registry.foo.bar/images/disk:1.0",
+			},
+			Configuration: &image.Configuration{
+				Installation: install.Installation{
+					Bootloader:   "grub",
+					CryptoPolicy: crypto.DefaultPolicy,
+				},
+			},
+		}
+
+		Expect(customizeRunner.Run(context.Background(), def, output)).To(Succeed())
+		Expect(pushed.Image).To(Equal("customized.raw"))
+		Expect(pushed.Annotations).To(HaveKeyWithValue(artifact.MediaTypeAnnotation, "disk"))
+		Expect(string(pushed.ReleaseManifest)).To(ContainSubstring("base: registry.foo.bar/base-os:0.0.1"))
+		Expect(string(pushed.Deployment)).To(ContainSubstring("uri: oci://registry.foo.bar/base-os:0.0.1"))
+	})
+
+	It("fails to push the image", func() {
+		customizeRunner.ConfigManager = &configManagerMock{
+			configFunc: func(ctx context.Context, conf *image.Configuration, output config.Output) (*resolver.ResolvedManifest, error) {
+				return &resolver.ResolvedManifest{
+					CorePlatform: &core.ReleaseManifest{
+						Components: core.Components{
+							OperatingSystem: &core.OperatingSystem{
+								Image: core.Image{Base: "registry.foo.bar/base-os:0.0.1"},
+							},
+						},
+					},
+				}, nil
+			},
+		}
+		customizeRunner.DiskImage = &diskImageMock{
+			buildFunc: func(d *deployment.Deployment, output string) error {
+				return nil
+			},
+		}
+		customizeRunner.Publisher = &publisherMock{
+			pushFunc: func(uri string, a *artifact.Artifact) (string, error) {
+				return "", fmt.Errorf("unauthorized")
+			},
+		}
+
+		def := &image.Definition{
+			Image: image.Image{
+				ImageType:       "disk",
+				OutputImageName: "customized.raw",
+				PushReference:   "oci://registry.foo.bar/images/disk:1.0",
+			},
+			Configuration: &image.Configuration{
+				Installation: install.Installation{
+					Bootloader:   "grub",
+					CryptoPolicy: crypto.DefaultPolicy,
+				},
+			},
+		}
+
+		Expect(customizeRunner.Run(context.Background(), def, output)).To(MatchError("unauthorized"))
+	})
+
+	It("fails to parse the disk image format", func() {
+		def := &image.Definition{
+			Image: image.Image{
+				ImageType:  "disk",
+				DiskFormat: "vdi",
+			},
+			Configuration: &image.Configuration{},
+		}
+
+		err := customizeRunner.Run(context.Background(), def, output)
+		Expect(err).To(MatchError(ContainSubstring("unsupported disk image format vdi")))
+	})
+
 	It("fails to configure components", func() {
 		customizeRunner.ConfigManager = &configManagerMock{
 			configFunc: func(ctx context.Context, conf *image.Configuration, output config.Output) (*resolver.ResolvedManifest, error) {
@@ -421,6 +621,30 @@ func (m *mediaMock) Customize(d *deployment.Deployment) error {
 	panic("not implemented")
 }
 
+type diskImageMock struct {
+	buildFunc func(d *deployment.Deployment, output string) error
+}
+
+func (m *diskImageMock) Build(d *deployment.Deployment, output string) error {
+	if m.buildFunc != nil {
+		return m.buildFunc(d, output)
+	}
+
+	panic("not implemented")
+}
+
+type publisherMock struct {
+	pushFunc func(uri string, a *artifact.Artifact) (string, error)
+}
+
+func (m *publisherMock) Push(uri string, a *artifact.Artifact) (string, error) {
+	if m.pushFunc != nil {
+		return m.pushFunc(uri, a)
+	}
+
+	panic("not implemented")
+}
+
 func defaultCustomizeDeploymentValidation(dep *deployment.Deployment, def *image.Definition) {
 	Expect(dep.BootConfig.Bootloader).To(Equal("grub"))
 
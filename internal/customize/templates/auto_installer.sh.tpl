@@ -37,10 +37,10 @@ StartLimitBurst=3
 
 [Service]
 Type=oneshot
-{{- if eq .MediaType "iso" }}
-ExecStart=/usr/bin/elemental3ctl --debug install
-{{- else }}
+{{- if eq .MediaType "raw" }}
 ExecStart=/usr/bin/elemental3ctl --debug reset
+{{- else }}
+ExecStart=/usr/bin/elemental3ctl --debug install
 {{- end }}
 Restart=on-failure
 RestartSec=5
@@ -46,8 +46,11 @@ type Configuration struct {
 
 type Image struct {
 	ImageType       string
+	DiskFormat      string
+	NetbootURL      string
 	Platform        *platform.Platform
 	OutputImageName string
+	PushReference   string
 }
 
 type Network struct {
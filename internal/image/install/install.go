@@ -60,7 +60,8 @@ func (d DiskSize) ToMiB() (uint64, error) {
 
 type Installation struct {
 	SchemaVersion string        `yaml:"schema"`
-	Bootloader    string        `yaml:"bootloader" validate:"omitempty,oneof=grub none"`
+	Bootloader    string        `yaml:"bootloader" validate:"omitempty,oneof=grub systemd-boot none"`
+	BIOS          bool          `yaml:"bios,omitempty"`
 	KernelCmdLine string        `yaml:"kernelCmdLine"`
 	RAW           RAW           `yaml:"raw"`
 	ISO           ISO           `yaml:"iso"`
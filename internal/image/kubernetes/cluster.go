@@ -55,7 +55,7 @@ type Cluster struct {
 	RegistriesConfig ConfigMap
 }
 
-func NewCluster(s *sys.System, kube *Kubernetes) (*Cluster, error) {
+func NewCluster(s *sys.System, kube *Kubernetes, dist Distribution) (*Cluster, error) {
 	registriesConfig, err := ParseKubernetesConfig(s, kube.Config.RegistriesFilePath)
 	if err != nil {
 		return nil, fmt.Errorf("parsing registries config: %w", err)
@@ -91,7 +91,7 @@ func NewCluster(s *sys.System, kube *Kubernetes) (*Cluster, error) {
 	}
 
 	prioritizeIPv6 := IsIPv6Priority(serverConfig)
-	err = setMultiNodeConfigDefaults(s.Logger(), kube, serverConfig, ip4, ip6, prioritizeIPv6)
+	err = setMultiNodeConfigDefaults(s.Logger(), kube, serverConfig, ip4, ip6, dist.SupervisorPort(), prioritizeIPv6)
 	if err != nil {
 		return nil, fmt.Errorf("failed setting multi-node configuration: %w", err)
 	}
@@ -105,7 +105,10 @@ func NewCluster(s *sys.System, kube *Kubernetes) (*Cluster, error) {
 	agentConfig[tokenKey] = serverConfig[tokenKey]
 	agentConfig[serverKey] = serverConfig[serverKey]
 	agentConfig[selinuxKey] = serverConfig[selinuxKey]
-	agentConfig[cniKey] = serverConfig[cniKey]
+	if dist == RKE2 {
+		// K3s agents do not accept a CNI option, flannel is configured on servers only
+		agentConfig[cniKey] = serverConfig[cniKey]
+	}
 
 	initConfig := ConfigMap{}
 	maps.Copy(initConfig, serverConfig)
@@ -163,10 +166,10 @@ func setSingleNodeConfigDefaults(logger log.Logger, kube *Kubernetes, config Con
 	delete(config, serverKey)
 }
 
-func setMultiNodeConfigDefaults(logger log.Logger, kube *Kubernetes, config ConfigMap, ip4 netip.Addr, ip6 netip.Addr, prioritizeIPv6 bool) error {
-	const rke2ServerPort = 9345
-
-	err := setClusterAPIAddress(config, ip4, ip6, rke2ServerPort, prioritizeIPv6)
+func setMultiNodeConfigDefaults(
+	logger log.Logger, kube *Kubernetes, config ConfigMap, ip4 netip.Addr, ip6 netip.Addr, port uint16, prioritizeIPv6 bool,
+) error {
+	err := setClusterAPIAddress(config, ip4, ip6, port, prioritizeIPv6)
 	if err != nil {
 		return err
 	}
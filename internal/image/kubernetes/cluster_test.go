@@ -8,6 +8,7 @@ import (
 	. "github.com/onsi/gomega"
 
 	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/manifest/api/core"
 	"github.com/suse/elemental/v3/pkg/sys"
 	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
 	"github.com/suse/elemental/v3/pkg/sys/vfs"
@@ -82,7 +83,7 @@ var _ = Describe("Cluster", func() {
 			},
 		}
 
-		cluster, err := NewCluster(s, kubernetes)
+		cluster, err := NewCluster(s, kubernetes, RKE2)
 		Expect(err).ToNot(HaveOccurred())
 
 		Expect(cluster.ServerConfig).ToNot(BeEmpty())
@@ -107,7 +108,7 @@ var _ = Describe("Cluster", func() {
 			},
 		}
 
-		cluster, err := NewCluster(s, kubernetes)
+		cluster, err := NewCluster(s, kubernetes, RKE2)
 		Expect(err).ToNot(HaveOccurred())
 
 		Expect(cluster.ServerConfig).ToNot(BeEmpty())
@@ -136,7 +137,7 @@ var _ = Describe("Cluster", func() {
 			},
 		}
 
-		cluster, err := NewCluster(s, kubernetes)
+		cluster, err := NewCluster(s, kubernetes, RKE2)
 		Expect(err).ToNot(HaveOccurred())
 
 		Expect(cluster.ServerConfig).ToNot(BeEmpty())
@@ -173,7 +174,7 @@ var _ = Describe("Cluster", func() {
 			},
 		}
 
-		cluster, err := NewCluster(s, kubernetes)
+		cluster, err := NewCluster(s, kubernetes, RKE2)
 		Expect(err).ToNot(HaveOccurred())
 
 		Expect(cluster.RegistriesConfig).ToNot(BeEmpty())
@@ -209,6 +210,54 @@ var _ = Describe("Cluster", func() {
 		Expect(cluster.AgentConfig["selinux"]).To(BeTrue())
 		Expect(cluster.AgentConfig["debug"]).To(BeTrue())
 	})
+	It("Sets k3s multi-node config defaults", func() {
+		kubernetes := &Kubernetes{
+			Network: Network{
+				APIVIP4: "192.168.122.50",
+			},
+			Nodes: Nodes{
+				{Hostname: "host1.suse.com", Type: NodeTypeServer, Init: true},
+				{Hostname: "host2.suse.com", Type: NodeTypeAgent},
+			},
+			Config: Config{
+				ServerFilePath: "/etc/kubernetes/single-node/server.yaml",
+			},
+		}
+
+		cluster, err := NewCluster(s, kubernetes, K3s)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(cluster.ServerConfig["server"]).To(Equal("https://192.168.122.50:6443"))
+		Expect(cluster.InitServerConfig["server"]).To(BeNil())
+		Expect(cluster.AgentConfig["server"]).To(Equal("https://192.168.122.50:6443"))
+		Expect(cluster.AgentConfig["token"]).To(Equal("token123"))
+		Expect(cluster.AgentConfig).ToNot(HaveKey("cni"))
+	})
+})
+
+var _ = Describe("Distribution", func() {
+	It("defaults to rke2", func() {
+		Expect(NewDistribution(nil)).To(Equal(RKE2))
+		Expect(NewDistribution(&core.Kubernetes{})).To(Equal(RKE2))
+	})
+	It("fails on unknown distributions", func() {
+		_, err := NewDistribution(&core.Kubernetes{Distribution: "k0s"})
+		Expect(err).To(MatchError("unsupported kubernetes distribution 'k0s'"))
+	})
+	It("provides the paths of the distribution", func() {
+		d, err := NewDistribution(&core.Kubernetes{Distribution: "k3s"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(d.ConfigDir()).To(Equal("/etc/rancher/k3s"))
+		Expect(d.Kubeconfig()).To(Equal("/etc/rancher/k3s/k3s.yaml"))
+		Expect(d.Kubectl()).To(Equal("/opt/k3s/bin/kubectl"))
+		Expect(d.ImagesPath()).To(Equal("var/lib/rancher/k3s/agent/images"))
+		Expect(d.Service(NodeTypeServer)).To(Equal("k3s.service"))
+		Expect(d.Service(NodeTypeAgent)).To(Equal("k3s-agent.service"))
+
+		Expect(RKE2.Kubectl()).To(Equal("/var/lib/rancher/rke2/bin/kubectl"))
+		Expect(RKE2.Service(NodeTypeServer)).To(Equal("rke2-server.service"))
+		Expect(RKE2.SupervisorPort()).To(Equal(uint16(9345)))
+	})
 })
 
 var _ = Describe("Cluster Helpers", func() {
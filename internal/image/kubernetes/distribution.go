@@ -0,0 +1,120 @@
+/*
+Copyright © 2022-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/suse/elemental/v3/pkg/manifest/api/core"
+)
+
+// Distribution is the Kubernetes distribution a cluster is deployed with
+type Distribution string
+
+const (
+	RKE2 Distribution = core.DistributionRKE2
+	K3s  Distribution = core.DistributionK3s
+)
+
+// NewDistribution returns the Kubernetes distribution of the given release component
+func NewDistribution(k *core.Kubernetes) (Distribution, error) {
+	if k == nil {
+		return RKE2, nil
+	}
+
+	switch d := Distribution(k.GetDistribution()); d {
+	case RKE2, K3s:
+		return d, nil
+	default:
+		return "", fmt.Errorf("unsupported kubernetes distribution '%s'", d)
+	}
+}
+
+// Name returns the display name of the distribution
+func (d Distribution) Name() string {
+	if d == K3s {
+		return "K3s"
+	}
+	return strings.ToUpper(string(d))
+}
+
+// ConfigDir returns the directory of the distribution configuration files
+func (d Distribution) ConfigDir() string {
+	return filepath.Join("/etc/rancher", string(d))
+}
+
+// DataDir returns the data directory of the distribution
+func (d Distribution) DataDir() string {
+	return filepath.Join("/var/lib/rancher", string(d))
+}
+
+// ImagesPath returns the path of the directory the agent imports image archives from at startup
+func (d Distribution) ImagesPath() string {
+	return filepath.Join("var", "lib", "rancher", string(d), "agent", "images")
+}
+
+// ManifestsDir returns the directory of the manifests deployed by the distribution
+func (d Distribution) ManifestsDir() string {
+	return filepath.Join(d.DataDir(), "server", "manifests")
+}
+
+// Kubeconfig returns the path of the admin kubeconfig written by server nodes
+func (d Distribution) Kubeconfig() string {
+	return filepath.Join(d.ConfigDir(), string(d)+".yaml")
+}
+
+// Kubectl returns the path of the kubectl binary shipped with the distribution
+func (d Distribution) Kubectl() string {
+	if d == K3s {
+		return filepath.Join(d.BinDir(), "kubectl")
+	}
+	return filepath.Join(d.DataDir(), "bin", "kubectl")
+}
+
+// InstallPrefix returns the directory the distribution is installed to
+func (d Distribution) InstallPrefix() string {
+	return filepath.Join("/opt", string(d))
+}
+
+// BinDir returns the directory the distribution binaries are installed to
+func (d Distribution) BinDir() string {
+	return filepath.Join(d.InstallPrefix(), "bin")
+}
+
+// SupervisorPort returns the port nodes register to the cluster with
+func (d Distribution) SupervisorPort() uint16 {
+	if d == K3s {
+		return 6443
+	}
+	return 9345
+}
+
+// Service returns the name of the systemd service running a node of the given type
+func (d Distribution) Service(nodeType string) string {
+	if d == K3s && nodeType == NodeTypeServer {
+		return "k3s.service"
+	}
+	return fmt.Sprintf("%s-%s.service", d, nodeType)
+}
+
+// SELinuxPolicy returns the path of the SELinux policy module of the distribution
+func (d Distribution) SELinuxPolicy() string {
+	return filepath.Join("/usr/share/selinux/packages", string(d)+".pp")
+}
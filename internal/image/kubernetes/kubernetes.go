@@ -34,6 +34,8 @@ type Kubernetes struct {
 	RemoteManifests []string `yaml:"manifests,omitempty" validate:"dive,required,url"`
 	// Helm - charts specified under config/kubernetes/cluster.yaml
 	Helm *Helm `yaml:"helm,omitempty" validate:"omitempty"`
+	// EmbedArtifacts - embeds the Helm chart archives and the images of the charts into the image
+	EmbedArtifacts bool `yaml:"embedArtifacts,omitempty"`
 	// LocalManifests - local manifest files specified under config/kubernetes/manifests
 	LocalManifests []string
 	Nodes          Nodes   `yaml:"nodes,omitempty" validate:"dive"`
@@ -42,11 +44,11 @@ type Kubernetes struct {
 }
 
 type Config struct {
-	// AgentFilePath path to agent.yaml rke2 configuration file
+	// AgentFilePath path to agent.yaml Kubernetes distribution configuration file
 	AgentFilePath string
-	// ServerFilePath path to server.yaml rke2 configuration file
+	// ServerFilePath path to server.yaml Kubernetes distribution configuration file
 	ServerFilePath string
-	// RegistriesFilePath path to the registries.yaml rke2 configuration file
+	// RegistriesFilePath path to the registries.yaml Kubernetes distribution configuration file
 	RegistriesFilePath string
 }
 
@@ -0,0 +1,316 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/suse/elemental/v3/pkg/manifest/api/core"
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+	"github.com/suse/elemental/v3/pkg/upgrade"
+)
+
+const (
+	installPath     = "PATH=/usr/sbin:/usr/bin:/sbin:/bin"
+	k3sImagesPrefix = "k3s-airgap-images-"
+	drainTimeout    = "10m"
+)
+
+// NodeUpgrader completes on the running node the Kubernetes upgrade staged by an OS upgrade
+type NodeUpgrader struct {
+	*sys.System
+
+	// Retries and Interval bound the attempts to reach the cluster API from the node
+	Retries  int
+	Interval time.Duration
+}
+
+// NewNodeUpgrader returns a NodeUpgrader retrying cluster API calls for up to five minutes
+func NewNodeUpgrader(s *sys.System) *NodeUpgrader {
+	return &NodeUpgrader{
+		System:   s,
+		Retries:  30,
+		Interval: 10 * time.Second,
+	}
+}
+
+// Upgrade installs the staged Kubernetes artifacts. On boot it runs before the Kubernetes service
+// starts, otherwise the running service is restarted and the node is uncordoned if it was drained.
+// The outcome is recorded in the Kubernetes upgrade record of the node.
+func (n *NodeUpgrader) Upgrade(ctx context.Context) (err error) {
+	record, err := upgrade.ParseKubernetesUpgrade(n.System, "/")
+	if err != nil {
+		return err
+	}
+	if record == nil || !record.Pending() {
+		n.Logger().Info("No Kubernetes upgrade pending")
+		return nil
+	}
+
+	defer func() {
+		err = n.writeRecord(record, err)
+	}()
+
+	dist, err := NewDistribution(&core.Kubernetes{Distribution: record.Distribution})
+	if err != nil {
+		return err
+	}
+
+	nodeType := n.nodeType(dist)
+	n.Logger().Info("Upgrading %s %s node from %s to %s", dist.Name(), nodeType, record.FromVersion, record.ToVersion)
+
+	if err = n.install(dist, nodeType); err != nil {
+		return err
+	}
+
+	service := dist.Service(nodeType)
+	if !n.active(service) {
+		n.Logger().Info("Kubernetes %s installed, %s starts with the new version", record.ToVersion, service)
+		record.State = upgrade.KubernetesUpgradeCompleted
+		return nil
+	}
+
+	n.Logger().Info("Restarting %s", service)
+	if _, err = n.Runner().Run("systemctl", "restart", service); err != nil {
+		return fmt.Errorf("restarting '%s': %w", service, err)
+	}
+
+	if err = n.UncordonDrained(ctx); err != nil {
+		return err
+	}
+
+	n.Logger().Info("Kubernetes upgraded to %s", record.ToVersion)
+	record.State = upgrade.KubernetesUpgradeCompleted
+	return nil
+}
+
+// UncordonDrained uncordons the node if it was drained by an OS upgrade and removes the drain record
+func (n *NodeUpgrader) UncordonDrained(ctx context.Context) error {
+	record, err := upgrade.ParseKubernetesDrain(n.System)
+	if err != nil {
+		return err
+	}
+	if record == nil {
+		n.Logger().Info("Node not drained by an upgrade, nothing to uncordon")
+		return nil
+	}
+
+	if err = n.Uncordon(ctx, record.Distribution); err != nil {
+		return err
+	}
+	return upgrade.RemoveKubernetesDrain(n.System)
+}
+
+// Drain drains the node before rebooting into a snapshot staging a Kubernetes upgrade. Agent nodes
+// are not drained as they have no access to the cluster admin credentials.
+func (n *NodeUpgrader) Drain(ctx context.Context, distribution string) (bool, error) {
+	dist, err := NewDistribution(&core.Kubernetes{Distribution: distribution})
+	if err != nil {
+		return false, err
+	}
+
+	if n.nodeType(dist) == NodeTypeAgent {
+		n.Logger().Warn("Skipping drain, agent nodes have no access to the cluster admin credentials")
+		return false, nil
+	}
+
+	node, err := n.hostname()
+	if err != nil {
+		return false, err
+	}
+
+	n.Logger().Info("Draining node '%s'", node)
+	err = n.retry(ctx, func() error {
+		return n.kubectl(dist, "drain", node, "--ignore-daemonsets", "--delete-emptydir-data", "--timeout="+drainTimeout)
+	})
+	if err != nil {
+		return false, fmt.Errorf("draining node '%s': %w", node, err)
+	}
+	return true, nil
+}
+
+// Uncordon uncordons the node, retrying until the cluster API is reachable. Agent nodes are skipped
+// as they are never drained.
+func (n *NodeUpgrader) Uncordon(ctx context.Context, distribution string) error {
+	dist, err := NewDistribution(&core.Kubernetes{Distribution: distribution})
+	if err != nil {
+		return err
+	}
+
+	if n.nodeType(dist) == NodeTypeAgent {
+		return nil
+	}
+
+	node, err := n.hostname()
+	if err != nil {
+		return err
+	}
+
+	n.Logger().Info("Uncordoning node '%s'", node)
+	err = n.retry(ctx, func() error {
+		return n.kubectl(dist, "uncordon", node)
+	})
+	if err != nil {
+		return fmt.Errorf("uncordoning node '%s': %w", node, err)
+	}
+	return nil
+}
+
+// writeRecord records the outcome of the upgrade, a failure is recorded with its message
+func (n *NodeUpgrader) writeRecord(record *upgrade.KubernetesUpgrade, err error) error {
+	record.Message = ""
+	if err != nil {
+		record.State = upgrade.KubernetesUpgradeFailed
+		record.Message = err.Error()
+	}
+	return errors.Join(err, upgrade.WriteKubernetesUpgrade(n.System, "/", record))
+}
+
+// nodeType returns the type of the node based on the enabled Kubernetes service
+func (n *NodeUpgrader) nodeType(dist Distribution) string {
+	if _, err := n.Runner().Run("systemctl", "is-enabled", "--quiet", dist.Service(NodeTypeAgent)); err == nil {
+		return NodeTypeAgent
+	}
+	return NodeTypeServer
+}
+
+// active returns true if the given service is running
+func (n *NodeUpgrader) active(service string) bool {
+	_, err := n.Runner().Run("systemctl", "is-active", "--quiet", service)
+	return err == nil
+}
+
+// install runs the install script of the staged artifacts the same way the first boot installer does
+func (n *NodeUpgrader) install(dist Distribution, nodeType string) error {
+	artifactsDir := upgrade.KubernetesArtifactsDir
+	installScript := filepath.Join(artifactsDir, "install.sh")
+
+	if ok, _ := vfs.Exists(n.FS(), installScript); !ok {
+		return fmt.Errorf("kubernetes install script %q not found", installScript)
+	}
+
+	env := []string{installPath}
+	switch dist {
+	case K3s:
+		if err := n.stageK3s(dist, artifactsDir); err != nil {
+			return err
+		}
+		env = append(env,
+			"INSTALL_K3S_SKIP_DOWNLOAD=true",
+			"INSTALL_K3S_SKIP_ENABLE=true",
+			"INSTALL_K3S_SKIP_START=true",
+			"INSTALL_K3S_SKIP_SELINUX_RPM=true",
+			"INSTALL_K3S_BIN_DIR="+dist.BinDir(),
+			"INSTALL_K3S_EXEC="+nodeType,
+		)
+	default:
+		env = append(env,
+			"INSTALL_RKE2_ARTIFACT_PATH="+artifactsDir,
+			"INSTALL_RKE2_TAR_PREFIX="+dist.InstallPrefix(),
+		)
+	}
+
+	n.Logger().Info("Installing %s from %s", dist.Name(), artifactsDir)
+	if _, err := n.Runner().RunEnv("sh", env, installScript); err != nil {
+		return fmt.Errorf("installing %s: %w", dist.Name(), err)
+	}
+	return nil
+}
+
+// stageK3s installs the K3s binary and air-gapped images archives, as the K3s install script
+// does not handle them
+func (n *NodeUpgrader) stageK3s(dist Distribution, artifactsDir string) error {
+	fs := n.FS()
+
+	if err := vfs.MkdirAll(fs, dist.BinDir(), vfs.DirPerm); err != nil {
+		return fmt.Errorf("creating K3s binaries directory: %w", err)
+	}
+	binary := filepath.Join(dist.BinDir(), "k3s")
+	if err := vfs.CopyFile(fs, filepath.Join(artifactsDir, "k3s"), binary); err != nil {
+		return fmt.Errorf("copying K3s binary: %w", err)
+	}
+	if err := fs.Chmod(binary, 0755); err != nil {
+		return fmt.Errorf("setting K3s binary permissions: %w", err)
+	}
+
+	entries, err := fs.ReadDir(artifactsDir)
+	if err != nil {
+		return fmt.Errorf("reading kubernetes artifacts directory: %w", err)
+	}
+
+	imagesDir := filepath.Join("/", dist.ImagesPath())
+	if err = vfs.MkdirAll(fs, imagesDir, vfs.DirPerm); err != nil {
+		return fmt.Errorf("creating K3s images directory: %w", err)
+	}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasPrefix(e.Name(), k3sImagesPrefix) {
+			continue
+		}
+		if err = vfs.CopyFile(fs, filepath.Join(artifactsDir, e.Name()), imagesDir); err != nil {
+			return fmt.Errorf("copying K3s images archive '%s': %w", e.Name(), err)
+		}
+	}
+	return nil
+}
+
+// kubectl runs kubectl with the admin credentials of the node
+func (n *NodeUpgrader) kubectl(dist Distribution, args ...string) error {
+	args = append([]string{"--kubeconfig", dist.Kubeconfig()}, args...)
+	out, err := n.Runner().Run(dist.Kubectl(), args...)
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// retry calls the given function until it succeeds or the attempts are exhausted
+func (n *NodeUpgrader) retry(ctx context.Context, f func() error) (err error) {
+	attempts := max(n.Retries, 1)
+	for i := 1; ; i++ {
+		if err = f(); err == nil || i >= attempts {
+			return err
+		}
+		n.Logger().Debug("Attempt %d/%d failed: %v", i, attempts, err)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(n.Interval):
+		}
+	}
+}
+
+// hostname returns the hostname of the node, which is the name of the node in the cluster
+func (n *NodeUpgrader) hostname() (string, error) {
+	for _, file := range []string{"/etc/hostname", "/proc/sys/kernel/hostname"} {
+		data, err := n.FS().ReadFile(file)
+		if err != nil {
+			continue
+		}
+		if hostname := strings.TrimSpace(string(data)); hostname != "" {
+			return hostname, nil
+		}
+	}
+	return "", fmt.Errorf("determining the node hostname")
+}
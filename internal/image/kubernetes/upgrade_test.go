@@ -0,0 +1,218 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+	"github.com/suse/elemental/v3/pkg/upgrade"
+)
+
+var _ = Describe("NodeUpgrader", func() {
+	var (
+		s       *sys.System
+		fs      vfs.FS
+		runner  *sysmock.Runner
+		n       *NodeUpgrader
+		cleanup func()
+	)
+
+	record := func() *upgrade.KubernetesUpgrade {
+		k, err := upgrade.ParseKubernetesUpgrade(s, "/")
+		Expect(err).NotTo(HaveOccurred())
+		return k
+	}
+
+	BeforeEach(func() {
+		var err error
+
+		fs, cleanup, err = sysmock.TestFS(map[string]any{
+			"/etc/hostname": "node01\n",
+			"/usr/lib/elemental/kubernetes/install.sh":                      "",
+			"/usr/lib/elemental/kubernetes/k3s":                             "",
+			"/usr/lib/elemental/kubernetes/k3s-airgap-images-amd64.tar.zst": "",
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		runner = sysmock.NewRunner()
+		s, err = sys.NewSystem(
+			sys.WithLogger(log.New(log.WithDiscardAll())),
+			sys.WithFS(fs), sys.WithRunner(runner),
+		)
+		Expect(err).ToNot(HaveOccurred())
+
+		n = NewNodeUpgrader(s)
+		n.Interval = 0
+		n.Retries = 2
+
+		Expect(upgrade.WriteKubernetesUpgrade(s, "/", &upgrade.KubernetesUpgrade{
+			Distribution: "rke2", FromVersion: "v1.34.2+rke2r1", ToVersion: "v1.35.0+rke2r1",
+			State: upgrade.KubernetesUpgradeStaged,
+		})).To(Succeed())
+
+		// server nodes do not have the agent service enabled and the server is not running yet on boot
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			if cmd == "systemctl" && (args[0] == "is-enabled" || args[0] == "is-active") {
+				return nil, fmt.Errorf("disabled")
+			}
+			return []byte{}, nil
+		}
+	})
+
+	AfterEach(func() {
+		cleanup()
+	})
+
+	kubectl := []string{"/var/lib/rancher/rke2/bin/kubectl", "--kubeconfig", "/etc/rancher/rke2/rke2.yaml"}
+
+	It("Installs the staged version before the server starts", func() {
+		Expect(n.Upgrade(context.Background())).To(Succeed())
+
+		Expect(runner.CmdsMatch([][]string{
+			{"systemctl", "is-enabled", "--quiet", "rke2-agent.service"},
+			{"sh", "/usr/lib/elemental/kubernetes/install.sh"},
+			{"systemctl", "is-active", "--quiet", "rke2-server.service"},
+		})).To(Succeed())
+		Expect(runner.EnvsMatch([][]string{
+			{"systemctl"},
+			{"sh", installPath, "INSTALL_RKE2_ARTIFACT_PATH=/usr/lib/elemental/kubernetes", "INSTALL_RKE2_TAR_PREFIX=/opt/rke2"},
+			{"systemctl"},
+		})).To(Succeed())
+		Expect(record().State).To(Equal(upgrade.KubernetesUpgradeCompleted))
+	})
+
+	It("Uncordons the node drained by an upgrade", func() {
+		Expect(upgrade.WriteKubernetesDrain(s, &upgrade.KubernetesDrain{Distribution: "rke2"})).To(Succeed())
+
+		Expect(n.UncordonDrained(context.Background())).To(Succeed())
+		Expect(runner.CmdsMatch([][]string{
+			{"systemctl", "is-enabled"},
+			append(kubectl, "uncordon", "node01"),
+		})).To(Succeed())
+		Expect(upgrade.ParseKubernetesDrain(s)).To(BeNil())
+	})
+
+	It("Keeps the drain record if the node can't be uncordoned", func() {
+		Expect(upgrade.WriteKubernetesDrain(s, &upgrade.KubernetesDrain{Distribution: "rke2"})).To(Succeed())
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			if cmd == "systemctl" {
+				return nil, fmt.Errorf("disabled")
+			}
+			return []byte("connection refused"), fmt.Errorf("exit status 1")
+		}
+
+		Expect(n.UncordonDrained(context.Background())).To(MatchError(
+			"uncordoning node 'node01': exit status 1: connection refused",
+		))
+		Expect(vfs.Exists(fs, upgrade.KubernetesDrainFile)).To(BeTrue())
+	})
+
+	It("Does nothing if the node was not drained by an upgrade", func() {
+		Expect(n.UncordonDrained(context.Background())).To(Succeed())
+		Expect(runner.GetCmds()).To(BeEmpty())
+	})
+
+	It("Restarts the running server and uncordons it", func() {
+		Expect(upgrade.WriteKubernetesDrain(s, &upgrade.KubernetesDrain{Distribution: "rke2"})).To(Succeed())
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			if cmd == "systemctl" && args[0] == "is-enabled" {
+				return nil, fmt.Errorf("disabled")
+			}
+			return []byte{}, nil
+		}
+
+		Expect(n.Upgrade(context.Background())).To(Succeed())
+
+		Expect(runner.CmdsMatch([][]string{
+			{"systemctl", "is-enabled"},
+			{"sh", "/usr/lib/elemental/kubernetes/install.sh"},
+			{"systemctl", "is-active", "--quiet", "rke2-server.service"},
+			{"systemctl", "restart", "rke2-server.service"},
+			{"systemctl", "is-enabled"},
+			append(kubectl, "uncordon", "node01"),
+		})).To(Succeed())
+		Expect(record().State).To(Equal(upgrade.KubernetesUpgradeCompleted))
+		Expect(upgrade.ParseKubernetesDrain(s)).To(BeNil())
+	})
+
+	It("Drains server nodes", func() {
+		drained, err := n.Drain(context.Background(), "rke2")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(drained).To(BeTrue())
+		Expect(runner.CmdsMatch([][]string{
+			{"systemctl", "is-enabled"},
+			append(kubectl, "drain", "node01", "--ignore-daemonsets"),
+		})).To(Succeed())
+	})
+
+	It("Skips the drain and uncordon of agent nodes", func() {
+		runner.SideEffect = nil
+
+		drained, err := n.Drain(context.Background(), "rke2")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(drained).To(BeFalse())
+		Expect(runner.IncludesCmds([][]string{{kubectl[0]}})).NotTo(Succeed())
+		Expect(n.Uncordon(context.Background(), "rke2")).To(Succeed())
+		Expect(runner.IncludesCmds([][]string{{kubectl[0]}})).NotTo(Succeed())
+	})
+
+	It("Installs the K3s binary and images before running the install script", func() {
+		Expect(upgrade.WriteKubernetesUpgrade(s, "/", &upgrade.KubernetesUpgrade{
+			Distribution: "k3s", FromVersion: "v1.34.2+k3s1", ToVersion: "v1.35.0+k3s1",
+			State: upgrade.KubernetesUpgradeStaged,
+		})).To(Succeed())
+
+		Expect(n.Upgrade(context.Background())).To(Succeed())
+
+		Expect(vfs.Exists(fs, "/opt/k3s/bin/k3s")).To(BeTrue())
+		Expect(vfs.Exists(fs, "/var/lib/rancher/k3s/agent/images/k3s-airgap-images-amd64.tar.zst")).To(BeTrue())
+		Expect(runner.IncludesCmds([][]string{{"systemctl", "is-active", "--quiet", "k3s.service"}})).To(Succeed())
+		Expect(runner.EnvsMatch([][]string{
+			{"systemctl"},
+			{"sh", installPath, "INSTALL_K3S_SKIP_DOWNLOAD=true"},
+			{"systemctl"},
+		})).To(Succeed())
+	})
+
+	It("Records the failure and retries on the next run", func() {
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			switch cmd {
+			case "systemctl":
+				if args[0] == "is-enabled" {
+					return nil, fmt.Errorf("disabled")
+				}
+			case "sh":
+				return nil, fmt.Errorf("script failed")
+			}
+			return []byte{}, nil
+		}
+
+		Expect(n.Upgrade(context.Background())).To(MatchError("installing RKE2: script failed"))
+		k := record()
+		Expect(k.State).To(Equal(upgrade.KubernetesUpgradeFailed))
+		Expect(k.Message).To(Equal("installing RKE2: script failed"))
+		Expect(k.Pending()).To(BeTrue())
+	})
+
+	It("Does nothing if the upgrade is already completed", func() {
+		k := record()
+		k.State = upgrade.KubernetesUpgradeCompleted
+		Expect(upgrade.WriteKubernetesUpgrade(s, "/", k)).To(Succeed())
+
+		Expect(n.Upgrade(context.Background())).To(Succeed())
+		Expect(runner.GetCmds()).To(BeEmpty())
+	})
+
+	It("Does nothing if there is no upgrade record", func() {
+		Expect(fs.Remove(upgrade.KubernetesUpgradeFile)).To(Succeed())
+
+		Expect(n.Upgrade(context.Background())).To(Succeed())
+		Expect(runner.GetCmds()).To(BeEmpty())
+	})
+})
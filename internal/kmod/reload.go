@@ -21,9 +21,11 @@ import (
 	"context"
 	"fmt"
 	"path/filepath"
+	"slices"
 
 	"github.com/suse/elemental/v3/internal/overlay"
 	"github.com/suse/elemental/v3/pkg/cleanstack"
+	"github.com/suse/elemental/v3/pkg/extensions"
 	"github.com/suse/elemental/v3/pkg/sys"
 	"github.com/suse/elemental/v3/pkg/sys/vfs"
 )
@@ -53,6 +55,8 @@ func (r *Reloader) Reload(ctx context.Context, kernelModules []string) (err erro
 		return err
 	}
 
+	r.reportMissingModules(kernelModules)
+
 	logger.Info("Activating kernel modules")
 	if err = manageModules(ctx, r.System, modulesDir, kernelModules, false); err != nil {
 		logger.Error("Activating one or more kernel modules failed")
@@ -64,6 +68,28 @@ func (r *Reloader) Reload(ctx context.Context, kernelModules []string) (err erro
 	return nil
 }
 
+// reportMissingModules logs the given modules which were not found for the running kernel when
+// validating the extensions on upgrade
+func (r *Reloader) reportMissingModules(kernelModules []string) {
+	report, err := extensions.ParseModulesReport(r.System, "/")
+	if err != nil {
+		r.Logger().Debug("Kernel modules report not available: %v", err)
+		return
+	}
+
+	kernelVersion := filepath.Base(r.KernelDir)
+	if report.KernelVersion != kernelVersion {
+		r.Logger().Debug("Kernel modules report is for kernel %s, running %s", report.KernelVersion, kernelVersion)
+		return
+	}
+
+	for _, m := range report.Missing() {
+		if slices.Contains(kernelModules, m.Module) {
+			r.Logger().Warn("Kernel module %s of extension %s was not found for kernel %s", m.Module, m.Extension, kernelVersion)
+		}
+	}
+}
+
 func (r *Reloader) generateModules(ctx context.Context, cleanup *cleanstack.CleanStack) (string, error) {
 	if err := prepareEnvironment(r.System, r.Config, cleanup); err != nil {
 		return "", fmt.Errorf("preparing environment: %w", err)
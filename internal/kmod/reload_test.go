@@ -18,6 +18,7 @@ limitations under the License.
 package kmod
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"slices"
@@ -25,6 +26,7 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	"github.com/suse/elemental/v3/pkg/extensions"
 	"github.com/suse/elemental/v3/pkg/log"
 	"github.com/suse/elemental/v3/pkg/sys"
 	"github.com/suse/elemental/v3/pkg/sys/mock"
@@ -171,4 +173,33 @@ var _ = Describe("Reloading tests", func() {
 
 		Expect(r.Reload(context.Background(), []string{"nvidia"})).To(Succeed())
 	})
+	It("Reports modules not found for the running kernel on upgrade", func() {
+		buffer := &bytes.Buffer{}
+		s, err := sys.NewSystem(sys.WithFS(tfs),
+			sys.WithRunner(runner),
+			sys.WithMounter(mounter),
+			sys.WithLogger(log.New(log.WithBuffer(buffer))))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(extensions.WriteModulesReport(s, "/", &extensions.ModulesReport{
+			KernelVersion: "6.12.0-160000.15-default",
+			Modules: []extensions.ModuleStatus{
+				{Extension: "nvidia-driver", Module: "nvidia"},
+				{Extension: "nvidia-driver", Module: "nvidia_uvm", Path: "/usr/lib/modules/6.12.0-160000.15-default/nvidia_uvm.ko"},
+			},
+		})).To(Succeed())
+
+		r := &Reloader{
+			System:      s,
+			Config:      conf,
+			ModuleCache: &moduleCacheMock{},
+			KernelDir:   "/usr/lib/modules/6.12.0-160000.15-default",
+		}
+
+		Expect(r.Reload(context.Background(), []string{"nvidia", "nvidia_uvm"})).To(Succeed())
+		Expect(buffer.String()).To(ContainSubstring(
+			"Kernel module nvidia of extension nvidia-driver was not found for kernel 6.12.0-160000.15-default",
+		))
+		Expect(buffer.String()).NotTo(ContainSubstring("Kernel module nvidia_uvm"))
+	})
 })
@@ -0,0 +1,332 @@
+/*
+Copyright © 2022-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sysext
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/suse/elemental/v3/pkg/cleanstack"
+	"github.com/suse/elemental/v3/pkg/extensions"
+	"github.com/suse/elemental/v3/pkg/http"
+	"github.com/suse/elemental/v3/pkg/manifest/api"
+	"github.com/suse/elemental/v3/pkg/snapper"
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+	"github.com/suse/elemental/v3/pkg/unpack"
+)
+
+type kernelModulesReloader interface {
+	Reload(ctx context.Context, kernelModules []string) error
+}
+
+type snapshotter interface {
+	CreateSnapshot(root string, config string, base int, rw bool, description string, metadata snapper.Metadata) (int, error)
+}
+
+type downloadFunc func(ctx context.Context, fs vfs.FS, url, path string) error
+
+type Option func(*Manager)
+
+// Manager adds, updates and removes the systemd extensions of a running system. Each change
+// is applied as a whole: on failure the previous extensions and extensions file are restored.
+type Manager struct {
+	ctx          context.Context
+	s            *sys.System
+	unpackOpts   []unpack.OCIOpt
+	reloader     kernelModulesReloader
+	snapshotter  snapshotter
+	snapConfig   string
+	downloadFile downloadFunc
+}
+
+func WithUnpackOpts(opts ...unpack.OCIOpt) Option {
+	return func(m *Manager) {
+		m.unpackOpts = opts
+	}
+}
+
+// WithKernelModulesReloader sets the reloader of the kernel modules declared by the
+// extensions, modules are reloaded after each change.
+func WithKernelModulesReloader(r kernelModulesReloader) Option {
+	return func(m *Manager) {
+		m.reloader = r
+	}
+}
+
+// WithSnapshotter sets the snapshotter and the snapshotter configuration of the volume holding
+// the extensions file, a snapshot is created prior to each change so the extensions file can be
+// rolled back.
+func WithSnapshotter(sn snapshotter, config string) Option {
+	return func(m *Manager) {
+		m.snapshotter = sn
+		m.snapConfig = config
+	}
+}
+
+func WithDownloadFunc(d downloadFunc) Option {
+	return func(m *Manager) {
+		m.downloadFile = d
+	}
+}
+
+func New(ctx context.Context, s *sys.System, opts ...Option) *Manager {
+	m := &Manager{
+		ctx:          ctx,
+		s:            s,
+		downloadFile: http.DownloadFile,
+	}
+
+	for _, o := range opts {
+		o(m)
+	}
+
+	return m
+}
+
+// List returns the extensions enabled on the system
+func (m *Manager) List() ([]api.SystemdExtension, error) {
+	exts, err := extensions.Parse(m.s, "/")
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return exts, nil
+}
+
+// Add installs and enables the given extension, it fails if the extension is already enabled
+func (m *Manager) Add(ext api.SystemdExtension) error {
+	exts, err := m.List()
+	if err != nil {
+		return err
+	}
+
+	if slices.ContainsFunc(exts, byName(ext.Name)) {
+		return fmt.Errorf("extension '%s' is already enabled", ext.Name)
+	}
+
+	return m.apply(fmt.Sprintf("add extension %s", ext.Name), nil, &ext, append(exts, ext))
+}
+
+// Update replaces the installed image of the given extension, it fails if the extension is not enabled
+func (m *Manager) Update(ext api.SystemdExtension) error {
+	exts, err := m.List()
+	if err != nil {
+		return err
+	}
+
+	i := slices.IndexFunc(exts, byName(ext.Name))
+	if i < 0 {
+		return fmt.Errorf("extension '%s' is not enabled", ext.Name)
+	}
+	current := exts[i]
+	exts[i] = ext
+
+	return m.apply(fmt.Sprintf("update extension %s", ext.Name), &current, &ext, exts)
+}
+
+// Remove uninstalls the given extension, it fails if the extension is not enabled
+func (m *Manager) Remove(name string) error {
+	exts, err := m.List()
+	if err != nil {
+		return err
+	}
+
+	i := slices.IndexFunc(exts, byName(name))
+	if i < 0 {
+		return fmt.Errorf("extension '%s' is not enabled", name)
+	}
+	current := exts[i]
+
+	return m.apply(fmt.Sprintf("remove extension %s", name), &current, nil, slices.Delete(exts, i, i+1))
+}
+
+// apply replaces the artifacts of the current extension, if any, with the ones of the new extension,
+// if any, and writes the resulting extensions list. Extensions are then refreshed and kernel modules
+// reloaded. Any failure restores the previous state.
+func (m *Manager) apply(description string, current, ext *api.SystemdExtension, exts []api.SystemdExtension) (err error) {
+	fs := m.s.FS()
+	logger := m.s.Logger()
+
+	cleanup := cleanstack.NewCleanStack()
+	defer func() { err = cleanup.Cleanup(err) }()
+
+	if m.snapshotter != nil {
+		id, err := m.snapshotter.CreateSnapshot("/", m.snapConfig, 0, false, "elemental3ctl: "+description, nil)
+		if err != nil {
+			return fmt.Errorf("creating snapshot of the extensions file volume: %w", err)
+		}
+		logger.Info("Created snapshot %d of the extensions file volume before the change", id)
+	}
+
+	if err = vfs.MkdirAll(fs, extensions.Dir, vfs.DirPerm); err != nil {
+		return fmt.Errorf("creating extensions directory: %w", err)
+	}
+
+	var staged []string
+	if ext != nil {
+		stagingDir, err := vfs.TempDir(fs, filepath.Dir(extensions.Dir), ".sysext-")
+		if err != nil {
+			return fmt.Errorf("creating staging directory: %w", err)
+		}
+		cleanup.Push(func() error { return fs.RemoveAll(stagingDir) })
+
+		logger.Info("Pulling extension %s from %s", ext.Name, ext.Image)
+		if staged, err = m.fetch(*ext, stagingDir); err != nil {
+			return fmt.Errorf("fetching extension '%s': %w", ext.Name, err)
+		}
+
+		if err = m.check(staged); err != nil {
+			return fmt.Errorf("validating extension '%s': %w", ext.Name, err)
+		}
+	}
+
+	// Refresh extensions once the previous state is restored
+	cleanup.PushErrorOnly(m.refresh)
+
+	if current != nil {
+		backupDir, err := vfs.TempDir(fs, filepath.Dir(extensions.Dir), ".sysext-backup-")
+		if err != nil {
+			return fmt.Errorf("creating backup directory: %w", err)
+		}
+		cleanup.Push(func() error { return fs.RemoveAll(backupDir) })
+
+		installed, err := m.installed(*current)
+		if err != nil {
+			return err
+		}
+		for _, path := range installed {
+			backup := filepath.Join(backupDir, filepath.Base(path))
+			if err = fs.Rename(path, backup); err != nil {
+				return fmt.Errorf("moving aside '%s': %w", path, err)
+			}
+			cleanup.PushErrorOnly(func() error { return fs.Rename(backup, path) })
+		}
+	}
+
+	for _, path := range staged {
+		target := filepath.Join(extensions.Dir, filepath.Base(path))
+		if ok, _ := vfs.Exists(fs, target); ok {
+			return fmt.Errorf("extension artifact '%s' already exists", target)
+		}
+		if err = fs.Rename(path, target); err != nil {
+			return fmt.Errorf("installing '%s': %w", target, err)
+		}
+		cleanup.PushErrorOnly(func() error { return fs.RemoveAll(target) })
+	}
+
+	if err = m.writeExtensionsFile(exts, cleanup); err != nil {
+		return err
+	}
+
+	if err = m.refresh(); err != nil {
+		return err
+	}
+
+	return m.reloadKernelModules(exts)
+}
+
+// fetch places the artifacts of the given extension in the given directory and returns their paths
+func (m *Manager) fetch(ext api.SystemdExtension, dir string) ([]string, error) {
+	fs := m.s.FS()
+
+	if extensions.IsRemoteURL(ext.Image) {
+		path := filepath.Join(dir, filepath.Base(ext.Image))
+		if err := m.downloadFile(m.ctx, fs, ext.Image, path); err != nil {
+			return nil, err
+		}
+	} else if err := extensions.Unpack(m.ctx, m.s, ext, dir, m.unpackOpts...); err != nil {
+		return nil, err
+	}
+
+	entries, err := fs.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading extension artifacts: %w", err)
+	}
+
+	var paths []string
+	for _, e := range entries {
+		paths = append(paths, filepath.Join(dir, e.Name()))
+	}
+	return paths, nil
+}
+
+// check verifies the given extension artifacts are compatible with the running OS
+func (m *Manager) check(paths []string) error {
+	osRelease, err := extensions.ReadOSRelease(m.s, "/")
+	if err != nil {
+		return err
+	}
+
+	return extensions.Check(m.s, paths, osRelease, extensions.Architecture(m.s.Platform()))
+}
+
+// installed returns the paths of the artifacts of the given extension within the extensions directory
+func (m *Manager) installed(ext api.SystemdExtension) ([]string, error) {
+	return extensions.Paths(m.s.FS(), extensions.Dir, ext)
+}
+
+func (m *Manager) writeExtensionsFile(exts []api.SystemdExtension, cleanup *cleanstack.CleanStack) error {
+	fs := m.s.FS()
+
+	previous, err := fs.ReadFile(extensions.File)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("reading extensions file: %w", err)
+	}
+	cleanup.PushErrorOnly(func() error {
+		if previous == nil {
+			return fs.Remove(extensions.File)
+		}
+		return fs.WriteFile(extensions.File, previous, vfs.FilePerm)
+	})
+
+	return extensions.Write(m.s, "/", exts)
+}
+
+func (m *Manager) refresh() error {
+	m.s.Logger().Info("Refreshing systemd extensions")
+	if _, err := m.s.Runner().RunContext(m.ctx, "systemd-sysext", "refresh"); err != nil {
+		return fmt.Errorf("refreshing systemd extensions: %w", err)
+	}
+	return nil
+}
+
+func (m *Manager) reloadKernelModules(exts []api.SystemdExtension) error {
+	var modules []string
+	for _, e := range exts {
+		modules = append(modules, e.KernelModules...)
+	}
+
+	if m.reloader == nil || len(modules) == 0 {
+		return nil
+	}
+
+	if err := m.reloader.Reload(m.ctx, modules); err != nil {
+		return fmt.Errorf("reloading kernel modules: %w", err)
+	}
+	return nil
+}
+
+func byName(name string) func(api.SystemdExtension) bool {
+	return func(e api.SystemdExtension) bool {
+		return e.Name == name
+	}
+}
@@ -0,0 +1,247 @@
+/*
+Copyright © 2022-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sysext
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/extensions"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/manifest/api"
+	"github.com/suse/elemental/v3/pkg/snapper"
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+func TestSysextSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Systemd extensions test suite")
+}
+
+type reloaderMock struct {
+	modules []string
+	err     error
+}
+
+func (r *reloaderMock) Reload(_ context.Context, modules []string) error {
+	r.modules = modules
+	return r.err
+}
+
+type snapshotterMock struct {
+	descriptions []string
+}
+
+func (s *snapshotterMock) CreateSnapshot(_ string, config string, _ int, _ bool, description string, _ snapper.Metadata) (int, error) {
+	s.descriptions = append(s.descriptions, config+": "+description)
+	return len(s.descriptions), nil
+}
+
+const extensionsFile = `# self-generated content, do not edit
+
+- name: foo
+  image: https://example.com/foo.raw
+  kernelModules:
+    - foo_mod
+`
+
+var _ = Describe("Systemd extensions manager", func() {
+	var s *sys.System
+	var tfs vfs.FS
+	var cleanup func()
+	var runner *mock.Runner
+	var reloader *reloaderMock
+	var sn *snapshotterMock
+	var m *Manager
+
+	var release string
+
+	download := func(_ context.Context, fs vfs.FS, url, path string) error {
+		return fs.WriteFile(path, []byte("content of "+url), vfs.FilePerm)
+	}
+
+	// dissect mocks 'systemd-dissect --copy-from <image> <dir> <target>' calls
+	dissect := func(cmd string, args ...string) ([]byte, error) {
+		if cmd == "systemd-dissect" {
+			target := filepath.Join(args[3], "extension-release."+strings.TrimSuffix(filepath.Base(args[1]), ".raw"))
+			Expect(vfs.MkdirAll(tfs, args[3], vfs.DirPerm)).To(Succeed())
+			return nil, tfs.WriteFile(target, []byte(release), vfs.FilePerm)
+		}
+		return nil, nil
+	}
+
+	BeforeEach(func() {
+		var err error
+		tfs, cleanup, err = mock.TestFS(map[string]string{
+			extensions.File:               extensionsFile,
+			"/var/lib/extensions/foo.raw": "content of https://example.com/foo.raw",
+			extensions.OSReleaseFile:      "ID=sl-micro\nVERSION_ID=6.2\n",
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		release = "ID=sl-micro\nVERSION_ID=6.2\n"
+		runner = mock.NewRunner()
+		runner.SideEffect = dissect
+		s, err = sys.NewSystem(
+			sys.WithFS(tfs), sys.WithRunner(runner), sys.WithLogger(log.New(log.WithDiscardAll())),
+		)
+		Expect(err).NotTo(HaveOccurred())
+
+		reloader = &reloaderMock{}
+		sn = &snapshotterMock{}
+		m = New(
+			context.Background(), s, WithDownloadFunc(download),
+			WithKernelModulesReloader(reloader), WithSnapshotter(sn, "etc"),
+		)
+	})
+
+	AfterEach(func() {
+		cleanup()
+	})
+
+	It("lists the enabled extensions", func() {
+		exts, err := m.List()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(exts).To(Equal([]api.SystemdExtension{
+			{Name: "foo", Image: "https://example.com/foo.raw", KernelModules: []string{"foo_mod"}},
+		}))
+	})
+
+	It("lists no extensions if the extensions file is missing", func() {
+		Expect(tfs.Remove(extensions.File)).To(Succeed())
+		exts, err := m.List()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(exts).To(BeEmpty())
+	})
+
+	It("adds an extension", func() {
+		ext := api.SystemdExtension{Name: "bar", Image: "https://example.com/bar.raw", KernelModules: []string{"bar_mod"}}
+		Expect(m.Add(ext)).To(Succeed())
+
+		data, err := tfs.ReadFile("/var/lib/extensions/bar.raw")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal("content of https://example.com/bar.raw"))
+		Expect(vfs.Exists(tfs, "/var/lib/extensions/foo.raw")).To(BeTrue())
+
+		exts, err := m.List()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(exts).To(HaveLen(2))
+		Expect(exts[1]).To(Equal(ext))
+
+		Expect(runner.CmdsMatch([][]string{{"systemd-dissect"}, {"systemd-sysext", "refresh"}})).To(Succeed())
+		Expect(reloader.modules).To(Equal([]string{"foo_mod", "bar_mod"}))
+		Expect(sn.descriptions).To(Equal([]string{"etc: elemental3ctl: add extension bar"}))
+	})
+
+	It("fails to add an already enabled extension", func() {
+		err := m.Add(api.SystemdExtension{Name: "foo", Image: "https://example.com/foo.raw"})
+		Expect(err).To(MatchError("extension 'foo' is already enabled"))
+		Expect(runner.GetCmds()).To(BeEmpty())
+	})
+
+	It("updates an extension", func() {
+		ext := api.SystemdExtension{Name: "foo", Image: "https://example.com/v2/foo.raw"}
+		Expect(m.Update(ext)).To(Succeed())
+
+		data, err := tfs.ReadFile("/var/lib/extensions/foo.raw")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal("content of https://example.com/v2/foo.raw"))
+
+		exts, err := m.List()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(exts).To(Equal([]api.SystemdExtension{ext}))
+
+		Expect(runner.CmdsMatch([][]string{{"systemd-dissect"}, {"systemd-sysext", "refresh"}})).To(Succeed())
+		Expect(reloader.modules).To(BeNil())
+	})
+
+	It("restores the previous extension if the refresh fails", func() {
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			if len(runner.GetCmds()) == 2 {
+				return nil, fmt.Errorf("refresh failed")
+			}
+			return dissect(cmd, args...)
+		}
+
+		err := m.Update(api.SystemdExtension{Name: "foo", Image: "https://example.com/v2/foo.raw"})
+		Expect(err).To(MatchError("refreshing systemd extensions: refresh failed"))
+
+		data, err := tfs.ReadFile("/var/lib/extensions/foo.raw")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal("content of https://example.com/foo.raw"))
+
+		data, err = tfs.ReadFile(extensions.File)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal(extensionsFile))
+
+		Expect(runner.CmdsMatch([][]string{
+			{"systemd-dissect"}, {"systemd-sysext", "refresh"}, {"systemd-sysext", "refresh"},
+		})).To(Succeed())
+	})
+
+	It("fails to add an extension which is not compatible with the OS", func() {
+		release = "ID=sl-micro\nVERSION_ID=6.1\n"
+
+		err := m.Add(api.SystemdExtension{Name: "bar", Image: "https://example.com/bar.raw"})
+		Expect(err).To(MatchError(
+			"validating extension 'bar': 'bar.raw' is not compatible: VERSION_ID '6.1' does not match the OS VERSION_ID '6.2'",
+		))
+		Expect(vfs.Exists(tfs, "/var/lib/extensions/bar.raw")).To(BeFalse())
+		Expect(runner.CmdsMatch([][]string{{"systemd-dissect"}})).To(Succeed())
+
+		exts, err := m.List()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(exts).To(HaveLen(1))
+	})
+
+	It("removes the new extension if reloading kernel modules fails", func() {
+		reloader.err = fmt.Errorf("modprobe failed")
+
+		err := m.Add(api.SystemdExtension{Name: "bar", Image: "https://example.com/bar.raw"})
+		Expect(err).To(MatchError("reloading kernel modules: modprobe failed"))
+		Expect(vfs.Exists(tfs, "/var/lib/extensions/bar.raw")).To(BeFalse())
+
+		exts, err := m.List()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(exts).To(HaveLen(1))
+	})
+
+	It("removes an extension", func() {
+		Expect(m.Remove("foo")).To(Succeed())
+		Expect(vfs.Exists(tfs, "/var/lib/extensions/foo.raw")).To(BeFalse())
+
+		exts, err := m.List()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(exts).To(BeEmpty())
+
+		Expect(runner.CmdsMatch([][]string{{"systemd-sysext", "refresh"}})).To(Succeed())
+		Expect(sn.descriptions).To(Equal([]string{"etc: elemental3ctl: remove extension foo"}))
+	})
+
+	It("fails to remove an extension which is not enabled", func() {
+		Expect(m.Remove("bar")).To(MatchError("extension 'bar' is not enabled"))
+	})
+})
@@ -169,6 +169,81 @@ func ExtractTar(ctx context.Context, s *sys.System, body io.Reader, target strin
 	return nil
 }
 
+// CreateTar archives the contents of the source directory into a .tar tarball file. Only
+// directories, regular files and symlinks are included.
+func CreateTar(ctx context.Context, s *sys.System, source, tarball string) (err error) {
+	file, err := s.FS().OpenFile(tarball, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, vfs.FilePerm)
+	if err != nil {
+		return fmt.Errorf("creating tarball %s: %w", tarball, err)
+	}
+	defer func() {
+		e := file.Close()
+		if err == nil && e != nil {
+			err = e
+		}
+	}()
+
+	tw := tar.NewWriter(file)
+	err = vfs.WalkDirFs(s.FS(), source, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("stop writing tar, context cancelled")
+		default:
+		}
+
+		rel, err := filepath.Rel(source, path)
+		if err != nil || rel == "." {
+			return err
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		var target string
+		if info.Mode()&os.ModeSymlink != 0 {
+			if target, err = s.FS().Readlink(path); err != nil {
+				return err
+			}
+		} else if !info.IsDir() && !info.Mode().IsRegular() {
+			s.Logger().Warn("Ignoring unsupported file type '%s'", path)
+			return nil
+		}
+
+		header, err := tar.FileInfoHeader(info, target)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if err = tw.WriteHeader(header); err != nil {
+			return err
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		src, err := s.FS().Open(path)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = src.Close() }()
+
+		_, err = io.Copy(tw, newCancelableReader(ctx, src))
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("archiving %s: %w", source, err)
+	}
+
+	return tw.Close()
+}
+
 func copyFile(ctx context.Context, s *sys.System, path string, mode os.FileMode, src io.Reader) (err error) {
 	dir := filepath.Dir(path)
 	info, err := s.FS().Lstat(dir)
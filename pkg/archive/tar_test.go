@@ -120,4 +120,20 @@ var _ = Describe("Archive", Label("archive"), func() {
 		Expect(archive.ExtractTarball(context.Background(), s, "/data/test.tar", "/root")).NotTo(Succeed())
 		Expect(archive.ExtractTarball(context.Background(), s, "/data/test.tar.bz2", "/root")).NotTo(Succeed())
 	})
+
+	It("creates a tarball that extracts to the same content", func() {
+		Expect(archive.ExtractTarball(context.Background(), s, "/data/test.tar.gz", "/root")).To(Succeed())
+		Expect(archive.CreateTar(context.Background(), s, "/root", "/data/created.tar")).To(Succeed())
+		Expect(vfs.MkdirAll(tfs, "/target", vfs.DirPerm)).To(Succeed())
+		Expect(archive.ExtractTarball(context.Background(), s, "/data/created.tar", "/target")).To(Succeed())
+
+		data, err := tfs.ReadFile("/target/etc/os-release")
+		Expect(err).NotTo(HaveOccurred())
+		orig, err := tfs.ReadFile("/root/etc/os-release")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(data).To(Equal(orig))
+		info, err := tfs.Lstat("/target/etc/elemental/symlink")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Mode() & os.ModeSymlink).To(Equal(os.ModeSymlink))
+	})
 })
@@ -0,0 +1,328 @@
+/*
+Copyright © 2022-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package artifact
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	containerregistry "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+const (
+	// Scheme is the URI scheme required for push references, e.g. oci://registry/repo:tag
+	Scheme = "oci://"
+
+	ArtifactType             = "application/vnd.suse.elemental.media.v1"
+	ImageMediaType           = "application/vnd.suse.elemental.image.v1"
+	ChecksumMediaType        = "application/vnd.suse.elemental.image.checksum.v1+sha256"
+	ReleaseManifestMediaType = "application/vnd.suse.elemental.release-manifest.v1+yaml"
+	DeploymentMediaType      = "application/vnd.suse.elemental.deployment.v1+yaml"
+	MediaTypeAnnotation      = "com.suse.elemental.media.type"
+	releaseManifestTitle     = "release-manifest.yaml"
+	deploymentTitle          = "deployment.yaml"
+	checksumSuffix           = ".sha256"
+	emptyConfig              = "{}"
+)
+
+// Artifact defines the content of the OCI artifact pushed for a built image
+type Artifact struct {
+	// Image is the path of the image file or the path of a directory including several image
+	// files, as netboot media. Checksum files next to each image file are also included.
+	Image string
+	// ReleaseManifest is the resolved release manifest the image was built from, if any
+	ReleaseManifest []byte
+	// Deployment is the deployment description of the image, if any
+	Deployment []byte
+	// Annotations are additional annotations to set in the artifact manifest
+	Annotations map[string]string
+}
+
+type Publisher struct {
+	s      *sys.System
+	ctx    context.Context
+	verify bool
+}
+
+type Opt func(*Publisher)
+
+func WithContext(ctx context.Context) Opt {
+	return func(p *Publisher) {
+		p.ctx = ctx
+	}
+}
+
+func WithVerify(verify bool) Opt {
+	return func(p *Publisher) {
+		p.verify = verify
+	}
+}
+
+func NewPublisher(s *sys.System, opts ...Opt) *Publisher {
+	p := &Publisher{
+		s:      s,
+		ctx:    context.Background(),
+		verify: true,
+	}
+	for _, o := range opts {
+		o(p)
+	}
+	return p
+}
+
+// ParseReference parses the given push URI, it requires the 'oci://' scheme
+func ParseReference(uri string, verify bool) (name.Reference, error) {
+	ref, ok := strings.CutPrefix(uri, Scheme)
+	if !ok {
+		return nil, fmt.Errorf("invalid push reference '%s', expected '%sregistry/repository:tag'", uri, Scheme)
+	}
+
+	opts := []name.Option{name.StrictValidation}
+	if !verify {
+		opts = append(opts, name.Insecure)
+	}
+
+	r, err := name.ParseReference(ref, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("parsing push reference '%s': %w", uri, err)
+	}
+	return r, nil
+}
+
+// Push uploads the given artifact to the given 'oci://' reference and returns the
+// digest reference of the pushed manifest
+func (p Publisher) Push(uri string, a *Artifact) (string, error) {
+	ref, err := ParseReference(uri, p.verify)
+	if err != nil {
+		return "", err
+	}
+
+	layers, err := p.imageLayers(a.Image)
+	if err != nil {
+		return "", err
+	}
+	if len(a.ReleaseManifest) > 0 {
+		layers = append(layers, titledLayer{
+			Layer: static.NewLayer(a.ReleaseManifest, ReleaseManifestMediaType),
+			title: releaseManifestTitle,
+		})
+	}
+	if len(a.Deployment) > 0 {
+		layers = append(layers, titledLayer{
+			Layer: static.NewLayer(a.Deployment, DeploymentMediaType),
+			title: deploymentTitle,
+		})
+	}
+
+	opts := []remote.Option{
+		remote.WithTransport(http.DefaultTransport),
+		remote.WithAuthFromKeychain(authn.DefaultKeychain),
+		remote.WithContext(p.ctx),
+	}
+
+	config := static.NewLayer([]byte(emptyConfig), ocispec.MediaTypeEmptyJSON)
+	if err = remote.WriteLayer(ref.Context(), config, opts...); err != nil {
+		return "", fmt.Errorf("uploading artifact config: %w", err)
+	}
+	configDesc, err := descriptor(config, nil)
+	if err != nil {
+		return "", err
+	}
+
+	manifest := containerregistry.Manifest{
+		SchemaVersion: 2,
+		MediaType:     types.OCIManifestSchema1,
+		ArtifactType:  ArtifactType,
+		Config:        configDesc,
+		Annotations: map[string]string{
+			ocispec.AnnotationCreated: time.Now().UTC().Format(time.RFC3339),
+		},
+	}
+	for k, v := range a.Annotations {
+		manifest.Annotations[k] = v
+	}
+
+	for _, l := range layers {
+		p.s.Logger().Info("Uploading %s to %s", l.title, ref.Context())
+		if err = remote.WriteLayer(ref.Context(), l, opts...); err != nil {
+			return "", fmt.Errorf("uploading '%s': %w", l.title, err)
+		}
+		desc, err := descriptor(l, map[string]string{ocispec.AnnotationTitle: l.title})
+		if err != nil {
+			return "", err
+		}
+		manifest.Layers = append(manifest.Layers, desc)
+	}
+
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return "", fmt.Errorf("marshalling artifact manifest: %w", err)
+	}
+
+	if err = remote.Put(ref, rawManifest(data), opts...); err != nil {
+		return "", fmt.Errorf("pushing artifact manifest to '%s': %w", ref, err)
+	}
+
+	digest, _, err := containerregistry.SHA256(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("computing artifact manifest digest: %w", err)
+	}
+	return ref.Context().Digest(digest.String()).String(), nil
+}
+
+// imageLayers returns the layers of the image files and checksums found at the given path
+func (p Publisher) imageLayers(path string) ([]titledLayer, error) {
+	if path == "" {
+		return nil, fmt.Errorf("no image to push defined")
+	}
+
+	files := []string{path}
+	if ok, _ := vfs.IsDir(p.s.FS(), path); ok {
+		files = []string{}
+		entries, err := p.s.FS().ReadDir(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading image directory '%s': %w", path, err)
+		}
+		for _, entry := range entries {
+			if entry.Type().IsRegular() && !strings.HasSuffix(entry.Name(), checksumSuffix) {
+				files = append(files, filepath.Join(path, entry.Name()))
+			}
+		}
+	}
+
+	var layers []titledLayer
+	for _, file := range files {
+		l, err := newFileLayer(p.s.FS(), file, ImageMediaType)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, titledLayer{Layer: l, title: filepath.Base(file)})
+
+		checksum := file + checksumSuffix
+		if ok, _ := vfs.Exists(p.s.FS(), checksum); !ok {
+			continue
+		}
+		l, err = newFileLayer(p.s.FS(), checksum, ChecksumMediaType)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, titledLayer{Layer: l, title: filepath.Base(checksum)})
+	}
+	return layers, nil
+}
+
+func descriptor(l containerregistry.Layer, annotations map[string]string) (containerregistry.Descriptor, error) {
+	digest, err := l.Digest()
+	if err != nil {
+		return containerregistry.Descriptor{}, fmt.Errorf("computing blob digest: %w", err)
+	}
+	size, err := l.Size()
+	if err != nil {
+		return containerregistry.Descriptor{}, fmt.Errorf("computing blob size: %w", err)
+	}
+	mt, err := l.MediaType()
+	if err != nil {
+		return containerregistry.Descriptor{}, fmt.Errorf("getting blob media type: %w", err)
+	}
+	return containerregistry.Descriptor{
+		MediaType:   mt,
+		Size:        size,
+		Digest:      digest,
+		Annotations: annotations,
+	}, nil
+}
+
+type titledLayer struct {
+	containerregistry.Layer
+	title string
+}
+
+// rawManifest is an already marshalled OCI image manifest
+type rawManifest []byte
+
+func (m rawManifest) RawManifest() ([]byte, error) {
+	return m, nil
+}
+
+func (m rawManifest) MediaType() (types.MediaType, error) {
+	return types.OCIManifestSchema1, nil
+}
+
+// fileLayer is a layer streamed from a file, so big images are never loaded in memory
+type fileLayer struct {
+	fs        vfs.FS
+	path      string
+	mediaType types.MediaType
+	digest    containerregistry.Hash
+	size      int64
+}
+
+func newFileLayer(fs vfs.FS, path string, mediaType types.MediaType) (*fileLayer, error) {
+	f, err := fs.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening '%s': %w", path, err)
+	}
+	defer f.Close()
+
+	digest, size, err := containerregistry.SHA256(f)
+	if err != nil {
+		return nil, fmt.Errorf("computing digest of '%s': %w", path, err)
+	}
+
+	return &fileLayer{fs: fs, path: path, mediaType: mediaType, digest: digest, size: size}, nil
+}
+
+func (l *fileLayer) Digest() (containerregistry.Hash, error) {
+	return l.digest, nil
+}
+
+func (l *fileLayer) DiffID() (containerregistry.Hash, error) {
+	return l.digest, nil
+}
+
+func (l *fileLayer) Compressed() (io.ReadCloser, error) {
+	return l.fs.Open(l.path)
+}
+
+func (l *fileLayer) Uncompressed() (io.ReadCloser, error) {
+	return l.fs.Open(l.path)
+}
+
+func (l *fileLayer) Size() (int64, error) {
+	return l.size, nil
+}
+
+func (l *fileLayer) MediaType() (types.MediaType, error) {
+	return l.mediaType, nil
+}
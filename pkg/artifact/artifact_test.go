@@ -0,0 +1,144 @@
+/*
+Copyright © 2022-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package artifact_test
+
+import (
+	"io"
+	stdlog "log"
+	"net/http/httptest"
+	"net/url"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/suse/elemental/v3/pkg/artifact"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+var _ = Describe("Publisher", Label("artifact"), func() {
+	var tfs vfs.FS
+	var s *sys.System
+	var cleanup func()
+	var server *httptest.Server
+	var host string
+	var publisher *artifact.Publisher
+	BeforeEach(func() {
+		var err error
+		tfs, cleanup, err = sysmock.TestFS(map[string]any{
+			"/output/image.iso":        "iso data",
+			"/output/image.iso.sha256": "1234 image.iso\n",
+			"/output/netboot/kernel":   "kernel data",
+			"/output/netboot/initrd":   "initrd data",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		s, err = sys.NewSystem(sys.WithFS(tfs), sys.WithLogger(log.New(log.WithDiscardAll())))
+		Expect(err).NotTo(HaveOccurred())
+
+		server = httptest.NewServer(registry.New(registry.Logger(stdlog.New(io.Discard, "", 0))))
+		u, err := url.Parse(server.URL)
+		Expect(err).NotTo(HaveOccurred())
+		host = u.Host
+
+		publisher = artifact.NewPublisher(s)
+	})
+	AfterEach(func() {
+		server.Close()
+		cleanup()
+	})
+	It("pushes the image, checksum, release manifest and deployment as an OCI artifact", func() {
+		digest, err := publisher.Push("oci://"+host+"/elemental/media:1.0", &artifact.Artifact{
+			Image:           "/output/image.iso",
+			ReleaseManifest: []byte("metadata:\n  name: release\n"),
+			Deployment:      []byte("disks: []\n"),
+			Annotations:     map[string]string{artifact.MediaTypeAnnotation: "iso"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		ref, err := name.ParseReference(host + "/elemental/media:1.0")
+		Expect(err).NotTo(HaveOccurred())
+		img, err := remote.Image(ref)
+		Expect(err).NotTo(HaveOccurred())
+
+		imgDigest, err := img.Digest()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(digest).To(Equal(host + "/elemental/media@" + imgDigest.String()))
+
+		manifest, err := img.Manifest()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(manifest.ArtifactType).To(Equal(artifact.ArtifactType))
+		Expect(string(manifest.Config.MediaType)).To(Equal(ocispec.MediaTypeEmptyJSON))
+		Expect(manifest.Annotations).To(HaveKeyWithValue(artifact.MediaTypeAnnotation, "iso"))
+		Expect(manifest.Annotations).To(HaveKey(ocispec.AnnotationCreated))
+
+		Expect(manifest.Layers).To(HaveLen(4))
+		titles := map[string]string{}
+		for _, l := range manifest.Layers {
+			titles[l.Annotations[ocispec.AnnotationTitle]] = string(l.MediaType)
+		}
+		Expect(titles).To(Equal(map[string]string{
+			"image.iso":             artifact.ImageMediaType,
+			"image.iso.sha256":      artifact.ChecksumMediaType,
+			"release-manifest.yaml": artifact.ReleaseManifestMediaType,
+			"deployment.yaml":       artifact.DeploymentMediaType,
+		}))
+
+		layer, err := remote.Layer(ref.Context().Digest(manifest.Layers[0].Digest.String()))
+		Expect(err).NotTo(HaveOccurred())
+		rc, err := layer.Compressed()
+		Expect(err).NotTo(HaveOccurred())
+		data, err := io.ReadAll(rc)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rc.Close()).To(Succeed())
+		Expect(string(data)).To(Equal("iso data"))
+	})
+	It("pushes all files of a netboot directory", func() {
+		_, err := publisher.Push("oci://"+host+"/elemental/netboot:1.0", &artifact.Artifact{
+			Image: "/output/netboot",
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		ref, err := name.ParseReference(host + "/elemental/netboot:1.0")
+		Expect(err).NotTo(HaveOccurred())
+		img, err := remote.Image(ref)
+		Expect(err).NotTo(HaveOccurred())
+		manifest, err := img.Manifest()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(manifest.Layers).To(HaveLen(2))
+		for _, l := range manifest.Layers {
+			Expect(string(l.MediaType)).To(Equal(artifact.ImageMediaType))
+		}
+	})
+	It("fails on references without the oci scheme", func() {
+		_, err := publisher.Push(host+"/elemental/media:1.0", &artifact.Artifact{Image: "/output/image.iso"})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("invalid push reference"))
+	})
+	It("fails if the image does not exist", func() {
+		_, err := publisher.Push("oci://"+host+"/elemental/media:1.0", &artifact.Artifact{Image: "/output/missing.iso"})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("opening '/output/missing.iso'"))
+	})
+})
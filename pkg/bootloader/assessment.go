@@ -0,0 +1,64 @@
+/*
+Copyright © 2022-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bootloader
+
+import (
+	_ "embed"
+	"fmt"
+	"path/filepath"
+
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+const (
+	BootAssessmentUnitName = "elemental-boot-assessment.service"
+
+	systemdUnitsDir = "/etc/systemd/system"
+	bootTargetWants = "multi-user.target.wants"
+)
+
+//go:embed grubtemplates/elemental-boot-assessment.service
+var bootAssessmentUnit []byte
+
+// InstallBootAssessmentUnit installs and enables the systemd unit marking the boot as good once the
+// system reaches the multi-user target. Boot entries not marked as good are eventually replaced
+// by their fallback entry.
+func InstallBootAssessmentUnit(s *sys.System, rootPath string) error {
+	unitsDir := filepath.Join(rootPath, systemdUnitsDir)
+	err := vfs.MkdirAll(s.FS(), filepath.Join(unitsDir, bootTargetWants), vfs.DirPerm)
+	if err != nil {
+		return fmt.Errorf("creating systemd units dir: %w", err)
+	}
+
+	unitPath := filepath.Join(unitsDir, BootAssessmentUnitName)
+	err = s.FS().WriteFile(unitPath, bootAssessmentUnit, vfs.FilePerm)
+	if err != nil {
+		return fmt.Errorf("writing unit '%s': %w", unitPath, err)
+	}
+
+	link := filepath.Join(unitsDir, bootTargetWants, BootAssessmentUnitName)
+	if ok, _ := vfs.Exists(s.FS(), link); ok {
+		return nil
+	}
+	err = s.FS().Symlink(filepath.Join(systemdUnitsDir, BootAssessmentUnitName), link)
+	if err != nil {
+		return fmt.Errorf("enabling unit '%s': %w", BootAssessmentUnitName, err)
+	}
+	return nil
+}
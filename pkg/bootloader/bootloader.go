@@ -21,6 +21,7 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/suse/elemental/v3/pkg/deployment"
 	"github.com/suse/elemental/v3/pkg/sys"
 )
 
@@ -28,11 +29,24 @@ type Bootloader interface {
 	Install(rootPath, espDir, espLabel, entryID, kernelCmdline, recKernelCmdline string) error
 	InstallLive(rootPath, espDir, kernelCmdline string) error
 	Prune(rootPath, espDir string, keepEntryIDs []int) error
+	SetDefaultEntry(espDir, entryID string) error
+	DefaultEntry(espDir string) (string, error)
+	MarkGood(espDir string) (string, error)
+	// EFILoader returns the path within the ESP of the EFI application booting the bootloader,
+	// it is empty if there is no EFI application to boot.
+	EFILoader() string
+}
+
+// BIOSBootloader is implemented by bootloaders able to boot on legacy BIOS firmware
+type BIOSBootloader interface {
+	InstallBIOS(rootPath, espDir, espLabel, device string) error
+	InstallLiveBIOS(rootPath, target string) error
 }
 
 const (
-	BootNone = "none"
-	BootGrub = "grub"
+	BootNone        = "none"
+	BootGrub        = "grub"
+	BootSystemdBoot = "systemd-boot"
 )
 
 type None struct {
@@ -58,13 +72,50 @@ func (n *None) Prune(_, _ string, _ []int) error {
 	return nil
 }
 
+func (n *None) SetDefaultEntry(_, _ string) error {
+	n.s.Logger().Info("Skipping bootloader default entry update")
+	return nil
+}
+
+func (n *None) DefaultEntry(_ string) (string, error) {
+	return "", nil
+}
+
+func (n *None) MarkGood(_ string) (string, error) {
+	n.s.Logger().Info("Skipping boot assessment")
+	return "", nil
+}
+
+func (n *None) EFILoader() string {
+	return ""
+}
+
 func New(name string, s *sys.System) (Bootloader, error) {
 	switch name {
 	case BootNone:
 		return NewNone(s), nil
 	case BootGrub:
 		return NewGrub(s), nil
+	case BootSystemdBoot:
+		return NewSystemdBoot(s), nil
 	}
 
 	return nil, fmt.Errorf("new bootloader '%s': %w", name, errors.ErrUnsupported)
 }
+
+// NewFromConfig returns the bootloader defined by the given boot configuration
+func NewFromConfig(s *sys.System, conf *deployment.BootConfig) (Bootloader, error) {
+	if conf == nil || conf.Bootloader == "" {
+		return NewNone(s), nil
+	}
+	if conf.IsUKIEnabled() {
+		if conf.Bootloader != BootSystemdBoot {
+			return nil, fmt.Errorf("new bootloader '%s': unified kernel images require '%s'", conf.Bootloader, BootSystemdBoot)
+		}
+		return NewUKI(
+			s, conf.UKI.SigningKey, conf.UKI.SigningCert,
+			WithPCRSigning(conf.UKI.PCRKey, conf.UKI.PCRPublicKey),
+		), nil
+	}
+	return New(conf.Bootloader, s)
+}
@@ -25,6 +25,7 @@ import (
 	. "github.com/onsi/gomega"
 
 	"github.com/suse/elemental/v3/pkg/bootloader"
+	"github.com/suse/elemental/v3/pkg/deployment"
 	"github.com/suse/elemental/v3/pkg/log"
 	"github.com/suse/elemental/v3/pkg/sys"
 )
@@ -45,12 +46,26 @@ var _ = Describe("Bootloader tests", Label("bootloader", "grub", "none"), func()
 		Expect(err).NotTo(HaveOccurred())
 	})
 	It("Successfully creates a new bootloader", func() {
-		for _, name := range []string{"none", "grub"} {
+		for _, name := range []string{"none", "grub", "systemd-boot"} {
 			b, err := bootloader.New(name, s)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(b).NotTo(BeNil())
 		}
 	})
+	It("Creates the bootloader defined in the boot configuration", func() {
+		b, err := bootloader.NewFromConfig(s, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(b).To(BeAssignableToTypeOf(&bootloader.None{}))
+
+		conf := &deployment.BootConfig{Bootloader: "systemd-boot", UKI: &deployment.UKIConfig{Enabled: true}}
+		b, err = bootloader.NewFromConfig(s, conf)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(b).To(BeAssignableToTypeOf(&bootloader.UKI{}))
+
+		conf.Bootloader = "grub"
+		_, err = bootloader.NewFromConfig(s, conf)
+		Expect(err).To(HaveOccurred())
+	})
 	It("New() returns unsupported error for unknown bootloader", func() {
 		b, err := bootloader.New("bogus", s)
 		Expect(b).To(BeNil())
@@ -42,14 +42,6 @@ type Grub struct {
 	s *sys.System
 }
 
-type grubBootEntry struct {
-	Linux       string
-	Initrd      string
-	CmdLine     string
-	DisplayName string
-	ID          string
-}
-
 type Option func(*Grub)
 
 func NewGrub(s *sys.System, opts ...Option) *Grub {
@@ -70,8 +62,33 @@ const (
 
 	liveBootPath = "/boot"
 	grubEnvFile  = "grubenv"
+
+	// DefaultBootTries is the number of attempts to boot a new default entry before falling back
+	// to the previous one.
+	DefaultBootTries = 3
+
+	// GrubBIOSDir is the path of the i386-pc grub modules and images within the OS image
+	GrubBIOSDir = "/usr/share/grub2/i386-pc"
+	// GrubHybridMBR is the hybrid MBR boot code image for live media within GrubBIOSDir
+	GrubHybridMBR = "boot_hybrid.img"
+	// LiveBIOSImg is the path of the El Torito boot image within the live media tree
+	LiveBIOSImg = liveBootPath + "/grub2/i386-pc/eltorito.img"
+
+	bootTriesVar    = "boot_tries_left"
+	bootFallbackVar = "boot_fallback"
+	targetIDVar     = "target_id"
+
+	// snapshotMenuPrefix prefixes the grub menu entry IDs of snapshot entries, grub interprets
+	// numeric values of the default variable as menu positions instead of IDs
+	snapshotMenuPrefix = "snapshot-"
 )
 
+// bootTry is a step of the boot tries countdown rendered in grub.cfg
+type bootTry struct {
+	Left int
+	Next int
+}
+
 //go:embed grubtemplates/grub.cfg
 var grubCfg []byte
 
@@ -90,7 +107,7 @@ func (g *Grub) InstallLive(rootPath, target, kernelCmdLine string) error {
 		return fmt.Errorf("installing grub config: %w", err)
 	}
 
-	entry, err := g.installKernelInitrd(rootPath, target, liveBootPath)
+	entry, err := installKernelInitrd(g.s, rootPath, target, liveBootPath)
 	if err != nil {
 		return fmt.Errorf("installing kernel+initrd: %w", err)
 	}
@@ -123,6 +140,61 @@ func (g *Grub) InstallLive(rootPath, target, kernelCmdLine string) error {
 	return nil
 }
 
+// InstallLiveBIOS creates the El Torito boot image for legacy BIOS boot of live media. It
+// is built from the i386-pc grub modules of the OS image and it loads the grub configuration
+// installed by InstallLive.
+func (g *Grub) InstallLiveBIOS(rootPath, target string) error {
+	g.s.Logger().Info("Preparing GRUB El Torito image for legacy BIOS boot")
+
+	modDir := filepath.Join(rootPath, GrubBIOSDir)
+	if ok, _ := vfs.Exists(g.s.FS(), modDir); !ok {
+		return fmt.Errorf("grub i386-pc modules not found in OS image, '%s' does not exist", GrubBIOSDir)
+	}
+
+	img := filepath.Join(target, LiveBIOSImg)
+	err := vfs.MkdirAll(g.s.FS(), filepath.Dir(img), vfs.DirPerm)
+	if err != nil {
+		return fmt.Errorf("creating dir '%s': %w", filepath.Dir(img), err)
+	}
+
+	stdOut, err := g.s.Runner().Run(
+		"grub2-mkimage", "-O", "i386-pc-eltorito", "-d", modDir, "-p", filepath.Join(liveBootPath, "grub2"),
+		"-o", img, "biosdisk", "iso9660", "part_gpt", "part_msdos",
+	)
+	g.s.Logger().Debug("grub2-mkimage stdout: %s", string(stdOut))
+	if err != nil {
+		return fmt.Errorf("creating El Torito boot image: %w", err)
+	}
+	return nil
+}
+
+// InstallBIOS installs the i386-pc grub core image of the OS image to the BIOS boot partition
+// of the given device. The core image loads a grub configuration from the ESP equivalent to
+// the EFI one, so both firmware types share boot entries and boot assessment.
+func (g *Grub) InstallBIOS(rootPath, espDir, espLabel, device string) error {
+	g.s.Logger().Info("Installing GRUB for legacy BIOS boot to %s", device)
+
+	modDir := filepath.Join(rootPath, GrubBIOSDir)
+	if ok, _ := vfs.Exists(g.s.FS(), modDir); !ok {
+		return fmt.Errorf("grub i386-pc modules not found in OS image, '%s' does not exist", GrubBIOSDir)
+	}
+
+	err := g.writeGrubConfig(filepath.Join(espDir, "grub2"), grubCfg, grubCfgData(espLabel))
+	if err != nil {
+		return fmt.Errorf("failed writing BIOS grub config file: %w", err)
+	}
+
+	stdOut, err := g.s.Runner().Run(
+		"grub2-install", "--target=i386-pc", fmt.Sprintf("--directory=%s", modDir),
+		fmt.Sprintf("--boot-directory=%s", espDir), device,
+	)
+	g.s.Logger().Debug("grub2-install stdout: %s", string(stdOut))
+	if err != nil {
+		return fmt.Errorf("installing grub core image to '%s': %w", device, err)
+	}
+	return nil
+}
+
 // Install installs the bootloader to the specified root.
 func (g *Grub) Install(rootPath, espDir, espLabel, entryID, kernelCmdline, recKernelCmdline string) error {
 	err := g.installElementalEFI(rootPath, espDir, espLabel)
@@ -135,29 +207,41 @@ func (g *Grub) Install(rootPath, espDir, espLabel, entryID, kernelCmdline, recKe
 		return fmt.Errorf("installing grub config: %w", err)
 	}
 
-	entry, err := g.installKernelInitrd(rootPath, espDir, "")
+	// Every snapshot marks its boot as good, including the first one a failed upgrade falls back to
+	err = InstallBootAssessmentUnit(g.s, rootPath)
+	if err != nil {
+		return fmt.Errorf("installing boot assessment unit: %w", err)
+	}
+
+	entry, err := installKernelInitrd(g.s, rootPath, espDir, "")
 	if err != nil {
 		return fmt.Errorf("installing kernel+initrd: %w", err)
 	}
 
+	prevEntryID, err := g.defaultEntryTarget(espDir)
+	if err != nil {
+		return fmt.Errorf("reading current default boot entry: %w", err)
+	}
+
 	displayName := entry.DisplayName
 	entry.ID = entryID
 	entry.CmdLine = kernelCmdline
-	entries := []*grubBootEntry{&entry}
+	entries := []*bootEntry{&entry}
 
 	// append default entry
 	entry.DisplayName = fmt.Sprintf("%s (%s)", displayName, entryID)
-	defaultEntry := grubBootEntry{
+	defaultEntry := bootEntry{
 		Linux:       entry.Linux,
 		Initrd:      entry.Initrd,
 		DisplayName: displayName,
 		CmdLine:     entry.CmdLine,
 		ID:          DefaultBootID,
+		TargetID:    entryID,
 	}
 	entries = append(entries, &defaultEntry)
 
 	if recKernelCmdline != "" {
-		recoveryEntry := grubBootEntry{
+		recoveryEntry := bootEntry{
 			Linux:       entry.Linux,
 			Initrd:      entry.Initrd,
 			DisplayName: fmt.Sprintf("%s (%s)", displayName, RecoveryBootID),
@@ -172,6 +256,14 @@ func (g *Grub) Install(rootPath, espDir, espLabel, entryID, kernelCmdline, recKe
 		return fmt.Errorf("updating boot entries: %w", err)
 	}
 
+	// Only a default entry replacing a previous one requires boot assessment
+	if prevEntryID != "" && prevEntryID != entryID {
+		err = g.startBootAssessment(espDir, prevEntryID)
+		if err != nil {
+			return fmt.Errorf("starting boot assessment: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -239,61 +331,162 @@ func (g Grub) Prune(rootPath, espDir string, keepSnapshotIDs []int) (err error)
 		return fmt.Errorf("failed saving %s: %w", grubEnvPath, err)
 	}
 
+	// There is nothing to fall back to if the fallback entry was pruned
+	fallback := strings.TrimPrefix(grubEnv[bootFallbackVar], snapshotMenuPrefix)
+	if slices.Contains(toDelete, fallback) {
+		g.s.Logger().Warn("Fallback boot entry '%s' pruned, cancelling boot assessment", fallback)
+		err = g.clearBootAssessment(grubEnvPath)
+		if err != nil {
+			return err
+		}
+	}
+
 	return g.pruneOldKernels(rootPath, espDir, activeEntries)
 }
 
-func (g Grub) pruneOldKernels(rootPath, espDir string, activeEntries []string) error {
-	activeKernels := map[string]bool{}
+// SetDefaultEntry sets the boot entry of the given ID as the default boot entry.
+func (g *Grub) SetDefaultEntry(espDir, entryID string) error {
+	g.s.Logger().Info("Setting boot entry '%s' as default", entryID)
+	entryPath := filepath.Join(espDir, "loader", "entries", entryID)
+	if ok, _ := vfs.Exists(g.s.FS(), entryPath); !ok {
+		return fmt.Errorf("boot entry '%s' not found", entryID)
+	}
+	vars, err := g.readGrubEnv(entryPath)
+	if err != nil {
+		return fmt.Errorf("reading boot entry '%s': %w", entryID, err)
+	}
+	defaultEntry := &bootEntry{
+		Linux:       vars["linux"],
+		Initrd:      vars["initrd"],
+		CmdLine:     vars["cmdline"],
+		DisplayName: strings.TrimSuffix(vars["display_name"], fmt.Sprintf(" (%s)", entryID)),
+		ID:          DefaultBootID,
+		TargetID:    entryID,
+	}
+	err = g.writeBootEntry(espDir, defaultEntry)
+	if err != nil {
+		return fmt.Errorf("writing default boot entry: %w", err)
+	}
 
-	for _, entry := range activeEntries {
-		grubEnv := filepath.Join(espDir, "loader", "entries", entry)
-		vars, err := g.readGrubEnv(grubEnv)
+	// An explicitly selected default entry cancels any pending boot assessment
+	grubEnvPath := filepath.Join(espDir, grubEnvFile)
+	if ok, _ := vfs.Exists(g.s.FS(), grubEnvPath); ok {
+		err = g.clearBootAssessment(grubEnvPath)
 		if err != nil {
-			return fmt.Errorf("failed reading grubenv '%s': %w", grubEnv, err)
+			return err
 		}
+	}
+	return nil
+}
+
+// DefaultEntry returns the ID of the boot entry booted by default, this is the entry booted
+// next unless a boot assessment falls back to another entry. Returns an empty string if there
+// is no default entry yet.
+func (g *Grub) DefaultEntry(espDir string) (string, error) {
+	entryID, err := g.defaultEntryTarget(espDir)
+	if err != nil {
+		return "", fmt.Errorf("reading default boot entry: %w", err)
+	}
+	return entryID, nil
+}
+
+// MarkGood marks the current boot as successful by clearing the boot assessment counters.
+// It returns the ID of the fallback boot entry of the cleared assessment, if any.
+func (g *Grub) MarkGood(espDir string) (string, error) {
+	grubEnvPath := filepath.Join(espDir, grubEnvFile)
+	grubEnv, err := g.readGrubEnv(grubEnvPath)
+	if err != nil {
+		return "", fmt.Errorf("reading grubenv: %w", err)
+	}
 
-		linux := vars["linux"]
-		linuxDir, _ := filepath.Split(linux)
-		version := filepath.Base(linuxDir)
+	fallback := strings.TrimPrefix(grubEnv[bootFallbackVar], snapshotMenuPrefix)
+	if fallback == "" && grubEnv[bootTriesVar] == "" {
+		g.s.Logger().Info("No boot assessment in progress")
+		return "", nil
+	}
 
-		activeKernels[version] = true
+	g.s.Logger().Info("Marking current boot as successful")
+	err = g.clearBootAssessment(grubEnvPath)
+	if err != nil {
+		return "", err
 	}
+	return fallback, nil
+}
 
-	osVars, err := vfs.LoadEnvFile(g.s.FS(), filepath.Join(rootPath, OsReleasePath))
+// EFILoader returns the path of the shim EFI application installed in the Elemental EFI directory
+func (g *Grub) EFILoader() string {
+	_, target := defaultEfiBootFileName(g.s.Platform())
+	return filepath.Join("/EFI", "ELEMENTAL", target)
+}
+
+// defaultEntryTarget returns the ID of the boot entry the current default entry points to.
+// Returns an empty string if there is no default entry yet.
+func (g *Grub) defaultEntryTarget(espDir string) (string, error) {
+	entryPath := filepath.Join(espDir, "loader", "entries", DefaultBootID)
+	if ok, _ := vfs.Exists(g.s.FS(), entryPath); !ok {
+		return "", nil
+	}
+	vars, err := g.readGrubEnv(entryPath)
 	if err != nil {
-		return fmt.Errorf("loading %s vars: %w", OsReleasePath, err)
+		return "", err
+	}
+	return vars[targetIDVar], nil
+}
+
+// grubCfgData returns the data to render the grub.cfg template of installed systems
+func grubCfgData(espLabel string) map[string]any {
+	tries := []bootTry{}
+	for left := DefaultBootTries; left > 0; left-- {
+		tries = append(tries, bootTry{Left: left, Next: left - 1})
+	}
+	return map[string]any{
+		"Label":          espLabel,
+		"BootTries":      tries,
+		"SnapshotPrefix": snapshotMenuPrefix,
+		"DefaultID":      DefaultBootID,
+		"RecoveryID":     RecoveryBootID,
 	}
+}
 
-	var (
-		ok   bool
-		osID string
-	)
-	if osID, ok = osVars["ID"]; !ok {
-		return fmt.Errorf("%s ID not set", OsReleasePath)
+// startBootAssessment sets the boot counter, grub decreases it on each boot and boots the fallback
+// entry once it is exhausted, unless the boot is marked as good in the meantime.
+func (g *Grub) startBootAssessment(espDir, fallbackID string) error {
+	g.s.Logger().Info("Enabling boot assessment with fallback to boot entry '%s'", fallbackID)
+	grubEnvPath := filepath.Join(espDir, grubEnvFile)
+	tries := fmt.Sprintf("%s=%d", bootTriesVar, DefaultBootTries)
+	fallback := fmt.Sprintf("%s=%s%s", bootFallbackVar, snapshotMenuPrefix, fallbackID)
+
+	stdOut, err := g.s.Runner().Run("grub2-editenv", grubEnvPath, "set", tries, fallback)
+	g.s.Logger().Debug("grub2-editenv stdout: %s", string(stdOut))
+	if err != nil {
+		return fmt.Errorf("failed saving %s: %w", grubEnvPath, err)
 	}
+	return nil
+}
 
-	// look for older kernels
-	kernelDir := filepath.Join(espDir, osID)
-	kernelDirs, err := g.s.FS().ReadDir(kernelDir)
+func (g *Grub) clearBootAssessment(grubEnvPath string) error {
+	stdOut, err := g.s.Runner().Run("grub2-editenv", grubEnvPath, "unset", bootTriesVar, bootFallbackVar)
+	g.s.Logger().Debug("grub2-editenv stdout: %s", string(stdOut))
 	if err != nil {
-		return fmt.Errorf("reading sub-directories: %w", err)
+		return fmt.Errorf("clearing boot assessment in %s: %w", grubEnvPath, err)
 	}
+	return nil
+}
 
-	for _, dirEntry := range kernelDirs {
-		if !dirEntry.IsDir() {
-			continue
-		}
+func (g Grub) pruneOldKernels(rootPath, espDir string, activeEntries []string) error {
+	kernels := []string{}
 
-		if _, ok := activeKernels[dirEntry.Name()]; !ok {
-			path := filepath.Join(kernelDir, dirEntry.Name())
-			err := g.s.FS().RemoveAll(path)
-			if err != nil {
-				return fmt.Errorf("failed removing old kernel '%s': %w", path, err)
-			}
+	for _, entry := range activeEntries {
+		grubEnv := filepath.Join(espDir, "loader", "entries", entry)
+		vars, err := g.readGrubEnv(grubEnv)
+		if err != nil {
+			return fmt.Errorf("failed reading grubenv '%s': %w", grubEnv, err)
 		}
+
+		kernels = append(kernels, vars["linux"])
 	}
 
-	return nil
+	return pruneKernels(g.s, rootPath, espDir, kernels)
 }
 
 func (g Grub) generateIDFile(targetDir string) (string, error) {
@@ -344,7 +537,7 @@ func (g *Grub) installElementalEFI(rootPath, espDir, espLabel string) error {
 
 	for _, efiEntry := range []string{"BOOT", "ELEMENTAL"} {
 		targetDir := filepath.Join(espDir, "EFI", efiEntry)
-		err := g.installEFIEntry(rootPath, targetDir, grubCfg, map[string]string{"Label": espLabel})
+		err := g.installEFIEntry(rootPath, targetDir, grubCfg, grubCfgData(espLabel))
 		if err != nil {
 			return fmt.Errorf("failed setting '%s' EFI entry: %w", efiEntry, err)
 		}
@@ -447,91 +640,6 @@ func (g *Grub) installGrub(rootPath, espDir string) error {
 	return nil
 }
 
-// readIDAndName parses OS ID and OS name from os-relese file. Returns error of no OS ID is found.
-func (g *Grub) readIDAndName(rootPath string) (osID string, displayName string, err error) {
-	g.s.Logger().Info("Reading OS Release")
-
-	osVars, err := vfs.LoadEnvFile(g.s.FS(), filepath.Join(rootPath, OsReleasePath))
-	if err != nil {
-		return "", "", fmt.Errorf("loading %s vars: %w", OsReleasePath, err)
-	}
-
-	var ok bool
-	if osID, ok = osVars["ID"]; !ok {
-		return "", "", fmt.Errorf("%s ID not set", OsReleasePath)
-	}
-
-	displayName, ok = osVars["PRETTY_NAME"]
-	if !ok {
-		displayName, ok = osVars["VARIANT"]
-		if !ok {
-			displayName = osVars["NAME"]
-		}
-	}
-	return osID, displayName, nil
-}
-
-// installKernelInitrd copies the kernel and initrd to the given ESP path.
-//
-// This function takes a rootPath to find and copy kernel and initrd from there. The espDir parameter
-// is the target path where artifacts will be copied to. The subfolder specifies the location under espDir
-// where artifacts will be copied (mostly used on live images to specify a "boot" folder). The snapshotID parameter
-// is an identifier of the non default generated grubBootEntry. Finally kernelCmdline provides the kernel arguments
-// for the generated grubBootEntries.
-//
-// Returns a grubBootEntry list with two items, one defined as a default entry and another one identified with the provided ID.
-func (g *Grub) installKernelInitrd(rootPath, espDir, subfolder string) (grubBootEntry, error) {
-	g.s.Logger().Info("Installing kernel/initrd")
-	entry := grubBootEntry{}
-
-	osID, displayName, err := g.readIDAndName(rootPath)
-	if err != nil {
-		return entry, fmt.Errorf("failed parsing OS release: %w", err)
-	}
-
-	kernel, kernelVersion, err := vfs.FindKernel(g.s.FS(), rootPath)
-	if err != nil {
-		return entry, fmt.Errorf("finding kernel: %w", err)
-	}
-
-	targetDir := filepath.Join(espDir, subfolder, osID, kernelVersion)
-	err = vfs.MkdirAll(g.s.FS(), targetDir, vfs.DirPerm)
-	if err != nil {
-		return entry, fmt.Errorf("creating kernel dir '%s': %w", targetDir, err)
-	}
-
-	err = vfs.CopyFile(g.s.FS(), kernel, targetDir)
-	if err != nil {
-		return entry, fmt.Errorf("copying kernel '%s': %w", kernel, err)
-	}
-
-	// Copy kernel .hmac in order to enable FIPS.
-	kernelHmac, err := vfs.FindKernelHmac(g.s.FS(), kernel)
-	if err != nil {
-		return entry, fmt.Errorf("finding kernel hmac '%s': %w", kernel, err)
-	}
-
-	err = vfs.CopyFile(g.s.FS(), kernelHmac, targetDir)
-	if err != nil {
-		return entry, fmt.Errorf("copying kernel hmac '%s': %w", kernelHmac, err)
-	}
-
-	initrdPath := filepath.Join(filepath.Dir(kernel), Initrd)
-	if exists, _ := vfs.Exists(g.s.FS(), initrdPath); !exists {
-		return entry, fmt.Errorf("initrd not found")
-	}
-
-	err = vfs.CopyFile(g.s.FS(), initrdPath, targetDir)
-	if err != nil {
-		return entry, fmt.Errorf("copying initrd '%s': %w", initrdPath, err)
-	}
-	entry.Linux = filepath.Join("/", subfolder, osID, kernelVersion, filepath.Base(kernel))
-	entry.Initrd = filepath.Join("/", subfolder, osID, kernelVersion, Initrd)
-	entry.DisplayName = displayName
-
-	return entry, nil
-}
-
 func (g *Grub) readGrubEnv(path string) (map[string]string, error) {
 	stdOut, err := g.s.Runner().Run("grub2-editenv", path, "list")
 	if err != nil {
@@ -546,7 +654,7 @@ func (g *Grub) readGrubEnv(path string) (map[string]string, error) {
 	return val, nil
 }
 
-func (g *Grub) updateBootEntries(espDir string, newEntries ...*grubBootEntry) error {
+func (g *Grub) updateBootEntries(espDir string, newEntries ...*bootEntry) error {
 	grubEnvPath := filepath.Join(espDir, grubEnvFile)
 	activeEntries := []string{}
 	hasRecovery := false
@@ -612,13 +720,18 @@ func (g *Grub) updateBootEntries(espDir string, newEntries ...*grubBootEntry) er
 	return err
 }
 
-func (g Grub) writeBootEntry(espDir string, entry *grubBootEntry) error {
+func (g Grub) writeBootEntry(espDir string, entry *bootEntry) error {
 	displayName := fmt.Sprintf("display_name=%s", entry.DisplayName)
 	linux := fmt.Sprintf("linux=%s", entry.Linux)
 	initrd := fmt.Sprintf("initrd=%s", entry.Initrd)
 	cmdline := fmt.Sprintf("cmdline=%s", entry.CmdLine)
+	vars := []string{displayName, linux, initrd, cmdline}
+	if entry.TargetID != "" {
+		vars = append(vars, fmt.Sprintf("%s=%s", targetIDVar, entry.TargetID))
+	}
 
-	stdOut, err := g.s.Runner().Run("grub2-editenv", filepath.Join(espDir, "loader", "entries", entry.ID), "set", displayName, linux, initrd, cmdline)
+	args := append([]string{filepath.Join(espDir, "loader", "entries", entry.ID), "set"}, vars...)
+	stdOut, err := g.s.Runner().Run("grub2-editenv", args...)
 	g.s.Logger().Debug("grub2-editenv stdout: %s", string(stdOut))
 	if err != nil {
 		return err
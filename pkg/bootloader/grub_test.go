@@ -21,6 +21,7 @@ import (
 	"errors"
 	"fmt"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -68,16 +69,13 @@ var _ = Describe("Grub tests", Label("bootloader", "grub"), func() {
 			case "grub2-editenv":
 				path := args[0]
 				switch args[1] {
-				case "set":
-					// Write args to the file
-					content := strings.Join(args[2:], "\n")
-					err := tfs.WriteFile(path, []byte(content), vfs.FilePerm)
-					Expect(err).NotTo(HaveOccurred())
+				case "set", "unset":
+					Expect(editGrubEnv(tfs, path, args[1], args[2:]...)).To(Succeed())
 				case "list":
 					return tfs.ReadFile(path)
 				}
 				return nil, nil
-			case "rsync":
+			case "rsync", "grub2-install", "grub2-mkimage":
 				return nil, nil
 			}
 
@@ -121,6 +119,7 @@ var _ = Describe("Grub tests", Label("bootloader", "grub"), func() {
 		Expect(vfs.Exists(tfs, "/target/dir/boot/EFI/ELEMENTAL/bootx64.efi")).To(BeTrue())
 		Expect(vfs.Exists(tfs, "/target/dir/boot/EFI/ELEMENTAL/MokManager.efi")).To(BeTrue())
 		Expect(vfs.Exists(tfs, "/target/dir/boot/EFI/ELEMENTAL/grub.efi")).To(BeTrue())
+		Expect(vfs.Exists(tfs, filepath.Join("/target/dir/boot", grub.EFILoader()))).To(BeTrue())
 
 		// Kernel and initrd exist
 		Expect(vfs.Exists(tfs, "/target/dir/boot/opensuse-tumbleweed/6.14.4-1-default/vmlinuz")).To(BeTrue())
@@ -150,6 +149,39 @@ var _ = Describe("Grub tests", Label("bootloader", "grub"), func() {
 		Expect(vfs.Exists(tfs, "/iso/dir/EFI/BOOT/grub.cfg")).To(BeTrue())
 		Expect(vfs.Exists(tfs, "/iso/dir/boot/grub2/grub.cfg")).To(BeTrue())
 	})
+	It("Installs grub for legacy BIOS boot", func() {
+		err := grub.InstallBIOS("/target/dir", "/target/dir/boot", "EFI", "/dev/sda")
+		Expect(err).To(MatchError(ContainSubstring("grub i386-pc modules not found")))
+
+		Expect(vfs.MkdirAll(tfs, "/target/dir/usr/share/grub2/i386-pc", vfs.DirPerm)).To(Succeed())
+		Expect(grub.InstallBIOS("/target/dir", "/target/dir/boot", "EFI", "/dev/sda")).To(Succeed())
+
+		grubCfg, err := tfs.ReadFile("/target/dir/boot/grub2/grub.cfg")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(grubCfg)).To(ContainSubstring("search --no-floppy --label --set=root EFI"))
+
+		// the boot tries countdown starts at the default number of tries
+		Expect(string(grubCfg)).To(ContainSubstring(
+			"if test \"${boot_tries_left}\" == \"3\"; then\n      set boot_tries_left=2\n" +
+				"    elif test \"${boot_tries_left}\" == \"2\"; then\n      set boot_tries_left=1\n" +
+				"    elif test \"${boot_tries_left}\" == \"1\"; then\n      set boot_tries_left=0\n    else",
+		))
+		Expect(string(grubCfg)).To(ContainSubstring(`set entry_id="snapshot-${entry}"`))
+		Expect(runner.MatchMilestones([][]string{{
+			"grub2-install", "--target=i386-pc", "--directory=/target/dir/usr/share/grub2/i386-pc",
+			"--boot-directory=/target/dir/boot", "/dev/sda",
+		}})).To(Succeed())
+	})
+	It("Creates the El Torito image for LiveOS image", func() {
+		Expect(vfs.MkdirAll(tfs, "/target/dir/usr/share/grub2/i386-pc", vfs.DirPerm)).To(Succeed())
+		Expect(grub.InstallLiveBIOS("/target/dir", "/iso/dir")).To(Succeed())
+
+		Expect(vfs.Exists(tfs, "/iso/dir/boot/grub2/i386-pc")).To(BeTrue())
+		Expect(runner.MatchMilestones([][]string{{
+			"grub2-mkimage", "-O", "i386-pc-eltorito", "-d", "/target/dir/usr/share/grub2/i386-pc",
+			"-p", "/boot/grub2", "-o", "/iso/dir/boot/grub2/i386-pc/eltorito.img",
+		}})).To(Succeed())
+	})
 	It("Fails with an error if initrd is not found", func() {
 		// Remove initrd
 		err := tfs.Remove("/target/dir/usr/lib/modules/6.14.4-1-default/initrd")
@@ -186,10 +218,127 @@ var _ = Describe("Grub tests", Label("bootloader", "grub"), func() {
 		Expect(err).ToNot(HaveOccurred())
 		Expect(strings.SplitSeq(string(entry2), "\n")).To(ContainElement("cmdline=snapshot2"))
 
-		// entries should read "active 2 1 recovery"
+		// entries should read "active 2 1 recovery" and boot assessment falls back to 1
+		entries, err := tfs.ReadFile("/target/dir/boot/grubenv")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(strings.Split(string(entries), "\n")).To(ConsistOf(
+			"entries=active 2 1 recovery", "boot_tries_left=3", "boot_fallback=snapshot-1",
+		))
+	})
+	It("Sets the given snapshot entry as the default entry", func() {
+		err := grub.Install("/target/dir", "/target/dir/boot", "EFI", "1", "snapshot1", "")
+		Expect(err).ToNot(HaveOccurred())
+
+		err = grub.Install("/target/dir", "/target/dir/boot", "EFI", "2", "snapshot2", "")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(grub.DefaultEntry("/target/dir/boot")).To(Equal("2"))
+		Expect(grub.SetDefaultEntry("/target/dir/boot", "1")).To(Succeed())
+		Expect(grub.DefaultEntry("/target/dir/boot")).To(Equal("1"))
+
+		// 'active' entry should point to snapshot 1 without the ID suffix in the display name
+		activeEntry, err := tfs.ReadFile("/target/dir/boot/loader/entries/active")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(strings.SplitSeq(string(activeEntry), "\n")).To(ContainElement("cmdline=snapshot1"))
+		Expect(strings.SplitSeq(string(activeEntry), "\n")).To(ContainElement("display_name=openSUSE Tumbleweed"))
+
+		// entries list is not modified
 		entries, err := tfs.ReadFile("/target/dir/boot/grubenv")
 		Expect(err).ToNot(HaveOccurred())
-		Expect(string(entries)).To(Equal("entries=active 2 1 recovery"))
+		Expect(string(entries)).To(Equal("entries=active 2 1"))
+	})
+	It("Fails to set the default entry for an unknown snapshot", func() {
+		err := grub.Install("/target/dir", "/target/dir/boot", "EFI", "1", "snapshot1", "")
+		Expect(err).ToNot(HaveOccurred())
+
+		err = grub.SetDefaultEntry("/target/dir/boot", "3")
+		Expect(err).To(MatchError("boot entry '3' not found"))
+	})
+	It("Does not start boot assessment on first installation", func() {
+		err := grub.Install("/target/dir", "/target/dir/boot", "EFI", "1", "snapshot1", "")
+		Expect(err).ToNot(HaveOccurred())
+
+		grubEnv, err := tfs.ReadFile("/target/dir/boot/grubenv")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(grubEnv)).To(Equal("entries=active 1"))
+
+		activeEntry, err := tfs.ReadFile("/target/dir/boot/loader/entries/active")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(strings.SplitSeq(string(activeEntry), "\n")).To(ContainElement("target_id=1"))
+
+		fallback, err := grub.MarkGood("/target/dir/boot")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fallback).To(BeEmpty())
+	})
+	It("Installs the boot assessment unit in the first installed snapshot", func() {
+		err := grub.Install("/target/dir", "/target/dir/boot", "EFI", "1", "snapshot1", "")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(vfs.Exists(tfs, "/target/dir/etc/systemd/system/elemental-boot-assessment.service")).To(BeTrue())
+		link, err := vfs.ReadLink(tfs, "/target/dir/etc/systemd/system/multi-user.target.wants/elemental-boot-assessment.service")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(link).To(Equal("/etc/systemd/system/elemental-boot-assessment.service"))
+	})
+	It("Falls back to the previous default entry on each upgrade", func() {
+		for _, id := range []string{"1", "2", "3"} {
+			err := grub.Install("/target/dir", "/target/dir/boot", "EFI", id, "snapshot"+id, "")
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		grubEnv, err := tfs.ReadFile("/target/dir/boot/grubenv")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(strings.Split(string(grubEnv), "\n")).To(ContainElements("boot_tries_left=3", "boot_fallback=snapshot-2"))
+	})
+	It("Clears the boot assessment once the boot is marked as good", func() {
+		err := grub.Install("/target/dir", "/target/dir/boot", "EFI", "1", "snapshot1", "")
+		Expect(err).ToNot(HaveOccurred())
+		err = grub.Install("/target/dir", "/target/dir/boot", "EFI", "2", "snapshot2", "")
+		Expect(err).ToNot(HaveOccurred())
+
+		// Simulate grub consuming some boot tries
+		Expect(editGrubEnv(tfs, "/target/dir/boot/grubenv", "set", "boot_tries_left=1")).To(Succeed())
+
+		fallback, err := grub.MarkGood("/target/dir/boot")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fallback).To(Equal("1"))
+		Expect(runner.IncludesCmds([][]string{
+			{"grub2-editenv", "/target/dir/boot/grubenv", "unset", "boot_tries_left", "boot_fallback"},
+		})).To(Succeed())
+
+		grubEnv, err := tfs.ReadFile("/target/dir/boot/grubenv")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(grubEnv)).To(Equal("entries=active 2 1"))
+
+		// Nothing left to clear
+		fallback, err = grub.MarkGood("/target/dir/boot")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fallback).To(BeEmpty())
+	})
+	It("Fails to mark the boot as good if grubenv can't be cleared", func() {
+		err := grub.Install("/target/dir", "/target/dir/boot", "EFI", "1", "snapshot1", "")
+		Expect(err).ToNot(HaveOccurred())
+		err = grub.Install("/target/dir", "/target/dir/boot", "EFI", "2", "snapshot2", "")
+		Expect(err).ToNot(HaveOccurred())
+
+		sideEffect := runner.SideEffect
+		runner.SideEffect = func(command string, args ...string) ([]byte, error) {
+			if command == "grub2-editenv" && args[1] == "unset" {
+				return nil, fmt.Errorf("read-only filesystem")
+			}
+			return sideEffect(command, args...)
+		}
+		_, err = grub.MarkGood("/target/dir/boot")
+		Expect(err).To(MatchError(ContainSubstring("clearing boot assessment")))
+	})
+	It("Installs and enables the boot assessment unit", func() {
+		Expect(bootloader.InstallBootAssessmentUnit(s, "/target/dir")).To(Succeed())
+		Expect(vfs.Exists(tfs, "/target/dir/etc/systemd/system/elemental-boot-assessment.service")).To(BeTrue())
+		link, err := vfs.ReadLink(tfs, "/target/dir/etc/systemd/system/multi-user.target.wants/elemental-boot-assessment.service")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(link).To(Equal("/etc/systemd/system/elemental-boot-assessment.service"))
+
+		// Installing it again is a no-op
+		Expect(bootloader.InstallBootAssessmentUnit(s, "/target/dir")).To(Succeed())
 	})
 	It("Prunes old snapshots", func() {
 		// "Install" older (6.6.99) kernel
@@ -233,9 +382,9 @@ var _ = Describe("Grub tests", Label("bootloader", "grub"), func() {
 		// entries should read "active 2 1 recovery"
 		entries, err := tfs.ReadFile("/target/dir/boot/grubenv")
 		Expect(err).ToNot(HaveOccurred())
-		Expect(string(entries)).To(Equal("entries=active 2 1 recovery"))
+		Expect(strings.Split(string(entries), "\n")).To(ContainElement("entries=active 2 1 recovery"))
 
-		// Prune snapshot 1 (keep 2)
+		// Prune snapshot 1 (keep 2), this also cancels the boot assessment falling back to 1
 		err = grub.Prune("/target/dir", "/target/dir/boot", []int{2})
 		Expect(err).ToNot(HaveOccurred())
 
@@ -255,3 +404,26 @@ var _ = Describe("Grub tests", Label("bootloader", "grub"), func() {
 		Expect(vfs.Exists(tfs, "/target/dir/boot/opensuse-tumbleweed/6.14.4-1-default/initrd")).To(BeTrue())
 	})
 })
+
+// editGrubEnv mimics grub2-editenv set and unset commands on the given file
+func editGrubEnv(tfs vfs.FS, path, action string, vars ...string) error {
+	var lines []string
+	if data, err := tfs.ReadFile(path); err == nil && len(data) > 0 {
+		lines = strings.Split(string(data), "\n")
+	}
+	for _, v := range vars {
+		key, _, _ := strings.Cut(v, "=")
+		i := slices.IndexFunc(lines, func(l string) bool {
+			return strings.HasPrefix(l, key+"=")
+		})
+		switch {
+		case action == "unset" && i >= 0:
+			lines = slices.Delete(lines, i, i+1)
+		case action == "set" && i >= 0:
+			lines[i] = v
+		case action == "set":
+			lines = append(lines, v)
+		}
+	}
+	return tfs.WriteFile(path, []byte(strings.Join(lines, "\n")), vfs.FilePerm)
+}
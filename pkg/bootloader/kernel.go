@@ -0,0 +1,170 @@
+/*
+Copyright © 2022-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bootloader
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+// bootEntry describes a kernel, initrd and command line to boot
+type bootEntry struct {
+	Linux       string
+	Initrd      string
+	CmdLine     string
+	DisplayName string
+	ID          string
+	// TargetID is the ID of the boot entry the default entry was created from
+	TargetID string
+}
+
+// readIDAndName parses OS ID and OS name from os-relese file. Returns error of no OS ID is found.
+func readIDAndName(s *sys.System, rootPath string) (osID string, displayName string, err error) {
+	s.Logger().Info("Reading OS Release")
+
+	osVars, err := vfs.LoadEnvFile(s.FS(), filepath.Join(rootPath, OsReleasePath))
+	if err != nil {
+		return "", "", fmt.Errorf("loading %s vars: %w", OsReleasePath, err)
+	}
+
+	var ok bool
+	if osID, ok = osVars["ID"]; !ok {
+		return "", "", fmt.Errorf("%s ID not set", OsReleasePath)
+	}
+
+	displayName, ok = osVars["PRETTY_NAME"]
+	if !ok {
+		displayName, ok = osVars["VARIANT"]
+		if !ok {
+			displayName = osVars["NAME"]
+		}
+	}
+	return osID, displayName, nil
+}
+
+// installKernelInitrd copies the kernel and initrd to the given ESP path.
+//
+// This function takes a rootPath to find and copy kernel and initrd from there. The espDir parameter
+// is the target path where artifacts will be copied to. The subfolder specifies the location under espDir
+// where artifacts will be copied (mostly used on live images to specify a "boot" folder). The snapshotID parameter
+// is an identifier of the non default generated bootEntry. Finally kernelCmdline provides the kernel arguments
+// for the generated boot entries.
+//
+// Returns a bootEntry list with two items, one defined as a default entry and another one identified with the provided ID.
+func installKernelInitrd(s *sys.System, rootPath, espDir, subfolder string) (bootEntry, error) {
+	s.Logger().Info("Installing kernel/initrd")
+	entry := bootEntry{}
+
+	osID, displayName, err := readIDAndName(s, rootPath)
+	if err != nil {
+		return entry, fmt.Errorf("failed parsing OS release: %w", err)
+	}
+
+	kernel, kernelVersion, err := vfs.FindKernel(s.FS(), rootPath)
+	if err != nil {
+		return entry, fmt.Errorf("finding kernel: %w", err)
+	}
+
+	targetDir := filepath.Join(espDir, subfolder, osID, kernelVersion)
+	err = vfs.MkdirAll(s.FS(), targetDir, vfs.DirPerm)
+	if err != nil {
+		return entry, fmt.Errorf("creating kernel dir '%s': %w", targetDir, err)
+	}
+
+	err = vfs.CopyFile(s.FS(), kernel, targetDir)
+	if err != nil {
+		return entry, fmt.Errorf("copying kernel '%s': %w", kernel, err)
+	}
+
+	// Copy kernel .hmac in order to enable FIPS.
+	kernelHmac, err := vfs.FindKernelHmac(s.FS(), kernel)
+	if err != nil {
+		return entry, fmt.Errorf("finding kernel hmac '%s': %w", kernel, err)
+	}
+
+	err = vfs.CopyFile(s.FS(), kernelHmac, targetDir)
+	if err != nil {
+		return entry, fmt.Errorf("copying kernel hmac '%s': %w", kernelHmac, err)
+	}
+
+	initrdPath := filepath.Join(filepath.Dir(kernel), Initrd)
+	if exists, _ := vfs.Exists(s.FS(), initrdPath); !exists {
+		return entry, fmt.Errorf("initrd not found")
+	}
+
+	err = vfs.CopyFile(s.FS(), initrdPath, targetDir)
+	if err != nil {
+		return entry, fmt.Errorf("copying initrd '%s': %w", initrdPath, err)
+	}
+	entry.Linux = filepath.Join("/", subfolder, osID, kernelVersion, filepath.Base(kernel))
+	entry.Initrd = filepath.Join("/", subfolder, osID, kernelVersion, Initrd)
+	entry.DisplayName = displayName
+
+	return entry, nil
+}
+
+// pruneKernels removes the kernels installed in the given ESP which are not referenced by any of the
+// given kernel paths. Kernel paths are relative to the ESP root.
+func pruneKernels(s *sys.System, rootPath, espDir string, kernels []string) error {
+	activeKernels := map[string]bool{}
+	for _, linux := range kernels {
+		linuxDir, _ := filepath.Split(linux)
+		version := filepath.Base(linuxDir)
+
+		activeKernels[version] = true
+	}
+
+	osVars, err := vfs.LoadEnvFile(s.FS(), filepath.Join(rootPath, OsReleasePath))
+	if err != nil {
+		return fmt.Errorf("loading %s vars: %w", OsReleasePath, err)
+	}
+
+	var (
+		ok   bool
+		osID string
+	)
+	if osID, ok = osVars["ID"]; !ok {
+		return fmt.Errorf("%s ID not set", OsReleasePath)
+	}
+
+	// look for older kernels
+	kernelDir := filepath.Join(espDir, osID)
+	kernelDirs, err := s.FS().ReadDir(kernelDir)
+	if err != nil {
+		return fmt.Errorf("reading sub-directories: %w", err)
+	}
+
+	for _, dirEntry := range kernelDirs {
+		if !dirEntry.IsDir() {
+			continue
+		}
+
+		if _, ok := activeKernels[dirEntry.Name()]; !ok {
+			path := filepath.Join(kernelDir, dirEntry.Name())
+			err := s.FS().RemoveAll(path)
+			if err != nil {
+				return fmt.Errorf("failed removing old kernel '%s': %w", path, err)
+			}
+		}
+	}
+
+	return nil
+}
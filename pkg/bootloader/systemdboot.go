@@ -0,0 +1,312 @@
+/*
+Copyright © 2022-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bootloader
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/platform"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+const (
+	loaderConf    = "loader.conf"
+	loaderTimeout = 10
+	entrySuffix   = ".conf"
+	liveEntryID   = "live"
+)
+
+// SystemdBoot installs systemd-boot and Boot Loader Specification entries to the ESP
+type SystemdBoot struct {
+	s *sys.System
+}
+
+func NewSystemdBoot(s *sys.System) *SystemdBoot {
+	return &SystemdBoot{s}
+}
+
+// Install installs systemd-boot to the specified ESP including the boot entry of the given ID and
+// sets it as the default entry.
+func (sd *SystemdBoot) Install(rootPath, espDir, _, entryID, kernelCmdline, recKernelCmdline string) error {
+	err := sd.installEFI(rootPath, espDir)
+	if err != nil {
+		return fmt.Errorf("installing systemd-boot EFI apps: %w", err)
+	}
+
+	entry, err := installKernelInitrd(sd.s, rootPath, espDir, "")
+	if err != nil {
+		return fmt.Errorf("installing kernel+initrd: %w", err)
+	}
+
+	displayName := entry.DisplayName
+	entry.ID = entryID
+	entry.CmdLine = kernelCmdline
+	entry.DisplayName = fmt.Sprintf("%s (%s)", displayName, entryID)
+
+	err = sd.writeBootEntry(espDir, &entry)
+	if err != nil {
+		return fmt.Errorf("writing boot entry: %w", err)
+	}
+
+	// do not update recovery entry if already exists
+	recoveryPath := sd.entryPath(espDir, RecoveryBootID)
+	if ok, _ := vfs.Exists(sd.s.FS(), recoveryPath); recKernelCmdline != "" && !ok {
+		recoveryEntry := bootEntry{
+			Linux:       entry.Linux,
+			Initrd:      entry.Initrd,
+			DisplayName: fmt.Sprintf("%s (%s)", displayName, RecoveryBootID),
+			CmdLine:     recKernelCmdline,
+			ID:          RecoveryBootID,
+		}
+		err = sd.writeBootEntry(espDir, &recoveryEntry)
+		if err != nil {
+			return fmt.Errorf("writing recovery boot entry: %w", err)
+		}
+	}
+
+	err = sd.writeLoaderConf(espDir, entryID+entrySuffix)
+	if err != nil {
+		return fmt.Errorf("writing loader configuration: %w", err)
+	}
+
+	return nil
+}
+
+// InstallLive installs systemd-boot for live media to the specified target. Kernel and initrd are installed
+// under the EFI directory, as systemd-boot can only load them from the EFI partition.
+func (sd *SystemdBoot) InstallLive(rootPath, target, kernelCmdline string) error {
+	sd.s.Logger().Info("Preparing systemd-boot bootloader for live media")
+
+	err := sd.installEFI(rootPath, target)
+	if err != nil {
+		return fmt.Errorf("installing systemd-boot EFI apps: %w", err)
+	}
+
+	entry, err := installKernelInitrd(sd.s, rootPath, target, "EFI")
+	if err != nil {
+		return fmt.Errorf("installing kernel+initrd: %w", err)
+	}
+	entry.ID = liveEntryID
+	entry.CmdLine = kernelCmdline
+
+	err = sd.writeBootEntry(target, &entry)
+	if err != nil {
+		return fmt.Errorf("writing boot entry: %w", err)
+	}
+
+	err = sd.writeLoaderConf(target, liveEntryID+entrySuffix)
+	if err != nil {
+		return fmt.Errorf("writing loader configuration: %w", err)
+	}
+
+	return nil
+}
+
+// Prune removes the boot entries and kernels of the snapshots not included in keepSnapshotIDs.
+func (sd *SystemdBoot) Prune(rootPath, espDir string, keepSnapshotIDs []int) error {
+	sd.s.Logger().Info("Pruning old boot artifacts in %s", espDir)
+
+	entriesDir := filepath.Join(espDir, "loader", "entries")
+	dirEntries, err := sd.s.FS().ReadDir(entriesDir)
+	if err != nil {
+		return fmt.Errorf("reading boot entries: %w", err)
+	}
+
+	kernels := []string{}
+	for _, dirEntry := range dirEntries {
+		name := dirEntry.Name()
+		if dirEntry.IsDir() || !strings.HasSuffix(name, entrySuffix) {
+			continue
+		}
+
+		entryID := strings.TrimSuffix(name, entrySuffix)
+		snapshotID, err := strconv.Atoi(entryID)
+		if err == nil && !slices.Contains(keepSnapshotIDs, snapshotID) {
+			err = sd.s.FS().Remove(filepath.Join(entriesDir, name))
+			if err != nil {
+				sd.s.Logger().Warn("failed removing '%s'", entryID)
+				return err
+			}
+			continue
+		}
+
+		entry, err := sd.readBootEntry(espDir, entryID)
+		if err != nil {
+			return err
+		}
+		kernels = append(kernels, entry.Linux)
+	}
+
+	return pruneKernels(sd.s, rootPath, espDir, kernels)
+}
+
+// SetDefaultEntry sets the boot entry of the given ID as the default boot entry.
+func (sd *SystemdBoot) SetDefaultEntry(espDir, entryID string) error {
+	sd.s.Logger().Info("Setting boot entry '%s' as default", entryID)
+	if ok, _ := vfs.Exists(sd.s.FS(), sd.entryPath(espDir, entryID)); !ok {
+		return fmt.Errorf("boot entry '%s' not found", entryID)
+	}
+	return sd.writeLoaderConf(espDir, entryID+entrySuffix)
+}
+
+// DefaultEntry returns the ID of the boot entry booted by default. Returns an empty string if
+// there is no default entry yet.
+func (sd *SystemdBoot) DefaultEntry(espDir string) (string, error) {
+	entry, err := sd.readLoaderConfDefault(espDir)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(entry, entrySuffix), nil
+}
+
+// MarkGood is a no-op, boot assessment is not supported with systemd-boot.
+func (sd *SystemdBoot) MarkGood(_ string) (string, error) {
+	sd.s.Logger().Info("Skipping boot assessment, not supported by systemd-boot")
+	return "", nil
+}
+
+// EFILoader returns the path of the systemd-boot EFI application installed in its own EFI directory
+func (sd *SystemdBoot) EFILoader() string {
+	efiApp, _ := systemdBootEfiFileName(sd.s.Platform())
+	return filepath.Join("/EFI", "systemd", efiApp)
+}
+
+// installEFI copies the systemd-boot EFI application to its own path and to the default
+// removable media path of the ESP.
+func (sd *SystemdBoot) installEFI(rootPath, espDir string) error {
+	sd.s.Logger().Info("Installing systemd-boot EFI applications")
+
+	efiApp, defaultApp := systemdBootEfiFileName(sd.s.Platform())
+	src := filepath.Join(rootPath, "usr", "lib", "systemd", "boot", "efi", efiApp)
+	if ok, _ := vfs.Exists(sd.s.FS(), src); !ok {
+		return fmt.Errorf("systemd-boot EFI application '%s' not found", src)
+	}
+
+	targets := map[string]string{
+		filepath.Join(espDir, "EFI", "systemd"): efiApp,
+		filepath.Join(espDir, "EFI", "BOOT"):    defaultApp,
+	}
+	for dir, name := range targets {
+		err := vfs.MkdirAll(sd.s.FS(), dir, vfs.DirPerm)
+		if err != nil {
+			return fmt.Errorf("creating dir '%s': %w", dir, err)
+		}
+		err = vfs.CopyFile(sd.s.FS(), src, filepath.Join(dir, name))
+		if err != nil {
+			return fmt.Errorf("copying file '%s': %w", src, err)
+		}
+	}
+
+	return nil
+}
+
+// writeLoaderConf writes the systemd-boot loader.conf with the given entry file as the default one
+func (sd *SystemdBoot) writeLoaderConf(espDir, defaultEntry string) error {
+	loaderDir := filepath.Join(espDir, "loader")
+	err := vfs.MkdirAll(sd.s.FS(), loaderDir, vfs.DirPerm)
+	if err != nil {
+		return fmt.Errorf("creating loader dir: %w", err)
+	}
+
+	conf := fmt.Sprintf("timeout %d\ndefault %s\neditor no\n", loaderTimeout, defaultEntry)
+	return sd.s.FS().WriteFile(filepath.Join(loaderDir, loaderConf), []byte(conf), vfs.FilePerm)
+}
+
+// readLoaderConfDefault returns the default entry set in the loader configuration, if any
+func (sd *SystemdBoot) readLoaderConfDefault(espDir string) (string, error) {
+	path := filepath.Join(espDir, "loader", loaderConf)
+	if ok, _ := vfs.Exists(sd.s.FS(), path); !ok {
+		return "", nil
+	}
+	data, err := sd.s.FS().ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("reading loader configuration: %w", err)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		key, value, _ := strings.Cut(strings.TrimSpace(line), " ")
+		if key == "default" {
+			return strings.TrimSpace(value), nil
+		}
+	}
+	return "", nil
+}
+
+// writeBootEntry writes a Boot Loader Specification type #1 entry
+func (sd *SystemdBoot) writeBootEntry(espDir string, entry *bootEntry) error {
+	entriesDir := filepath.Join(espDir, "loader", "entries")
+	err := vfs.MkdirAll(sd.s.FS(), entriesDir, vfs.DirPerm)
+	if err != nil {
+		return fmt.Errorf("creating loader dir: %w", err)
+	}
+
+	lines := []string{
+		fmt.Sprintf("title %s", entry.DisplayName),
+		fmt.Sprintf("linux %s", entry.Linux),
+		fmt.Sprintf("initrd %s", entry.Initrd),
+		fmt.Sprintf("options %s", entry.CmdLine),
+	}
+	data := strings.Join(lines, "\n") + "\n"
+	return sd.s.FS().WriteFile(sd.entryPath(espDir, entry.ID), []byte(data), vfs.FilePerm)
+}
+
+// readBootEntry parses the Boot Loader Specification entry of the given ID
+func (sd *SystemdBoot) readBootEntry(espDir, entryID string) (*bootEntry, error) {
+	data, err := sd.s.FS().ReadFile(sd.entryPath(espDir, entryID))
+	if err != nil {
+		return nil, fmt.Errorf("reading boot entry '%s': %w", entryID, err)
+	}
+
+	entry := &bootEntry{ID: entryID}
+	for line := range strings.Lines(string(data)) {
+		key, value, _ := strings.Cut(strings.TrimSpace(line), " ")
+		value = strings.TrimSpace(value)
+		switch key {
+		case "title":
+			entry.DisplayName = value
+		case "linux":
+			entry.Linux = value
+		case "initrd":
+			entry.Initrd = value
+		case "options":
+			entry.CmdLine = value
+		}
+	}
+	return entry, nil
+}
+
+func (sd *SystemdBoot) entryPath(espDir, entryID string) string {
+	return filepath.Join(espDir, "loader", "entries", entryID+entrySuffix)
+}
+
+// systemdBootEfiFileName returns the systemd-boot EFI application name and the default
+// removable media name for the provided platform, defaults to x86_64.
+func systemdBootEfiFileName(p *platform.Platform) (string, string) {
+	switch p.Arch {
+	case platform.ArchAarch64, platform.ArchArm64:
+		return "systemd-bootaa64.efi", "BOOTAA64.EFI"
+	case platform.ArchRiscv64:
+		return "systemd-bootriscv64.efi", "BOOTRISCV64.EFI"
+	default:
+		return "systemd-bootx64.efi", "BOOTX64.EFI"
+	}
+}
@@ -0,0 +1,139 @@
+/*
+Copyright © 2022-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bootloader_test
+
+import (
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/bootloader"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+var _ = Describe("Systemd-boot tests", Label("bootloader", "systemd-boot"), func() {
+	var tfs vfs.FS
+	var s *sys.System
+	var cleanup func()
+	var sdBoot *bootloader.SystemdBoot
+	BeforeEach(func() {
+		var err error
+		tfs, cleanup, err = sysmock.TestFS(map[string]any{
+			"/target/dir/usr/lib/systemd/boot/efi/systemd-bootx64.efi":     "x86_64 systemd-boot",
+			"/target/dir/etc/os-release":                                   "ID=opensuse-tumbleweed\nNAME=openSUSE Tumbleweed",
+			"/target/dir/usr/lib/modules/6.14.4-1-default/vmlinuz":         "6.14.4-1-default vmlinux",
+			"/target/dir/usr/lib/modules/6.14.4-1-default/.vmlinuz.hmac":   "6.14.4-1-default .vmlinux.hmac",
+			"/target/dir/usr/lib/modules/6.14.4-1-default/initrd":          "6.14.4-1-default initrd",
+			"/target/dir/boot/opensuse-tumbleweed/6.6.99-1-default/initrd": "6.6.99-1-default initrd",
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		s, err = sys.NewSystem(
+			sys.WithFS(tfs),
+			sys.WithLogger(log.New(log.WithDiscardAll())),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		sdBoot = bootloader.NewSystemdBoot(s)
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+	It("Installs systemd-boot, boot entries and loader configuration", func() {
+		err := sdBoot.Install("/target/dir", "/target/dir/boot", "EFI", "1", "snapshot1", "recovery cmdline")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(vfs.Exists(tfs, "/target/dir/boot/EFI/systemd/systemd-bootx64.efi")).To(BeTrue())
+		Expect(vfs.Exists(tfs, "/target/dir/boot/EFI/BOOT/BOOTX64.EFI")).To(BeTrue())
+		Expect(vfs.Exists(tfs, filepath.Join("/target/dir/boot", sdBoot.EFILoader()))).To(BeTrue())
+		Expect(vfs.Exists(tfs, "/target/dir/boot/opensuse-tumbleweed/6.14.4-1-default/vmlinuz")).To(BeTrue())
+		Expect(vfs.Exists(tfs, "/target/dir/boot/opensuse-tumbleweed/6.14.4-1-default/initrd")).To(BeTrue())
+
+		entry, err := tfs.ReadFile("/target/dir/boot/loader/entries/1.conf")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(strings.Split(string(entry), "\n")).To(ContainElements(
+			"title openSUSE Tumbleweed (1)",
+			"linux /opensuse-tumbleweed/6.14.4-1-default/vmlinuz",
+			"initrd /opensuse-tumbleweed/6.14.4-1-default/initrd",
+			"options snapshot1",
+		))
+
+		recovery, err := tfs.ReadFile("/target/dir/boot/loader/entries/recovery.conf")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(strings.Split(string(recovery), "\n")).To(ContainElement("options recovery cmdline"))
+
+		loaderConf, err := tfs.ReadFile("/target/dir/boot/loader/loader.conf")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(strings.Split(string(loaderConf), "\n")).To(ContainElement("default 1.conf"))
+	})
+	It("Fails if the systemd-boot EFI application is not found", func() {
+		Expect(tfs.Remove("/target/dir/usr/lib/systemd/boot/efi/systemd-bootx64.efi")).To(Succeed())
+		err := sdBoot.Install("/target/dir", "/target/dir/boot", "EFI", "1", "snapshot1", "")
+		Expect(err).To(MatchError(ContainSubstring("systemd-boot EFI application")))
+	})
+	It("Keeps the recovery entry and sets the new entry as default on upgrades", func() {
+		Expect(sdBoot.Install("/target/dir", "/target/dir/boot", "EFI", "1", "snapshot1", "recovery1")).To(Succeed())
+		Expect(sdBoot.Install("/target/dir", "/target/dir/boot", "EFI", "2", "snapshot2", "recovery2")).To(Succeed())
+
+		recovery, err := tfs.ReadFile("/target/dir/boot/loader/entries/recovery.conf")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(strings.Split(string(recovery), "\n")).To(ContainElement("options recovery1"))
+
+		loaderConf, err := tfs.ReadFile("/target/dir/boot/loader/loader.conf")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(strings.Split(string(loaderConf), "\n")).To(ContainElement("default 2.conf"))
+
+		Expect(sdBoot.SetDefaultEntry("/target/dir/boot", "1")).To(Succeed())
+		loaderConf, err = tfs.ReadFile("/target/dir/boot/loader/loader.conf")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(strings.Split(string(loaderConf), "\n")).To(ContainElement("default 1.conf"))
+		Expect(sdBoot.DefaultEntry("/target/dir/boot")).To(Equal("1"))
+
+		Expect(sdBoot.SetDefaultEntry("/target/dir/boot", "3")).To(MatchError("boot entry '3' not found"))
+	})
+	It("Installs systemd-boot for live media", func() {
+		Expect(sdBoot.InstallLive("/target/dir", "/iso/dir", "live cmdline")).To(Succeed())
+
+		Expect(vfs.Exists(tfs, "/iso/dir/EFI/BOOT/BOOTX64.EFI")).To(BeTrue())
+		Expect(vfs.Exists(tfs, "/iso/dir/EFI/opensuse-tumbleweed/6.14.4-1-default/vmlinuz")).To(BeTrue())
+
+		entry, err := tfs.ReadFile("/iso/dir/loader/entries/live.conf")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(strings.Split(string(entry), "\n")).To(ContainElements(
+			"linux /EFI/opensuse-tumbleweed/6.14.4-1-default/vmlinuz", "options live cmdline",
+		))
+	})
+	It("Prunes old snapshots and kernels", func() {
+		Expect(sdBoot.Install("/target/dir", "/target/dir/boot", "EFI", "1", "snapshot1", "recoverycmd")).To(Succeed())
+		Expect(sdBoot.Install("/target/dir", "/target/dir/boot", "EFI", "2", "snapshot2", "recoverycmd")).To(Succeed())
+
+		Expect(sdBoot.Prune("/target/dir", "/target/dir/boot", []int{2})).To(Succeed())
+
+		Expect(vfs.Exists(tfs, "/target/dir/boot/loader/entries/1.conf")).To(BeFalse())
+		Expect(vfs.Exists(tfs, "/target/dir/boot/loader/entries/2.conf")).To(BeTrue())
+		Expect(vfs.Exists(tfs, "/target/dir/boot/loader/entries/recovery.conf")).To(BeTrue())
+
+		// Older kernels are removed, the kernel in use is kept
+		Expect(vfs.Exists(tfs, "/target/dir/boot/opensuse-tumbleweed/6.6.99-1-default")).To(BeFalse())
+		Expect(vfs.Exists(tfs, "/target/dir/boot/opensuse-tumbleweed/6.14.4-1-default/vmlinuz")).To(BeTrue())
+	})
+})
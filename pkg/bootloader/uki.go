@@ -0,0 +1,223 @@
+/*
+Copyright © 2022-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bootloader
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+const ukiSuffix = ".efi"
+
+// UKI installs systemd-boot and a Unified Kernel Image per snapshot to the ESP. UKIs are
+// placed in the EFI/Linux directory, where systemd-boot discovers them with no additional
+// boot entries. If a key and certificate pair is provided UKIs and systemd-boot are signed
+// for Secure Boot.
+type UKI struct {
+	*SystemdBoot
+	signingKey   string
+	signingCert  string
+	pcrKey       string
+	pcrPublicKey string
+}
+
+type UKIOpt func(*UKI)
+
+// WithPCRSigning sets the key pair to sign the expected PCR 11 values of each UKI. TPM2 keys
+// bound to the public key remain valid across upgrades as long as UKIs are signed with the
+// same key.
+func WithPCRSigning(key, publicKey string) UKIOpt {
+	return func(u *UKI) {
+		u.pcrKey = key
+		u.pcrPublicKey = publicKey
+	}
+}
+
+func NewUKI(s *sys.System, signingKey, signingCert string, opts ...UKIOpt) *UKI {
+	uki := &UKI{SystemdBoot: NewSystemdBoot(s), signingKey: signingKey, signingCert: signingCert}
+	for _, o := range opts {
+		o(uki)
+	}
+	return uki
+}
+
+// Install installs systemd-boot and the UKI of the given entry ID to the specified ESP. The new
+// UKI is set as the default boot entry.
+func (u *UKI) Install(rootPath, espDir, _, entryID, kernelCmdline, recKernelCmdline string) error {
+	err := u.installEFI(rootPath, espDir)
+	if err != nil {
+		return fmt.Errorf("installing systemd-boot EFI apps: %w", err)
+	}
+
+	if u.signingKey != "" {
+		efiApp, defaultApp := systemdBootEfiFileName(u.s.Platform())
+		for _, app := range []string{
+			filepath.Join(espDir, "EFI", "systemd", efiApp),
+			filepath.Join(espDir, "EFI", "BOOT", defaultApp),
+		} {
+			err = u.sign(app)
+			if err != nil {
+				return fmt.Errorf("signing systemd-boot: %w", err)
+			}
+		}
+	}
+
+	err = u.buildUKI(rootPath, espDir, entryID, kernelCmdline)
+	if err != nil {
+		return fmt.Errorf("building unified kernel image: %w", err)
+	}
+
+	// do not update recovery UKI if already exists
+	if ok, _ := vfs.Exists(u.s.FS(), u.ukiPath(espDir, RecoveryBootID)); recKernelCmdline != "" && !ok {
+		err = u.buildUKI(rootPath, espDir, RecoveryBootID, recKernelCmdline)
+		if err != nil {
+			return fmt.Errorf("building recovery unified kernel image: %w", err)
+		}
+	}
+
+	err = u.writeLoaderConf(espDir, entryID+ukiSuffix)
+	if err != nil {
+		return fmt.Errorf("writing loader configuration: %w", err)
+	}
+
+	return nil
+}
+
+// Prune removes the UKIs of the snapshots not included in keepSnapshotIDs.
+func (u *UKI) Prune(_, espDir string, keepSnapshotIDs []int) error {
+	u.s.Logger().Info("Pruning old unified kernel images in %s", espDir)
+
+	ukiDir := filepath.Join(espDir, "EFI", "Linux")
+	dirEntries, err := u.s.FS().ReadDir(ukiDir)
+	if err != nil {
+		return fmt.Errorf("reading unified kernel images: %w", err)
+	}
+
+	for _, dirEntry := range dirEntries {
+		name := dirEntry.Name()
+		if dirEntry.IsDir() || !strings.HasSuffix(name, ukiSuffix) {
+			continue
+		}
+
+		snapshotID, err := strconv.Atoi(strings.TrimSuffix(name, ukiSuffix))
+		if err != nil || slices.Contains(keepSnapshotIDs, snapshotID) {
+			continue
+		}
+
+		err = u.s.FS().Remove(filepath.Join(ukiDir, name))
+		if err != nil {
+			return fmt.Errorf("failed removing unified kernel image '%s': %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// SetDefaultEntry sets the UKI of the given ID as the default boot entry.
+func (u *UKI) SetDefaultEntry(espDir, entryID string) error {
+	u.s.Logger().Info("Setting boot entry '%s' as default", entryID)
+	if ok, _ := vfs.Exists(u.s.FS(), u.ukiPath(espDir, entryID)); !ok {
+		return fmt.Errorf("boot entry '%s' not found", entryID)
+	}
+	return u.writeLoaderConf(espDir, entryID+ukiSuffix)
+}
+
+// DefaultEntry returns the ID of the UKI booted by default. Returns an empty string if there
+// is no default entry yet.
+func (u *UKI) DefaultEntry(espDir string) (string, error) {
+	entry, err := u.readLoaderConfDefault(espDir)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(entry, ukiSuffix), nil
+}
+
+// buildUKI assembles the kernel, initrd, command line and os-release of the given root into a UKI
+func (u *UKI) buildUKI(rootPath, espDir, entryID, cmdline string) error {
+	u.s.Logger().Info("Building unified kernel image for boot entry '%s'", entryID)
+
+	kernel, kernelVersion, err := vfs.FindKernel(u.s.FS(), rootPath)
+	if err != nil {
+		return fmt.Errorf("finding kernel: %w", err)
+	}
+
+	initrd := filepath.Join(filepath.Dir(kernel), Initrd)
+	if exists, _ := vfs.Exists(u.s.FS(), initrd); !exists {
+		return fmt.Errorf("initrd not found")
+	}
+
+	target := u.ukiPath(espDir, entryID)
+	err = vfs.MkdirAll(u.s.FS(), filepath.Dir(target), vfs.DirPerm)
+	if err != nil {
+		return fmt.Errorf("creating dir '%s': %w", filepath.Dir(target), err)
+	}
+
+	args := []string{
+		"build",
+		fmt.Sprintf("--linux=%s", kernel),
+		fmt.Sprintf("--initrd=%s", initrd),
+		fmt.Sprintf("--cmdline=%s", cmdline),
+		fmt.Sprintf("--os-release=@%s", filepath.Join(rootPath, OsReleasePath)),
+		fmt.Sprintf("--uname=%s", kernelVersion),
+		fmt.Sprintf("--output=%s", target),
+	}
+	if u.signingKey != "" {
+		args = append(args,
+			fmt.Sprintf("--secureboot-private-key=%s", u.signingKey),
+			fmt.Sprintf("--secureboot-certificate=%s", u.signingCert),
+		)
+	}
+	if u.pcrKey != "" {
+		args = append(args,
+			fmt.Sprintf("--pcr-private-key=%s", u.pcrKey),
+			fmt.Sprintf("--pcr-public-key=%s", u.pcrPublicKey),
+		)
+	}
+
+	stdOut, err := u.s.Runner().Run("ukify", args...)
+	u.s.Logger().Debug("ukify stdout: %s", string(stdOut))
+	if err != nil {
+		return fmt.Errorf("running ukify: %w", err)
+	}
+	return nil
+}
+
+// sign signs the given EFI application in place
+func (u *UKI) sign(efiApp string) error {
+	stdOut, err := u.s.Runner().Run("sbsign", "--key", u.signingKey, "--cert", u.signingCert, "--output", efiApp, efiApp)
+	u.s.Logger().Debug("sbsign stdout: %s", string(stdOut))
+	if err != nil {
+		return fmt.Errorf("signing '%s': %w", efiApp, err)
+	}
+	return nil
+}
+
+func (u *UKI) ukiPath(espDir, entryID string) string {
+	return UKIPath(espDir, entryID)
+}
+
+// UKIPath returns the path of the UKI of the given entry ID within the given ESP
+func UKIPath(espDir, entryID string) string {
+	return filepath.Join(espDir, "EFI", "Linux", entryID+ukiSuffix)
+}
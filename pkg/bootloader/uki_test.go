@@ -0,0 +1,171 @@
+/*
+Copyright © 2022-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bootloader_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/bootloader"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+var _ = Describe("UKI tests", Label("bootloader", "uki"), func() {
+	var tfs vfs.FS
+	var s *sys.System
+	var cleanup func()
+	var runner *sysmock.Runner
+	BeforeEach(func() {
+		var err error
+		tfs, cleanup, err = sysmock.TestFS(map[string]any{
+			"/target/dir/usr/lib/systemd/boot/efi/systemd-bootx64.efi": "x86_64 systemd-boot",
+			"/target/dir/etc/os-release":                               "ID=opensuse-tumbleweed\nNAME=openSUSE Tumbleweed",
+			"/target/dir/usr/lib/modules/6.14.4-1-default/vmlinuz":     "6.14.4-1-default vmlinux",
+			"/target/dir/usr/lib/modules/6.14.4-1-default/initrd":      "6.14.4-1-default initrd",
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		runner = sysmock.NewRunner()
+		runner.SideEffect = func(command string, args ...string) ([]byte, error) {
+			if command == "ukify" {
+				// Create the output UKI
+				for _, arg := range args {
+					if out, ok := strings.CutPrefix(arg, "--output="); ok {
+						return nil, tfs.WriteFile(out, []byte("uki"), vfs.FilePerm)
+					}
+				}
+			}
+			return nil, nil
+		}
+		s, err = sys.NewSystem(
+			sys.WithFS(tfs),
+			sys.WithRunner(runner),
+			sys.WithLogger(log.New(log.WithDiscardAll())),
+		)
+		Expect(err).NotTo(HaveOccurred())
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+	It("Builds unsigned UKIs and sets the default one", func() {
+		uki := bootloader.NewUKI(s, "", "")
+		err := uki.Install("/target/dir", "/target/dir/boot", "EFI", "1", "snapshot1", "recovery cmdline")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(runner.CmdsMatch([][]string{
+			{
+				"ukify", "build", "--linux=/target/dir/usr/lib/modules/6.14.4-1-default/vmlinuz",
+				"--initrd=/target/dir/usr/lib/modules/6.14.4-1-default/initrd", "--cmdline=snapshot1",
+				"--os-release=@/target/dir/etc/os-release", "--uname=6.14.4-1-default",
+				"--output=/target/dir/boot/EFI/Linux/1.efi",
+			}, {
+				"ukify", "build", "--linux=/target/dir/usr/lib/modules/6.14.4-1-default/vmlinuz",
+				"--initrd=/target/dir/usr/lib/modules/6.14.4-1-default/initrd", "--cmdline=recovery cmdline",
+				"--os-release=@/target/dir/etc/os-release", "--uname=6.14.4-1-default",
+				"--output=/target/dir/boot/EFI/Linux/recovery.efi",
+			},
+		})).To(Succeed())
+
+		// No loose kernels are copied to the ESP
+		Expect(vfs.Exists(tfs, "/target/dir/boot/opensuse-tumbleweed")).To(BeFalse())
+		Expect(vfs.Exists(tfs, "/target/dir/boot/EFI/BOOT/BOOTX64.EFI")).To(BeTrue())
+
+		loaderConf, err := tfs.ReadFile("/target/dir/boot/loader/loader.conf")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(strings.Split(string(loaderConf), "\n")).To(ContainElement("default 1.efi"))
+	})
+	It("Signs systemd-boot and UKIs with the given key pair", func() {
+		uki := bootloader.NewUKI(s, "/keys/db.key", "/keys/db.crt")
+		err := uki.Install("/target/dir", "/target/dir/boot", "EFI", "1", "snapshot1", "")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(runner.IncludesCmds([][]string{
+			{
+				"sbsign", "--key", "/keys/db.key", "--cert", "/keys/db.crt", "--output",
+				"/target/dir/boot/EFI/BOOT/BOOTX64.EFI", "/target/dir/boot/EFI/BOOT/BOOTX64.EFI",
+			}, {
+				"sbsign", "--key", "/keys/db.key", "--cert", "/keys/db.crt", "--output",
+				"/target/dir/boot/EFI/systemd/systemd-bootx64.efi", "/target/dir/boot/EFI/systemd/systemd-bootx64.efi",
+			},
+		})).To(Succeed())
+
+		ukifyCmd := []string{}
+		for _, cmd := range runner.GetCmds() {
+			if cmd[0] == "ukify" {
+				ukifyCmd = cmd
+			}
+		}
+		Expect(ukifyCmd).To(ContainElements(
+			"--secureboot-private-key=/keys/db.key", "--secureboot-certificate=/keys/db.crt",
+		))
+		Expect(ukifyCmd).NotTo(ContainElement(ContainSubstring("--pcr-private-key")))
+	})
+	It("Signs the PCR policy of UKIs with the given key pair", func() {
+		uki := bootloader.NewUKI(s, "", "", bootloader.WithPCRSigning("/keys/pcr.key", "/keys/pcr.pub"))
+		err := uki.Install("/target/dir", "/target/dir/boot", "EFI", "1", "snapshot1", "")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(runner.MatchMilestones([][]string{{
+			"ukify", "build", "--linux=/target/dir/usr/lib/modules/6.14.4-1-default/vmlinuz",
+		}})).To(Succeed())
+		for _, cmd := range runner.GetCmds() {
+			if cmd[0] == "ukify" {
+				Expect(cmd).To(ContainElements("--pcr-private-key=/keys/pcr.key", "--pcr-public-key=/keys/pcr.pub"))
+			}
+		}
+	})
+	It("Fails if ukify fails", func() {
+		runner.SideEffect = func(command string, _ ...string) ([]byte, error) {
+			if command == "ukify" {
+				return nil, fmt.Errorf("ukify failed")
+			}
+			return nil, nil
+		}
+		uki := bootloader.NewUKI(s, "", "")
+		err := uki.Install("/target/dir", "/target/dir/boot", "EFI", "1", "snapshot1", "")
+		Expect(err).To(MatchError(ContainSubstring("building unified kernel image")))
+	})
+	It("Sets the default UKI and prunes old ones", func() {
+		uki := bootloader.NewUKI(s, "", "")
+		for _, id := range []string{"1", "2", "3"} {
+			err := uki.Install("/target/dir", "/target/dir/boot", "EFI", id, "snapshot"+id, "recovery")
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		Expect(uki.SetDefaultEntry("/target/dir/boot", "2")).To(Succeed())
+		loaderConf, err := tfs.ReadFile("/target/dir/boot/loader/loader.conf")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(strings.Split(string(loaderConf), "\n")).To(ContainElement("default 2.efi"))
+		Expect(uki.DefaultEntry("/target/dir/boot")).To(Equal("2"))
+		Expect(uki.SetDefaultEntry("/target/dir/boot", "4")).To(MatchError("boot entry '4' not found"))
+
+		Expect(uki.Prune("/target/dir", "/target/dir/boot", []int{2, 3})).To(Succeed())
+		ukiDir := "/target/dir/boot/EFI/Linux"
+		Expect(vfs.Exists(tfs, filepath.Join(ukiDir, "1.efi"))).To(BeFalse())
+		Expect(vfs.Exists(tfs, filepath.Join(ukiDir, "2.efi"))).To(BeTrue())
+		Expect(vfs.Exists(tfs, filepath.Join(ukiDir, "3.efi"))).To(BeTrue())
+		Expect(vfs.Exists(tfs, filepath.Join(ukiDir, "recovery.efi"))).To(BeTrue())
+	})
+})
@@ -20,6 +20,8 @@ package btrfs
 import (
 	"fmt"
 	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/suse/elemental/v3/pkg/sys"
 	"github.com/suse/elemental/v3/pkg/sys/vfs"
@@ -134,3 +136,53 @@ func SetDefaultSubvolume(s *sys.System, path string) error {
 	}
 	return nil
 }
+
+// FreeSpace returns the estimated free space in bytes of the btrfs filesystem, path is
+// usually the mountpoint of the btrfs filesystem
+func FreeSpace(s *sys.System, path string) (uint64, error) {
+	cmdOut, err := s.Runner().Run("btrfs", "filesystem", "usage", "-b", path)
+	if err != nil {
+		return 0, fmt.Errorf("reading btrfs filesystem usage of %s: %s: %w", path, string(cmdOut), err)
+	}
+	for line := range strings.Lines(string(cmdOut)) {
+		value, found := strings.CutPrefix(strings.TrimSpace(line), "Free (estimated):")
+		if !found {
+			continue
+		}
+		fields := strings.Fields(value)
+		if len(fields) == 0 {
+			break
+		}
+		return strconv.ParseUint(fields[0], 10, 64)
+	}
+	return 0, fmt.Errorf("free space not found in btrfs filesystem usage of %s", path)
+}
+
+// QuotaGroupUsage returns the referenced bytes and the referenced bytes limit of the given
+// quota group. The limit is zero if the quota group has no limit set. Path is usually the
+// mountpoint of the btrfs filesystem
+func QuotaGroupUsage(s *sys.System, path, qGroup string) (used uint64, limit uint64, err error) {
+	cmdOut, err := s.Runner().Run("btrfs", "qgroup", "show", "-re", "--raw", path)
+	if err != nil {
+		return 0, 0, fmt.Errorf("reading quota groups of %s: %s: %w", path, string(cmdOut), err)
+	}
+	for line := range strings.Lines(string(cmdOut)) {
+		fields := strings.Fields(line)
+		if len(fields) < 4 || fields[0] != qGroup {
+			continue
+		}
+		used, err = strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("parsing referenced size of quota group %s: %w", qGroup, err)
+		}
+		if fields[3] == "none" {
+			return used, 0, nil
+		}
+		limit, err = strconv.ParseUint(fields[3], 10, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("parsing referenced limit of quota group %s: %w", qGroup, err)
+		}
+		return used, limit, nil
+	}
+	return 0, 0, fmt.Errorf("quota group %s not found in %s", qGroup, path)
+}
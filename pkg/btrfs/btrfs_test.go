@@ -103,4 +103,34 @@ var _ = Describe("DirectoryUnpacker", Label("directory"), func() {
 			{"btrfs", "subvolume", "set-default", "/path/to/mountpoint/@"},
 		})).To(Succeed())
 	})
+	It("reads the estimated free space", func() {
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			return []byte("Overall:\n    Device size:\t\t10737418240\n    Free (estimated):\t\t5368709120\t(min: 2684354560)\n"), nil
+		}
+		free, err := btrfs.FreeSpace(s, "/path/to/mountpoint")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(free).To(Equal(uint64(5368709120)))
+		Expect(runner.IncludesCmds([][]string{
+			{"btrfs", "filesystem", "usage", "-b", "/path/to/mountpoint"},
+		})).To(Succeed())
+	})
+	It("reads the quota group usage and limit", func() {
+		output := "qgroupid rfer excl max_rfer max_excl\n-------- ---- ---- -------- --------\n" +
+			"0/5 16384 16384 none none\n1/0 4096000 1024000 8192000 none\n1/1 4096 4096 none none\n"
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			return []byte(output), nil
+		}
+		used, limit, err := btrfs.QuotaGroupUsage(s, "/path/to/mountpoint", "1/0")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(used).To(Equal(uint64(4096000)))
+		Expect(limit).To(Equal(uint64(8192000)))
+
+		used, limit, err = btrfs.QuotaGroupUsage(s, "/path/to/mountpoint", "1/1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(used).To(Equal(uint64(4096)))
+		Expect(limit).To(BeZero())
+
+		_, _, err = btrfs.QuotaGroupUsage(s, "/path/to/mountpoint", "2/0")
+		Expect(err).To(MatchError("quota group 2/0 not found in /path/to/mountpoint"))
+	})
 })
@@ -0,0 +1,357 @@
+/*
+Copyright © 2022-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bundle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	containerregistry "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/suse/elemental/v3/pkg/archive"
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+const (
+	// SourceAnnotation holds the reference of images, the URL of files or the
+	// repository, name and version of Helm charts stored in the bundle
+	SourceAnnotation = "com.suse.elemental.bundle.source"
+	// KindAnnotation holds the kind of content of each bundle entry
+	KindAnnotation = "com.suse.elemental.bundle.kind"
+
+	FileMediaType     = "application/vnd.suse.elemental.bundle.file.v1"
+	ManifestMediaType = "application/vnd.suse.elemental.bundle.manifest.v1"
+	ChartMediaType    = "application/vnd.cncf.helm.chart.content.v1.tar+gzip"
+
+	kindImage     = "image"
+	kindFile      = "file"
+	kindChart     = "chart"
+	kindManifest  = "manifest"
+	kindSignature = "signature"
+
+	manifestTitle = "manifest.json"
+
+	tarSuffix = ".tar"
+)
+
+var ErrNotFound = errors.New("not found in bundle")
+
+// Bundle is an OCI image layout including all the images, files and Helm charts
+// required to customize an image without registry or network access. Bundles are
+// either stored as a directory or as a .tar tarball of that directory.
+type Bundle struct {
+	s       *sys.System
+	ctx     context.Context
+	path    layout.Path
+	tarball string
+	tmpDir  string
+}
+
+type Opt func(*Bundle)
+
+func WithContext(ctx context.Context) Opt {
+	return func(b *Bundle) {
+		b.ctx = ctx
+	}
+}
+
+// Create creates a new empty bundle at the given path. If the path has the .tar suffix
+// the bundle is written to a tarball on Close.
+func Create(s *sys.System, path string, opts ...Opt) (*Bundle, error) {
+	b := newBundle(s, opts...)
+
+	dir := path
+	if strings.HasSuffix(path, tarSuffix) {
+		var err error
+		if dir, err = b.tempDir(); err != nil {
+			return nil, err
+		}
+		b.tarball = path
+	} else if err := vfs.MkdirAll(s.FS(), dir, vfs.DirPerm); err != nil {
+		return nil, fmt.Errorf("creating bundle directory '%s': %w", dir, err)
+	}
+
+	rawDir, err := s.FS().RawPath(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	b.path, err = layout.Write(rawDir, empty.Index)
+	if err != nil {
+		_ = b.cleanup()
+		return nil, fmt.Errorf("initializing bundle at '%s': %w", path, err)
+	}
+	return b, nil
+}
+
+// Open opens an existing bundle at the given path, either a directory or a tarball.
+// Tarballs are extracted to a temporary directory until the bundle is closed. The content
+// of all the bundle blobs is checked against their digest.
+func Open(s *sys.System, path string, opts ...Opt) (*Bundle, error) {
+	b := newBundle(s, opts...)
+
+	dir := path
+	if isDir, _ := vfs.IsDir(s.FS(), path); !isDir {
+		var err error
+		if dir, err = b.tempDir(); err != nil {
+			return nil, err
+		}
+		if err = archive.ExtractTarball(b.ctx, s, path, dir); err != nil {
+			_ = b.cleanup()
+			return nil, fmt.Errorf("extracting bundle tarball '%s': %w", path, err)
+		}
+	}
+
+	rawDir, err := s.FS().RawPath(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	b.path, err = layout.FromPath(rawDir)
+	if err != nil {
+		_ = b.cleanup()
+		return nil, fmt.Errorf("opening bundle at '%s': %w", path, err)
+	}
+
+	if err = b.verifyBlobs(dir); err != nil {
+		_ = b.cleanup()
+		return nil, fmt.Errorf("verifying bundle at '%s': %w", path, err)
+	}
+	return b, nil
+}
+
+// Close writes the bundle tarball, if any, and releases the temporary resources of the bundle
+func (b *Bundle) Close() error {
+	if b.tarball != "" {
+		if err := archive.CreateTar(b.ctx, b.s, b.tmpDir, b.tarball); err != nil {
+			_ = b.cleanup()
+			return fmt.Errorf("writing bundle tarball: %w", err)
+		}
+		b.tarball = ""
+	}
+	return b.cleanup()
+}
+
+// Discard releases the temporary resources of the bundle without writing the bundle tarball, if any
+func (b *Bundle) Discard() error {
+	b.tarball = ""
+	return b.cleanup()
+}
+
+// Image returns the image of the given reference stored in the bundle. Digest references
+// matching a recorded manifest, as the ones of verified images, return the image stored for
+// that manifest.
+func (b *Bundle) Image(ref string) (containerregistry.Image, error) {
+	if digest, err := name.NewDigest(ref); err == nil {
+		img, err := b.verifiedImage(digest)
+		if !errors.Is(err, ErrNotFound) {
+			return img, err
+		}
+	}
+
+	desc, err := b.find(kindImage, imageKey(ref))
+	if err != nil {
+		return nil, fmt.Errorf("image '%s' %w", ref, err)
+	}
+	return b.path.Image(desc.Digest)
+}
+
+// HasImage returns true if the image of the given reference is stored in the bundle
+func (b *Bundle) HasImage(ref string) bool {
+	_, err := b.find(kindImage, imageKey(ref))
+	return err == nil
+}
+
+// AddImage stores the given image in the bundle under the given reference
+func (b *Bundle) AddImage(ref string, img containerregistry.Image) error {
+	return b.add(kindImage, imageKey(ref), img, ocispec.AnnotationRefName)
+}
+
+// File returns the contents of the file downloaded from the given URL stored in the bundle
+func (b *Bundle) File(url string) ([]byte, error) {
+	data, err := b.content(kindFile, url)
+	if err != nil {
+		return nil, fmt.Errorf("file '%s' %w", url, err)
+	}
+	return data, nil
+}
+
+// AddFile stores the given contents of the file downloaded from the given URL in the bundle
+func (b *Bundle) AddFile(url string, data []byte) error {
+	img, err := contentImage(data, FileMediaType, filepath.Base(url))
+	if err != nil {
+		return err
+	}
+	return b.add(kindFile, url, img)
+}
+
+// DownloadFile writes the contents of the file downloaded from the given URL to the given path.
+// This is a drop-in replacement of http.DownloadFile resolving files exclusively from the bundle.
+func (b *Bundle) DownloadFile(_ context.Context, fs vfs.FS, url, path string) error {
+	data, err := b.File(url)
+	if err != nil {
+		return err
+	}
+	return fs.WriteFile(path, data, vfs.FilePerm)
+}
+
+// Chart returns the tarball of the given Helm chart version and repository stored in the bundle
+func (b *Bundle) Chart(repository, chart, version string) ([]byte, error) {
+	data, err := b.content(kindChart, chartKey(repository, chart, version))
+	if err != nil {
+		return nil, fmt.Errorf("helm chart '%s' version '%s' %w", chart, version, err)
+	}
+	return data, nil
+}
+
+// AddChart stores the tarball of the given Helm chart version and repository in the bundle
+func (b *Bundle) AddChart(repository, chart, version string, data []byte) error {
+	img, err := contentImage(data, ChartMediaType, fmt.Sprintf("%s-%s.tgz", chart, version))
+	if err != nil {
+		return err
+	}
+	return b.add(kindChart, chartKey(repository, chart, version), img)
+}
+
+func newBundle(s *sys.System, opts ...Opt) *Bundle {
+	b := &Bundle{s: s, ctx: context.Background()}
+	for _, o := range opts {
+		o(b)
+	}
+	return b
+}
+
+func (b *Bundle) tempDir() (string, error) {
+	dir, err := vfs.TempDir(b.s.FS(), "", "elemental-bundle-")
+	if err != nil {
+		return "", fmt.Errorf("creating bundle temporary directory: %w", err)
+	}
+	b.tmpDir = dir
+	return dir, nil
+}
+
+func (b *Bundle) cleanup() error {
+	if b.tmpDir == "" {
+		return nil
+	}
+	err := vfs.ForceRemoveAll(b.s.FS(), b.tmpDir)
+	b.tmpDir = ""
+	return err
+}
+
+// add appends the image to the bundle index unless an entry of the same kind and source exists
+func (b *Bundle) add(kind, source string, img containerregistry.Image, extraAnnotations ...string) error {
+	if _, err := b.find(kind, source); err == nil {
+		return nil
+	}
+
+	annotations := map[string]string{
+		KindAnnotation:   kind,
+		SourceAnnotation: source,
+	}
+	for _, a := range extraAnnotations {
+		annotations[a] = source
+	}
+
+	if err := b.path.AppendImage(img, layout.WithAnnotations(annotations)); err != nil {
+		return fmt.Errorf("adding %s '%s' to bundle: %w", kind, source, err)
+	}
+	return nil
+}
+
+func (b *Bundle) find(kind, source string) (*containerregistry.Descriptor, error) {
+	index, err := b.path.ImageIndex()
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := index.IndexManifest()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, desc := range manifest.Manifests {
+		if desc.Annotations[KindAnnotation] == kind && desc.Annotations[SourceAnnotation] == source {
+			return &desc, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// content returns the contents of the single layer of a file or chart entry
+func (b *Bundle) content(kind, source string) ([]byte, error) {
+	desc, err := b.find(kind, source)
+	if err != nil {
+		return nil, err
+	}
+
+	img, err := b.path.Image(desc.Digest)
+	if err != nil {
+		return nil, err
+	}
+	layers, err := img.Layers()
+	if err != nil {
+		return nil, err
+	}
+	if len(layers) != 1 {
+		return nil, fmt.Errorf("unexpected number of layers in bundle entry '%s': %d", source, len(layers))
+	}
+
+	reader, err := layers[0].Compressed()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = reader.Close() }()
+
+	return io.ReadAll(reader)
+}
+
+// contentImage wraps the given data in a single layer OCI artifact
+func contentImage(data []byte, mediaType, title string) (containerregistry.Image, error) {
+	return mutate.Append(
+		mutate.MediaType(empty.Image, types.OCIManifestSchema1),
+		mutate.Addendum{
+			Layer:       static.NewLayer(data, types.MediaType(mediaType)),
+			Annotations: map[string]string{ocispec.AnnotationTitle: title},
+		},
+	)
+}
+
+// imageKey normalizes image references so equivalent references match the same bundle entry
+func imageKey(ref string) string {
+	parsed, err := name.ParseReference(ref)
+	if err != nil {
+		return ref
+	}
+	return parsed.Name()
+}
+
+func chartKey(repository, chart, version string) string {
+	return fmt.Sprintf("%s/%s:%s", strings.TrimSuffix(repository, "/"), chart, version)
+}
@@ -0,0 +1,288 @@
+/*
+Copyright © 2022-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bundle_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io"
+	stdlog "log"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	containerregistry "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+
+	"github.com/suse/elemental/v3/pkg/bundle"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/signature"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+func TestBundleSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Bundle test suite")
+}
+
+var _ = Describe("Bundle", Label("bundle"), func() {
+	var s *sys.System
+	var tfs vfs.FS
+	var cleanup func()
+
+	BeforeEach(func() {
+		var err error
+		tfs, cleanup, err = sysmock.TestFS(nil)
+		Expect(err).NotTo(HaveOccurred())
+		s, err = sys.NewSystem(sys.WithFS(tfs), sys.WithLogger(log.New(log.WithBuffer(&bytes.Buffer{}))))
+		Expect(err).NotTo(HaveOccurred())
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+
+	populate := func(path string) {
+		b, err := bundle.Create(s, path)
+		Expect(err).NotTo(HaveOccurred())
+
+		layer, err := crane.Layer(map[string][]byte{"etc/os-release": []byte("VERSION_ID=1")})
+		Expect(err).NotTo(HaveOccurred())
+		img, err := mutate.AppendLayers(empty.Image, layer)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(b.AddImage("registry.org/os:1.0", img)).To(Succeed())
+		Expect(b.AddImage("registry.org/os:1.0", img)).To(Succeed())
+		Expect(b.AddFile("https://example.com/manifest.yaml", []byte("kind: ConfigMap"))).To(Succeed())
+		Expect(b.AddChart("https://charts.example.com/", "metallb", "0.15.2", []byte("chart"))).To(Succeed())
+		Expect(b.Close()).To(Succeed())
+	}
+
+	check := func(path string) {
+		b, err := bundle.Open(s, path, bundle.WithContext(context.Background()))
+		Expect(err).NotTo(HaveOccurred())
+		defer func() { Expect(b.Close()).To(Succeed()) }()
+
+		Expect(b.HasImage("registry.org/os:1.0")).To(BeTrue())
+		img, err := b.Image("registry.org/os:1.0")
+		Expect(err).NotTo(HaveOccurred())
+		layers, err := img.Layers()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(layers).To(HaveLen(1))
+
+		_, err = b.Image("registry.org/os:2.0")
+		Expect(err).To(MatchError(bundle.ErrNotFound))
+		Expect(err).To(MatchError(ContainSubstring("image 'registry.org/os:2.0' not found in bundle")))
+
+		Expect(b.DownloadFile(context.Background(), tfs, "https://example.com/manifest.yaml", "/manifest.yaml")).To(Succeed())
+		data, err := tfs.ReadFile("/manifest.yaml")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal("kind: ConfigMap"))
+		_, err = b.File("https://example.com/other.yaml")
+		Expect(err).To(MatchError(bundle.ErrNotFound))
+
+		data, err = b.Chart("https://charts.example.com", "metallb", "0.15.2")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal("chart"))
+		_, err = b.Chart("https://charts.example.com", "metallb", "0.15.0")
+		Expect(err).To(MatchError(bundle.ErrNotFound))
+	}
+
+	It("stores and finds content in a bundle directory", func() {
+		populate("/bundle")
+		ok, _ := vfs.Exists(tfs, "/bundle/index.json")
+		Expect(ok).To(BeTrue())
+		check("/bundle")
+	})
+
+	It("stores and finds content in a bundle tarball", func() {
+		populate("/bundle.tar")
+		ok, _ := vfs.IsDir(tfs, "/bundle.tar")
+		Expect(ok).To(BeFalse())
+		check("/bundle.tar")
+	})
+
+	It("does not write the tarball of discarded bundles", func() {
+		b, err := bundle.Create(s, "/bundle.tar")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(b.AddFile("https://example.com/manifest.yaml", []byte("kind: ConfigMap"))).To(Succeed())
+		Expect(b.Discard()).To(Succeed())
+		ok, _ := vfs.Exists(tfs, "/bundle.tar")
+		Expect(ok).To(BeFalse())
+	})
+
+	It("fails to open a bundle that does not exist", func() {
+		_, err := bundle.Open(s, "/missing.tar")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("fails to open a bundle including modified blobs", func() {
+		populate("/bundle")
+		b, err := bundle.Open(s, "/bundle")
+		Expect(err).NotTo(HaveOccurred())
+		img, err := b.Image("registry.org/os:1.0")
+		Expect(err).NotTo(HaveOccurred())
+		layers, err := img.Layers()
+		Expect(err).NotTo(HaveOccurred())
+		digest, err := layers[0].Digest()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(b.Close()).To(Succeed())
+
+		Expect(tfs.WriteFile("/bundle/blobs/sha256/"+digest.Hex, []byte("modified"), vfs.FilePerm)).To(Succeed())
+		_, err = bundle.Open(s, "/bundle")
+		Expect(err).To(MatchError(ContainSubstring("does not match its digest")))
+	})
+
+	Describe("signatures", func() {
+		var server *httptest.Server
+		var key *ecdsa.PrivateKey
+		var ref name.Reference
+		var img containerregistry.Image
+
+		BeforeEach(func() {
+			var err error
+			server = httptest.NewServer(registry.New(registry.Logger(stdlog.New(io.Discard, "", 0))))
+			u, err := url.Parse(server.URL)
+			Expect(err).NotTo(HaveOccurred())
+
+			key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			Expect(err).NotTo(HaveOccurred())
+			der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tfs.WriteFile("/edge.pub", pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), vfs.FilePerm)).To(Succeed())
+			Expect(tfs.WriteFile("/policy.yaml", fmt.Appendf(nil, "requirements:\n  - scope: %s/edge\n    publicKey: /edge.pub\n", u.Host), vfs.FilePerm)).To(Succeed())
+
+			ref, err = name.ParseReference(u.Host + "/edge/os:1.0")
+			Expect(err).NotTo(HaveOccurred())
+			img, err = random.Image(256, 1)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(remote.Write(ref, img)).To(Succeed())
+		})
+		AfterEach(func() {
+			server.Close()
+		})
+
+		sign := func() {
+			digest, err := img.Digest()
+			Expect(err).NotTo(HaveOccurred())
+			payload := fmt.Appendf(nil, `{"critical":{"image":{"docker-manifest-digest":"%s"},"type":"%s"}}`, digest, signature.SimpleSigningType)
+			hash := sha256.Sum256(payload)
+			sig, err := ecdsa.SignASN1(rand.Reader, key, hash[:])
+			Expect(err).NotTo(HaveOccurred())
+
+			sigImg, err := mutate.Append(empty.Image, mutate.Addendum{
+				Layer:       static.NewLayer(payload, "application/vnd.dev.cosign.simplesigning.v1+json"),
+				Annotations: map[string]string{signature.SignatureAnnotation: base64.StdEncoding.EncodeToString(sig)},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(remote.Write(ref.Context().Tag(fmt.Sprintf("%s-%s.sig", digest.Algorithm, digest.Hex)), sigImg)).To(Succeed())
+		}
+
+		export := func(record bool) {
+			b, err := bundle.Create(s, "/bundle")
+			Expect(err).NotTo(HaveOccurred())
+			if record {
+				v, err := signature.NewVerifier(s, "/policy.yaml", signature.WithRegistry(b.Recorder(signature.NewRemoteRegistry())))
+				Expect(err).NotTo(HaveOccurred())
+				_, err = v.Verify(context.Background(), ref.String(), false)
+				Expect(err).NotTo(HaveOccurred())
+			}
+			Expect(b.AddImage(ref.String(), img)).To(Succeed())
+			Expect(b.Close()).To(Succeed())
+		}
+
+		It("verifies images offline with the signatures recorded on export", func() {
+			sign()
+			export(true)
+			server.Close()
+
+			b, err := bundle.Open(s, "/bundle")
+			Expect(err).NotTo(HaveOccurred())
+			defer func() { Expect(b.Close()).To(Succeed()) }()
+
+			v, err := signature.NewVerifier(s, "/policy.yaml", signature.WithRegistry(b.Registry()))
+			Expect(err).NotTo(HaveOccurred())
+			digest, err := v.Verify(context.Background(), ref.String(), false)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(img.Digest()).To(HaveField("Hex", strings.TrimPrefix(digest, "sha256:")))
+
+			verified, err := b.Image(ref.Context().Digest(digest).String())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(verified.Digest()).To(HaveField("Hex", strings.TrimPrefix(digest, "sha256:")))
+		})
+
+		It("fails to return verified images not matching the recorded manifest", func() {
+			sign()
+			digest, err := img.Digest()
+			Expect(err).NotTo(HaveOccurred())
+
+			b, err := bundle.Create(s, "/bundle")
+			Expect(err).NotTo(HaveOccurred())
+			v, err := signature.NewVerifier(s, "/policy.yaml", signature.WithRegistry(b.Recorder(signature.NewRemoteRegistry())))
+			Expect(err).NotTo(HaveOccurred())
+			_, err = v.Verify(context.Background(), ref.String(), false)
+			Expect(err).NotTo(HaveOccurred())
+
+			// store a different image for the verified reference
+			other, err := random.Image(256, 1)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(b.AddImage(ref.String(), other)).To(Succeed())
+			Expect(b.Close()).To(Succeed())
+
+			b, err = bundle.Open(s, "/bundle")
+			Expect(err).NotTo(HaveOccurred())
+			defer func() { Expect(b.Close()).To(Succeed()) }()
+			_, err = b.Image(ref.Context().Digest(digest.String()).String())
+			Expect(err).To(MatchError(ContainSubstring("does not match the recorded manifest")))
+		})
+
+		It("fails to verify images without recorded signatures", func() {
+			export(false)
+
+			b, err := bundle.Open(s, "/bundle")
+			Expect(err).NotTo(HaveOccurred())
+			defer func() { Expect(b.Close()).To(Succeed()) }()
+
+			v, err := signature.NewVerifier(s, "/policy.yaml", signature.WithRegistry(b.Registry()))
+			Expect(err).NotTo(HaveOccurred())
+			_, err = v.Verify(context.Background(), ref.String(), false)
+			Expect(err).To(MatchError(bundle.ErrNotFound))
+		})
+	})
+})
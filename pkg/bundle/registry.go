@@ -0,0 +1,181 @@
+/*
+Copyright © 2022-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bundle
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	containerregistry "github.com/google/go-containerregistry/pkg/v1"
+
+	"github.com/suse/elemental/v3/pkg/signature"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+// Registry returns a signature registry serving the image manifests and the signatures and
+// attestations recorded in the bundle, so images are verified offline as they were on export
+func (b *Bundle) Registry() signature.Registry {
+	return bundleRegistry{b: b}
+}
+
+// Recorder returns a signature registry fetching from the given registry, which records in the
+// bundle all the image manifests, signatures and attestations it fetches
+func (b *Bundle) Recorder(r signature.Registry) signature.Registry {
+	return recorder{b: b, r: r}
+}
+
+type bundleRegistry struct {
+	b *Bundle
+}
+
+func (r bundleRegistry) Manifest(_ context.Context, ref name.Reference) ([]byte, error) {
+	data, err := r.b.content(kindManifest, ref.Name())
+	if err != nil {
+		return nil, fmt.Errorf("manifest of image '%s' %w", ref, err)
+	}
+	return data, nil
+}
+
+func (r bundleRegistry) Image(_ context.Context, ref name.Reference) (containerregistry.Image, error) {
+	desc, err := r.b.find(kindSignature, ref.Name())
+	if err != nil {
+		return nil, fmt.Errorf("'%s' %w", ref, err)
+	}
+	return r.b.path.Image(desc.Digest)
+}
+
+type recorder struct {
+	b *Bundle
+	r signature.Registry
+}
+
+func (r recorder) Manifest(ctx context.Context, ref name.Reference) ([]byte, error) {
+	data, err := r.r.Manifest(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	img, err := contentImage(data, ManifestMediaType, manifestTitle)
+	if err != nil {
+		return nil, err
+	}
+	if err = r.b.add(kindManifest, ref.Name(), img); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (r recorder) Image(ctx context.Context, ref name.Reference) (containerregistry.Image, error) {
+	img, err := r.r.Image(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = r.b.add(kindSignature, ref.Name(), img); err != nil {
+		return nil, err
+	}
+	return img, nil
+}
+
+// verifiedImage returns the image stored for the recorded manifest of the given digest. The stored
+// image is either the manifest itself or, for multi-platform images, one of the manifest index images.
+// Returns ErrNotFound if no manifest of the given digest is recorded.
+func (b *Bundle) verifiedImage(ref name.Digest) (containerregistry.Image, error) {
+	index, err := b.path.ImageIndex()
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := index.IndexManifest()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, desc := range manifest.Manifests {
+		source := desc.Annotations[SourceAnnotation]
+		if desc.Annotations[KindAnnotation] != kindManifest {
+			continue
+		}
+		if parsed, err := name.ParseReference(source); err != nil || parsed.Context().Name() != ref.Context().Name() {
+			continue
+		}
+
+		data, err := b.content(kindManifest, source)
+		if err != nil {
+			return nil, err
+		}
+		digest, _, err := containerregistry.SHA256(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		if digest.String() != ref.DigestStr() {
+			continue
+		}
+
+		imgDesc, err := b.find(kindImage, source)
+		if err != nil {
+			return nil, fmt.Errorf("image '%s' %w", source, err)
+		}
+		if imgDesc.Digest == digest {
+			return b.path.Image(imgDesc.Digest)
+		}
+		platforms, err := containerregistry.ParseIndexManifest(bytes.NewReader(data))
+		if err == nil && slices.ContainsFunc(platforms.Manifests, func(d containerregistry.Descriptor) bool {
+			return d.Digest == imgDesc.Digest
+		}) {
+			return b.path.Image(imgDesc.Digest)
+		}
+		return nil, fmt.Errorf("image '%s' does not match the recorded manifest %s", source, digest)
+	}
+	return nil, ErrNotFound
+}
+
+// verifyBlobs checks the content of all the blobs of the bundle at the given directory matches
+// their digest, so the content of the verified manifests can be trusted
+func (b *Bundle) verifyBlobs(dir string) error {
+	blobsDir := filepath.Join(dir, "blobs")
+	return vfs.WalkDirFs(b.s.FS(), blobsDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		expected := containerregistry.Hash{Algorithm: filepath.Base(filepath.Dir(path)), Hex: d.Name()}
+		if expected.Algorithm != "sha256" {
+			return fmt.Errorf("unsupported digest algorithm of blob '%s'", expected)
+		}
+
+		f, err := b.s.FS().Open(path)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = f.Close() }()
+
+		digest, _, err := containerregistry.SHA256(f)
+		if err != nil {
+			return fmt.Errorf("computing digest of blob '%s': %w", expected, err)
+		}
+		if digest != expected {
+			return fmt.Errorf("blob '%s' does not match its digest", expected)
+		}
+		return nil
+	})
+}
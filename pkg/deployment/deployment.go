@@ -63,14 +63,56 @@ const (
 	SystemMnt            = "/"
 	AllAvailableSize MiB = 0
 
+	BiosLabel     = "BIOS"
+	BiosSize  MiB = 2
+
 	ConfigLabel = "ignition"
 	ConfigMnt   = "/run/elemental/firstboot"
 
+	// LUKSKeyFile is the path of the LUKS2 key file within the config partition
+	LUKSKeyFile = "/luks.key"
+
 	deploymentFile = "/etc/elemental/deployment.yaml"
 
 	Unknown = "unknown"
 )
 
+const (
+	// KeyPassphrase unlocks the LUKS2 volume with a passphrase prompted at boot
+	KeyPassphrase = "passphrase"
+	// KeyFile unlocks the LUKS2 volume with a key file stored in the config partition
+	KeyFile = "keyfile"
+	// KeyTPM2 unlocks the LUKS2 volume with a key enrolled in the TPM2 device
+	KeyTPM2 = "tpm2"
+
+	// PCRStatic binds TPM2 keys to a fixed set of PCRs not altered by upgrades
+	PCRStatic = "static"
+	// PCRSigned binds TPM2 keys to the signed PCR policy embedded in each UKI
+	PCRSigned = "signed"
+	// PCRLock binds TPM2 keys to a systemd-pcrlock policy updated on each upgrade
+	PCRLock = "pcrlock"
+
+	luksMapperPrefix = "luks-"
+)
+
+const (
+	// MergePreferLocal keeps local changes over new OS image changes on conflicts
+	MergePreferLocal = "prefer-local"
+	// MergePreferImage keeps new OS image changes over local changes on conflicts
+	MergePreferImage = "prefer-image"
+	// MergeKeepBoth keeps local changes and saves the new OS image version in a '.rpmnew' sidecar file on conflicts
+	MergeKeepBoth = "keep-both"
+	// MergeFailOnConflict fails the upgrade on conflicts
+	MergeFailOnConflict = "fail-on-conflict"
+)
+
+const (
+	// RAID1 mirrors the partition across all RAID member devices
+	RAID1 = 1
+	// RAID10 stripes the partition across mirrored pairs of RAID member devices
+	RAID10 = 10
+)
+
 type PartRole int
 
 const (
@@ -79,6 +121,7 @@ const (
 	Recovery
 	Generic
 	Config
+	BIOS
 )
 
 type FileSystem int
@@ -158,6 +201,8 @@ func ParseRole(function string) (PartRole, error) {
 		return Generic, nil
 	case "config":
 		return Config, nil
+	case "bios":
+		return BIOS, nil
 	default:
 		return PartRole(0), fmt.Errorf("unknown partition function: %s", function)
 	}
@@ -175,6 +220,8 @@ func (p PartRole) String() string {
 		return "generic"
 	case Config:
 		return "config"
+	case BIOS:
+		return "bios"
 	default:
 		return Unknown
 	}
@@ -199,25 +246,89 @@ func (p *PartRole) UnmarshalYAML(data *yaml.Node) (err error) {
 	return err
 }
 
+// RWVolume defines a read-write subvolume of a btrfs partition. MergePolicy defines how
+// conflicts between local and new OS image changes are solved on upgrades of snapshotted
+// volumes, by default local changes are kept.
 type RWVolume struct {
 	Path          string   `yaml:"path" validate:"required,abspath"`
 	Snapshotted   bool     `yaml:"snapshotted,omitempty"`
 	NoCopyOnWrite bool     `yaml:"noCopyOnWrite,omitempty"`
 	MountOpts     []string `yaml:"mountOpts,omitempty"`
+	MergePolicy   string   `yaml:"mergePolicy,omitempty" validate:"omitempty,oneof=prefer-local prefer-image keep-both fail-on-conflict"`
+}
+
+// GetMergePolicy returns the merge policy of the volume, defaults to keep local changes
+func (v RWVolume) GetMergePolicy() string {
+	if v.MergePolicy == "" {
+		return MergePreferLocal
+	}
+	return v.MergePolicy
 }
 
 type RWVolumes []RWVolume
 
 type Partition struct {
-	Label      string     `yaml:"label,omitempty"`
-	FileSystem FileSystem `yaml:"fileSystem,omitempty"`
-	Size       MiB        `yaml:"size,omitempty"`
-	Role       PartRole   `yaml:"role"`
-	MountPoint string     `yaml:"mountPoint,omitempty" validate:"recovery_mountpoint"`
-	MountOpts  []string   `yaml:"mountOpts,omitempty"`
-	RWVolumes  RWVolumes  `yaml:"rwVolumes,omitempty" validate:"excluded_unless=FileSystem 1,dive"` // FileSystem 1 = btrfs
-	UUID       string     `yaml:"uuid,omitempty"`
-	Hidden     bool       `yaml:"hidden,omitempty"`
+	Label      string      `yaml:"label,omitempty"`
+	FileSystem FileSystem  `yaml:"fileSystem,omitempty"`
+	Size       MiB         `yaml:"size,omitempty"`
+	Role       PartRole    `yaml:"role"`
+	MountPoint string      `yaml:"mountPoint,omitempty" validate:"recovery_mountpoint"`
+	MountOpts  []string    `yaml:"mountOpts,omitempty"`
+	RWVolumes  RWVolumes   `yaml:"rwVolumes,omitempty" validate:"excluded_unless=FileSystem 1,dive"` // FileSystem 1 = btrfs
+	UUID       string      `yaml:"uuid,omitempty"`
+	Hidden     bool        `yaml:"hidden,omitempty"`
+	Encryption *Encryption `yaml:"encryption,omitempty" validate:"omitempty"`
+	RAID       *RAID       `yaml:"raid,omitempty" validate:"omitempty"`
+}
+
+// RAID defines the software RAID (mdadm) array the partition is built on. The partition
+// device itself is the first member of the array, Devices lists the additional member disks
+// an equivalent partition is created in. Devices are runtime information only required at
+// installation time. UUID is the array UUID and it is set once the array is created.
+type RAID struct {
+	Level   int      `yaml:"level" validate:"oneof=1 10"`
+	Devices []string `yaml:"devices,omitempty"`
+	UUID    string   `yaml:"uuid,omitempty"`
+}
+
+// Encryption defines the LUKS2 encryption of a partition. KeyFile is the path to the
+// file including the passphrase or key used to format the LUKS2 volume, for TPM2 based
+// encryption it is kept enrolled as the recovery key. UUID is the LUKS2 header UUID and
+// it is set once the partition is formatted. PCRPolicy and PCRs define how TPM2 keys are
+// bound to the system state, by default they are bound to PCR 7 (Secure Boot state).
+type Encryption struct {
+	KeySource string `yaml:"keySource" validate:"oneof=passphrase keyfile tpm2"`
+	KeyFile   string `yaml:"keyFile,omitempty"`
+	UUID      string `yaml:"uuid,omitempty"`
+	PCRPolicy string `yaml:"pcrPolicy,omitempty" validate:"omitempty,oneof=static signed pcrlock"`
+	PCRs      []int  `yaml:"pcrs,omitempty" validate:"dive,min=0,max=23"`
+}
+
+// GetPCRPolicy returns the PCR policy for TPM2 keys, defaults to a static policy
+func (e Encryption) GetPCRPolicy() string {
+	if e.PCRPolicy == "" {
+		return PCRStatic
+	}
+	return e.PCRPolicy
+}
+
+// IsEncrypted returns true if the partition is defined to be LUKS2 encrypted
+func (p Partition) IsEncrypted() bool {
+	return p.Encryption != nil
+}
+
+// MapperName returns the device mapper name of the unlocked LUKS2 volume. It matches
+// the default name systemd-cryptsetup uses for volumes unlocked at boot.
+func (p Partition) MapperName() string {
+	if !p.IsEncrypted() || p.Encryption.UUID == "" {
+		return ""
+	}
+	return luksMapperPrefix + p.Encryption.UUID
+}
+
+// IsRAID returns true if the partition is defined to be a software RAID array
+func (p Partition) IsRAID() bool {
+	return p.RAID != nil
 }
 
 type Partitions []*Partition
@@ -227,9 +338,34 @@ type Disk struct {
 	Partitions Partitions `yaml:"partitions" validate:"required,min=1,dive"`
 }
 
+// BootConfig defines the bootloader setup. BIOS enables legacy BIOS boot in addition to
+// UEFI, it requires grub and a 'bios' partition in the same disk of the EFI partition.
 type BootConfig struct {
-	Bootloader    string `yaml:"name"`
-	KernelCmdline string `yaml:"kernelCmdline"`
+	Bootloader    string     `yaml:"name"`
+	KernelCmdline string     `yaml:"kernelCmdline"`
+	UKI           *UKIConfig `yaml:"uki,omitempty"`
+	BIOS          bool       `yaml:"bios,omitempty"`
+}
+
+// UKIConfig defines the Unified Kernel Images boot setup, UKIs are optionally signed
+// with the given key and certificate pair. If a PCR key pair is provided UKIs include
+// a signed PCR 11 policy TPM2 keys can be bound to.
+type UKIConfig struct {
+	Enabled      bool   `yaml:"enabled"`
+	SigningKey   string `yaml:"signingKey,omitempty"`
+	SigningCert  string `yaml:"signingCert,omitempty"`
+	PCRKey       string `yaml:"pcrKey,omitempty"`
+	PCRPublicKey string `yaml:"pcrPublicKey,omitempty"`
+}
+
+// IsUKIEnabled returns true if the boot configuration requires Unified Kernel Images
+func (b *BootConfig) IsUKIEnabled() bool {
+	return b != nil && b.UKI != nil && b.UKI.Enabled
+}
+
+// IsBIOSEnabled returns true if the boot configuration requires legacy BIOS boot
+func (b *BootConfig) IsBIOSEnabled() bool {
+	return b != nil && b.BIOS
 }
 
 type FirmwareConfig struct {
@@ -250,11 +386,18 @@ type LiveInstaller struct {
 	KernelCmdline string       `yaml:"kernelCmdline,omitempty"`
 }
 
+// Release identifies the release manifest a deployment was built from
+type Release struct {
+	Name    string `yaml:"name"`
+	Version string `yaml:"version"`
+}
+
 type Deployment struct {
+	Release     *Release           `yaml:"release,omitempty"`
 	SourceOS    *ImageSource       `yaml:"sourceOS" validate:"required,not_empty_source"`
-	Disks       []*Disk            `yaml:"disks" validate:"required,min=1,dive,system_partition,multiple_system_partitions,efi_partition,multiple_efi_partitions,recovery_partition,last_partition_size,rw_volumes"`
+	Disks       []*Disk            `yaml:"disks" validate:"required,min=1,encryption,raid,bios,dive,system_partition,multiple_system_partitions,efi_partition,multiple_efi_partitions,recovery_partition,last_partition_size,rw_volumes"`
 	Firmware    *FirmwareConfig    `yaml:"firmware"`
-	BootConfig  *BootConfig        `yaml:"bootloader"`
+	BootConfig  *BootConfig        `yaml:"bootloader" validate:"omitempty,uki_config"`
 	Security    *SecurityConfig    `yaml:"security" validate:"required"`
 	Snapshotter *SnapshotterConfig `yaml:"snapshotter"`
 	OverlayTree *ImageSource       `yaml:"overlayTree,omitempty"`
@@ -275,6 +418,10 @@ func init() {
 	_ = validate.RegisterValidation("rw_volumes", validateRWVolumes)
 	_ = validate.RegisterValidation("crypto_policy", validateCryptoPolicy)
 	_ = validate.RegisterValidation("abspath", validateAbsPath)
+	_ = validate.RegisterValidation("uki_config", validateUKIConfig)
+	_ = validate.RegisterValidation("encryption", validateEncryption)
+	_ = validate.RegisterValidation("raid", validateRAID)
+	_ = validate.RegisterValidation("bios", validateBIOS)
 	_ = validate.RegisterValidationCtx("disk_device_exists", validateDiskDeviceExists)
 	_ = validate.RegisterValidationCtx("disk_device_required", validateDiskDeviceRequired)
 	_ = validate.RegisterValidationCtx("recovery_mountpoint", validateRecoveryMountPoint)
@@ -493,6 +640,47 @@ func validateAbsPath(fl validator.FieldLevel) bool {
 	return filepath.IsAbs(fl.Field().String())
 }
 
+func validateUKIConfig(fl validator.FieldLevel) bool {
+	bootConf, ok := fl.Field().Interface().(BootConfig)
+	if !ok {
+		return false
+	}
+	return bootConf.checkUKIConfig() == nil
+}
+
+func validateEncryption(fl validator.FieldLevel) bool {
+	disks, bootConf, ok := deploymentDisks(fl)
+	return ok && checkEncryption(disks, bootConf) == nil
+}
+
+func validateRAID(fl validator.FieldLevel) bool {
+	disks, _, ok := deploymentDisks(fl)
+	return ok && checkRAID(disks) == nil
+}
+
+func validateBIOS(fl validator.FieldLevel) bool {
+	disks, bootConf, ok := deploymentDisks(fl)
+	return ok && checkBIOS(disks, bootConf) == nil
+}
+
+// deploymentDisks returns the validated disks and the boot configuration of the deployment.
+// Encryption, RAID and BIOS partitions relate to partitions of other disks, hence they are
+// validated against the whole list of disks and not per disk.
+func deploymentDisks(fl validator.FieldLevel) ([]*Disk, *BootConfig, bool) {
+	disks, ok := fl.Field().Interface().([]*Disk)
+	if !ok {
+		return nil, nil, false
+	}
+	var bootConf *BootConfig
+	switch d := fl.Top().Interface().(type) {
+	case *Deployment:
+		bootConf = d.BootConfig
+	case Deployment:
+		bootConf = d.BootConfig
+	}
+	return disks, bootConf, true
+}
+
 func validateDiskDeviceExists(ctx context.Context, fl validator.FieldLevel) bool {
 	if skip, ok := ctx.Value(contextKeySkipDiskDeviceExists).(bool); ok && skip {
 		return true
@@ -541,6 +729,12 @@ func LiveKernelCmdline(label string) string {
 	return fmt.Sprintf("root=live:LABEL=%s rd.live.overlay.overlayfs=1", label)
 }
 
+// NetbootKernelCmdline returns the default kernel command line to live boot from the live
+// image fetched over the network from the given URL
+func NetbootKernelCmdline(url string) string {
+	return fmt.Sprintf("root=live:%s rd.live.overlay.overlayfs=1 rd.neednet=1 ip=dhcp", url)
+}
+
 // GetSnapshottedVolumes returns a list of snapshotted rw volumes defined in the
 // given partitions list.
 func (p Partitions) GetSnapshottedVolumes() RWVolumes {
@@ -671,9 +865,96 @@ func (d Deployment) GetEfiDisk() *Disk {
 	return nil
 }
 
+// GetBIOSPartition gets the data of the BIOS boot partition.
+// returns nil if not found
+func (d Deployment) GetBIOSPartition() *Partition {
+	for _, disk := range d.Disks {
+		if disk == nil {
+			continue
+		}
+		for _, part := range disk.Partitions {
+			if part != nil && part.Role == BIOS {
+				return part
+			}
+		}
+	}
+	return nil
+}
+
+// GetConfigPartition gets the data of the config partition.
+// returns nil if not found
+func (d Deployment) GetConfigPartition() *Partition {
+	for _, disk := range d.Disks {
+		if disk == nil {
+			continue
+		}
+		for _, part := range disk.Partitions {
+			if part != nil && part.Role == Config {
+				return part
+			}
+		}
+	}
+	return nil
+}
+
+// GetEncryptedPartitions returns all the encrypted partitions in all disks
+func (d Deployment) GetEncryptedPartitions() Partitions {
+	var parts Partitions
+
+	for _, disk := range d.Disks {
+		if disk == nil {
+			continue
+		}
+		for _, part := range disk.Partitions {
+			if part != nil && part.IsEncrypted() {
+				parts = append(parts, part)
+			}
+		}
+	}
+	return parts
+}
+
+// GetRAIDPartitions returns all the software RAID partitions in all disks
+func (d Deployment) GetRAIDPartitions() Partitions {
+	var parts Partitions
+
+	for _, disk := range d.Disks {
+		if disk == nil {
+			continue
+		}
+		for _, part := range disk.Partitions {
+			if part != nil && part.IsRAID() {
+				parts = append(parts, part)
+			}
+		}
+	}
+	return parts
+}
+
 // BaseKernelCmdline returns the base kernel command line for the current deployment
 func (d Deployment) BaseKernelCmdline() string {
-	return fmt.Sprintf("root=LABEL=%s", d.GetSystemLabel())
+	cmdline := []string{fmt.Sprintf("root=LABEL=%s", d.GetSystemLabel())}
+	for _, part := range d.GetRAIDPartitions() {
+		if part.RAID.UUID != "" {
+			cmdline = append(cmdline, fmt.Sprintf("rd.md.uuid=%s", part.RAID.UUID))
+		}
+	}
+	for _, part := range d.GetEncryptedPartitions() {
+		uuid := part.Encryption.UUID
+		if uuid == "" {
+			continue
+		}
+		cmdline = append(cmdline, fmt.Sprintf("rd.luks.uuid=%s", uuid))
+		switch part.Encryption.KeySource {
+		case KeyTPM2:
+			cmdline = append(cmdline, fmt.Sprintf("rd.luks.options=%s=tpm2-device=auto", uuid))
+		case KeyFile:
+			if conf := d.GetConfigPartition(); conf != nil {
+				cmdline = append(cmdline, fmt.Sprintf("rd.luks.key=%s=%s:LABEL=%s", uuid, LUKSKeyFile, conf.Label))
+			}
+		}
+	}
+	return strings.Join(cmdline, " ")
 }
 
 // RecoveryKernelCmdline returns the base kernel command line for the current deployment
@@ -763,6 +1044,25 @@ func (d *Deployment) setDefaults(s *sys.System) {
 					part.Label = RecoveryLabel
 				}
 			}
+			if part.Role == BIOS {
+				// BIOS boot partitions are raw areas to embed the bootloader core image
+				if part.FileSystem.String() != Unknown || part.MountPoint != "" || len(part.RWVolumes) > 0 {
+					s.Logger().Warn("bios partition does not support filesystems, mountpoints or volumes")
+					s.Logger().Info("cleared filesystem, mountpoint and read-write volumes for bios")
+					part.FileSystem = FileSystem(0)
+					part.MountPoint = ""
+					part.RWVolumes = nil
+				}
+				if part.Label == "" {
+					part.Label = BiosLabel
+				}
+				if part.Size < BiosSize {
+					s.Logger().Warn("bios partition size cannot be less than %dMiB", BiosSize)
+					s.Logger().Info("bios partition size set to %dMiB", BiosSize)
+					part.Size = BiosSize
+				}
+				continue
+			}
 			if part.FileSystem.String() == Unknown {
 				part.FileSystem = Btrfs
 			}
@@ -815,6 +1115,14 @@ func (d *Deployment) formatValidationErrors(s *sys.System, errs validator.Valida
 			return fmt.Errorf("only last partition can be defined to be as big as available size in disk")
 		case "rw_volumes":
 			return d.checkRWVolumes()
+		case "encryption":
+			return checkEncryption(d.Disks, d.BootConfig)
+		case "raid":
+			return checkRAID(d.Disks)
+		case "bios":
+			return checkBIOS(d.Disks, d.BootConfig)
+		case "uki_config":
+			return d.BootConfig.checkUKIConfig()
 		case "crypto_policy":
 			return fmt.Errorf("invalid crypto policy: %s", d.Security.CryptoPolicy)
 		case "not_empty_source":
@@ -866,6 +1174,190 @@ func (d *Deployment) checkRWVolumes() error {
 	return nil
 }
 
+// checkEncryption is kept as a helper for specific error messages when validator fails
+func checkEncryption(disks []*Disk, bootConf *BootConfig) error {
+	var hasConfig, needsConfig bool
+	for _, disk := range disks {
+		if disk == nil {
+			continue
+		}
+		var diskKey string
+		for _, part := range disk.Partitions {
+			if part == nil {
+				continue
+			}
+			if part.Role == Config {
+				hasConfig = true
+			}
+			if !part.IsEncrypted() {
+				continue
+			}
+			switch part.Role {
+			case EFI, Recovery, Config, BIOS:
+				return fmt.Errorf("encryption is not supported for the '%s' partition", part.Role)
+			}
+			if part.Encryption.KeySource == KeyFile {
+				needsConfig = true
+			}
+			err := part.Encryption.checkPCRPolicy(bootConf)
+			if err != nil {
+				return err
+			}
+			// Already encrypted partitions do not require the key file anymore
+			if part.Encryption.UUID != "" {
+				continue
+			}
+			if part.Encryption.KeyFile == "" {
+				return fmt.Errorf("encrypted partition '%s' requires a key file", part.Label)
+			}
+			if diskKey != "" && diskKey != part.Encryption.KeyFile {
+				return fmt.Errorf("all encrypted partitions within the same disk must share the key file")
+			}
+			diskKey = part.Encryption.KeyFile
+		}
+	}
+	if needsConfig && !hasConfig {
+		return fmt.Errorf("'%s' encryption key source requires a 'config' partition", KeyFile)
+	}
+	return nil
+}
+
+// checkRAID is kept as a helper for specific error messages when validator fails
+func checkRAID(disks []*Disk) error {
+	targets := map[string]bool{}
+	for _, disk := range disks {
+		if disk != nil && disk.Device != "" {
+			targets[disk.Device] = true
+		}
+	}
+	for _, disk := range disks {
+		if disk == nil {
+			continue
+		}
+		for _, part := range disk.Partitions {
+			if part == nil || !part.IsRAID() {
+				continue
+			}
+			switch part.Role {
+			case EFI, Recovery, Config, BIOS:
+				return fmt.Errorf("RAID is not supported for the '%s' partition", part.Role)
+			}
+			if part.IsEncrypted() {
+				return fmt.Errorf("encryption is not supported for RAID partitions")
+			}
+			if part.Label == "" {
+				return fmt.Errorf("RAID partitions require a label to name the array")
+			}
+			// Already created arrays do not require the member devices anymore
+			if part.RAID.UUID != "" {
+				continue
+			}
+			minDevices := 2
+			if part.RAID.Level == RAID10 {
+				minDevices = 4
+			}
+			if len(part.RAID.Devices)+1 < minDevices {
+				return fmt.Errorf(
+					"RAID%d partition '%s' requires at least %d member devices including the partition disk",
+					part.RAID.Level, part.Label, minDevices,
+				)
+			}
+			members := map[string]bool{}
+			for _, device := range part.RAID.Devices {
+				if device == "" {
+					return fmt.Errorf("empty RAID member device for partition '%s'", part.Label)
+				}
+				if members[device] {
+					return fmt.Errorf("duplicated RAID member device '%s' for partition '%s'", device, part.Label)
+				}
+				if device == disk.Device || targets[device] {
+					return fmt.Errorf("RAID member device '%s' is already a deployment disk", device)
+				}
+				members[device] = true
+			}
+		}
+	}
+	return nil
+}
+
+// checkBIOS is kept as a helper for specific error messages when validator fails
+func checkBIOS(disks []*Disk, bootConf *BootConfig) error {
+	var biosDisk, efiDisk *Disk
+	for _, disk := range disks {
+		if disk == nil {
+			continue
+		}
+		for _, part := range disk.Partitions {
+			if part == nil {
+				continue
+			}
+			switch part.Role {
+			case BIOS:
+				if biosDisk != nil {
+					return fmt.Errorf("multiple 'bios' partitions defined, there can be only one")
+				}
+				biosDisk = disk
+			case EFI:
+				efiDisk = disk
+			}
+		}
+	}
+	if !bootConf.IsBIOSEnabled() {
+		if biosDisk != nil {
+			return fmt.Errorf("'bios' partition defined but legacy BIOS boot is not enabled")
+		}
+		return nil
+	}
+	if bootConf.Bootloader != "grub" {
+		return fmt.Errorf("legacy BIOS boot is only supported with grub bootloader")
+	}
+	if biosDisk == nil {
+		return fmt.Errorf("legacy BIOS boot requires a 'bios' partition")
+	}
+	if efiDisk != nil && biosDisk != efiDisk {
+		return fmt.Errorf("the 'bios' partition must be in the same disk of the 'efi' partition")
+	}
+	return nil
+}
+
+// checkPCRPolicy is kept as a helper for specific error messages when validator fails
+func (e Encryption) checkPCRPolicy(bootConf *BootConfig) error {
+	if e.KeySource != KeyTPM2 {
+		if e.PCRPolicy != "" || len(e.PCRs) > 0 {
+			return fmt.Errorf("PCR policies are only supported for the '%s' key source", KeyTPM2)
+		}
+		return nil
+	}
+	switch e.GetPCRPolicy() {
+	case PCRSigned:
+		if !bootConf.IsUKIEnabled() || bootConf.UKI.PCRKey == "" || bootConf.UKI.PCRPublicKey == "" {
+			return fmt.Errorf("'%s' PCR policy requires unified kernel images signed with a PCR key pair", PCRSigned)
+		}
+	case PCRLock:
+		if len(e.PCRs) > 0 {
+			return fmt.Errorf("custom PCRs are not supported for the '%s' PCR policy", PCRLock)
+		}
+	}
+	return nil
+}
+
+// checkUKIConfig is kept as a helper for specific error messages when validator fails
+func (b BootConfig) checkUKIConfig() error {
+	if b.UKI == nil || !b.UKI.Enabled {
+		return nil
+	}
+	if b.Bootloader != "systemd-boot" {
+		return fmt.Errorf("unified kernel images are only supported with systemd-boot bootloader")
+	}
+	if (b.UKI.SigningKey == "") != (b.UKI.SigningCert == "") {
+		return fmt.Errorf("signing unified kernel images requires both a key and a certificate")
+	}
+	if (b.UKI.PCRKey == "") != (b.UKI.PCRPublicKey == "") {
+		return fmt.Errorf("signing PCR policies requires both a private and a public key")
+	}
+	return nil
+}
+
 // Dummy function to keep compatibility with existing code using these variables
 var (
 	CheckDiskDevice SanitizeDeployment = func(*sys.System, *Deployment) error { return nil }
@@ -911,15 +1403,42 @@ func (d *Deployment) WriteDeploymentFile(s *sys.System, root string) error {
 		}
 	}
 
+	data, err := d.MarshalDescription()
+	if err != nil {
+		return err
+	}
+
+	dataStr := string(data)
+	dataStr = "# self-generated content, do not edit\n\n" + dataStr
+
+	err = s.FS().WriteFile(path, []byte(dataStr), 0444)
+	if err != nil {
+		return fmt.Errorf("writing deployment file '%s': %w", path, err)
+	}
+	return nil
+}
+
+// MarshalDescription returns the YAML description of the deployment omitting all the
+// runtime only data, this is the content stored in the deployment file
+func (d *Deployment) MarshalDescription() ([]byte, error) {
 	dep, err := d.DeepCopy()
 	if err != nil {
-		return fmt.Errorf("failed creating a deployment deep copy: %w", err)
+		return nil, fmt.Errorf("failed creating a deployment deep copy: %w", err)
 	}
 
 	// omit the device name as this is a runtime information which might
 	// not be consistent across reboots, there is no need to store it.
 	for _, disk := range dep.Disks {
 		disk.Device = ""
+		// key file paths and RAID member devices are only meaningful at installation time
+		for _, part := range disk.Partitions {
+			if part.IsEncrypted() {
+				part.Encryption.KeyFile = ""
+			}
+			if part.IsRAID() {
+				part.RAID.Devices = nil
+			}
+		}
 	}
 	// omit the OverlayTree, CfgScript and Installer as this is a runtime information which might
 	// not be consistent across reboots, there is no need to store it.
@@ -927,19 +1446,17 @@ func (d *Deployment) WriteDeploymentFile(s *sys.System, root string) error {
 	dep.CfgScript = ""
 	dep.Installer = LiveInstaller{}
 
-	data, err := yaml.Marshal(dep)
-	if err != nil {
-		return fmt.Errorf("could not re-marshal deployment: %w", err)
+	// private keys are not stored, only the certificate and public key to verify signatures are kept
+	if dep.BootConfig.IsUKIEnabled() {
+		dep.BootConfig.UKI.SigningKey = ""
+		dep.BootConfig.UKI.PCRKey = ""
 	}
 
-	dataStr := string(data)
-	dataStr = "# self-generated content, do not edit\n\n" + dataStr
-
-	err = s.FS().WriteFile(path, []byte(dataStr), 0444)
+	data, err := yaml.Marshal(dep)
 	if err != nil {
-		return fmt.Errorf("writing deployment file '%s': %w", path, err)
+		return nil, fmt.Errorf("could not re-marshal deployment: %w", err)
 	}
-	return nil
+	return data, nil
 }
 
 // Parse reads a deployment yaml file from the given root and returns a
@@ -1043,6 +1560,17 @@ func WithConfigPartition(size MiB) Opt {
 	return WithPartitions(1, part)
 }
 
+// WithBIOSPartition inserts a BIOS boot partition as the first partition to the
+// system disk. The partition holds the grub core image for legacy BIOS boot.
+func WithBIOSPartition() Opt {
+	part := &Partition{
+		Label: BiosLabel,
+		Role:  BIOS,
+		Size:  BiosSize,
+	}
+	return WithPartitions(0, part)
+}
+
 // WithRecoveryPartition inserts a recovery partition as the second partition
 // to the systemd disk. The given size is the amount of data expected to store in
 // the partition, then the partition is sized to be aligned with 128MiB and to ensure
@@ -148,6 +148,260 @@ var _ = Describe("Deployment", Label("deployment"), func() {
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("no 'efi'"))
 		})
+		It("validates the unified kernel images configuration", func() {
+			d := deployment.DefaultDeployment()
+			d.SourceOS = deployment.NewDirSrc("/some/dir")
+			d.Disks[0].Device = "/dev/device"
+			d.BootConfig.UKI = &deployment.UKIConfig{Enabled: true}
+
+			err = d.Sanitize(s)
+			Expect(err).To(MatchError(ContainSubstring("only supported with systemd-boot")))
+
+			d.BootConfig.Bootloader = "systemd-boot"
+			d.BootConfig.UKI.SigningKey = "/etc/keys/db.key"
+			err = d.Sanitize(s)
+			Expect(err).To(MatchError(ContainSubstring("both a key and a certificate")))
+
+			d.BootConfig.UKI.SigningCert = "/etc/keys/db.crt"
+			Expect(d.Sanitize(s)).To(Succeed())
+			Expect(d.BootConfig.IsUKIEnabled()).To(BeTrue())
+		})
+		It("validates the partitions encryption configuration", func() {
+			d := deployment.New(deployment.WithPartitions(1, &deployment.Partition{
+				Role: deployment.Generic, Label: "DATA", MountPoint: "/data", Size: 1024,
+				Encryption: &deployment.Encryption{KeySource: deployment.KeyFile},
+			}))
+			d.SourceOS = deployment.NewDirSrc("/some/dir")
+			d.Disks[0].Device = "/dev/device"
+
+			err = d.Sanitize(s)
+			Expect(err).To(MatchError(ContainSubstring("requires a key file")))
+
+			d.Disks[0].Partitions[1].Encryption.KeyFile = "/etc/keys/luks.key"
+			d.Disks[0].Partitions[2].Encryption = &deployment.Encryption{
+				KeySource: deployment.KeyTPM2, KeyFile: "/etc/keys/other.key",
+			}
+			err = d.Sanitize(s)
+			Expect(err).To(MatchError(ContainSubstring("must share the key file")))
+
+			d.Disks[0].Partitions[2].Encryption.KeyFile = "/etc/keys/luks.key"
+			err = d.Sanitize(s)
+			Expect(err).To(MatchError(ContainSubstring("requires a 'config' partition")))
+
+			deployment.WithConfigPartition(0)(d)
+			Expect(d.Sanitize(s)).To(Succeed())
+
+			d.Disks[0].Partitions[1].Encryption = &deployment.Encryption{
+				KeySource: deployment.KeyPassphrase, KeyFile: "/etc/keys/luks.key",
+			}
+			err = d.Sanitize(s)
+			Expect(err).To(MatchError(ContainSubstring("not supported for the 'config' partition")))
+		})
+		It("validates the partitions encryption configuration across disks", func() {
+			d := deployment.DefaultDeployment()
+			d.SourceOS = deployment.NewDirSrc("/some/dir")
+			d.Disks[0].Device = "/dev/device"
+			d.Disks = append(d.Disks, &deployment.Disk{
+				Device: "/dev/data",
+				Partitions: deployment.Partitions{{
+					Role: deployment.Generic, Label: "DATA", MountPoint: "/data", Size: deployment.AllAvailableSize,
+					Encryption: &deployment.Encryption{KeySource: deployment.KeyFile, KeyFile: "/etc/keys/luks.key"},
+				}},
+			})
+
+			err = d.Sanitize(s, deployment.CheckDiskDevice)
+			Expect(err).To(MatchError(ContainSubstring("requires a 'config' partition")))
+
+			// the config partition holding the key file can be in another disk
+			deployment.WithConfigPartition(0)(d)
+			err = d.Sanitize(s, deployment.CheckDiskDevice)
+			Expect(err).NotTo(MatchError(ContainSubstring("encryption")))
+
+			d.Disks[1].Partitions = append(d.Disks[1].Partitions, &deployment.Partition{
+				Role: deployment.Config, Label: "CFG", Size: 64,
+				Encryption: &deployment.Encryption{KeySource: deployment.KeyPassphrase, KeyFile: "/etc/keys/luks.key"},
+			})
+			err = d.Sanitize(s, deployment.CheckDiskDevice)
+			Expect(err).To(MatchError(ContainSubstring("not supported for the 'config' partition")))
+		})
+		It("validates the TPM2 PCR policy configuration", func() {
+			d := deployment.DefaultDeployment()
+			d.SourceOS = deployment.NewDirSrc("/some/dir")
+			d.Disks[0].Device = "/dev/device"
+			enc := &deployment.Encryption{KeySource: deployment.KeyPassphrase, KeyFile: "/etc/keys/luks.key", PCRs: []int{7}}
+			d.GetSystemPartition().Encryption = enc
+
+			err = d.Sanitize(s)
+			Expect(err).To(MatchError(ContainSubstring("only supported for the 'tpm2' key source")))
+
+			enc.KeySource = deployment.KeyTPM2
+			Expect(d.Sanitize(s)).To(Succeed())
+			Expect(enc.GetPCRPolicy()).To(Equal(deployment.PCRStatic))
+
+			enc.PCRPolicy = deployment.PCRLock
+			err = d.Sanitize(s)
+			Expect(err).To(MatchError(ContainSubstring("custom PCRs are not supported")))
+
+			enc.PCRs = nil
+			Expect(d.Sanitize(s)).To(Succeed())
+
+			d.BootConfig.Bootloader = "systemd-boot"
+			d.BootConfig.UKI = &deployment.UKIConfig{Enabled: true, PCRKey: "/etc/keys/pcr.key"}
+			err = d.Sanitize(s)
+			Expect(err).To(MatchError(ContainSubstring("both a private and a public key")))
+
+			d.BootConfig.UKI.PCRKey = ""
+			enc.PCRPolicy = deployment.PCRSigned
+			err = d.Sanitize(s)
+			Expect(err).To(MatchError(ContainSubstring("signed with a PCR key pair")))
+
+			d.BootConfig.UKI.PCRKey = "/etc/keys/pcr.key"
+			d.BootConfig.UKI.PCRPublicKey = "/etc/keys/pcr.pub"
+			Expect(d.Sanitize(s)).To(Succeed())
+		})
+		It("validates the merge policy of RW volumes", func() {
+			d := deployment.DefaultDeployment()
+			d.SourceOS = deployment.NewDirSrc("/some/dir")
+			d.Disks[0].Device = "/dev/device"
+			etc := &d.GetSystemPartition().RWVolumes[2]
+			Expect(etc.GetMergePolicy()).To(Equal(deployment.MergePreferLocal))
+
+			etc.MergePolicy = "prefer-nothing"
+			err = d.Sanitize(s)
+			Expect(err).To(MatchError(ContainSubstring("'MergePolicy' failed on the 'oneof' tag")))
+
+			etc.MergePolicy = deployment.MergeKeepBoth
+			Expect(d.Sanitize(s)).To(Succeed())
+			Expect(etc.GetMergePolicy()).To(Equal(deployment.MergeKeepBoth))
+		})
+		It("appends LUKS kernel parameters for encrypted partitions", func() {
+			d := deployment.New(
+				deployment.WithPartitions(1, &deployment.Partition{
+					Role: deployment.Generic, Label: "DATA", MountPoint: "/data", Size: 1024,
+					Encryption: &deployment.Encryption{KeySource: deployment.KeyFile, UUID: "data-uuid"},
+				}),
+				deployment.WithConfigPartition(0),
+			)
+			Expect(d.BaseKernelCmdline()).To(Equal(
+				"root=LABEL=SYSTEM rd.luks.uuid=data-uuid rd.luks.key=data-uuid=/luks.key:LABEL=ignition",
+			))
+
+			d.GetSystemPartition().Encryption = &deployment.Encryption{KeySource: deployment.KeyTPM2, UUID: "sys-uuid"}
+			Expect(d.BaseKernelCmdline()).To(HaveSuffix(
+				"rd.luks.uuid=sys-uuid rd.luks.options=sys-uuid=tpm2-device=auto",
+			))
+			Expect(d.GetSystemPartition().MapperName()).To(Equal("luks-sys-uuid"))
+		})
+		It("validates the RAID partitions configuration", func() {
+			d := deployment.DefaultDeployment()
+			d.SourceOS = deployment.NewDirSrc("/some/dir")
+			d.Disks[0].Device = "/dev/device"
+			sysPart := d.GetSystemPartition()
+			sysPart.RAID = &deployment.RAID{Level: deployment.RAID1}
+
+			err = d.Sanitize(s)
+			Expect(err).To(MatchError(ContainSubstring("requires at least 2 member devices")))
+
+			sysPart.RAID.Devices = []string{"/dev/device"}
+			err = d.Sanitize(s)
+			Expect(err).To(MatchError(ContainSubstring("is already a deployment disk")))
+
+			sysPart.RAID.Devices = []string{"/dev/mirror", "/dev/mirror"}
+			err = d.Sanitize(s)
+			Expect(err).To(MatchError(ContainSubstring("duplicated RAID member device")))
+
+			sysPart.RAID.Devices = []string{"/dev/mirror"}
+			Expect(d.Sanitize(s)).To(Succeed())
+
+			// members are checked against the devices of all deployment disks
+			d.Disks = append(d.Disks, &deployment.Disk{
+				Device: "/dev/mirror",
+				Partitions: deployment.Partitions{{
+					Role: deployment.Generic, Label: "DATA", Size: deployment.AllAvailableSize,
+				}},
+			})
+			err = d.Sanitize(s, deployment.CheckDiskDevice)
+			Expect(err).To(MatchError(ContainSubstring("RAID member device '/dev/mirror' is already a deployment disk")))
+			d.Disks = d.Disks[:1]
+
+			sysPart.RAID.Level = deployment.RAID10
+			err = d.Sanitize(s)
+			Expect(err).To(MatchError(ContainSubstring("requires at least 4 member devices")))
+
+			sysPart.RAID.Level = deployment.RAID1
+			sysPart.Encryption = &deployment.Encryption{KeySource: deployment.KeyPassphrase, KeyFile: "/etc/keys/luks.key"}
+			err = d.Sanitize(s)
+			Expect(err).To(MatchError(ContainSubstring("encryption is not supported for RAID partitions")))
+
+			sysPart.Encryption = nil
+			d.GetEfiPartition().RAID = &deployment.RAID{Level: deployment.RAID1, Devices: []string{"/dev/mirror"}}
+			err = d.Sanitize(s)
+			Expect(err).To(MatchError(ContainSubstring("RAID is not supported for the 'efi' partition")))
+		})
+		It("validates the legacy BIOS boot configuration", func() {
+			d := deployment.New(deployment.WithBIOSPartition())
+			d.SourceOS = deployment.NewDirSrc("/some/dir")
+			d.Disks[0].Device = "/dev/device"
+
+			err = d.Sanitize(s)
+			Expect(err).To(MatchError(ContainSubstring("'bios' partition defined but legacy BIOS boot is not enabled")))
+
+			d.BootConfig.BIOS = true
+			err = d.Sanitize(s)
+			Expect(err).To(MatchError(ContainSubstring("legacy BIOS boot is only supported with grub bootloader")))
+
+			d.BootConfig.Bootloader = "grub"
+			biosPart := d.GetBIOSPartition()
+			biosPart.FileSystem = deployment.Ext4
+			biosPart.MountPoint = "/bios"
+			biosPart.Size = 0
+			Expect(d.Sanitize(s)).To(Succeed())
+			Expect(biosPart.FileSystem.String()).To(Equal(deployment.Unknown))
+			Expect(biosPart.MountPoint).To(BeEmpty())
+			Expect(biosPart.Size).To(Equal(deployment.BiosSize))
+
+			biosPart.Encryption = &deployment.Encryption{KeySource: deployment.KeyPassphrase, KeyFile: "/etc/keys/luks.key"}
+			err = d.Sanitize(s)
+			Expect(err).To(MatchError(ContainSubstring("encryption is not supported for the 'bios' partition")))
+
+			biosPart.Encryption = nil
+			d.Disks = append(d.Disks, &deployment.Disk{
+				Device:     "/dev/device",
+				Partitions: []*deployment.Partition{{Role: deployment.BIOS}},
+			})
+			err = d.Sanitize(s)
+			Expect(err).To(MatchError(ContainSubstring("multiple 'bios' partitions defined")))
+
+			d.Disks = d.Disks[:1]
+			d.Disks[0].Partitions = d.Disks[0].Partitions[1:]
+			err = d.Sanitize(s)
+			Expect(err).To(MatchError(ContainSubstring("legacy BIOS boot requires a 'bios' partition")))
+
+			d.Disks = append(d.Disks, &deployment.Disk{
+				Device:     "/dev/device",
+				Partitions: []*deployment.Partition{{Role: deployment.BIOS}, {Role: deployment.Generic}},
+			})
+			err = d.Sanitize(s)
+			Expect(err).To(MatchError(ContainSubstring("must be in the same disk of the 'efi' partition")))
+		})
+		It("appends mdadm kernel parameters for RAID partitions", func() {
+			d := deployment.DefaultDeployment()
+			d.GetSystemPartition().RAID = &deployment.RAID{Level: deployment.RAID1, UUID: "md-uuid"}
+			Expect(d.BaseKernelCmdline()).To(Equal("root=LABEL=SYSTEM rd.md.uuid=md-uuid"))
+		})
+		It("omits RAID member devices from deployment files", func() {
+			d := deployment.DefaultDeployment()
+			d.SourceOS = deployment.NewDirSrc("/some/image")
+			d.GetSystemPartition().RAID = &deployment.RAID{
+				Level: deployment.RAID1, Devices: []string{"/dev/mirror"}, UUID: "md-uuid",
+			}
+			Expect(d.WriteDeploymentFile(s, "/some/dir")).To(Succeed())
+			rD, err := deployment.Parse(s, "/some/dir")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rD.GetSystemPartition().RAID.Devices).To(BeEmpty())
+			Expect(rD.GetSystemPartition().RAID.UUID).To(Equal("md-uuid"))
+			Expect(rD.Sanitize(s, deployment.CheckDiskDevice)).To(Succeed())
+		})
 		It("feeds default values even if some where undefined", func() {
 			d := deployment.DefaultDeployment()
 			d.Disks = []*deployment.Disk{
@@ -179,6 +433,34 @@ var _ = Describe("Deployment", Label("deployment"), func() {
 			Expect(len(rD.Disks[0].Partitions)).To(Equal(2))
 			Expect(rD.Sanitize(s, deployment.CheckDiskDevice)).To(Succeed())
 		})
+		It("omits encryption key files from deployment files", func() {
+			d := deployment.DefaultDeployment()
+			d.SourceOS = deployment.NewDirSrc("/some/image")
+			d.Disks[0].Partitions[1].Encryption = &deployment.Encryption{
+				KeySource: deployment.KeyPassphrase, KeyFile: "/etc/keys/luks.key", UUID: "sys-uuid",
+			}
+			Expect(d.WriteDeploymentFile(s, "/some/dir")).To(Succeed())
+			rD, err := deployment.Parse(s, "/some/dir")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rD.Disks[0].Partitions[1].Encryption.KeyFile).To(BeEmpty())
+			Expect(rD.Disks[0].Partitions[1].Encryption.UUID).To(Equal("sys-uuid"))
+			Expect(rD.Sanitize(s, deployment.CheckDiskDevice)).To(Succeed())
+		})
+		It("omits the unified kernel images private keys from deployment files", func() {
+			d := deployment.DefaultDeployment()
+			d.SourceOS = deployment.NewDirSrc("/some/image")
+			d.BootConfig = &deployment.BootConfig{Bootloader: "systemd-boot", UKI: &deployment.UKIConfig{
+				Enabled: true, SigningKey: "/keys/db.key", SigningCert: "/keys/db.crt",
+				PCRKey: "/keys/pcr.key", PCRPublicKey: "/keys/pcr.pub",
+			}}
+			Expect(d.WriteDeploymentFile(s, "/some/dir")).To(Succeed())
+			rD, err := deployment.Parse(s, "/some/dir")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*rD.BootConfig.UKI).To(Equal(deployment.UKIConfig{
+				Enabled: true, SigningCert: "/keys/db.crt", PCRPublicKey: "/keys/pcr.pub",
+			}))
+			Expect(d.BootConfig.UKI.SigningKey).To(Equal("/keys/db.key"))
+		})
 		It("unmarshals Disk.Device", func() {
 			disk := "target: /dev/sometarget"
 
@@ -243,7 +525,7 @@ var _ = Describe("Deployment", Label("deployment"), func() {
 			Expect(err).To(HaveOccurred())
 		})
 		It("Un/marshals PartRole", func() {
-			roles := []string{"efi", "system", "recovery", "config", "generic"}
+			roles := []string{"efi", "system", "recovery", "config", "generic", "bios"}
 			var r deployment.PartRole
 
 			for _, role := range roles {
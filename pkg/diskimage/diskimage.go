@@ -0,0 +1,272 @@
+/*
+Copyright © 2022-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diskimage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/suse/elemental/v3/pkg/bootloader"
+	"github.com/suse/elemental/v3/pkg/cleanstack"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/install"
+	"github.com/suse/elemental/v3/pkg/installer"
+	"github.com/suse/elemental/v3/pkg/repart"
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+	"github.com/suse/elemental/v3/pkg/unpack"
+	"github.com/suse/elemental/v3/pkg/upgrade"
+)
+
+// Format is the file format of the disk image
+type Format string
+
+const (
+	// MediaType is the media type name of disk images including an already deployed OS
+	MediaType = "disk"
+
+	Raw   Format = "raw"
+	QCOW2 Format = "qcow2"
+	VMDK  Format = "vmdk"
+	VHDX  Format = "vhdx"
+
+	// DefaultSize is the disk image size used if none is provided
+	DefaultSize deployment.MiB = 12 * 1024
+
+	// growFsOpt is the mount option which makes systemd grow the filesystem on mount
+	growFsOpt = "x-systemd.growfs"
+)
+
+// ParseFormat returns the disk image format for the given string
+func ParseFormat(format string) (Format, error) {
+	f := Format(strings.ToLower(format))
+	switch f {
+	case Raw, QCOW2, VMDK, VHDX:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unsupported disk image format %s: %w", format, errors.ErrUnsupported)
+	}
+}
+
+type deployer interface {
+	Deploy(d *deployment.Deployment) error
+}
+
+type Option func(*Builder)
+
+// Builder creates disk images including an already deployed OS, ready to boot
+type Builder struct {
+	s          *sys.System
+	ctx        context.Context
+	format     Format
+	size       deployment.MiB
+	bl         bootloader.Bootloader
+	unpackOpts []unpack.Opt
+	deployer   deployer
+}
+
+// WithFormat sets the file format of the disk image
+func WithFormat(format Format) Option {
+	return func(b *Builder) {
+		b.format = format
+	}
+}
+
+// WithSize sets the size of the disk image in MiB
+func WithSize(size deployment.MiB) Option {
+	return func(b *Builder) {
+		b.size = size
+	}
+}
+
+// WithBootloader sets the bootloader to install in the disk image
+func WithBootloader(bl bootloader.Bootloader) Option {
+	return func(b *Builder) {
+		b.bl = bl
+	}
+}
+
+// WithUnpackOpts sets the unpack package options used to extract the OS image
+func WithUnpackOpts(opts ...unpack.Opt) Option {
+	return func(b *Builder) {
+		b.unpackOpts = opts
+	}
+}
+
+// WithDeployer sets the deployer which installs the OS into the partitioned disk image
+func WithDeployer(d deployer) Option {
+	return func(b *Builder) {
+		b.deployer = d
+	}
+}
+
+func New(ctx context.Context, s *sys.System, opts ...Option) *Builder {
+	builder := &Builder{
+		s:      s,
+		ctx:    ctx,
+		format: Raw,
+		size:   DefaultSize,
+	}
+	for _, o := range opts {
+		o(builder)
+	}
+	if builder.bl == nil {
+		builder.bl = bootloader.NewNone(s)
+	}
+	if builder.deployer == nil {
+		upgrader := upgrade.New(
+			ctx, s, upgrade.WithBootloader(builder.bl), upgrade.WithUnpackOpts(builder.unpackOpts...),
+			upgrade.WithFirstBootGrowth(true),
+		)
+		builder.deployer = install.New(
+			ctx, s, install.WithUpgrader(upgrader), install.WithUnpackOpts(builder.unpackOpts...),
+		)
+	}
+	return builder
+}
+
+// Build creates the disk image at the given output path for the given deployment. The
+// partition table and filesystems are written by systemd-repart directly into the image file,
+// the image is only attached to a loop device to deploy the OS in a snapper transaction.
+// The last partition and its filesystem are grown to fill the whole disk on first boot.
+func (b Builder) Build(d *deployment.Deployment, output string) (err error) {
+	err = b.sanitize(d, output)
+	if err != nil {
+		return fmt.Errorf("cannot proceed with disk image build due to inconsistent setup: %w", err)
+	}
+
+	err = unpack.Verify(b.ctx, b.s, d.SourceOS, b.unpackOpts...)
+	if err != nil {
+		return fmt.Errorf("verifying OS image: %w", err)
+	}
+
+	cleanup := cleanstack.NewCleanStack()
+	defer func() { err = cleanup.Cleanup(err) }()
+
+	rawFile := output
+	if b.format != Raw {
+		tempDir, err := vfs.TempDir(b.s.FS(), filepath.Dir(output), "elemental-disk")
+		if err != nil {
+			return fmt.Errorf("could not create working directory for disk image build: %w", err)
+		}
+		cleanup.Push(func() error { return b.s.FS().RemoveAll(tempDir) })
+		rawFile = filepath.Join(tempDir, "disk.raw")
+	}
+
+	disk := d.GetSystemDisk()
+	last := disk.Partitions[len(disk.Partitions)-1]
+	if !slices.Contains(last.MountOpts, growFsOpt) {
+		last.MountOpts = append(last.MountOpts, growFsOpt)
+	}
+
+	parts := make([]repart.Partition, len(disk.Partitions))
+	for i, part := range disk.Partitions {
+		parts[i] = repart.Partition{Partition: part}
+	}
+	err = repart.CreateDiskImage(b.s, rawFile, b.size, parts)
+	if err != nil {
+		return fmt.Errorf("creating disk image '%s': %w", rawFile, err)
+	}
+
+	err = b.deploy(rawFile, d)
+	if err != nil {
+		return err
+	}
+
+	if b.format != Raw {
+		err = b.convert(rawFile, output)
+		if err != nil {
+			return err
+		}
+	}
+
+	return installer.WriteChecksum(b.s, output)
+}
+
+// deploy attaches the given raw disk image to a loop device and installs the deployment on it
+func (b Builder) deploy(rawFile string, d *deployment.Deployment) (err error) {
+	out, err := b.s.Runner().Run("losetup", "--find", "--show", "--partscan", rawFile)
+	if err != nil {
+		return fmt.Errorf("attaching loop device to '%s': %w", rawFile, err)
+	}
+	device := strings.TrimSpace(string(out))
+	defer func() {
+		_, dErr := b.s.Runner().Run("losetup", "--detach", device)
+		if dErr != nil {
+			err = errors.Join(err, fmt.Errorf("detaching loop device '%s': %w", device, dErr))
+		}
+	}()
+
+	disk := d.GetSystemDisk()
+	disk.Device = device
+	defer func() { disk.Device = "" }()
+
+	err = b.deployer.Deploy(d)
+	if err != nil {
+		return fmt.Errorf("deploying OS to disk image: %w", err)
+	}
+	return nil
+}
+
+// convert converts the given raw disk image into the configured format
+func (b Builder) convert(rawFile, output string) error {
+	b.s.Logger().Info("Converting disk image to %s", b.format)
+
+	args := []string{"convert", "-f", string(Raw), "-O", string(b.format)}
+	switch b.format {
+	case VHDX:
+		args = append(args, "-o", "subformat=dynamic")
+	case VMDK:
+		args = append(args, "-o", "subformat=streamOptimized")
+	}
+	args = append(args, rawFile, output)
+
+	_, err := b.s.Runner().RunContext(b.ctx, "qemu-img", args...)
+	if err != nil {
+		return fmt.Errorf("converting disk image to %s: %w", b.format, err)
+	}
+	return nil
+}
+
+// sanitize checks the given deployment and output path are suitable for a disk image build
+func (b Builder) sanitize(d *deployment.Deployment, output string) error {
+	if output == "" {
+		return fmt.Errorf("undefined output file")
+	}
+	if ok, _ := vfs.Exists(b.s.FS(), output); ok {
+		return fmt.Errorf("target output file %s is an already existing file", output)
+	}
+	if _, err := ParseFormat(string(b.format)); err != nil {
+		return err
+	}
+	if b.size == 0 {
+		return fmt.Errorf("undefined disk image size")
+	}
+	if len(d.Disks) != 1 || d.GetSystemDisk() == nil {
+		return fmt.Errorf("disk images require a single disk including the system partition")
+	}
+	disk := d.GetSystemDisk()
+	if disk.Partitions[len(disk.Partitions)-1].Role != deployment.System {
+		return fmt.Errorf("the system partition must be the last partition of disk images")
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+/*
+Copyright © 2022-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diskimage_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/diskimage"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+func TestDiskImageSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Disk image test suite")
+}
+
+const systemdRepartJson = `[
+	{"uuid" : "c60d1845-7b04-4fc4-8639-8c49eb7277d5", "file" : "/tmp/elemental-repart.d/0-efi.conf"},
+	{"uuid" : "ddb334a8-48a2-c4de-ddb3-849eb2443e92", "file" : "/tmp/elemental-repart.d/1-system.conf"}
+]`
+
+type deployerMock struct {
+	device string
+	err    error
+}
+
+func (m *deployerMock) Deploy(d *deployment.Deployment) error {
+	m.device = d.Disks[0].Device
+	return m.err
+}
+
+var _ = Describe("Disk image", Label("diskimage"), func() {
+	var runner *sysmock.Runner
+	var fs vfs.FS
+	var cleanup func()
+	var s *sys.System
+	var d *deployment.Deployment
+	var deployer *deployerMock
+	var sideEffects map[string]func(...string) ([]byte, error)
+
+	BeforeEach(func() {
+		var err error
+		runner = sysmock.NewRunner()
+		fs, cleanup, err = sysmock.TestFS(map[string]any{"/output/empty": []byte{}})
+		Expect(err).ToNot(HaveOccurred())
+		s, err = sys.NewSystem(
+			sys.WithRunner(runner), sys.WithFS(fs),
+			sys.WithLogger(log.New(log.WithDiscardAll())),
+		)
+		Expect(err).NotTo(HaveOccurred())
+
+		sideEffects = map[string]func(...string) ([]byte, error){}
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			if f := sideEffects[cmd]; f != nil {
+				return f(args...)
+			}
+			return runner.ReturnValue, runner.ReturnError
+		}
+		sideEffects["systemd-repart"] = func(args ...string) ([]byte, error) {
+			return []byte(systemdRepartJson), nil
+		}
+		sideEffects["losetup"] = func(args ...string) ([]byte, error) {
+			if args[0] == "--find" {
+				Expect(fs.WriteFile(args[len(args)-1], []byte("raw image"), vfs.FilePerm)).To(Succeed())
+				return []byte("/dev/loop0\n"), nil
+			}
+			return []byte{}, nil
+		}
+		sideEffects["qemu-img"] = func(args ...string) ([]byte, error) {
+			return []byte{}, fs.WriteFile(args[len(args)-1], []byte("converted image"), vfs.FilePerm)
+		}
+
+		d = deployment.DefaultDeployment()
+		deployer = &deployerMock{}
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+	It("parses disk image formats", func() {
+		f, err := diskimage.ParseFormat("QCOW2")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(f).To(Equal(diskimage.QCOW2))
+		_, err = diskimage.ParseFormat("vdi")
+		Expect(err).To(HaveOccurred())
+	})
+	It("builds a raw disk image", func() {
+		b := diskimage.New(context.Background(), s, diskimage.WithDeployer(deployer), diskimage.WithSize(4096))
+		Expect(b.Build(d, "/output/disk.raw")).To(Succeed())
+
+		Expect(deployer.device).To(Equal("/dev/loop0"))
+		Expect(d.Disks[0].Device).To(BeEmpty())
+		Expect(d.GetSystemPartition().UUID).To(Equal("ddb334a8-48a2-c4de-ddb3-849eb2443e92"))
+		Expect(d.GetSystemPartition().MountOpts).To(ContainElement("x-systemd.growfs"))
+		Expect(runner.MatchMilestones([][]string{
+			{"systemd-repart", "--json=pretty", "--definitions=/tmp/elemental-repart.d", "--dry-run=no", "--empty=create", "--size=4096M"},
+			{"losetup", "--find", "--show", "--partscan", "/output/disk.raw"},
+			{"losetup", "--detach", "/dev/loop0"},
+		})).To(Succeed())
+		Expect(runner.IncludesCmds([][]string{{"qemu-img"}})).NotTo(Succeed())
+		Expect(vfs.Exists(fs, "/output/disk.raw.sha256")).To(BeTrue())
+	})
+	It("builds a qcow2 disk image", func() {
+		b := diskimage.New(
+			context.Background(), s, diskimage.WithDeployer(deployer), diskimage.WithFormat(diskimage.QCOW2),
+		)
+		Expect(b.Build(d, "/output/disk.qcow2")).To(Succeed())
+
+		cmds := runner.GetCmds()
+		raw := cmds[len(cmds)-1][len(cmds[len(cmds)-1])-2]
+		Expect(runner.MatchMilestones([][]string{
+			{"systemd-repart"},
+			{"losetup", "--find", "--show", "--partscan", raw},
+			{"losetup", "--detach", "/dev/loop0"},
+			{"qemu-img", "convert", "-f", "raw", "-O", "qcow2", raw, "/output/disk.qcow2"},
+		})).To(Succeed())
+		Expect(vfs.Exists(fs, raw)).To(BeFalse())
+		Expect(vfs.Exists(fs, "/output/disk.qcow2.sha256")).To(BeTrue())
+	})
+	It("detaches the loop device if the deployment fails", func() {
+		deployer.err = fmt.Errorf("deployment failed")
+		b := diskimage.New(context.Background(), s, diskimage.WithDeployer(deployer))
+		Expect(b.Build(d, "/output/disk.raw")).To(MatchError(ContainSubstring("deployment failed")))
+		Expect(runner.IncludesCmds([][]string{{"losetup", "--detach", "/dev/loop0"}})).To(Succeed())
+	})
+	It("fails to convert the disk image", func() {
+		sideEffects["qemu-img"] = func(args ...string) ([]byte, error) {
+			return nil, fmt.Errorf("qemu-img failed")
+		}
+		b := diskimage.New(
+			context.Background(), s, diskimage.WithDeployer(deployer), diskimage.WithFormat(diskimage.VHDX),
+		)
+		Expect(b.Build(d, "/output/disk.vhdx")).To(MatchError(ContainSubstring("qemu-img failed")))
+		Expect(runner.IncludesCmds([][]string{{"qemu-img", "convert", "-f", "raw", "-O", "vhdx", "-o", "subformat=dynamic"}})).To(Succeed())
+	})
+	It("fails if the system partition is not the last one", func() {
+		deployment.WithPartitions(2, &deployment.Partition{Role: deployment.Generic, FileSystem: deployment.Ext4})(d)
+		b := diskimage.New(context.Background(), s, diskimage.WithDeployer(deployer))
+		Expect(b.Build(d, "/output/disk.raw")).To(MatchError(ContainSubstring("must be the last partition")))
+		Expect(runner.GetCmds()).To(BeEmpty())
+	})
+	It("fails if the output file already exists", func() {
+		b := diskimage.New(context.Background(), s, diskimage.WithDeployer(deployer))
+		Expect(b.Build(d, "/output/empty")).To(MatchError(ContainSubstring("already existing file")))
+	})
+})
@@ -18,18 +18,25 @@ limitations under the License.
 package extensions
 
 import (
+	"context"
 	"fmt"
+	iofs "io/fs"
 	"os"
 	"path/filepath"
+	"slices"
 
 	"github.com/suse/elemental/v3/pkg/manifest/api"
+	"github.com/suse/elemental/v3/pkg/rsync"
 	"github.com/suse/elemental/v3/pkg/sys"
 	"github.com/suse/elemental/v3/pkg/sys/vfs"
+	"github.com/suse/elemental/v3/pkg/unpack"
 	"go.yaml.in/yaml/v3"
 )
 
 const (
 	File = "/etc/elemental/extensions.yaml"
+	// Dir is the directory systemd-sysext merges the extensions from
+	Dir = "/var/lib/extensions"
 )
 
 func Parse(s *sys.System, root string) ([]api.SystemdExtension, error) {
@@ -73,3 +80,89 @@ func Serialize(extensions []api.SystemdExtension) (string, error) {
 
 	return dataStr, err
 }
+
+// Write serializes the given extensions into the extensions file of the given root
+func Write(s *sys.System, root string, extensions []api.SystemdExtension) error {
+	data, err := Serialize(extensions)
+	if err != nil {
+		return fmt.Errorf("serializing extensions: %w", err)
+	}
+
+	path := filepath.Join(root, File)
+	if err = vfs.MkdirAll(s.FS(), filepath.Dir(path), vfs.DirPerm); err != nil {
+		return fmt.Errorf("creating extensions file directory: %w", err)
+	}
+
+	if err = s.FS().WriteFile(path, []byte(data), vfs.FilePerm); err != nil {
+		return fmt.Errorf("writing extensions file '%s': %w", path, err)
+	}
+
+	return nil
+}
+
+// Unpack unpacks the OCI image of the given extension into the extensions directory. The image
+// must include either a single extension image file or a /usr directory, and optionally an /opt directory.
+func Unpack(ctx context.Context, s *sys.System, extension api.SystemdExtension, extensionsDir string, opts ...unpack.OCIOpt) error {
+	fs := s.FS()
+
+	tempDir, err := vfs.TempDir(fs, "", fmt.Sprintf("%s-", extension.Name))
+	if err != nil {
+		return fmt.Errorf("creating temp directory: %w", err)
+	}
+	defer func() {
+		_ = fs.RemoveAll(tempDir)
+	}()
+
+	unpacker := unpack.NewOCIUnpacker(s, extension.Image, opts...)
+	if _, err = unpacker.Unpack(ctx, tempDir); err != nil {
+		return fmt.Errorf("unpacking extension: %w", err)
+	}
+
+	entries, err := fs.ReadDir(tempDir)
+	if err != nil {
+		return fmt.Errorf("reading unpacked directory: %w", err)
+	}
+
+	if len(entries) == 1 {
+		entry := entries[0]
+		if !entry.IsDir() {
+			file := filepath.Join(tempDir, entry.Name())
+			if err = vfs.CopyFile(fs, file, extensionsDir); err != nil {
+				return fmt.Errorf("copying extension file %s: %w", file, err)
+			}
+
+			return nil
+		}
+	}
+
+	if !slices.ContainsFunc(entries, func(entry iofs.DirEntry) bool {
+		return entry.Name() == "usr" && entry.IsDir()
+	}) {
+		return fmt.Errorf("invalid extension: either a single image file or a /usr directory is required")
+	}
+
+	sync := rsync.NewRsync(s, rsync.WithContext(ctx))
+	syncDirectory := func(dirName string) error {
+		sourcePath := filepath.Join(tempDir, dirName)
+		if exists, _ := vfs.Exists(fs, sourcePath); !exists {
+			return nil
+		}
+
+		targetPath := filepath.Join(extensionsDir, extension.Name, dirName)
+		if err = vfs.MkdirAll(fs, targetPath, 0755); err != nil {
+			return fmt.Errorf("creating extension directory /%s: %w", dirName, err)
+		}
+
+		if err = sync.SyncData(sourcePath, targetPath); err != nil {
+			return fmt.Errorf("syncing extension directory /%s: %w", dirName, err)
+		}
+
+		return nil
+	}
+
+	if err = syncDirectory("usr"); err != nil {
+		return err
+	}
+
+	return syncDirectory("opt")
+}
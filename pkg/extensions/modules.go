@@ -0,0 +1,251 @@
+/*
+Copyright © 2022-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package extensions
+
+import (
+	"fmt"
+	iofs "io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+
+	"github.com/suse/elemental/v3/pkg/chroot"
+	"github.com/suse/elemental/v3/pkg/manifest/api"
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+const (
+	// ModulesReportFile records the kernel modules validation of the enabled extensions
+	ModulesReportFile = "/etc/elemental/extensions-modules.yaml"
+
+	modulesCheckDir = "/run/elemental/kmod-check"
+)
+
+// ModuleStatus is the resolution of a kernel module required by an extension
+type ModuleStatus struct {
+	Extension string `yaml:"extension"`
+	Module    string `yaml:"module"`
+	// Path is the module file the module resolves to, empty if it was not found
+	Path string `yaml:"path,omitempty"`
+}
+
+// ModulesReport holds the resolution of the kernel modules of the enabled extensions for a kernel version
+type ModulesReport struct {
+	KernelVersion string         `yaml:"kernelVersion"`
+	Modules       []ModuleStatus `yaml:"modules"`
+}
+
+// Missing returns the modules which could not be resolved
+func (r ModulesReport) Missing() []ModuleStatus {
+	var missing []ModuleStatus
+	for _, m := range r.Modules {
+		if m.Path == "" {
+			missing = append(missing, m)
+		}
+	}
+	return missing
+}
+
+// CheckKernelModules resolves the kernel modules of the given extensions for the given kernel version of the
+// given root. Modules are looked up first within the extension artifacts in the extensions directory and then
+// within the kernel of root. Module files are inspected with modinfo chrooted in root.
+func CheckKernelModules(s *sys.System, root, kernelVersion string, exts []api.SystemdExtension) (*ModulesReport, error) {
+	fs := s.FS()
+
+	tempDir, err := vfs.TempDir(fs, "", "kmod-check-")
+	if err != nil {
+		return nil, fmt.Errorf("creating temp directory: %w", err)
+	}
+	defer func() {
+		_ = fs.RemoveAll(tempDir)
+	}()
+
+	binds := map[string]string{}
+	candidates := map[string]map[string]string{}
+	for _, ext := range exts {
+		if len(ext.KernelModules) == 0 {
+			continue
+		}
+
+		dirs, err := modulesDirs(s, ext, kernelVersion, filepath.Join(tempDir, ext.Name))
+		if err != nil {
+			return nil, fmt.Errorf("finding kernel modules of extension '%s': %w", ext.Name, err)
+		}
+
+		candidates[ext.Name] = map[string]string{}
+		for i, dir := range dirs {
+			target := filepath.Join(modulesCheckDir, ext.Name, strconv.Itoa(i))
+			binds[dir] = target
+
+			files, err := moduleFiles(fs, dir)
+			if err != nil {
+				return nil, fmt.Errorf("listing kernel modules of extension '%s': %w", ext.Name, err)
+			}
+			for name, file := range files {
+				candidates[ext.Name][name] = filepath.Join(target, file)
+			}
+		}
+	}
+
+	report := &ModulesReport{KernelVersion: kernelVersion}
+	callback := func() error {
+		for _, ext := range exts {
+			for _, module := range ext.KernelModules {
+				status := ModuleStatus{Extension: ext.Name, Module: module}
+				status.Path = resolveModule(s, kernelVersion, candidates[ext.Name][moduleName(module)], module)
+				report.Modules = append(report.Modules, status)
+			}
+		}
+		return nil
+	}
+
+	if err = chroot.ChrootedCallback(s, root, binds, callback); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// WriteModulesReport stores the given report in the given root
+func WriteModulesReport(s *sys.System, root string, report *ModulesReport) error {
+	data, err := yaml.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("marshalling kernel modules report: %w", err)
+	}
+
+	path := filepath.Join(root, ModulesReportFile)
+	if err = vfs.MkdirAll(s.FS(), filepath.Dir(path), vfs.DirPerm); err != nil {
+		return fmt.Errorf("creating kernel modules report directory: %w", err)
+	}
+
+	if err = s.FS().WriteFile(path, data, vfs.FilePerm); err != nil {
+		return fmt.Errorf("writing kernel modules report '%s': %w", path, err)
+	}
+	return nil
+}
+
+// ParseModulesReport reads the kernel modules report of the given root
+func ParseModulesReport(s *sys.System, root string) (*ModulesReport, error) {
+	path := filepath.Join(root, ModulesReportFile)
+
+	data, err := s.FS().ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading kernel modules report '%s': %w", path, err)
+	}
+
+	report := &ModulesReport{}
+	if err = yaml.Unmarshal(data, report); err != nil {
+		return nil, fmt.Errorf("unmarshalling kernel modules report '%s': %w", path, err)
+	}
+	return report, nil
+}
+
+// modulesDirs returns the directories including the kernel modules of the given extension for the given
+// kernel version. Modules of disk image extensions are extracted into the given directory.
+func modulesDirs(s *sys.System, ext api.SystemdExtension, kernelVersion, extractDir string) ([]string, error) {
+	paths, err := Paths(s.FS(), Dir, ext)
+	if err != nil {
+		return nil, err
+	}
+
+	modulesDir := filepath.Join("/usr/lib/modules", kernelVersion)
+
+	var dirs []string
+	for i, path := range paths {
+		if ok, _ := vfs.IsDir(s.FS(), path); ok {
+			dir := filepath.Join(path, modulesDir)
+			if ok, _ = vfs.IsDir(s.FS(), dir); ok {
+				dirs = append(dirs, dir)
+			}
+			continue
+		}
+
+		target := filepath.Join(extractDir, strconv.Itoa(i))
+		if err = vfs.MkdirAll(s.FS(), extractDir, vfs.DirPerm); err != nil {
+			return nil, err
+		}
+		if _, err = s.Runner().Run("systemd-dissect", "--copy-from", path, modulesDir, target); err != nil {
+			s.Logger().Debug("No kernel modules for kernel %s in '%s': %v", kernelVersion, path, err)
+			continue
+		}
+		dirs = append(dirs, target)
+	}
+	return dirs, nil
+}
+
+// moduleFiles returns the kernel module files within the given directory indexed by module name
+func moduleFiles(fs vfs.FS, dir string) (map[string]string, error) {
+	files := map[string]string{}
+	err := vfs.WalkDirFs(fs, dir, func(path string, d iofs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !isModuleFile(d.Name()) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files[moduleName(d.Name())] = rel
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return files, nil
+}
+
+// resolveModule returns the file the given module resolves to for the given kernel version. The module
+// file provided by the extension, if any, is verified to be built for the kernel version, otherwise
+// the module is looked up within the kernel modules. Returns an empty string if it can't be resolved.
+func resolveModule(s *sys.System, kernelVersion, file, module string) string {
+	if file != "" {
+		out, err := s.Runner().Run("modinfo", "-k", kernelVersion, "-F", "vermagic", file)
+		if err == nil && strings.HasPrefix(strings.TrimSpace(string(out)), kernelVersion+" ") {
+			return file
+		}
+		s.Logger().Warn("Kernel module file '%s' is not built for kernel %s", file, kernelVersion)
+	}
+
+	out, err := s.Runner().Run("modinfo", "-k", kernelVersion, "-F", "filename", module)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(out))
+}
+
+func isModuleFile(name string) bool {
+	for _, ext := range []string{".ko", ".ko.xz", ".ko.zst", ".ko.gz"} {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// moduleName normalizes a module or module file name the way modprobe does
+func moduleName(name string) string {
+	name, _, _ = strings.Cut(filepath.Base(name), ".ko")
+	return strings.ReplaceAll(name, "-", "_")
+}
@@ -0,0 +1,133 @@
+/*
+Copyright © 2022-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package extensions_test
+
+import (
+	"fmt"
+	"slices"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/extensions"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/manifest/api"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+var _ = Describe("Extension kernel modules", func() {
+	const kernelVersion = "6.12.0-160000.15-default"
+
+	var s *sys.System
+	var tfs vfs.FS
+	var cleanup func()
+	var runner *sysmock.Runner
+	var mounter *sysmock.Mounter
+
+	exts := []api.SystemdExtension{
+		{Name: "foo", KernelModules: []string{"foo_mod"}},
+		{Name: "bar", Image: "https://example.com/bar.raw", KernelModules: []string{"bar_mod", "missing"}},
+		{Name: "baz"},
+	}
+
+	BeforeEach(func() {
+		var err error
+		tfs, cleanup, err = sysmock.TestFS(map[string]string{
+			"/dev/pts/empty":  "",
+			"/proc/empty":     "",
+			"/sys/empty":      "",
+			"/root/etc/empty": "",
+			"/var/lib/extensions/foo/usr/lib/modules/" + kernelVersion + "/extra/foo-mod.ko.zst": "",
+			"/var/lib/extensions/bar.raw": "",
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		runner = sysmock.NewRunner()
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			switch {
+			case cmd == "systemd-dissect":
+				return nil, fmt.Errorf("no such file or directory")
+			case cmd == "modinfo" && slices.Contains(args, "vermagic"):
+				return []byte(kernelVersion + " SMP preempt mod_unload modversions\n"), nil
+			case cmd == "modinfo" && slices.Contains(args, "bar_mod"):
+				return []byte("(builtin)\n"), nil
+			case cmd == "modinfo":
+				return nil, fmt.Errorf("module %s not found", args[len(args)-1])
+			}
+			return nil, nil
+		}
+		mounter = sysmock.NewMounter()
+
+		s, err = sys.NewSystem(
+			sys.WithFS(tfs), sys.WithRunner(runner), sys.WithMounter(mounter),
+			sys.WithSyscall(&sysmock.Syscall{}), sys.WithLogger(log.New(log.WithDiscardAll())),
+		)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		cleanup()
+	})
+
+	It("resolves the kernel modules of the extensions for the given kernel", func() {
+		report, err := extensions.CheckKernelModules(s, "/root", kernelVersion, exts)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.KernelVersion).To(Equal(kernelVersion))
+		Expect(report.Modules).To(Equal([]extensions.ModuleStatus{
+			{Extension: "foo", Module: "foo_mod", Path: "/run/elemental/kmod-check/foo/0/extra/foo-mod.ko.zst"},
+			{Extension: "bar", Module: "bar_mod", Path: "(builtin)"},
+			{Extension: "bar", Module: "missing"},
+		}))
+		Expect(report.Missing()).To(Equal([]extensions.ModuleStatus{{Extension: "bar", Module: "missing"}}))
+
+		Expect(runner.CmdsMatch([][]string{
+			{"systemd-dissect", "--copy-from", "/var/lib/extensions/bar.raw", "/usr/lib/modules/" + kernelVersion},
+			{"modinfo", "-k", kernelVersion, "-F", "vermagic", "/run/elemental/kmod-check/foo/0/extra/foo-mod.ko.zst"},
+			{"modinfo", "-k", kernelVersion, "-F", "filename", "bar_mod"},
+			{"modinfo", "-k", kernelVersion, "-F", "filename", "missing"},
+			{"sync"},
+		})).To(Succeed())
+	})
+
+	It("falls back to the kernel modules if the extension module is built for another kernel", func() {
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			if slices.Contains(args, "vermagic") {
+				return []byte("6.4.0-150600.23-default SMP mod_unload\n"), nil
+			}
+			return nil, fmt.Errorf("not found")
+		}
+
+		report, err := extensions.CheckKernelModules(s, "/root", kernelVersion, exts[:1])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.Missing()).To(Equal([]extensions.ModuleStatus{{Extension: "foo", Module: "foo_mod"}}))
+	})
+
+	It("stores and parses the report", func() {
+		report := &extensions.ModulesReport{
+			KernelVersion: kernelVersion,
+			Modules:       []extensions.ModuleStatus{{Extension: "foo", Module: "foo_mod"}},
+		}
+		Expect(extensions.WriteModulesReport(s, "/root", report)).To(Succeed())
+		Expect(extensions.ParseModulesReport(s, "/root")).To(Equal(report))
+
+		_, err := extensions.ParseModulesReport(s, "/")
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,211 @@
+/*
+Copyright © 2022-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package extensions
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/suse/elemental/v3/pkg/manifest/api"
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/platform"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+const (
+	// ReleaseDir is the directory including the extension-release file of an extension
+	ReleaseDir = "/usr/lib/extension-release.d"
+	// OSReleaseFile is the os-release file extensions are checked against
+	OSReleaseFile = "/usr/lib/os-release"
+
+	anyValue = "_any"
+)
+
+// Release holds the fields of an extension-release or os-release file systemd-sysext
+// matches before merging an extension
+type Release struct {
+	ID           string
+	VersionID    string
+	SysextLevel  string
+	Architecture string
+}
+
+// NewRelease returns the release of the given os-release or extension-release variables
+func NewRelease(vars map[string]string) Release {
+	return Release{
+		ID:           vars["ID"],
+		VersionID:    vars["VERSION_ID"],
+		SysextLevel:  vars["SYSEXT_LEVEL"],
+		Architecture: vars["ARCHITECTURE"],
+	}
+}
+
+// ReadOSRelease reads the os-release file of the given root
+func ReadOSRelease(s *sys.System, root string) (Release, error) {
+	path := filepath.Join(root, OSReleaseFile)
+	if ok, _ := vfs.Exists(s.FS(), path); !ok {
+		path = filepath.Join(root, "/etc/os-release")
+	}
+
+	vars, err := vfs.LoadEnvFile(s.FS(), path)
+	if err != nil {
+		return Release{}, fmt.Errorf("loading os-release file: %w", err)
+	}
+	return NewRelease(vars), nil
+}
+
+// ReadRelease reads the extension-release file of the extension at the given path, either
+// a directory tree or a disk image. Disk images are inspected with systemd-dissect.
+func ReadRelease(s *sys.System, path string) (Release, error) {
+	fs := s.FS()
+
+	root := path
+	if ok, _ := vfs.IsDir(fs, path); !ok {
+		tempDir, err := vfs.TempDir(fs, "", "extension-release-")
+		if err != nil {
+			return Release{}, fmt.Errorf("creating temp directory: %w", err)
+		}
+		defer func() {
+			_ = fs.RemoveAll(tempDir)
+		}()
+
+		target := filepath.Join(tempDir, ReleaseDir)
+		if err = vfs.MkdirAll(fs, filepath.Dir(target), vfs.DirPerm); err != nil {
+			return Release{}, fmt.Errorf("creating extension-release directory: %w", err)
+		}
+
+		_, err = s.Runner().Run("systemd-dissect", "--copy-from", path, ReleaseDir, target)
+		if err != nil {
+			return Release{}, fmt.Errorf("extracting extension-release file from '%s': %w", path, err)
+		}
+		root = tempDir
+	}
+
+	releaseFile := ""
+	entries, _ := fs.ReadDir(filepath.Join(root, ReleaseDir))
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasPrefix(e.Name(), "extension-release.") {
+			releaseFile = filepath.Join(root, ReleaseDir, e.Name())
+			break
+		}
+	}
+	if releaseFile == "" {
+		return Release{}, fmt.Errorf("extension-release file not found in '%s'", path)
+	}
+
+	vars, err := vfs.LoadEnvFile(fs, releaseFile)
+	if err != nil {
+		return Release{}, fmt.Errorf("loading extension-release file: %w", err)
+	}
+	return NewRelease(vars), nil
+}
+
+// CheckCompatibility verifies an extension with this release can be merged on an OS with the given
+// os-release and architecture, applying the same rules as systemd-sysext.
+func (r Release) CheckCompatibility(os Release, arch string) error {
+	if r.ID == "" {
+		return fmt.Errorf("extension-release ID is not set")
+	}
+	if r.ID != anyValue && r.ID != os.ID {
+		return fmt.Errorf("ID '%s' does not match the OS ID '%s'", r.ID, os.ID)
+	}
+
+	if r.ID != anyValue {
+		switch {
+		case r.SysextLevel != "":
+			if r.SysextLevel != os.SysextLevel {
+				return fmt.Errorf("SYSEXT_LEVEL '%s' does not match the OS SYSEXT_LEVEL '%s'", r.SysextLevel, os.SysextLevel)
+			}
+		case r.VersionID != "":
+			if r.VersionID != os.VersionID {
+				return fmt.Errorf("VERSION_ID '%s' does not match the OS VERSION_ID '%s'", r.VersionID, os.VersionID)
+			}
+		default:
+			return fmt.Errorf("neither SYSEXT_LEVEL nor VERSION_ID are set")
+		}
+	}
+
+	if r.Architecture != "" && r.Architecture != anyValue && r.Architecture != arch {
+		return fmt.Errorf("ARCHITECTURE '%s' does not match the OS architecture '%s'", r.Architecture, arch)
+	}
+
+	return nil
+}
+
+// Architecture returns the systemd architecture identifier of the given platform
+func Architecture(p *platform.Platform) string {
+	if p == nil {
+		return ""
+	}
+	if p.Arch == platform.Archx86 {
+		return "x86-64"
+	}
+	return p.Arch
+}
+
+// Paths returns the paths of the artifacts of the given extension within the given extensions directory.
+// These are either a directory or an image file named after the extension or the file downloaded
+// from the extension URL.
+func Paths(fs vfs.FS, dir string, ext api.SystemdExtension) ([]string, error) {
+	entries, err := fs.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("reading extensions directory: %w", err)
+	}
+
+	var paths []string
+	for _, e := range entries {
+		name := e.Name()
+		if name == ext.Name || strings.TrimSuffix(name, filepath.Ext(name)) == ext.Name ||
+			(IsRemoteURL(ext.Image) && name == filepath.Base(ext.Image)) {
+			paths = append(paths, filepath.Join(dir, name))
+		}
+	}
+	return paths, nil
+}
+
+// IsRemoteURL reports whether the given extension image is an HTTP(S) URL to download
+// instead of an OCI image reference
+func IsRemoteURL(s string) bool {
+	u, err := url.Parse(s)
+	if err != nil {
+		return false
+	}
+
+	return u.Scheme == "http" || u.Scheme == "https"
+}
+
+// Check verifies all the given extension artifacts are compatible with the given os-release and architecture
+func Check(s *sys.System, paths []string, osRelease Release, arch string) error {
+	if len(paths) == 0 {
+		return fmt.Errorf("no extension artifacts found")
+	}
+
+	for _, path := range paths {
+		release, err := ReadRelease(s, path)
+		if err != nil {
+			return err
+		}
+		if err = release.CheckCompatibility(osRelease, arch); err != nil {
+			return fmt.Errorf("'%s' is not compatible: %w", filepath.Base(path), err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+/*
+Copyright © 2022-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package extensions_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/extensions"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/manifest/api"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/platform"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+func TestExtensionsSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Extensions test suite")
+}
+
+var _ = Describe("Extension release", func() {
+	osRelease := extensions.Release{ID: "sl-micro", VersionID: "6.2", SysextLevel: "1.0"}
+
+	It("matches extensions built for the OS version", func() {
+		ext := extensions.Release{ID: "sl-micro", VersionID: "6.2"}
+		Expect(ext.CheckCompatibility(osRelease, "x86-64")).To(Succeed())
+	})
+
+	It("matches on SYSEXT_LEVEL over VERSION_ID", func() {
+		ext := extensions.Release{ID: "sl-micro", VersionID: "6.1", SysextLevel: "1.0"}
+		Expect(ext.CheckCompatibility(osRelease, "x86-64")).To(Succeed())
+
+		ext.SysextLevel = "2.0"
+		Expect(ext.CheckCompatibility(osRelease, "x86-64")).To(MatchError(
+			"SYSEXT_LEVEL '2.0' does not match the OS SYSEXT_LEVEL '1.0'",
+		))
+	})
+
+	It("matches extensions for any OS", func() {
+		ext := extensions.Release{ID: "_any", Architecture: "_any"}
+		Expect(ext.CheckCompatibility(osRelease, "arm64")).To(Succeed())
+	})
+
+	It("fails on mismatching fields", func() {
+		ext := extensions.Release{ID: "fedora", VersionID: "6.2"}
+		Expect(ext.CheckCompatibility(osRelease, "x86-64")).To(MatchError("ID 'fedora' does not match the OS ID 'sl-micro'"))
+
+		ext = extensions.Release{ID: "sl-micro", VersionID: "6.1"}
+		Expect(ext.CheckCompatibility(osRelease, "x86-64")).To(MatchError("VERSION_ID '6.1' does not match the OS VERSION_ID '6.2'"))
+
+		ext = extensions.Release{ID: "sl-micro", VersionID: "6.2", Architecture: "arm64"}
+		Expect(ext.CheckCompatibility(osRelease, "x86-64")).To(MatchError("ARCHITECTURE 'arm64' does not match the OS architecture 'x86-64'"))
+
+		ext = extensions.Release{ID: "sl-micro"}
+		Expect(ext.CheckCompatibility(osRelease, "x86-64")).To(MatchError("neither SYSEXT_LEVEL nor VERSION_ID are set"))
+
+		Expect(extensions.Release{}.CheckCompatibility(osRelease, "x86-64")).To(MatchError("extension-release ID is not set"))
+	})
+
+	It("maps platforms to systemd architectures", func() {
+		Expect(extensions.Architecture(&platform.Platform{Arch: platform.Archx86})).To(Equal("x86-64"))
+		Expect(extensions.Architecture(&platform.Platform{Arch: platform.ArchArm64})).To(Equal("arm64"))
+	})
+
+	Describe("Artifacts", func() {
+		var s *sys.System
+		var tfs vfs.FS
+		var cleanup func()
+		var runner *sysmock.Runner
+
+		BeforeEach(func() {
+			var err error
+			tfs, cleanup, err = sysmock.TestFS(map[string]string{
+				"/etc/os-release": "ID=sl-micro\nVERSION_ID=\"6.2\"\n",
+				"/extensions/foo/usr/lib/extension-release.d/extension-release.foo": "ID=sl-micro\nVERSION_ID=6.2\n",
+				"/extensions/bar.raw": "",
+				"/extensions/baz.raw": "",
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			runner = sysmock.NewRunner()
+			s, err = sys.NewSystem(sys.WithFS(tfs), sys.WithRunner(runner), sys.WithLogger(log.New(log.WithDiscardAll())))
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			cleanup()
+		})
+
+		It("reads the os-release file of a root", func() {
+			Expect(extensions.ReadOSRelease(s, "/")).To(Equal(extensions.Release{ID: "sl-micro", VersionID: "6.2"}))
+		})
+
+		It("reads the extension-release file of directory extensions", func() {
+			Expect(extensions.ReadRelease(s, "/extensions/foo")).To(Equal(extensions.Release{ID: "sl-micro", VersionID: "6.2"}))
+			Expect(runner.GetCmds()).To(BeEmpty())
+		})
+
+		It("reads the extension-release file of image extensions", func() {
+			runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+				Expect(vfs.MkdirAll(tfs, args[3], vfs.DirPerm)).To(Succeed())
+				return nil, tfs.WriteFile(args[3]+"/extension-release.bar", []byte("ID=_any\n"), vfs.FilePerm)
+			}
+
+			Expect(extensions.ReadRelease(s, "/extensions/bar.raw")).To(Equal(extensions.Release{ID: "_any"}))
+			Expect(runner.CmdsMatch([][]string{
+				{"systemd-dissect", "--copy-from", "/extensions/bar.raw", extensions.ReleaseDir},
+			})).To(Succeed())
+		})
+
+		It("fails if the extension has no extension-release file", func() {
+			_, err := extensions.ReadRelease(s, "/extensions/baz.raw")
+			Expect(err).To(MatchError("extension-release file not found in '/extensions/baz.raw'"))
+		})
+
+		It("finds the artifacts of an extension", func() {
+			Expect(extensions.Paths(tfs, "/extensions", api.SystemdExtension{Name: "foo"})).To(Equal([]string{"/extensions/foo"}))
+			Expect(extensions.Paths(tfs, "/extensions", api.SystemdExtension{
+				Name: "other", Image: "https://example.com/bar.raw",
+			})).To(Equal([]string{"/extensions/bar.raw"}))
+			Expect(extensions.Paths(tfs, "/missing", api.SystemdExtension{Name: "foo"})).To(BeEmpty())
+		})
+
+		It("checks all artifacts", func() {
+			osRelease, err := extensions.ReadOSRelease(s, "/")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(extensions.Check(s, []string{"/extensions/foo"}, osRelease, "x86-64")).To(Succeed())
+			Expect(extensions.Check(s, nil, osRelease, "x86-64")).To(MatchError("no extension artifacts found"))
+		})
+	})
+})
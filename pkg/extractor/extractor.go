@@ -35,11 +35,16 @@ type OCIUnpacker interface {
 }
 
 type ociUnpacker struct {
-	system *sys.System
+	system   *sys.System
+	verifier unpack.SignatureVerifier
+	source   unpack.ImageSource
 }
 
 func (o *ociUnpacker) Unpack(ctx context.Context, uri, dest string, local bool) (digest string, err error) {
-	unpacker := unpack.NewOCIUnpacker(o.system, uri, unpack.WithLocalOCI(local))
+	unpacker := unpack.NewOCIUnpacker(
+		o.system, uri, unpack.WithLocalOCI(local), unpack.WithSignatureVerifierOCI(o.verifier),
+		unpack.WithImageSourceOCI(o.source),
+	)
 	return unpacker.Unpack(ctx, dest)
 }
 
@@ -59,6 +64,8 @@ type OCIFileExtractor struct {
 	fs       vfs.FS
 	ctx      context.Context
 	local    bool
+	verifier unpack.SignatureVerifier
+	source   unpack.ImageSource
 }
 
 type OCIFileExtractorOpts func(o *OCIFileExtractor)
@@ -93,6 +100,22 @@ func WithLocal(local bool) OCIFileExtractorOpts {
 	}
 }
 
+// WithSignatureVerifier sets the verifier of the OCI image signatures, it has
+// no effect if a custom OCI unpacker is set
+func WithSignatureVerifier(v unpack.SignatureVerifier) OCIFileExtractorOpts {
+	return func(r *OCIFileExtractor) {
+		r.verifier = v
+	}
+}
+
+// WithImageSource sets the source OCI images are fetched from instead of remote registries,
+// it has no effect if a custom OCI unpacker is set
+func WithImageSource(src unpack.ImageSource) OCIFileExtractorOpts {
+	return func(r *OCIFileExtractor) {
+		r.source = src
+	}
+}
+
 func New(searchPaths []string, opts ...OCIFileExtractorOpts) (*OCIFileExtractor, error) {
 	extr := &OCIFileExtractor{
 		searchPaths: searchPaths,
@@ -124,7 +147,9 @@ func New(searchPaths []string, opts ...OCIFileExtractorOpts) (*OCIFileExtractor,
 		}
 
 		extr.unpacker = &ociUnpacker{
-			system: s,
+			system:   s,
+			verifier: extr.verifier,
+			source:   extr.source,
 		}
 	}
 
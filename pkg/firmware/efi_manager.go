@@ -18,10 +18,7 @@ limitations under the License.
 package firmware
 
 import (
-	"path/filepath"
-
 	"github.com/suse/elemental/v3/pkg/sys"
-	"github.com/suse/elemental/v3/pkg/sys/platform"
 )
 
 const (
@@ -66,21 +63,11 @@ func (b *EfiBootManager) CreateBootEntries(entries []*EfiBootEntry) error {
 	return nil
 }
 
-// DefaultBootEntry generates the default EFI boot entry for the platform.
-func DefaultBootEntry(p *platform.Platform, disk string) *EfiBootEntry {
-	efiImgName := ""
-	switch p.Arch {
-	case platform.Archx86:
-		efiImgName = EfiImgX86
-	case platform.ArchAarch64:
-		efiImgName = EfiImgArm64
-	case platform.ArchRiscv64:
-		efiImgName = EfiImgRiscv64
-	}
-
+// DefaultBootEntry generates the default EFI boot entry for the given EFI application of the bootloader.
+func DefaultBootEntry(loader, disk string) *EfiBootEntry {
 	return &EfiBootEntry{
 		Label:  EfiBootEntryName,
-		Loader: filepath.Join(EfiEntryPath, efiImgName),
+		Loader: loader,
 		Disk:   disk,
 	}
 }
@@ -18,6 +18,7 @@ limitations under the License.
 package helm
 
 import (
+	"encoding/base64"
 	"fmt"
 	"strings"
 )
@@ -45,6 +46,7 @@ type Spec struct {
 	Chart                 string      `yaml:"chart"`
 	Version               string      `yaml:"version"`
 	Repo                  string      `yaml:"repo,omitempty"`
+	ChartContent          string      `yaml:"chartContent,omitempty"`
 	ValuesContent         string      `yaml:"valuesContent,omitempty"`
 	TargetNamespace       string      `yaml:"targetNamespace,omitempty"`
 	CreateNamespace       bool        `yaml:"createNamespace,omitempty"`
@@ -121,3 +123,14 @@ func NewCRD(namespace, chart, version, valuesContent string, repository string,
 
 	return crd
 }
+
+// EmbedChart inlines the given chart archive in the resource, so that the chart is installed
+// without fetching it from its repository. Repository references and credentials are dropped.
+func (c *CRD) EmbedChart(archive []byte) {
+	c.Spec.Chart = c.Metadata.Name
+	c.Spec.Repo = ""
+	c.Spec.ChartContent = base64.StdEncoding.EncodeToString(archive)
+	c.Spec.RegistryAuthSecret = nil
+	c.Spec.RepositoryAuthSecret = nil
+	c.Spec.InsecureSkipTLSVerify = false
+}
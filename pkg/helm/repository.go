@@ -0,0 +1,230 @@
+/*
+Copyright © 2022-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"go.yaml.in/yaml/v3"
+)
+
+const (
+	// ChartContentMediaType is the media type of the chart tarball layer of charts stored in OCI registries
+	ChartContentMediaType = "application/vnd.cncf.helm.chart.content.v1.tar+gzip"
+
+	ociScheme     = "oci://"
+	indexFile     = "index.yaml"
+	fetchTimeout  = 90 * time.Second
+	maxChartBytes = 100 << 20
+)
+
+type repositoryIndex struct {
+	Entries map[string][]struct {
+		Version string   `yaml:"version"`
+		URLs    []string `yaml:"urls"`
+		Digest  string   `yaml:"digest"`
+	} `yaml:"entries"`
+}
+
+type fetchOptions struct {
+	username      string
+	password      string
+	skipTLSVerify bool
+}
+
+type FetchOpt func(*fetchOptions)
+
+// WithBasicAuth sets the credentials used to authenticate against the chart repository
+func WithBasicAuth(username, password string) FetchOpt {
+	return func(o *fetchOptions) {
+		o.username = username
+		o.password = password
+	}
+}
+
+// WithInsecureSkipTLSVerify disables the verification of the repository TLS certificates
+func WithInsecureSkipTLSVerify(skip bool) FetchOpt {
+	return func(o *fetchOptions) {
+		o.skipTLSVerify = skip
+	}
+}
+
+// FetchChart downloads the tarball of the given chart version from an HTTP(s) chart repository
+// or an OCI registry, if the repository has the oci:// scheme.
+func FetchChart(ctx context.Context, repository, chart, version string, opts ...FetchOpt) ([]byte, error) {
+	o := &fetchOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if strings.HasPrefix(repository, ociScheme) {
+		return fetchOCIChart(ctx, repository, chart, version, o)
+	}
+	return fetchRepositoryChart(ctx, repository, chart, version, o)
+}
+
+func fetchOCIChart(ctx context.Context, repository, chart, version string, o *fetchOptions) ([]byte, error) {
+	nameOpts := []name.Option{}
+	if o.skipTLSVerify {
+		nameOpts = append(nameOpts, name.Insecure)
+	}
+
+	reference := fmt.Sprintf("%s/%s:%s", strings.TrimSuffix(strings.TrimPrefix(repository, ociScheme), "/"), chart, version)
+	ref, err := name.ParseReference(reference, nameOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("parsing chart reference '%s': %w", reference, err)
+	}
+
+	remoteOpts := []remote.Option{remote.WithContext(ctx), remote.WithAuthFromKeychain(authn.DefaultKeychain)}
+	if o.username != "" {
+		remoteOpts = append(remoteOpts, remote.WithAuth(&authn.Basic{Username: o.username, Password: o.password}))
+	}
+	if o.skipTLSVerify {
+		remoteOpts = append(remoteOpts, remote.WithTransport(insecureTransport()))
+	}
+
+	img, err := remote.Image(ref, remoteOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("fetching chart '%s': %w", reference, err)
+	}
+
+	layers, err := img.Layers()
+	if err != nil {
+		return nil, fmt.Errorf("reading chart '%s' layers: %w", reference, err)
+	}
+
+	for _, layer := range layers {
+		mediaType, err := layer.MediaType()
+		if err != nil {
+			return nil, err
+		}
+		if string(mediaType) != ChartContentMediaType {
+			continue
+		}
+
+		reader, err := layer.Compressed()
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = reader.Close() }()
+
+		return readAll(reader, maxChartBytes)
+	}
+
+	return nil, fmt.Errorf("chart content not found in '%s'", reference)
+}
+
+func fetchRepositoryChart(ctx context.Context, repository, chart, version string, o *fetchOptions) ([]byte, error) {
+	repoURL, err := url.Parse(strings.TrimSuffix(repository, "/") + "/")
+	if err != nil {
+		return nil, fmt.Errorf("parsing repository url '%s': %w", repository, err)
+	}
+
+	data, err := get(ctx, repoURL.JoinPath(indexFile).String(), o)
+	if err != nil {
+		return nil, fmt.Errorf("fetching index of repository '%s': %w", repository, err)
+	}
+
+	index := &repositoryIndex{}
+	if err = yaml.Unmarshal(data, index); err != nil {
+		return nil, fmt.Errorf("parsing index of repository '%s': %w", repository, err)
+	}
+
+	for _, entry := range index.Entries[chart] {
+		if strings.TrimPrefix(entry.Version, "v") != strings.TrimPrefix(version, "v") || len(entry.URLs) == 0 {
+			continue
+		}
+
+		chartURL, err := repoURL.Parse(entry.URLs[0])
+		if err != nil {
+			return nil, fmt.Errorf("parsing url of chart '%s': %w", chart, err)
+		}
+
+		data, err = get(ctx, chartURL.String(), o)
+		if err != nil {
+			return nil, fmt.Errorf("downloading chart '%s': %w", chart, err)
+		}
+
+		if entry.Digest != "" {
+			digest := sha256.Sum256(data)
+			if hex.EncodeToString(digest[:]) != entry.Digest {
+				return nil, fmt.Errorf("chart '%s' does not match the digest '%s' of repository '%s'", chart, entry.Digest, repository)
+			}
+		}
+		return data, nil
+	}
+
+	return nil, fmt.Errorf("chart '%s' version '%s' not found in repository '%s'", chart, version, repository)
+}
+
+func get(ctx context.Context, url string, o *fetchOptions) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	if o.username != "" {
+		req.SetBasicAuth(o.username, o.password)
+	}
+
+	client := &http.Client{Timeout: fetchTimeout}
+	if o.skipTLSVerify {
+		client.Transport = insecureTransport()
+	}
+
+	resp, err := client.Do(req) // #nosec G704 -- url is assumed to be trusted.
+	if err != nil {
+		return nil, fmt.Errorf("executing request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	return readAll(resp.Body, maxChartBytes)
+}
+
+// readAll reads the given reader until EOF and fails if it provides more than limit bytes
+func readAll(r io.Reader, limit int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("content exceeds the maximum size of %d bytes", limit)
+	}
+	return data, nil
+}
+
+func insecureTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true} // #nosec G402 -- explicitly requested by the repository configuration
+	return transport
+}
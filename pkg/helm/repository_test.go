@@ -0,0 +1,114 @@
+/*
+Copyright © 2022-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+import (
+	"context"
+	"fmt"
+	"io"
+	stdlog "log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+var _ = Describe("Chart repository tests", func() {
+	It("fetches a chart from an HTTP repository", func() {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/charts/index.yaml", func(w http.ResponseWriter, r *http.Request) {
+			user, pass, ok := r.BasicAuth()
+			if !ok || user != "user" || pass != "pass" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = fmt.Fprint(w, `entries:
+  metallb:
+  - version: 0.15.2
+    urls:
+    - metallb-0.15.2.tgz
+    digest: 447b4822ecd91d57dcdc75e18556685174247d1ad7277f675bcfcf95e636013b
+  - version: 0.15.0
+    urls:
+    - metallb-0.15.0.tgz
+    digest: 0000000000000000000000000000000000000000000000000000000000000000
+`)
+		})
+		mux.HandleFunc("/charts/metallb-0.15.2.tgz", func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = fmt.Fprint(w, "chart content")
+		})
+		mux.HandleFunc("/charts/metallb-0.15.0.tgz", func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = fmt.Fprint(w, "tampered chart content")
+		})
+		server := httptest.NewServer(mux)
+		defer server.Close()
+
+		data, err := FetchChart(context.Background(), server.URL+"/charts", "metallb", "0.15.2", WithBasicAuth("user", "pass"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal("chart content"))
+
+		_, err = FetchChart(context.Background(), server.URL+"/charts", "metallb", "0.15.2")
+		Expect(err).To(MatchError(ContainSubstring("unexpected status code: 401")))
+
+		_, err = FetchChart(context.Background(), server.URL+"/charts", "metallb", "0.16.0", WithBasicAuth("user", "pass"))
+		Expect(err).To(MatchError(ContainSubstring("version '0.16.0' not found")))
+
+		_, err = FetchChart(context.Background(), server.URL+"/charts", "metallb", "0.15.0", WithBasicAuth("user", "pass"))
+		Expect(err).To(MatchError(ContainSubstring("does not match the digest")))
+	})
+
+	It("fails to read content bigger than the limit", func() {
+		data, err := readAll(strings.NewReader("chart content"), 13)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal("chart content"))
+
+		_, err = readAll(strings.NewReader("chart content"), 12)
+		Expect(err).To(MatchError("content exceeds the maximum size of 12 bytes"))
+	})
+
+	It("fetches a chart from an OCI registry", func() {
+		server := httptest.NewServer(registry.New(registry.Logger(stdlog.New(io.Discard, "", 0))))
+		defer server.Close()
+		u, err := url.Parse(server.URL)
+		Expect(err).NotTo(HaveOccurred())
+
+		img, err := mutate.Append(
+			mutate.MediaType(empty.Image, types.OCIManifestSchema1),
+			mutate.Addendum{Layer: static.NewLayer([]byte("chart content"), ChartContentMediaType)},
+		)
+		Expect(err).NotTo(HaveOccurred())
+		ref, err := name.ParseReference(fmt.Sprintf("%s/charts/metallb:0.15.2", u.Host))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(remote.Write(ref, img)).To(Succeed())
+
+		data, err := FetchChart(context.Background(), fmt.Sprintf("oci://%s/charts", u.Host), "metallb", "0.15.2")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal("chart content"))
+	})
+})
@@ -21,6 +21,7 @@ import (
 	"context"
 	"fmt"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/suse/elemental/v3/pkg/block"
@@ -30,6 +31,8 @@ import (
 	"github.com/suse/elemental/v3/pkg/cleanstack"
 	"github.com/suse/elemental/v3/pkg/deployment"
 	"github.com/suse/elemental/v3/pkg/installer"
+	"github.com/suse/elemental/v3/pkg/luks"
+	"github.com/suse/elemental/v3/pkg/raid"
 	"github.com/suse/elemental/v3/pkg/repart"
 	"github.com/suse/elemental/v3/pkg/sys"
 	"github.com/suse/elemental/v3/pkg/sys/vfs"
@@ -110,6 +113,11 @@ func (i Installer) Install(d *deployment.Deployment) (err error) {
 	cleanup := cleanstack.NewCleanStack()
 	defer func() { err = cleanup.Cleanup(err) }()
 
+	err = unpack.Verify(i.ctx, i.s, d.SourceOS, i.unpackOpts...)
+	if err != nil {
+		return fmt.Errorf("verifying OS image: %w", err)
+	}
+
 	err = i.checkTargetDisks(d)
 	if err != nil {
 		return err
@@ -120,6 +128,14 @@ func (i Installer) Install(d *deployment.Deployment) (err error) {
 		if err != nil {
 			return fmt.Errorf("partitioning disk '%s': %w", disk.Device, err)
 		}
+		err = luks.Setup(i.s, cleanup, lsblk.NewLsDevice(i.s), disk)
+		if err != nil {
+			return fmt.Errorf("setting up encrypted partitions: %w", err)
+		}
+		err = raid.Setup(i.s, cleanup, lsblk.NewLsDevice(i.s), disk)
+		if err != nil {
+			return fmt.Errorf("setting up RAID partitions: %w", err)
+		}
 		for _, part := range disk.Partitions {
 			i.s.Logger().Debug("creating partition volumes: %+v", part.RWVolumes)
 			err = createPartitionVolumes(i.s, cleanup, part)
@@ -129,6 +145,11 @@ func (i Installer) Install(d *deployment.Deployment) (err error) {
 		}
 	}
 
+	err = i.installLUKSKeyFile(cleanup, d)
+	if err != nil {
+		return fmt.Errorf("installing LUKS key file: %w", err)
+	}
+
 	err = i.installRecoveryPartition(cleanup, d)
 	if err != nil {
 		return fmt.Errorf("installing recovery system: %w", err)
@@ -151,6 +172,14 @@ func (i Installer) Reset(d *deployment.Deployment) (err error) {
 		if err != nil {
 			return fmt.Errorf("partitioning disk '%s': %w", disk.Device, err)
 		}
+		err = luks.Setup(i.s, cleanup, lsblk.NewLsDevice(i.s), disk)
+		if err != nil {
+			return fmt.Errorf("setting up encrypted partitions: %w", err)
+		}
+		err = raid.Setup(i.s, cleanup, lsblk.NewLsDevice(i.s), disk)
+		if err != nil {
+			return fmt.Errorf("setting up RAID partitions: %w", err)
+		}
 		for _, part := range disk.Partitions {
 			i.s.Logger().Debug("creating partition volumes: %+v", part.RWVolumes)
 			err = createPartitionVolumes(i.s, cleanup, part)
@@ -168,16 +197,57 @@ func (i Installer) Reset(d *deployment.Deployment) (err error) {
 	return nil
 }
 
+// Deploy installs the given deployment on disks which are already partitioned and formatted,
+// such as disk images created with repart.CreateDiskImage. Partition UUIDs are expected to be
+// set in the deployment. Encrypted and RAID partitions are not supported.
+func (i Installer) Deploy(d *deployment.Deployment) (err error) {
+	cleanup := cleanstack.NewCleanStack()
+	defer func() { err = cleanup.Cleanup(err) }()
+
+	if len(d.GetEncryptedPartitions()) > 0 || len(d.GetRAIDPartitions()) > 0 {
+		return fmt.Errorf("encrypted and RAID partitions are not supported on already partitioned disks")
+	}
+
+	for _, disk := range d.Disks {
+		for _, part := range disk.Partitions {
+			i.s.Logger().Debug("creating partition volumes: %+v", part.RWVolumes)
+			err = createPartitionVolumes(i.s, cleanup, part)
+			if err != nil {
+				return fmt.Errorf("creating partition volumes: %w", err)
+			}
+		}
+	}
+
+	err = i.installRecoveryPartition(cleanup, d)
+	if err != nil {
+		return fmt.Errorf("installing recovery system: %w", err)
+	}
+
+	err = i.u.Upgrade(d)
+	if err != nil {
+		return fmt.Errorf("executing transaction: %w", err)
+	}
+
+	return nil
+}
+
 func (i Installer) checkTargetDisks(d *deployment.Deployment) error {
 	bDev := lsblk.NewLsDevice(i.s)
+	devices := []string{}
 	for _, disk := range d.Disks {
-		parts, err := bDev.GetDevicePartitions(disk.Device)
+		devices = append(devices, disk.Device)
+	}
+	for _, part := range d.GetRAIDPartitions() {
+		devices = append(devices, part.RAID.Devices...)
+	}
+	for _, device := range devices {
+		parts, err := bDev.GetDevicePartitions(device)
 		if err != nil {
 			return fmt.Errorf("failed to list target device partitions: %w", err)
 		}
 		for _, part := range parts {
 			if part != nil && len(part.MountPoints) > 0 {
-				return fmt.Errorf("cannot install, target device (%s) has active mountpoints: %v", disk.Device, part.MountPoints)
+				return fmt.Errorf("cannot install, target device (%s) has active mountpoints: %v", device, part.MountPoints)
 			}
 		}
 	}
@@ -224,6 +294,39 @@ func (i Installer) installRecoveryPartition(cleanup *cleanstack.CleanStack, d *d
 	return nil
 }
 
+// installLUKSKeyFile copies the key file into the config partition for encrypted
+// partitions that are unlocked at boot from the config partition.
+func (i Installer) installLUKSKeyFile(cleanup *cleanstack.CleanStack, d *deployment.Deployment) error {
+	confPart := d.GetConfigPartition()
+	if confPart == nil {
+		return nil
+	}
+	needsKey := slices.ContainsFunc(d.GetEncryptedPartitions(), func(p *deployment.Partition) bool {
+		return p.Encryption.KeySource == deployment.KeyFile
+	})
+	if !needsKey {
+		return nil
+	}
+
+	mountPoint, err := vfs.TempDir(i.s.FS(), "", "elemental_"+confPart.Role.String())
+	if err != nil {
+		return fmt.Errorf("creating temporary directory to mount config partition: %w", err)
+	}
+	cleanup.PushSuccessOnly(func() error { return i.s.FS().RemoveAll(mountPoint) })
+
+	bPart, err := block.GetPartitionByUUID(i.s, lsblk.NewLsDevice(i.s), confPart.UUID, 4)
+	if err != nil {
+		return fmt.Errorf("finding partition '%s': %w", confPart.UUID, err)
+	}
+	err = i.s.Mounter().Mount(bPart.Path, mountPoint, "", []string{"rw"})
+	if err != nil {
+		return fmt.Errorf("mounting partition '%s': %w", bPart.Path, err)
+	}
+	cleanup.Push(func() error { return i.s.Mounter().Unmount(mountPoint) })
+
+	return luks.InstallKeyFile(i.s, mountPoint, d)
+}
+
 func createPartitionVolumes(s *sys.System, cleanStack *cleanstack.CleanStack, part *deployment.Partition) (err error) {
 	var mountPoint string
 
@@ -239,9 +342,10 @@ func createPartitionVolumes(s *sys.System, cleanStack *cleanstack.CleanStack, pa
 		if err != nil {
 			return fmt.Errorf("finding partition '%s': %w", part.UUID, err)
 		}
-		err = s.Mounter().Mount(bPart.Path, mountPoint, "", []string{})
+		device := raid.DevicePath(part, luks.DevicePath(part, bPart.Path))
+		err = s.Mounter().Mount(device, mountPoint, "", []string{})
 		if err != nil {
-			return fmt.Errorf("mounting partition '%s': %w", bPart.Path, err)
+			return fmt.Errorf("mounting partition '%s': %w", device, err)
 		}
 		cleanStack.Push(func() error { return s.Mounter().Unmount(mountPoint) })
 
@@ -32,6 +32,7 @@ import (
 	"github.com/suse/elemental/v3/pkg/sys"
 	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
 	"github.com/suse/elemental/v3/pkg/sys/vfs"
+	"github.com/suse/elemental/v3/pkg/unpack"
 )
 
 func TestInstallSuite(t *testing.T) {
@@ -94,6 +95,16 @@ const lsblkJson = `{
 	]
  }`
 
+type verifierMock struct {
+	refs []string
+	err  error
+}
+
+func (v *verifierMock) Verify(_ context.Context, imageRef string, _ bool) (string, error) {
+	v.refs = append(v.refs, imageRef)
+	return "", v.err
+}
+
 type upgraderMock struct {
 	Error error
 }
@@ -201,6 +212,17 @@ var _ = Describe("Install", Label("install"), func() {
 		deployment.WithRecoveryPartition(0)(d)
 		Expect(i.Install(d)).To(MatchError(ContainSubstring("mksquasfs call failed")))
 	})
+	It("fails before partitioning if the OS image signature is not valid", func() {
+		d.SourceOS = deployment.NewOCISrc("registry.example.com/os:1.0")
+		verifier := &verifierMock{err: fmt.Errorf("no valid signature found")}
+		i = install.New(
+			context.Background(), s, install.WithUpgrader(upgrader),
+			install.WithUnpackOpts(unpack.WithSignatureVerifier(verifier)),
+		)
+		Expect(i.Install(d)).To(MatchError("verifying OS image: no valid signature found"))
+		Expect(verifier.refs).To(Equal([]string{"registry.example.com/os:1.0"}))
+		Expect(runner.GetCmds()).To(BeEmpty())
+	})
 	It("fails if upgrader errors out", func() {
 		deployment.WithRecoveryPartition(0)(d)
 		upgrader.Error = fmt.Errorf("transaction failed")
@@ -213,6 +235,26 @@ var _ = Describe("Install", Label("install"), func() {
 			{"mksquashfs"},
 		}))
 	})
+	It("deploys the given deployment on an already partitioned disk", func() {
+		deployment.WithRecoveryPartition(0)(d)
+		uuids := []string{
+			"c60d1845-7b04-4fc4-8639-8c49eb7277d5", "ddb334a8-48a2-c4de-ddb3-849eb2443e92",
+			"34a8abb8-ddb3-48a2-8ecc-2443e92c7510",
+		}
+		for j, part := range d.Disks[0].Partitions {
+			part.UUID = uuids[j]
+		}
+		Expect(i.Deploy(d)).To(Succeed())
+		Expect(runner.MatchMilestones([][]string{
+			{"btrfs", "subvolume", "create"},
+			{"mksquashfs"},
+		})).To(Succeed())
+		Expect(runner.IncludesCmds([][]string{{"systemd-repart"}})).NotTo(Succeed())
+	})
+	It("fails to deploy encrypted partitions on an already partitioned disk", func() {
+		d.GetSystemPartition().Encryption = &deployment.Encryption{KeySource: deployment.KeyPassphrase}
+		Expect(i.Deploy(d)).To(MatchError(ContainSubstring("not supported")))
+	})
 	It("resets the given deployment", func() {
 		deployment.WithRecoveryPartition(0)(d)
 		Expect(i.Reset(d)).To(Succeed())
@@ -23,7 +23,9 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net/url"
 	"path/filepath"
+	"strings"
 
 	"go.yaml.in/yaml/v3"
 
@@ -61,6 +63,7 @@ type MediaType int
 const (
 	ISO MediaType = iota + 1
 	Disk
+	Netboot
 )
 
 func (m MediaType) String() string {
@@ -69,6 +72,8 @@ func (m MediaType) String() string {
 		return "iso"
 	case Disk:
 		return "raw"
+	case Netboot:
+		return "netboot"
 	default:
 		return "unknown"
 	}
@@ -80,6 +85,8 @@ func StringToMediaType(mType string) (MediaType, error) {
 		return Disk, nil
 	case "iso":
 		return ISO, nil
+	case "netboot":
+		return Netboot, nil
 	default:
 		return 0, fmt.Errorf("unsupported media type %s: %w", mType, errors.ErrUnsupported)
 	}
@@ -100,6 +107,7 @@ type Media struct {
 	bl          bootloader.Bootloader
 	outputFile  string
 	rawDiskSize deployment.MiB
+	netbootURL  string
 }
 
 // WithBootloader allows to create an ISO object with the given bootloader interface instance
@@ -122,6 +130,13 @@ func WithRawDiskSize(size deployment.MiB) Option {
 	}
 }
 
+// WithNetbootURL sets the base URL the netboot artifacts are served from
+func WithNetbootURL(url string) Option {
+	return func(i *Media) {
+		i.netbootURL = strings.TrimSuffix(url, "/")
+	}
+}
+
 func WithOutputFile(outputFile string) Option {
 	return func(i *Media) {
 		i.outputFile = outputFile
@@ -143,7 +158,7 @@ func NewMedia(ctx context.Context, s *sys.System, mType MediaType, opts ...Optio
 	if media.bl == nil {
 		media.bl, _ = bootloader.New(bootloader.BootGrub, media.s)
 	}
-	if media.mType == ISO {
+	if media.mType == ISO || media.mType == Netboot {
 		media.Label = "LIVE"
 	}
 	return media
@@ -222,9 +237,14 @@ func (i Media) Build(d *deployment.Deployment) (err error) {
 	switch i.mType {
 	case ISO:
 		cmdline := fmt.Sprintf("%s %s", deployment.LiveKernelCmdline(i.Label), d.Installer.KernelCmdline)
-		err = i.buildISO(tempDir, liveRoot, osRoot, cmdline)
+		err = i.buildISO(tempDir, liveRoot, osRoot, cmdline, d.BootConfig.IsBIOSEnabled())
 	case Disk:
+		if d.BootConfig.IsBIOSEnabled() {
+			i.s.Logger().Warn("raw installer media only boots on UEFI firmware, legacy BIOS boot is only set for the installed system")
+		}
 		err = i.buildDisk(tempDir, liveRoot, osRoot, d)
+	case Netboot:
+		err = i.buildNetboot(liveRoot, osRoot, d.Installer.KernelCmdline)
 	default:
 		return fmt.Errorf("unknown media type: %w", errors.ErrUnsupported)
 	}
@@ -401,6 +421,8 @@ func (i *Media) Customize(d *deployment.Deployment) (err error) {
 		err = i.customizeISO(i.InputFile, i.outputFile, m)
 	case Disk:
 		err = i.customizeDisk(tempDir, installDesc, m)
+	case Netboot:
+		err = i.customizeNetboot(tempDir, installDesc.Installer.KernelCmdline, m)
 	default:
 		err = fmt.Errorf("unknown media type: %w", errors.ErrUnsupported)
 	}
@@ -414,13 +436,30 @@ func (i *Media) Customize(d *deployment.Deployment) (err error) {
 // writeChecksum computes the checksum for the current media output file and writes
 // the checksum file to the same output file path, but with the *.sha256 suffix
 func (i Media) writeChecksum() error {
-	checksum, err := calcFileChecksum(i.s.FS(), i.outputFile)
+	if i.mType != Netboot {
+		return WriteChecksum(i.s, i.outputFile)
+	}
+
+	// Netboot media is a directory including all the artifacts to serve
+	for _, file := range i.netbootArtifacts() {
+		err := WriteChecksum(i.s, filepath.Join(i.outputFile, file))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteChecksum computes the checksum of the given image file and writes the checksum
+// file to the same path, but with the *.sha256 suffix
+func WriteChecksum(s *sys.System, imageFile string) error {
+	checksum, err := calcFileChecksum(s.FS(), imageFile)
 	if err != nil {
 		return fmt.Errorf("could not compute image checksum: %w", err)
 	}
 
-	checksumFile := fmt.Sprintf("%s.sha256", i.outputFile)
-	err = i.s.FS().WriteFile(checksumFile, fmt.Appendf(nil, "%s %s\n", checksum, filepath.Base(i.outputFile)), vfs.FilePerm)
+	checksumFile := fmt.Sprintf("%s.sha256", imageFile)
+	err = s.FS().WriteFile(checksumFile, fmt.Appendf(nil, "%s %s\n", checksum, filepath.Base(imageFile)), vfs.FilePerm)
 	if err != nil {
 		return fmt.Errorf("failed writing image checksum file %s: %w", checksumFile, err)
 	}
@@ -434,7 +473,7 @@ func (i Media) recreateGrubenv(target, kernelCmdline string, loadedDep *deployme
 		kernelCmdline = loadedDep.Installer.KernelCmdline
 	}
 	switch i.mType {
-	case ISO:
+	case ISO, Netboot:
 		kernelCmdline = fmt.Sprintf("%s %s", deployment.LiveKernelCmdline(i.Label), kernelCmdline)
 	case Disk:
 		kernelCmdline = fmt.Sprintf("%s %s %s", loadedDep.RecoveryKernelCmdline(), deployment.ResetMark, kernelCmdline)
@@ -473,6 +512,15 @@ func (i Media) increaseRecoverySize(mappedFiles map[string]string, d *deployment
 	return nil
 }
 
+// OutputFile returns the path of the media produced by Build or Customize, netboot
+// media is a directory including all the artifacts
+func (i Media) OutputFile() string {
+	if i.outputFile != "" {
+		return i.outputFile
+	}
+	return filepath.Join(i.OutputDir, fmt.Sprintf("%s.%s", i.Name, i.mType.String()))
+}
+
 // sanitize checks the current public attributes of the ISO object
 // and checks if they are good enough to proceed with an ISO build.
 func (i *Media) sanitize() error {
@@ -483,10 +531,17 @@ func (i *Media) sanitize() error {
 		}
 		i.OutputDir = path
 	}
-	if i.Label == "" && i.mType == ISO {
+	if i.Label == "" && (i.mType == ISO || i.mType == Netboot) {
 		return fmt.Errorf("undefined label for the installer filesystem")
 	}
 
+	if i.mType == Netboot {
+		u, err := url.Parse(i.netbootURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid netboot base URL '%s', an http or https URL is required", i.netbootURL)
+		}
+	}
+
 	if i.OutputDir == "" {
 		return fmt.Errorf("undefined output directory")
 	}
@@ -502,7 +557,7 @@ func (i *Media) sanitize() error {
 	}
 
 	if i.outputFile == "" {
-		i.outputFile = filepath.Join(i.OutputDir, fmt.Sprintf("%s.%s", i.Name, i.mType.String()))
+		i.outputFile = i.OutputFile()
 		if ok, _ := vfs.Exists(i.s.FS(), i.outputFile); ok {
 			return fmt.Errorf("target output file %s is an already existing file", i.outputFile)
 		}
@@ -788,13 +843,27 @@ func (i Media) buildDisk(tempDir, liveRoot, osRoot string, d *deployment.Deploym
 	return nil
 }
 
-// buildISO creates an ISO image from the prepared root
-func (i Media) buildISO(tempDir, isoDir, osRoot, kernelCmdline string) error {
+// buildISO creates an ISO image from the prepared root. If bios is set the image is also
+// bootable on legacy BIOS firmware from optical media (El Torito) and USB drives (hybrid MBR).
+func (i Media) buildISO(tempDir, isoDir, osRoot, kernelCmdline string, bios bool) error {
 	err := i.bl.InstallLive(osRoot, isoDir, kernelCmdline)
 	if err != nil {
 		return fmt.Errorf("failed installing bootloader in ISO directory tree: %w", err)
 	}
 
+	var hybridMBR string
+	if bios {
+		bl, ok := i.bl.(bootloader.BIOSBootloader)
+		if !ok {
+			return fmt.Errorf("configured bootloader does not support legacy BIOS boot")
+		}
+		err = bl.InstallLiveBIOS(osRoot, isoDir)
+		if err != nil {
+			return fmt.Errorf("failed installing BIOS bootloader in ISO directory tree: %w", err)
+		}
+		hybridMBR = filepath.Join(osRoot, bootloader.GrubBIOSDir, bootloader.GrubHybridMBR)
+	}
+
 	espDir := filepath.Join(tempDir, "esp")
 	err = vfs.MkdirAll(i.s.FS(), espDir, vfs.DirPerm)
 	if err != nil {
@@ -816,7 +885,7 @@ func (i Media) buildISO(tempDir, isoDir, osRoot, kernelCmdline string) error {
 		"-volid", "LIVE", "-padding", "0",
 		"-outdev", i.outputFile, "-map", isoDir, "/", "-chmod", "0755", "--",
 	}
-	args = append(args, xorrisoBootloaderArgs(efiImg)...)
+	args = append(args, xorrisoBootloaderArgs(efiImg, hybridMBR)...)
 
 	_, err = i.s.Runner().RunContext(i.ctx, xorriso, args...)
 	if err != nil {
@@ -826,19 +895,33 @@ func (i Media) buildISO(tempDir, isoDir, osRoot, kernelCmdline string) error {
 	return nil
 }
 
-// xorrisoBootloaderArgs returns a slice of flags for xorriso to defined a common bootloader parameters
+// xorrisoBootloaderArgs returns a slice of flags for xorriso to defined a common bootloader parameters.
+// If a hybrid MBR image is given a legacy BIOS El Torito boot entry is added before the EFI one.
 //
 //nolint:goconst
-func xorrisoBootloaderArgs(efiImg string) []string {
+func xorrisoBootloaderArgs(efiImg, hybridMBR string) []string {
 	args := []string{
 		"-append_partition", "2", "0xef", efiImg,
 		"-boot_image", "any", fmt.Sprintf("cat_path=%s", isoBootCatalog),
 		"-boot_image", "any", "cat_hidden=on",
+	}
+	if hybridMBR != "" {
+		args = append(args,
+			"-boot_image", "grub", fmt.Sprintf("bin_path=%s", bootloader.LiveBIOSImg),
+			"-boot_image", "grub", fmt.Sprintf("grub2_mbr=%s", hybridMBR),
+			"-boot_image", "grub", "grub2_boot_info=on",
+			"-boot_image", "any", "boot_info_table=on",
+			"-boot_image", "any", "load_size=2048",
+			"-boot_image", "any", "mbr_force_bootable=on",
+			"-boot_image", "any", "next",
+		)
+	}
+	args = append(args,
 		"-boot_image", "any", "efi_path=--interval:appended_partition_2:all::",
 		"-boot_image", "any", "platform_id=0xef",
 		"-boot_image", "any", "appended_part_as=gpt",
 		"-boot_image", "any", "partition_offset=16",
-	}
+	)
 	return args
 }
 
@@ -42,6 +42,21 @@ func TestInstallerMediaSuite(t *testing.T) {
 	RunSpecs(t, "InstallerMedia test suite")
 }
 
+// biosBootloader is a bootloader supporting legacy BIOS boot which records the live media target
+type biosBootloader struct {
+	*bootloader.None
+	liveTarget string
+}
+
+func (b *biosBootloader) InstallBIOS(_, _, _, _ string) error {
+	return nil
+}
+
+func (b *biosBootloader) InstallLiveBIOS(_, target string) error {
+	b.liveTarget = target
+	return nil
+}
+
 var _ = Describe("InstallerMedia", Label("installermedia"), func() {
 	var runner *sysmock.Runner
 	var fs vfs.FS
@@ -108,6 +123,33 @@ var _ = Describe("InstallerMedia", Label("installermedia"), func() {
 			{"xorriso", "-volid", "LIVE", "-padding", "0", "-outdev", "/some/dir/build/installer.iso"},
 		}))
 	})
+	It("Creates an installation ISO bootable on legacy BIOS firmware", func() {
+		var xorrisoArgs []string
+		sideEffects["xorriso"] = func(args ...string) ([]byte, error) {
+			xorrisoArgs = args
+			Expect(fs.WriteFile("/some/dir/build/installer.iso", []byte("data"), vfs.FilePerm)).To(Succeed())
+			return []byte{}, nil
+		}
+
+		d.SourceOS = deployment.NewDirSrc("/some/root")
+		d.BootConfig.BIOS = true
+
+		iso := installer.NewMedia(context.Background(), s, installer.ISO, installer.WithBootloader(bootloader.NewNone(s)))
+		iso.OutputDir = "/some/dir/build"
+		Expect(iso.Build(d)).To(MatchError(ContainSubstring("does not support legacy BIOS boot")))
+
+		bl := &biosBootloader{None: bootloader.NewNone(s)}
+		iso = installer.NewMedia(context.Background(), s, installer.ISO, installer.WithBootloader(bl))
+		iso.OutputDir = "/some/dir/build"
+		Expect(iso.Build(d)).To(Succeed())
+		Expect(bl.liveTarget).To(HaveSuffix("/liveroot"))
+
+		// BIOS El Torito entry is defined before the EFI one
+		args := strings.Join(xorrisoArgs, " ")
+		Expect(args).To(ContainSubstring("-boot_image grub bin_path=/boot/grub2/i386-pc/eltorito.img"))
+		Expect(args).To(MatchRegexp("-boot_image grub grub2_mbr=\\S+/osroot/usr/share/grub2/i386-pc/boot_hybrid.img"))
+		Expect(args).To(ContainSubstring("-boot_image any next -boot_image any efi_path="))
+	})
 	It("fails to create an ISO without an output directory defined", func() {
 		d.SourceOS = deployment.NewDirSrc("/some/root")
 		iso := installer.NewMedia(context.Background(), s, installer.ISO, installer.WithBootloader(bootloader.NewNone(s)))
@@ -203,6 +245,124 @@ var _ = Describe("InstallerMedia", Label("installermedia"), func() {
 
 		Expect(vfs.Exists(fs, "/some/dir/build/installer2.iso")).To(BeTrue())
 	})
+	It("creates netboot artifacts", func() {
+		sideEffects["rsync"] = func(args ...string) ([]byte, error) {
+			// rsync gets the host path of the test filesystem
+			if strings.HasSuffix(args[len(args)-1], "osroot/") {
+				target := "/some/dir/build/elemental-installer/osroot"
+				Expect(vfs.MkdirAll(fs, target+"/usr/lib/modules/6.4", vfs.DirPerm)).To(Succeed())
+				Expect(fs.WriteFile(target+"/usr/lib/modules/6.4/vmlinuz", []byte("kernel"), vfs.FilePerm)).To(Succeed())
+				Expect(fs.WriteFile(target+"/usr/lib/modules/6.4/initrd", []byte("initrd"), vfs.FilePerm)).To(Succeed())
+			}
+			return []byte{}, nil
+		}
+		sideEffects["mksquashfs"] = func(args ...string) ([]byte, error) {
+			// mksquashfs gets the host path of the test filesystem
+			target := "/some/dir/build/elemental-installer/liveroot/LiveOS/squashfs.img"
+			Expect(fs.WriteFile(target, []byte("squashfs"), vfs.FilePerm)).To(Succeed())
+			return []byte{}, nil
+		}
+		sideEffects["xorriso"] = func(args ...string) ([]byte, error) {
+			Expect(fs.WriteFile("/some/dir/build/installer.netboot/live.iso", []byte("data"), vfs.FilePerm)).To(Succeed())
+			return []byte{}, nil
+		}
+
+		d.SourceOS = deployment.NewDirSrc("/some/root")
+		d.Installer.KernelCmdline = "console=ttyS0"
+
+		media := installer.NewMedia(
+			context.Background(), s, installer.Netboot, installer.WithBootloader(bootloader.NewNone(s)),
+			installer.WithNetbootURL("http://10.0.0.1/elemental/"),
+		)
+		media.OutputDir = "/some/dir/build"
+
+		Expect(media.Build(d)).To(Succeed())
+		Expect(runner.IncludesCmds([][]string{
+			{"xorriso", "-volid", "LIVE", "-padding", "0", "-outdev", "/some/dir/build/installer.netboot/live.iso"},
+		})).To(Succeed())
+
+		for _, file := range []string{"vmlinuz", "initrd", "live.iso", "squashfs.img", "Install/install.yaml", "boot.ipxe", "grub.cfg"} {
+			Expect(vfs.Exists(fs, "/some/dir/build/installer.netboot/"+file)).To(BeTrue())
+			Expect(vfs.Exists(fs, "/some/dir/build/installer.netboot/"+file+".sha256")).To(BeTrue())
+		}
+
+		cmdline := "root=live:http://10.0.0.1/elemental/live.iso rd.live.overlay.overlayfs=1 rd.neednet=1 ip=dhcp console=ttyS0"
+		ipxe, err := fs.ReadFile("/some/dir/build/installer.netboot/boot.ipxe")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(ipxe)).To(ContainSubstring("set base-url http://10.0.0.1/elemental\n"))
+		Expect(string(ipxe)).To(ContainSubstring("kernel ${base-url}/vmlinuz initrd=initrd " + cmdline))
+		Expect(string(ipxe)).To(ContainSubstring("initrd ${base-url}/initrd"))
+
+		grub, err := fs.ReadFile("/some/dir/build/installer.netboot/grub.cfg")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(grub)).To(ContainSubstring("linux (http,10.0.0.1)/elemental/vmlinuz " + cmdline))
+		Expect(string(grub)).To(ContainSubstring("initrd (http,10.0.0.1)/elemental/initrd"))
+	})
+	It("fails to create netboot artifacts without a base URL", func() {
+		d.SourceOS = deployment.NewDirSrc("/some/root")
+		media := installer.NewMedia(context.Background(), s, installer.Netboot, installer.WithBootloader(bootloader.NewNone(s)))
+		media.OutputDir = "/some/dir/build"
+
+		Expect(media.Build(d)).To(MatchError(ContainSubstring("invalid netboot base URL")))
+	})
+	It("customizes an ISO into netboot artifacts", func() {
+		Expect(vfs.MkdirAll(fs, "/some/dir/build", vfs.DirPerm)).To(Succeed())
+
+		sideEffects["xorriso"] = func(args ...string) ([]byte, error) {
+			for i, arg := range args {
+				switch {
+				case arg == "-outdev":
+					_, err := fs.Create(args[i+1])
+					Expect(err).To(Succeed())
+				case arg == "-extract" && args[i+1] == "/boot":
+					dir := args[i+2] + "/sles/6.4"
+					Expect(vfs.MkdirAll(fs, dir, vfs.DirPerm)).To(Succeed())
+					Expect(fs.WriteFile(dir+"/.vmlinuz.hmac", []byte("hmac"), vfs.FilePerm)).To(Succeed())
+					Expect(fs.WriteFile(dir+"/vmlinuz", []byte("kernel"), vfs.FilePerm)).To(Succeed())
+					Expect(fs.WriteFile(dir+"/initrd", []byte("initrd"), vfs.FilePerm)).To(Succeed())
+				case arg == "-extract":
+					_, err := fs.Create(args[i+2])
+					Expect(err).To(Succeed())
+				}
+			}
+			return []byte{}, nil
+		}
+
+		_, err := fs.Create("/some/dir/installer.iso")
+		Expect(err).To(Succeed())
+
+		media := installer.NewMedia(
+			context.Background(), s, installer.Netboot, installer.WithBootloader(bootloader.NewNone(s)),
+			installer.WithNetbootURL("https://boot.example.com"),
+		)
+		media.InputFile = "/some/dir/installer.iso"
+		media.OutputDir = "/some/dir/build"
+		d.Installer.KernelCmdline = "console=ttyS0"
+
+		Expect(media.Customize(d)).To(Succeed())
+		Expect(runner.IncludesCmds([][]string{
+			{"xorriso", "-indev", "/some/dir/installer.iso", "-outdev", "/some/dir/build/installer.netboot/live.iso"},
+		})).To(Succeed())
+
+		Expect(runner.IncludesCmds([][]string{
+			{"xorriso", "-osirrox", "on:auto_chmod_on", "-overwrite", "nondir", "-indev",
+				"/some/dir/build/installer.netboot/live.iso", "-extract", "LiveOS/squashfs.img",
+				"/some/dir/build/installer.netboot/squashfs.img"},
+			{"xorriso", "-osirrox", "on:auto_chmod_on", "-overwrite", "nondir", "-indev",
+				"/some/dir/build/installer.netboot/live.iso", "-extract", "Install/install.yaml",
+				"/some/dir/build/installer.netboot/Install/install.yaml"},
+		})).To(Succeed())
+		Expect(vfs.Exists(fs, "/some/dir/build/installer.netboot/Install/install.yaml.sha256")).To(BeTrue())
+
+		kernel, err := fs.ReadFile("/some/dir/build/installer.netboot/vmlinuz")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(kernel)).To(Equal("kernel"))
+
+		ipxe, err := fs.ReadFile("/some/dir/build/installer.netboot/boot.ipxe")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(ipxe)).To(ContainSubstring("root=live:https://boot.example.com/live.iso"))
+		Expect(string(ipxe)).To(ContainSubstring("console=ttyS0"))
+	})
 	It("fails to customize an iso that is not including an install.yaml file", func() {
 		Expect(vfs.MkdirAll(fs, "/some/dir/build", vfs.DirPerm)).To(Succeed())
 
@@ -0,0 +1,259 @@
+/*
+Copyright © 2022-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package installer
+
+import (
+	_ "embed"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+const (
+	netbootLiveImg = "live.iso"
+	netbootInitrd  = "initrd"
+	ipxeScript     = "boot.ipxe"
+	grubNetCfg     = "grub.cfg"
+)
+
+//go:embed templates/netboot.ipxe.tpl
+var ipxeTpl string
+
+//go:embed templates/grub_net.cfg.tpl
+var grubNetTpl string
+
+// buildNetboot creates the netboot artifacts from the prepared roots. The live root is packed
+// into a live image which is fetched at boot and mounted as the installer media, so the installer
+// sees the very same tree as if it was booted from an ISO. The squashfs image and the install
+// description are also served as separate artifacts.
+func (i Media) buildNetboot(liveRoot, osRoot, cmdline string) error {
+	err := vfs.MkdirAll(i.s.FS(), i.outputFile, vfs.DirPerm)
+	if err != nil {
+		return fmt.Errorf("failed creating netboot directory: %w", err)
+	}
+
+	kernel, _, err := vfs.FindKernel(i.s.FS(), osRoot)
+	if err != nil {
+		return fmt.Errorf("failed finding kernel: %w", err)
+	}
+	initrd := filepath.Join(filepath.Dir(kernel), netbootInitrd)
+
+	err = i.copyNetbootFiles(kernel, initrd)
+	if err != nil {
+		return err
+	}
+
+	args := []string{
+		"-volid", i.Label, "-padding", "0",
+		"-outdev", filepath.Join(i.outputFile, netbootLiveImg), "-map", liveRoot, "/", "-chmod", "0755", "--",
+	}
+	_, err = i.s.Runner().RunContext(i.ctx, xorriso, args...)
+	if err != nil {
+		return fmt.Errorf("failed creating the netboot live image: %w", err)
+	}
+
+	for _, file := range []string{SquashfsRelPath, filepath.Join(installDir, installCfg)} {
+		err = i.copyNetbootArtifact(filepath.Join(liveRoot, file), file)
+		if err != nil {
+			return err
+		}
+	}
+
+	return i.writeNetbootConfigs(filepath.Base(kernel), cmdline)
+}
+
+// customizeNetboot creates the netboot artifacts from an existing installer ISO
+func (i Media) customizeNetboot(tempDir, cmdline string, fileMap map[string]string) error {
+	err := vfs.MkdirAll(i.s.FS(), i.outputFile, vfs.DirPerm)
+	if err != nil {
+		return fmt.Errorf("failed creating netboot directory: %w", err)
+	}
+
+	liveImg := filepath.Join(i.outputFile, netbootLiveImg)
+	err = i.customizeISO(i.InputFile, liveImg, fileMap)
+	if err != nil {
+		return err
+	}
+
+	err = extractISO(i.s, liveImg, SquashfsRelPath, filepath.Join(i.outputFile, squashfsImg))
+	if err != nil {
+		return err
+	}
+
+	installDesc := filepath.Join(installDir, installCfg)
+	err = vfs.MkdirAll(i.s.FS(), filepath.Join(i.outputFile, installDir), vfs.DirPerm)
+	if err != nil {
+		return fmt.Errorf("failed creating netboot install directory: %w", err)
+	}
+	err = extractISO(i.s, liveImg, installDesc, filepath.Join(i.outputFile, installDesc))
+	if err != nil {
+		return err
+	}
+
+	bootDir := filepath.Join(tempDir, "boot")
+	err = extractISO(i.s, i.InputFile, "/boot", bootDir)
+	if err != nil {
+		return err
+	}
+
+	kernel, initrd, err := findLiveBootFiles(i.s.FS(), bootDir)
+	if err != nil {
+		return err
+	}
+
+	err = i.copyNetbootFiles(kernel, initrd)
+	if err != nil {
+		return err
+	}
+
+	return i.writeNetbootConfigs(filepath.Base(kernel), cmdline)
+}
+
+// copyNetbootFiles copies the given kernel and initrd into the netboot directory
+func (i Media) copyNetbootFiles(kernel, initrd string) error {
+	err := vfs.CopyFile(i.s.FS(), kernel, i.outputFile)
+	if err != nil {
+		return fmt.Errorf("failed copying kernel '%s': %w", kernel, err)
+	}
+
+	err = vfs.CopyFile(i.s.FS(), initrd, filepath.Join(i.outputFile, netbootInitrd))
+	if err != nil {
+		return fmt.Errorf("failed copying initrd '%s': %w", initrd, err)
+	}
+	return nil
+}
+
+// copyNetbootArtifact copies the given live root file into the netboot directory. The squashfs
+// image is placed at the top level, any other file keeps its path relative to the live root.
+func (i Media) copyNetbootArtifact(src, relPath string) error {
+	target := filepath.Join(i.outputFile, relPath)
+	if relPath == SquashfsRelPath {
+		target = filepath.Join(i.outputFile, squashfsImg)
+	}
+
+	err := vfs.MkdirAll(i.s.FS(), filepath.Dir(target), vfs.DirPerm)
+	if err != nil {
+		return fmt.Errorf("failed creating directory for '%s': %w", target, err)
+	}
+
+	err = vfs.CopyFile(i.s.FS(), src, target)
+	if err != nil {
+		return fmt.Errorf("failed copying '%s' to the netboot directory: %w", src, err)
+	}
+	return nil
+}
+
+// writeNetbootConfigs writes the iPXE script and the GRUB configuration to boot the given kernel
+// over the network. The live image URL is included in the kernel command line.
+func (i Media) writeNetbootConfigs(kernel, cmdline string) error {
+	u, err := url.Parse(i.netbootURL)
+	if err != nil {
+		return fmt.Errorf("failed parsing netboot base URL '%s': %w", i.netbootURL, err)
+	}
+
+	liveURL := fmt.Sprintf("%s/%s", i.netbootURL, netbootLiveImg)
+	values := struct {
+		BaseURL string
+		Scheme  string
+		Host    string
+		Path    string
+		Kernel  string
+		Initrd  string
+		CmdLine string
+	}{
+		BaseURL: i.netbootURL,
+		Scheme:  u.Scheme,
+		Host:    u.Host,
+		Path:    u.Path,
+		Kernel:  kernel,
+		Initrd:  netbootInitrd,
+		CmdLine: strings.TrimSpace(fmt.Sprintf("%s %s", deployment.NetbootKernelCmdline(liveURL), cmdline)),
+	}
+
+	for name, tpl := range map[string]string{ipxeScript: ipxeTpl, grubNetCfg: grubNetTpl} {
+		cfg := filepath.Join(i.outputFile, name)
+		f, err := i.s.FS().Create(cfg)
+		if err != nil {
+			return fmt.Errorf("failed creating '%s': %w", cfg, err)
+		}
+		err = template.Must(template.New(name).Parse(tpl)).Execute(f, values)
+		if err != nil {
+			_ = f.Close()
+			return fmt.Errorf("failed writing '%s': %w", cfg, err)
+		}
+		err = f.Close()
+		if err != nil {
+			return fmt.Errorf("failed closing '%s': %w", cfg, err)
+		}
+	}
+	return nil
+}
+
+// netbootArtifacts returns the paths, relative to the netboot directory, of the files to serve
+// for a netboot
+func (i Media) netbootArtifacts() []string {
+	var files []string
+	_ = vfs.WalkDirFs(i.s.FS(), i.outputFile, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() || strings.HasSuffix(d.Name(), ".sha256") {
+			return nil
+		}
+		if rel, err := filepath.Rel(i.outputFile, path); err == nil {
+			files = append(files, rel)
+		}
+		return nil
+	})
+	return files
+}
+
+// findLiveBootFiles finds the kernel and initrd within the boot directory of a live media. Both are
+// stored in the same directory, the kernel being the only other regular file which is not hidden.
+func findLiveBootFiles(vfsys vfs.FS, bootDir string) (kernel, initrd string, err error) {
+	err = vfs.WalkDirFs(vfsys, bootDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if initrd == "" && !d.IsDir() && d.Name() == netbootInitrd {
+			initrd = path
+		}
+		return nil
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("failed searching the initrd in '%s': %w", bootDir, err)
+	}
+	if initrd == "" {
+		return "", "", fmt.Errorf("initrd not found in '%s'", bootDir)
+	}
+
+	entries, err := vfsys.ReadDir(filepath.Dir(initrd))
+	if err != nil {
+		return "", "", fmt.Errorf("failed listing '%s': %w", filepath.Dir(initrd), err)
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.Type().IsRegular() && name != netbootInitrd && !strings.HasPrefix(name, ".") {
+			return filepath.Join(filepath.Dir(initrd), name), initrd, nil
+		}
+	}
+	return "", "", fmt.Errorf("kernel not found in '%s'", filepath.Dir(initrd))
+}
@@ -0,0 +1,12 @@
+set default=0
+set timeout=5
+
+insmod http
+insmod part_gpt
+
+menuentry "Installer (network)" --id "installer" {
+	echo 'Loading Linux...'
+	linux ({{ .Scheme }},{{ .Host }}){{ .Path }}/{{ .Kernel }} {{ .CmdLine }}
+	echo 'Loading initial ramdisk...'
+	initrd ({{ .Scheme }},{{ .Host }}){{ .Path }}/{{ .Initrd }}
+}
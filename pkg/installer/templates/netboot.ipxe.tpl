@@ -0,0 +1,10 @@
+#!ipxe
+
+dhcp
+set base-url {{ .BaseURL }}
+
+echo Loading Linux...
+kernel ${base-url}/{{ .Kernel }} initrd={{ .Initrd }} {{ .CmdLine }}
+echo Loading initial ramdisk...
+initrd ${base-url}/{{ .Initrd }}
+boot
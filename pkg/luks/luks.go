@@ -0,0 +1,187 @@
+/*
+Copyright © 2022-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package luks
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/suse/elemental/v3/pkg/block"
+	"github.com/suse/elemental/v3/pkg/cleanstack"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+const (
+	CrypttabFile = "/etc/crypttab"
+
+	mapperDir = "/dev/mapper"
+	uuidDir   = "/dev/disk/by-uuid"
+	keyPerm   = 0400
+)
+
+// DevicePath returns the device path to mount for the given partition. For encrypted
+// partitions this is the unlocked device mapper path, otherwise it is the given device.
+func DevicePath(part *deployment.Partition, device string) string {
+	if name := part.MapperName(); name != "" {
+		return filepath.Join(mapperDir, name)
+	}
+	return device
+}
+
+// LUKSDevicePath returns the device path of the LUKS2 volume of the given encrypted partition
+func LUKSDevicePath(part *deployment.Partition) string {
+	return filepath.Join(uuidDir, part.Encryption.UUID)
+}
+
+// ReadUUID returns the LUKS2 header UUID of the given device
+func ReadUUID(s *sys.System, device string) (string, error) {
+	out, err := s.Runner().Run("cryptsetup", "luksUUID", device)
+	if err != nil {
+		return "", fmt.Errorf("reading LUKS UUID of '%s': %w", device, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// Open unlocks the given LUKS2 device with the given key file and maps it with the given name
+func Open(s *sys.System, device, name, keyFile string) error {
+	_, err := s.Runner().Run("cryptsetup", "open", "--type=luks2", fmt.Sprintf("--key-file=%s", keyFile), device, name)
+	if err != nil {
+		return fmt.Errorf("unlocking LUKS device '%s': %w", device, err)
+	}
+	return nil
+}
+
+// Close locks the LUKS2 device mapped with the given name
+func Close(s *sys.System, name string) error {
+	_, err := s.Runner().Run("cryptsetup", "close", name)
+	if err != nil {
+		return fmt.Errorf("locking LUKS device '%s': %w", name, err)
+	}
+	return nil
+}
+
+// Setup prepares the encrypted partitions of the given disk once they are formatted.
+// It records the LUKS2 UUID of each encrypted partition and unlocks the devices so they
+// can be mounted. Locking the devices is pushed to the given cleanstack. TPM2 keys are
+// enrolled later on, once the boot assets they are bound to are installed.
+func Setup(s *sys.System, cleanStack *cleanstack.CleanStack, bDev block.Device, disk *deployment.Disk) error {
+	for _, part := range disk.Partitions {
+		if part == nil || !part.IsEncrypted() {
+			continue
+		}
+		if part.Encryption.KeyFile == "" {
+			return fmt.Errorf("no key file to unlock encrypted partition '%s'", part.Label)
+		}
+		bPart, err := block.GetPartitionByUUID(s, bDev, part.UUID, 4)
+		if err != nil {
+			return fmt.Errorf("finding partition '%s': %w", part.UUID, err)
+		}
+		part.Encryption.UUID, err = ReadUUID(s, bPart.Path)
+		if err != nil {
+			return err
+		}
+		name := part.MapperName()
+		err = Open(s, bPart.Path, name, part.Encryption.KeyFile)
+		if err != nil {
+			return err
+		}
+		cleanStack.Push(func() error { return Close(s, name) })
+	}
+	return nil
+}
+
+// InstallKeyFile copies the key file of the partitions unlocked from the config partition
+// into the given config partition root.
+func InstallKeyFile(s *sys.System, configRoot string, d *deployment.Deployment) error {
+	for _, part := range d.GetEncryptedPartitions() {
+		if part.Encryption.KeySource != deployment.KeyFile || part.Encryption.KeyFile == "" {
+			continue
+		}
+		target := filepath.Join(configRoot, deployment.LUKSKeyFile)
+		err := vfs.CopyFile(s.FS(), part.Encryption.KeyFile, target)
+		if err != nil {
+			return fmt.Errorf("copying LUKS key file: %w", err)
+		}
+		err = s.FS().Chmod(target, keyPerm)
+		if err != nil {
+			return fmt.Errorf("setting LUKS key file permissions: %w", err)
+		}
+		// All partitions unlocked from the config partition share the same key file
+		return nil
+	}
+	return nil
+}
+
+// WriteCrypttab writes the crypttab file within the given root including all the
+// encrypted partitions of the given partitions list.
+func WriteCrypttab(s *sys.System, root string, parts deployment.Partitions) (err error) {
+	var encrypted deployment.Partitions
+	var confLabel string
+	for _, part := range parts {
+		if part.Role == deployment.Config {
+			confLabel = part.Label
+		}
+		if part.IsEncrypted() {
+			encrypted = append(encrypted, part)
+		}
+	}
+	if len(encrypted) == 0 {
+		return nil
+	}
+
+	crypttab, err := s.FS().Create(filepath.Join(root, CrypttabFile))
+	if err != nil {
+		return fmt.Errorf("creating crypttab file: %w", err)
+	}
+	defer func() {
+		e := crypttab.Close()
+		if err == nil && e != nil {
+			err = fmt.Errorf("closing crypttab file: %w", e)
+		}
+	}()
+
+	tw := tabwriter.NewWriter(crypttab, 1, 4, 1, ' ', 0)
+	for _, part := range encrypted {
+		if part.Encryption.UUID == "" {
+			return fmt.Errorf("encrypted partition '%s' has no LUKS UUID", part.Label)
+		}
+		key := "none"
+		opts := []string{"luks"}
+		switch part.Encryption.KeySource {
+		case deployment.KeyFile:
+			if confLabel == "" {
+				return fmt.Errorf("no config partition found to read the LUKS key file from")
+			}
+			key = fmt.Sprintf("%s:LABEL=%s", deployment.LUKSKeyFile, confLabel)
+		case deployment.KeyTPM2:
+			opts = append(opts, "tpm2-device=auto")
+		}
+		_, err = fmt.Fprintf(
+			tw, "%s\tUUID=%s\t%s\t%s\n",
+			part.MapperName(), part.Encryption.UUID, key, strings.Join(opts, ","),
+		)
+		if err != nil {
+			return fmt.Errorf("writing crypttab file: %w", err)
+		}
+	}
+	return tw.Flush()
+}
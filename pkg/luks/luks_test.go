@@ -0,0 +1,134 @@
+/*
+Copyright © 2022-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package luks_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/block"
+	blockmock "github.com/suse/elemental/v3/pkg/block/mock"
+	"github.com/suse/elemental/v3/pkg/cleanstack"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/luks"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+func TestLUKSSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "LUKS test suite")
+}
+
+var _ = Describe("LUKS", Label("luks"), func() {
+	var tfs vfs.FS
+	var s *sys.System
+	var cleanup func()
+	var err error
+	var runner *sysmock.Runner
+	var d *deployment.Deployment
+	var bDev *blockmock.Device
+	BeforeEach(func() {
+		runner = sysmock.NewRunner()
+		tfs, cleanup, err = sysmock.TestFS(map[string]any{
+			"/etc/keys/luks.key": "secret",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		s, err = sys.NewSystem(
+			sys.WithFS(tfs), sys.WithLogger(log.New(log.WithDiscardAll())),
+			sys.WithRunner(runner),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			if cmd == "cryptsetup" && args[0] == "luksUUID" {
+				return []byte(fmt.Sprintf("%s-luks\n", filepath.Base(args[1]))), nil
+			}
+			return []byte{}, runner.ReturnError
+		}
+		d = deployment.New(
+			deployment.WithPartitions(1, &deployment.Partition{
+				Role: deployment.Generic, Label: "DATA", MountPoint: "/data", UUID: "data-uuid",
+				Encryption: &deployment.Encryption{KeySource: deployment.KeyFile, KeyFile: "/etc/keys/luks.key"},
+			}),
+			deployment.WithConfigPartition(0),
+		)
+		sysPart := d.GetSystemPartition()
+		sysPart.UUID = "sys-uuid"
+		sysPart.Encryption = &deployment.Encryption{KeySource: deployment.KeyTPM2, KeyFile: "/etc/keys/luks.key"}
+		bDev = blockmock.NewBlockDevice(
+			&block.Partition{UUID: "data-uuid", Path: "/dev/sda3"},
+			&block.Partition{UUID: "sys-uuid", Path: "/dev/sda4"},
+		)
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+	It("sets up the encrypted partitions of a disk", func() {
+		cStack := cleanstack.NewCleanStack()
+		Expect(luks.Setup(s, cStack, bDev, d.Disks[0])).To(Succeed())
+		Expect(runner.MatchMilestones([][]string{
+			{"cryptsetup", "luksUUID", "/dev/sda3"},
+			{"cryptsetup", "open", "--type=luks2", "--key-file=/etc/keys/luks.key", "/dev/sda3", "luks-sda3-luks"},
+			{"cryptsetup", "luksUUID", "/dev/sda4"},
+			{"cryptsetup", "open", "--type=luks2", "--key-file=/etc/keys/luks.key", "/dev/sda4", "luks-sda4-luks"},
+		})).To(Succeed())
+		Expect(runner.MatchMilestones([][]string{{"systemd-cryptenroll"}})).NotTo(Succeed())
+		Expect(d.GetSystemPartition().Encryption.UUID).To(Equal("sda4-luks"))
+		Expect(luks.DevicePath(d.GetSystemPartition(), "/dev/sda4")).To(Equal("/dev/mapper/luks-sda4-luks"))
+
+		runner.ClearCmds()
+		Expect(cStack.Cleanup(nil)).To(Succeed())
+		Expect(runner.CmdsMatch([][]string{
+			{"cryptsetup", "close", "luks-sda4-luks"},
+			{"cryptsetup", "close", "luks-sda3-luks"},
+		})).To(Succeed())
+	})
+	It("fails to set up encrypted partitions without a key file", func() {
+		d.GetSystemPartition().Encryption.KeyFile = ""
+		Expect(luks.Setup(s, cleanstack.NewCleanStack(), bDev, d.Disks[0])).To(
+			MatchError(ContainSubstring("no key file to unlock")),
+		)
+	})
+	It("writes the crypttab file", func() {
+		d.Disks[0].Partitions[2].Encryption.UUID = "data-luks"
+		d.GetSystemPartition().Encryption.UUID = "sys-luks"
+		Expect(vfs.MkdirAll(tfs, "/root/etc", vfs.DirPerm)).To(Succeed())
+		Expect(luks.WriteCrypttab(s, "/root", d.Disks[0].Partitions)).To(Succeed())
+		data, err := tfs.ReadFile("/root/etc/crypttab")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(ContainSubstring("luks-data-luks UUID=data-luks /luks.key:LABEL=ignition luks\n"))
+		Expect(string(data)).To(ContainSubstring("luks-sys-luks  UUID=sys-luks  none                     luks,tpm2-device=auto\n"))
+	})
+	It("does not write a crypttab file if there are no encrypted partitions", func() {
+		Expect(luks.WriteCrypttab(s, "/root", deployment.DefaultDeployment().Disks[0].Partitions)).To(Succeed())
+		Expect(vfs.Exists(tfs, "/root/etc/crypttab")).To(BeFalse())
+	})
+	It("installs the key file into the config partition", func() {
+		Expect(vfs.MkdirAll(tfs, "/config", vfs.DirPerm)).To(Succeed())
+		Expect(luks.InstallKeyFile(s, "/config", d)).To(Succeed())
+		data, err := tfs.ReadFile("/config/luks.key")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal("secret"))
+	})
+})
@@ -0,0 +1,170 @@
+/*
+Copyright © 2022-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package luks
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+const (
+	// PCRLockPolicyFile is the default location of the systemd-pcrlock policy
+	PCRLockPolicyFile = "/var/lib/systemd/pcrlock.json"
+
+	// SignedPCR is the PCR UKIs measure their sections to, it is the PCR signed policies refer to
+	SignedPCR = 11
+
+	pcrlockBin       = "/usr/lib/systemd/systemd-pcrlock"
+	pcrlockDir       = "/var/lib/pcrlock.d"
+	pcrlockVendorDir = "/usr/lib/pcrlock.d"
+	pcrlockSuffix    = ".pcrlock"
+
+	// Alternative components for each boot entry, so all the installed boot entries can unlock TPM2 keys
+	kernelPCRLock  = "650-elemental-kernel.pcrlock.d"
+	cmdlinePCRLock = "710-elemental-kernel-cmdline.pcrlock.d"
+)
+
+// TPM2Policy defines the policy TPM2 keys are bound to. PCRs is a static list of PCRs,
+// PublicKey is the public key of signed PCR 11 policies and PCRLock is the path to a
+// systemd-pcrlock policy.
+type TPM2Policy struct {
+	PCRs      []int
+	PublicKey string
+	PCRLock   string
+}
+
+// EnrollTPM2 enrolls a TPM2 bound key into the given LUKS2 device with the given policy. The
+// given key file is used to unlock the device and it is kept enrolled as a recovery key.
+func EnrollTPM2(s *sys.System, device, keyFile string, policy TPM2Policy) error {
+	args := []string{fmt.Sprintf("--unlock-key-file=%s", keyFile), "--tpm2-device=auto"}
+	if len(policy.PCRs) > 0 {
+		pcrs := make([]string, len(policy.PCRs))
+		for i, pcr := range policy.PCRs {
+			pcrs[i] = strconv.Itoa(pcr)
+		}
+		args = append(args, fmt.Sprintf("--tpm2-pcrs=%s", strings.Join(pcrs, "+")))
+	}
+	if policy.PublicKey != "" {
+		args = append(args,
+			fmt.Sprintf("--tpm2-public-key=%s", policy.PublicKey),
+			fmt.Sprintf("--tpm2-public-key-pcrs=%d", SignedPCR),
+		)
+	}
+	if policy.PCRLock != "" {
+		args = append(args, fmt.Sprintf("--tpm2-pcrlock=%s", policy.PCRLock))
+	}
+	args = append(args, device)
+
+	_, err := s.Runner().Run("systemd-cryptenroll", args...)
+	if err != nil {
+		return fmt.Errorf("enrolling TPM2 key for '%s': %w", device, err)
+	}
+	return nil
+}
+
+// UpdatePCRLock predicts the PCR values of booting the given boot entry and updates the
+// systemd-pcrlock policy of the given root. bootImage is the UKI or kernel image of the boot
+// entry, cmdline is only measured for non UKI boot images. Predictions of other boot entries are
+// kept in place, so any of the installed boot entries can unlock the TPM2 bound keys. The
+// updated policy is stored in the TPM2 NV index the enrolled keys refer to, hence there is no
+// need to enroll keys again.
+func UpdatePCRLock(s *sys.System, root, entryID, bootImage, cmdline string, uki bool) error {
+	s.Logger().Info("Updating TPM2 PCR policy for boot entry '%s'", entryID)
+
+	lockDir := filepath.Join(root, pcrlockDir)
+	for _, dir := range []string{kernelPCRLock, cmdlinePCRLock} {
+		err := vfs.MkdirAll(s.FS(), filepath.Join(lockDir, dir), vfs.DirPerm)
+		if err != nil {
+			return fmt.Errorf("creating pcrlock directory: %w", err)
+		}
+	}
+
+	locks := [][]string{
+		{"lock-secureboot-policy", pcrlockFlag(lockDir, "230-secureboot-policy"+pcrlockSuffix)},
+		{"lock-secureboot-authority", pcrlockFlag(lockDir, "620-secureboot-authority"+pcrlockSuffix)},
+	}
+	entryLock := entryID + pcrlockSuffix
+	if uki {
+		locks = append(locks, []string{"lock-uki", pcrlockFlag(lockDir, kernelPCRLock, entryLock), bootImage})
+	} else {
+		cmdlineFile := filepath.Join(lockDir, cmdlinePCRLock, entryID+".cmdline")
+		err := s.FS().WriteFile(cmdlineFile, []byte(cmdline), vfs.FilePerm)
+		if err != nil {
+			return fmt.Errorf("writing kernel command line file: %w", err)
+		}
+		defer func() { _ = s.FS().Remove(cmdlineFile) }()
+
+		locks = append(locks,
+			[]string{"lock-pe", pcrlockFlag(lockDir, kernelPCRLock, entryLock), bootImage},
+			[]string{"lock-kernel-cmdline", pcrlockFlag(lockDir, cmdlinePCRLock, entryLock), cmdlineFile},
+		)
+	}
+
+	for _, lock := range locks {
+		_, err := s.Runner().Run(pcrlockBin, lock...)
+		if err != nil {
+			return fmt.Errorf("running pcrlock '%s': %w", lock[0], err)
+		}
+	}
+
+	_, err := s.Runner().Run(
+		pcrlockBin, "make-policy",
+		fmt.Sprintf("--components=%s", filepath.Join(root, pcrlockVendorDir)),
+		fmt.Sprintf("--components=%s", lockDir),
+		fmt.Sprintf("--policy=%s", filepath.Join(root, PCRLockPolicyFile)),
+	)
+	if err != nil {
+		return fmt.Errorf("making pcrlock policy: %w", err)
+	}
+	return nil
+}
+
+// PrunePCRLock removes the pcrlock predictions of the boot entries not included in keepIDs.
+func PrunePCRLock(s *sys.System, root string, keepIDs []int) error {
+	for _, dir := range []string{kernelPCRLock, cmdlinePCRLock} {
+		lockDir := filepath.Join(root, pcrlockDir, dir)
+		if ok, _ := vfs.Exists(s.FS(), lockDir); !ok {
+			continue
+		}
+		entries, err := s.FS().ReadDir(lockDir)
+		if err != nil {
+			return fmt.Errorf("reading pcrlock directory: %w", err)
+		}
+		for _, entry := range entries {
+			id, err := strconv.Atoi(strings.TrimSuffix(entry.Name(), pcrlockSuffix))
+			if err != nil || slices.Contains(keepIDs, id) {
+				continue
+			}
+			err = s.FS().Remove(filepath.Join(lockDir, entry.Name()))
+			if err != nil {
+				return fmt.Errorf("removing pcrlock file '%s': %w", entry.Name(), err)
+			}
+		}
+	}
+	return nil
+}
+
+func pcrlockFlag(paths ...string) string {
+	return fmt.Sprintf("--pcrlock=%s", filepath.Join(paths...))
+}
@@ -0,0 +1,113 @@
+/*
+Copyright © 2022-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package luks_test
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/luks"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+var _ = Describe("TPM2", Label("luks", "tpm2"), func() {
+	var tfs vfs.FS
+	var s *sys.System
+	var cleanup func()
+	var err error
+	var runner *sysmock.Runner
+	BeforeEach(func() {
+		runner = sysmock.NewRunner()
+		tfs, cleanup, err = sysmock.TestFS(nil)
+		Expect(err).NotTo(HaveOccurred())
+		s, err = sys.NewSystem(
+			sys.WithFS(tfs), sys.WithLogger(log.New(log.WithDiscardAll())),
+			sys.WithRunner(runner),
+		)
+		Expect(err).NotTo(HaveOccurred())
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+	It("enrolls TPM2 keys with the given policy", func() {
+		Expect(luks.EnrollTPM2(s, "/dev/sda3", "/key", luks.TPM2Policy{PCRs: []int{0, 7}})).To(Succeed())
+		Expect(luks.EnrollTPM2(s, "/dev/sda3", "/key", luks.TPM2Policy{PublicKey: "/pcr.pub"})).To(Succeed())
+		Expect(luks.EnrollTPM2(s, "/dev/sda3", "/key", luks.TPM2Policy{PCRLock: "/pcrlock.json"})).To(Succeed())
+		Expect(runner.CmdsMatch([][]string{
+			{"systemd-cryptenroll", "--unlock-key-file=/key", "--tpm2-device=auto", "--tpm2-pcrs=0+7", "/dev/sda3"},
+			{
+				"systemd-cryptenroll", "--unlock-key-file=/key", "--tpm2-device=auto",
+				"--tpm2-public-key=/pcr.pub", "--tpm2-public-key-pcrs=11", "/dev/sda3",
+			},
+			{"systemd-cryptenroll", "--unlock-key-file=/key", "--tpm2-device=auto", "--tpm2-pcrlock=/pcrlock.json", "/dev/sda3"},
+		})).To(Succeed())
+	})
+	It("fails to enroll TPM2 keys", func() {
+		runner.ReturnError = fmt.Errorf("no TPM2 device")
+		Expect(luks.EnrollTPM2(s, "/dev/sda3", "/key", luks.TPM2Policy{})).To(
+			MatchError(ContainSubstring("no TPM2 device")),
+		)
+	})
+	It("updates the pcrlock policy for a kernel boot entry", func() {
+		Expect(luks.UpdatePCRLock(s, "/root", "3", "/root/usr/lib/modules/6.4/vmlinuz", "root=LABEL=SYSTEM", false)).To(Succeed())
+		Expect(runner.CmdsMatch([][]string{
+			{"/usr/lib/systemd/systemd-pcrlock", "lock-secureboot-policy", "--pcrlock=/root/var/lib/pcrlock.d/230-secureboot-policy.pcrlock"},
+			{"/usr/lib/systemd/systemd-pcrlock", "lock-secureboot-authority", "--pcrlock=/root/var/lib/pcrlock.d/620-secureboot-authority.pcrlock"},
+			{
+				"/usr/lib/systemd/systemd-pcrlock", "lock-pe", "--pcrlock=/root/var/lib/pcrlock.d/650-elemental-kernel.pcrlock.d/3.pcrlock",
+				"/root/usr/lib/modules/6.4/vmlinuz",
+			},
+			{
+				"/usr/lib/systemd/systemd-pcrlock", "lock-kernel-cmdline",
+				"--pcrlock=/root/var/lib/pcrlock.d/710-elemental-kernel-cmdline.pcrlock.d/3.pcrlock",
+				"/root/var/lib/pcrlock.d/710-elemental-kernel-cmdline.pcrlock.d/3.cmdline",
+			},
+			{
+				"/usr/lib/systemd/systemd-pcrlock", "make-policy", "--components=/root/usr/lib/pcrlock.d",
+				"--components=/root/var/lib/pcrlock.d", "--policy=/root/var/lib/systemd/pcrlock.json",
+			},
+		})).To(Succeed())
+		// temporary command line file is removed
+		Expect(vfs.Exists(tfs, "/root/var/lib/pcrlock.d/710-elemental-kernel-cmdline.pcrlock.d/3.cmdline")).To(BeFalse())
+	})
+	It("updates the pcrlock policy for a UKI boot entry", func() {
+		Expect(luks.UpdatePCRLock(s, "/root", "3", "/boot/EFI/Linux/3.efi", "", true)).To(Succeed())
+		Expect(runner.MatchMilestones([][]string{
+			{"/usr/lib/systemd/systemd-pcrlock", "lock-uki", "--pcrlock=/root/var/lib/pcrlock.d/650-elemental-kernel.pcrlock.d/3.pcrlock", "/boot/EFI/Linux/3.efi"},
+			{"/usr/lib/systemd/systemd-pcrlock", "make-policy"},
+		})).To(Succeed())
+		Expect(runner.MatchMilestones([][]string{{"/usr/lib/systemd/systemd-pcrlock", "lock-kernel-cmdline"}})).NotTo(Succeed())
+	})
+	It("prunes pcrlock predictions of removed boot entries", func() {
+		kernelDir := "/root/var/lib/pcrlock.d/650-elemental-kernel.pcrlock.d"
+		Expect(vfs.MkdirAll(tfs, kernelDir, vfs.DirPerm)).To(Succeed())
+		for _, f := range []string{"1.pcrlock", "2.pcrlock", "3.pcrlock", "custom.pcrlock"} {
+			Expect(tfs.WriteFile(kernelDir+"/"+f, []byte{}, vfs.FilePerm)).To(Succeed())
+		}
+		Expect(luks.PrunePCRLock(s, "/root", []int{2, 3})).To(Succeed())
+		Expect(vfs.Exists(tfs, kernelDir+"/1.pcrlock")).To(BeFalse())
+		Expect(vfs.Exists(tfs, kernelDir+"/2.pcrlock")).To(BeTrue())
+		Expect(vfs.Exists(tfs, kernelDir+"/3.pcrlock")).To(BeTrue())
+		Expect(vfs.Exists(tfs, kernelDir+"/custom.pcrlock")).To(BeTrue())
+	})
+})
@@ -45,9 +45,24 @@ type OperatingSystem struct {
 	Image Image `yaml:"image" validate:"required"`
 }
 
+const (
+	DistributionRKE2 = "rke2"
+	DistributionK3s  = "k3s"
+)
+
 type Kubernetes struct {
-	Version string `yaml:"version" validate:"required"`
-	Image   string `yaml:"image" validate:"required"`
+	// Distribution is the Kubernetes distribution of the release, defaults to rke2
+	Distribution string `yaml:"distribution,omitempty" validate:"omitempty,oneof=rke2 k3s"`
+	Version      string `yaml:"version" validate:"required"`
+	Image        string `yaml:"image" validate:"required"`
+}
+
+// GetDistribution returns the Kubernetes distribution of the release
+func (k Kubernetes) GetDistribution() string {
+	if k.Distribution == "" {
+		return DistributionRKE2
+	}
+	return k.Distribution
 }
 
 type Image struct {
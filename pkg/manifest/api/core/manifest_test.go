@@ -20,6 +20,7 @@ package core_test
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -94,6 +95,7 @@ var _ = Describe("ReleaseManifest", Label("release-manifest"), func() {
 		Expect(rm.Components.Kubernetes).ToNot(BeNil())
 		Expect(rm.Components.Kubernetes.Version).To(Equal("v1.35.0+rke2r1"))
 		Expect(rm.Components.Kubernetes.Image).To(Equal("registry.example.com/rke2:1.35_1.0"))
+		Expect(rm.Components.Kubernetes.GetDistribution()).To(Equal(core.DistributionRKE2))
 
 		Expect(rm.Components.Helm).ToNot(BeNil())
 		Expect(len(rm.Components.Helm.Charts)).To(Equal(1))
@@ -122,6 +124,48 @@ var _ = Describe("ReleaseManifest", Label("release-manifest"), func() {
 		Expect(rm).To(BeNil())
 	})
 
+	It("parses upgrade paths", func() {
+		data := []byte(`
+metadata:
+  name: "suse-core"
+  version: "1.2.0"
+  minimumVersion: "1.0"
+  upgradePathsFrom:
+  - "1.1.x"
+  - ">= 1.0.2, < 1.1"
+components:
+  operatingSystem:
+    image:
+      base: "registry.com/foo/bar/os-base:6.2"
+      iso: "registry.com/foo/bar/installer-iso:6.2"
+`)
+		rm, err := core.Parse(data)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rm.Metadata.MinimumVersion).To(Equal("1.0"))
+		Expect(rm.Metadata.UpgradePathsFrom).To(Equal([]string{"1.1.x", ">= 1.0.2, < 1.1"}))
+	})
+
+	It("fails when versions or upgrade paths are not semantic versions", func() {
+		data := []byte(`
+metadata:
+  name: "suse-core"
+  version: "latest"
+  minimumVersion: "1.0"
+  upgradePathsFrom:
+  - "one.x"
+components:
+  operatingSystem:
+    image:
+      base: "registry.com/foo/bar/os-base:6.2"
+      iso: "registry.com/foo/bar/installer-iso:6.2"
+`)
+		rm, err := core.Parse(data)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring(`field "ReleaseManifest.metadata.version" must be a semantic version, but got "latest"`))
+		Expect(err.Error()).To(ContainSubstring(`field "ReleaseManifest.metadata.upgradePathsFrom[0]" must be a semantic version constraint, but got "one.x"`))
+		Expect(rm).To(BeNil())
+	})
+
 	It("defaults to schema v0 when schema field is missing", func() {
 		data := []byte(`
 components:
@@ -165,6 +209,28 @@ components:
 		Expect(rm).To(BeNil())
 	})
 
+	It("parses the kubernetes distribution", func() {
+		data := []byte(`
+components:
+  operatingSystem:
+    image:
+      base: "registry.com/foo/bar/os-base:6.2"
+      iso: "registry.com/foo/bar/installer-iso:6.2"
+  kubernetes:
+    distribution: k3s
+    version: "v1.35.0+k3s1"
+    image: "registry.com/foo/bar/k3s:1.35"
+`)
+		rm, err := core.Parse(data)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rm.Components.Kubernetes.GetDistribution()).To(Equal(core.DistributionK3s))
+
+		rm, err = core.Parse([]byte(strings.Replace(string(data), "distribution: k3s", "distribution: k8s", 1)))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring(`field "ReleaseManifest.components.kubernetes.distribution" must be one of [rke2 k3s], but got "k8s"`))
+		Expect(rm).To(BeNil())
+	})
+
 	It("fails when manifest is broken", func() {
 		expErrors := []string{
 			"field \"ReleaseManifest.components.operatingSystem.image.iso\" is required",
@@ -67,8 +67,13 @@ const (
 
 type Metadata struct {
 	Name         string `yaml:"name" validate:"required"`
-	Version      string `yaml:"version" validate:"required"`
+	Version      string `yaml:"version" validate:"required,semver_version"`
 	CreationDate string `yaml:"creationDate,omitempty"`
+	// UpgradePathsFrom lists the semver constraints a release needs to satisfy to be upgraded
+	// to this release, any release is accepted if empty
+	UpgradePathsFrom []string `yaml:"upgradePathsFrom,omitempty" validate:"dive,semver_constraint"`
+	// MinimumVersion is the oldest release that can be upgraded to this release
+	MinimumVersion string `yaml:"minimumVersion,omitempty" validate:"omitempty,semver_version"`
 }
 
 type Helm struct {
@@ -0,0 +1,87 @@
+/*
+Copyright © 2022-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+//revive:disable:var-naming
+package api
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// ErrUpgradeNotAllowed is returned when a release does not define an upgrade path from
+// the currently installed release
+var ErrUpgradeNotAllowed = errors.New("upgrade not allowed")
+
+// CheckUpgradeFrom checks if the release described by this metadata can be installed as an
+// upgrade of the given release. Versions are compared as semantic versions. Downgrades,
+// upgrades from a different release and upgrades from versions not matching the minimum
+// version or any of the upgrade paths fail with ErrUpgradeNotAllowed. Upgrading to the
+// same version is always allowed.
+func (m Metadata) CheckUpgradeFrom(name, version string) error {
+	if name != m.Name {
+		return fmt.Errorf("%w: release '%s' is not an upgrade of release '%s'", ErrUpgradeNotAllowed, m.Name, name)
+	}
+
+	target, err := semver.NewVersion(m.Version)
+	if err != nil {
+		return fmt.Errorf("parsing version of release '%s': %w", m.Name, err)
+	}
+
+	current, err := semver.NewVersion(version)
+	if err != nil {
+		return fmt.Errorf("parsing installed version '%s': %w", version, err)
+	}
+
+	switch current.Compare(target) {
+	case 0:
+		return nil
+	case 1:
+		return fmt.Errorf("%w: downgrading '%s' from %s to %s", ErrUpgradeNotAllowed, m.Name, current, target)
+	}
+
+	if m.MinimumVersion != "" {
+		minimum, err := semver.NewVersion(m.MinimumVersion)
+		if err != nil {
+			return fmt.Errorf("parsing minimum version of release '%s': %w", m.Name, err)
+		}
+		if current.LessThan(minimum) {
+			return fmt.Errorf(
+				"%w: '%s' %s requires at least version %s, found %s",
+				ErrUpgradeNotAllowed, m.Name, target, minimum, current,
+			)
+		}
+	}
+
+	if len(m.UpgradePathsFrom) == 0 {
+		return nil
+	}
+
+	for _, path := range m.UpgradePathsFrom {
+		constraint, err := semver.NewConstraint(path)
+		if err != nil {
+			return fmt.Errorf("parsing upgrade path '%s' of release '%s': %w", path, m.Name, err)
+		}
+		if constraint.Check(current) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: no upgrade path to '%s' %s from %s", ErrUpgradeNotAllowed, m.Name, target, current)
+}
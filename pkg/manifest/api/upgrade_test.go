@@ -0,0 +1,84 @@
+/*
+Copyright © 2022-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/manifest/api"
+)
+
+var _ = Describe("CheckUpgradeFrom", Label("release-manifest"), func() {
+	var m api.Metadata
+
+	BeforeEach(func() {
+		m = api.Metadata{
+			Name:             "suse-core",
+			Version:          "1.2.0",
+			MinimumVersion:   "1.0.0",
+			UpgradePathsFrom: []string{"1.1.x", ">= 1.0.2, < 1.1"},
+		}
+	})
+
+	It("allows upgrades matching any of the upgrade paths", func() {
+		Expect(m.CheckUpgradeFrom("suse-core", "1.1.4")).To(Succeed())
+		Expect(m.CheckUpgradeFrom("suse-core", "1.0.2")).To(Succeed())
+		Expect(m.CheckUpgradeFrom("suse-core", "v1.0.10")).To(Succeed())
+	})
+
+	It("allows upgrades to the same version", func() {
+		Expect(m.CheckUpgradeFrom("suse-core", "1.2")).To(Succeed())
+	})
+
+	It("allows any upgrade above the minimum version if there are no upgrade paths", func() {
+		m.UpgradePathsFrom = nil
+		Expect(m.CheckUpgradeFrom("suse-core", "1.0.0")).To(Succeed())
+		Expect(m.CheckUpgradeFrom("suse-core", "1.1.0-rc1")).To(Succeed())
+	})
+
+	It("refuses upgrades not matching any upgrade path", func() {
+		err := m.CheckUpgradeFrom("suse-core", "1.0.1")
+		Expect(err).To(MatchError(api.ErrUpgradeNotAllowed))
+		Expect(err.Error()).To(ContainSubstring("no upgrade path to 'suse-core' 1.2.0 from 1.0.1"))
+	})
+
+	It("refuses upgrades from versions below the minimum version", func() {
+		err := m.CheckUpgradeFrom("suse-core", "0.9.0")
+		Expect(err).To(MatchError(api.ErrUpgradeNotAllowed))
+		Expect(err.Error()).To(ContainSubstring("requires at least version 1.0.0, found 0.9.0"))
+	})
+
+	It("refuses downgrades", func() {
+		err := m.CheckUpgradeFrom("suse-core", "1.10.0")
+		Expect(err).To(MatchError(api.ErrUpgradeNotAllowed))
+		Expect(err.Error()).To(ContainSubstring("downgrading 'suse-core' from 1.10.0 to 1.2.0"))
+	})
+
+	It("refuses upgrades from a different release", func() {
+		err := m.CheckUpgradeFrom("suse-edge", "1.1.0")
+		Expect(err).To(MatchError(api.ErrUpgradeNotAllowed))
+	})
+
+	It("fails if the installed version is not a semantic version", func() {
+		err := m.CheckUpgradeFrom("suse-core", "latest")
+		Expect(err).To(HaveOccurred())
+		Expect(err).NotTo(MatchError(api.ErrUpgradeNotAllowed))
+		Expect(err.Error()).To(ContainSubstring("parsing installed version 'latest'"))
+	})
+})
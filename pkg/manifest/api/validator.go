@@ -7,6 +7,7 @@ import (
 	"reflect"
 	"strings"
 
+	"github.com/Masterminds/semver/v3"
 	"github.com/go-playground/validator/v10"
 )
 
@@ -40,6 +41,8 @@ func WithYAMLFieldNames() ValidatorOpts {
 
 func NewValidator(opts ...ValidatorOpts) *validator.Validate {
 	validate := validator.New(validator.WithRequiredStructEnabled())
+	_ = validate.RegisterValidation("semver_version", validateSemverVersion)
+	_ = validate.RegisterValidation("semver_constraint", validateSemverConstraint)
 	for _, opt := range opts {
 		opt(validate)
 	}
@@ -56,9 +59,23 @@ func FormatErrors(errs validator.ValidationErrors) error {
 			messages = append(messages, fmt.Sprintf("field %q must be one of [%s], but got %q", err.Namespace(), err.Param(), err.Value()))
 		case "url":
 			messages = append(messages, fmt.Sprintf("field %q must be a valid URL, but got %q", err.Namespace(), err.Value()))
+		case "semver_version":
+			messages = append(messages, fmt.Sprintf("field %q must be a semantic version, but got %q", err.Namespace(), err.Value()))
+		case "semver_constraint":
+			messages = append(messages, fmt.Sprintf("field %q must be a semantic version constraint, but got %q", err.Namespace(), err.Value()))
 		default:
 			messages = append(messages, fmt.Sprintf("field %q failed validation on tag %q", err.Namespace(), err.Tag()))
 		}
 	}
 	return errors.New(strings.Join(messages, "; "))
 }
+
+func validateSemverVersion(fl validator.FieldLevel) bool {
+	_, err := semver.NewVersion(fl.Field().String())
+	return err == nil
+}
+
+func validateSemverConstraint(fl validator.FieldLevel) bool {
+	_, err := semver.NewConstraint(fl.Field().String())
+	return err == nil
+}
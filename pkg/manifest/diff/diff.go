@@ -0,0 +1,247 @@
+/*
+Copyright © 2022-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/suse/elemental/v3/pkg/manifest/api"
+	"github.com/suse/elemental/v3/pkg/manifest/resolver"
+)
+
+// Status of a component in the new release compared to the old one
+type Status string
+
+const (
+	Added   Status = "added"
+	Removed Status = "removed"
+	Changed Status = "changed"
+)
+
+// Release identifies the release described by a release manifest
+type Release struct {
+	Name    string `json:"name,omitempty"`
+	Version string `json:"version,omitempty"`
+}
+
+// Change is the difference of a single field between two releases. Old is empty for added
+// fields and New is empty for removed fields.
+type Change struct {
+	Field string `json:"field"`
+	Old   string `json:"old,omitempty"`
+	New   string `json:"new,omitempty"`
+}
+
+// ComponentDiff lists the changes of a named component between two releases
+type ComponentDiff struct {
+	Name    string   `json:"name"`
+	Status  Status   `json:"status"`
+	Changes []Change `json:"changes"`
+}
+
+// Report includes all the differences between two releases
+type Report struct {
+	Old             Release         `json:"old"`
+	New             Release         `json:"new"`
+	OperatingSystem []Change        `json:"operatingSystem"`
+	Kubernetes      []Change        `json:"kubernetes"`
+	Extensions      []ComponentDiff `json:"extensions"`
+	HelmCharts      []ComponentDiff `json:"helmCharts"`
+}
+
+// IsEmpty returns true if the releases have no component differences
+func (r Report) IsEmpty() bool {
+	return len(r.OperatingSystem) == 0 && len(r.Kubernetes) == 0 && len(r.Extensions) == 0 && len(r.HelmCharts) == 0
+}
+
+// Compare reports the differences between the old and the new resolved release manifests. The
+// components of the solution extension take precedence over the core platform ones of the same name.
+func Compare(oldRM, newRM *resolver.ResolvedManifest) (*Report, error) {
+	r := &Report{
+		Old:             release(oldRM),
+		New:             release(newRM),
+		OperatingSystem: []Change{},
+		Kubernetes:      []Change{},
+		Extensions:      []ComponentDiff{},
+		HelmCharts:      []ComponentDiff{},
+	}
+
+	oldOS, newOS := osFields(oldRM), osFields(newRM)
+	r.OperatingSystem = compareFields(oldOS, newOS, []string{"base", "iso"})
+
+	oldK8s, newK8s := kubernetesFields(oldRM), kubernetesFields(newRM)
+	r.Kubernetes = compareFields(oldK8s, newK8s, []string{"distribution", "version", "image"})
+
+	r.Extensions = compareComponents(extensions(oldRM), extensions(newRM), []string{"image", "required", "kernelModules"})
+
+	oldCharts, err := charts(oldRM)
+	if err != nil {
+		return nil, err
+	}
+	newCharts, err := charts(newRM)
+	if err != nil {
+		return nil, err
+	}
+	r.HelmCharts = compareComponents(oldCharts, newCharts, []string{"version", "repository", "namespace", "values"})
+
+	return r, nil
+}
+
+func release(rm *resolver.ResolvedManifest) Release {
+	m := rm.Metadata()
+	if m == nil {
+		return Release{}
+	}
+	return Release{Name: m.Name, Version: m.Version}
+}
+
+func osFields(rm *resolver.ResolvedManifest) map[string]string {
+	fields := map[string]string{}
+	if rm.CorePlatform == nil || rm.CorePlatform.Components.OperatingSystem == nil {
+		return fields
+	}
+	img := rm.CorePlatform.Components.OperatingSystem.Image
+	fields["base"] = img.Base
+	fields["iso"] = img.ISO
+	return fields
+}
+
+func kubernetesFields(rm *resolver.ResolvedManifest) map[string]string {
+	fields := map[string]string{}
+	if rm.CorePlatform == nil || rm.CorePlatform.Components.Kubernetes == nil {
+		return fields
+	}
+	fields["distribution"] = rm.CorePlatform.Components.Kubernetes.GetDistribution()
+	fields["version"] = rm.CorePlatform.Components.Kubernetes.Version
+	fields["image"] = rm.CorePlatform.Components.Kubernetes.Image
+	return fields
+}
+
+// extensions returns the fields of each systemd extension of the release indexed by name
+func extensions(rm *resolver.ResolvedManifest) map[string]map[string]string {
+	var exts []api.SystemdExtension
+	if rm.CorePlatform != nil {
+		exts = append(exts, rm.CorePlatform.Components.Systemd.Extensions...)
+	}
+	if rm.SolutionExtension != nil {
+		exts = append(exts, rm.SolutionExtension.Components.Systemd.Extensions...)
+	}
+
+	components := map[string]map[string]string{}
+	for _, ext := range exts {
+		modules := slices.Clone(ext.KernelModules)
+		slices.Sort(modules)
+		components[ext.Name] = map[string]string{
+			"image":         ext.Image,
+			"required":      strconv.FormatBool(ext.Required),
+			"kernelModules": strings.Join(modules, ", "),
+		}
+	}
+	return components
+}
+
+// charts returns the fields of each Helm chart of the release indexed by chart name. The
+// repository is reported by URL, as repository names are local to each manifest.
+func charts(rm *resolver.ResolvedManifest) (map[string]map[string]string, error) {
+	components := map[string]map[string]string{}
+
+	var helms []*api.Helm
+	if rm.CorePlatform != nil {
+		helms = append(helms, rm.CorePlatform.Components.Helm)
+	}
+	if rm.SolutionExtension != nil {
+		helms = append(helms, rm.SolutionExtension.Components.Helm)
+	}
+
+	for _, h := range helms {
+		if h == nil {
+			continue
+		}
+		repos := map[string]string{}
+		for _, repo := range h.Repositories {
+			repos[repo.Name] = repo.URL
+		}
+		for _, c := range h.Charts {
+			repository := c.Repository
+			if url, ok := repos[c.Repository]; ok {
+				repository = url
+			}
+			values := ""
+			if len(c.Values) > 0 {
+				data, err := json.Marshal(c.Values)
+				if err != nil {
+					return nil, fmt.Errorf("marshalling values of chart '%s': %w", c.GetName(), err)
+				}
+				values = string(data)
+			}
+			components[c.GetName()] = map[string]string{
+				"version":    c.Version,
+				"repository": repository,
+				"namespace":  c.Namespace,
+				"values":     values,
+			}
+		}
+	}
+	return components, nil
+}
+
+// compareFields returns the changes between the old and new values of the given fields
+func compareFields(oldFields, newFields map[string]string, fields []string) []Change {
+	changes := []Change{}
+	for _, field := range fields {
+		if oldFields[field] != newFields[field] {
+			changes = append(changes, Change{Field: field, Old: oldFields[field], New: newFields[field]})
+		}
+	}
+	return changes
+}
+
+// compareComponents returns the added, removed and changed components sorted by name
+func compareComponents(oldComps, newComps map[string]map[string]string, fields []string) []ComponentDiff {
+	names := []string{}
+	for name := range oldComps {
+		names = append(names, name)
+	}
+	for name := range newComps {
+		if _, ok := oldComps[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	diffs := []ComponentDiff{}
+	for _, name := range names {
+		oldComp, inOld := oldComps[name]
+		newComp, inNew := newComps[name]
+
+		changes := compareFields(oldComp, newComp, fields)
+		switch {
+		case !inOld:
+			diffs = append(diffs, ComponentDiff{Name: name, Status: Added, Changes: changes})
+		case !inNew:
+			diffs = append(diffs, ComponentDiff{Name: name, Status: Removed, Changes: changes})
+		case len(changes) > 0:
+			diffs = append(diffs, ComponentDiff{Name: name, Status: Changed, Changes: changes})
+		}
+	}
+	return diffs
+}
@@ -0,0 +1,134 @@
+/*
+Copyright © 2022-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diff_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/manifest/api"
+	"github.com/suse/elemental/v3/pkg/manifest/api/core"
+	"github.com/suse/elemental/v3/pkg/manifest/api/solution"
+	"github.com/suse/elemental/v3/pkg/manifest/diff"
+	"github.com/suse/elemental/v3/pkg/manifest/resolver"
+)
+
+func TestDiffSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Release Manifest Diff test suite")
+}
+
+func coreManifest(version, k8s string, exts []api.SystemdExtension, charts []*api.HelmChart) *core.ReleaseManifest {
+	return &core.ReleaseManifest{
+		Metadata: &api.Metadata{Name: "suse-core", Version: version},
+		Components: core.Components{
+			OperatingSystem: &core.OperatingSystem{Image: core.Image{Base: "registry.org/os:" + version}},
+			Kubernetes:      &core.Kubernetes{Version: k8s, Image: "registry.org/rke2:" + k8s},
+			Systemd:         api.Systemd{Extensions: exts},
+			Helm: &api.Helm{
+				Charts:       charts,
+				Repositories: []*api.HelmRepository{{Name: "suse", URL: "https://charts.suse.com"}},
+			},
+		},
+	}
+}
+
+var _ = Describe("Diff", Label("release-manifest"), func() {
+	It("reports no differences for the same release", func() {
+		rm := &resolver.ResolvedManifest{
+			CorePlatform: coreManifest("1.0.0", "v1.32.0", []api.SystemdExtension{{Name: "nvidia", Image: "registry.org/nvidia:1"}}, nil),
+		}
+		report, err := diff.Compare(rm, rm)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.IsEmpty()).To(BeTrue())
+		Expect(report.Old).To(Equal(diff.Release{Name: "suse-core", Version: "1.0.0"}))
+	})
+
+	It("reports changed components between releases", func() {
+		oldRM := &resolver.ResolvedManifest{
+			CorePlatform: coreManifest("1.0.0", "v1.32.0",
+				[]api.SystemdExtension{
+					{Name: "nvidia", Image: "registry.org/nvidia:1", KernelModules: []string{"nvidia", "nvidia_drm"}},
+					{Name: "legacy", Image: "registry.org/legacy:1"},
+				},
+				[]*api.HelmChart{
+					{Chart: "metallb", Version: "0.14.0", Namespace: "metallb", Repository: "suse"},
+					{Chart: "longhorn", Version: "1.7.0", Repository: "suse", Values: map[string]any{"replicas": 3}},
+				},
+			),
+		}
+		newRM := &resolver.ResolvedManifest{
+			CorePlatform: coreManifest("1.1.0", "v1.33.1",
+				[]api.SystemdExtension{
+					{Name: "nvidia", Image: "registry.org/nvidia:2", KernelModules: []string{"nvidia_drm", "nvidia"}},
+				},
+				[]*api.HelmChart{
+					{Chart: "metallb", Version: "0.14.0", Namespace: "metallb", Repository: "suse"},
+					{Chart: "longhorn", Version: "1.8.0", Repository: "suse", Values: map[string]any{"replicas": 2}},
+				},
+			),
+			SolutionExtension: &solution.ReleaseManifest{
+				Metadata: &api.Metadata{Name: "suse-edge", Version: "3.0.0"},
+				Components: solution.Components{
+					Systemd: api.Systemd{Extensions: []api.SystemdExtension{{Name: "edge", Image: "registry.org/edge:1", Required: true}}},
+					Helm: &api.Helm{
+						Charts:       []*api.HelmChart{{Chart: "rancher", Version: "2.11.0", Repository: "rancher"}},
+						Repositories: []*api.HelmRepository{{Name: "rancher", URL: "https://releases.rancher.com"}},
+					},
+				},
+			},
+		}
+
+		report, err := diff.Compare(oldRM, newRM)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.IsEmpty()).To(BeFalse())
+		Expect(report.New).To(Equal(diff.Release{Name: "suse-edge", Version: "3.0.0"}))
+		Expect(report.OperatingSystem).To(Equal([]diff.Change{
+			{Field: "base", Old: "registry.org/os:1.0.0", New: "registry.org/os:1.1.0"},
+		}))
+		Expect(report.Kubernetes).To(Equal([]diff.Change{
+			{Field: "version", Old: "v1.32.0", New: "v1.33.1"},
+			{Field: "image", Old: "registry.org/rke2:v1.32.0", New: "registry.org/rke2:v1.33.1"},
+		}))
+		Expect(report.Extensions).To(Equal([]diff.ComponentDiff{
+			{Name: "edge", Status: diff.Added, Changes: []diff.Change{
+				{Field: "image", New: "registry.org/edge:1"},
+				{Field: "required", New: "true"},
+			}},
+			{Name: "legacy", Status: diff.Removed, Changes: []diff.Change{
+				{Field: "image", Old: "registry.org/legacy:1"},
+				{Field: "required", Old: "false"},
+			}},
+			{Name: "nvidia", Status: diff.Changed, Changes: []diff.Change{
+				{Field: "image", Old: "registry.org/nvidia:1", New: "registry.org/nvidia:2"},
+			}},
+		}))
+		Expect(report.HelmCharts).To(Equal([]diff.ComponentDiff{
+			{Name: "longhorn", Status: diff.Changed, Changes: []diff.Change{
+				{Field: "version", Old: "1.7.0", New: "1.8.0"},
+				{Field: "values", Old: `{"replicas":3}`, New: `{"replicas":2}`},
+			}},
+			{Name: "rancher", Status: diff.Added, Changes: []diff.Change{
+				{Field: "version", New: "2.11.0"},
+				{Field: "repository", New: "https://releases.rancher.com"},
+			}},
+		}))
+	})
+})
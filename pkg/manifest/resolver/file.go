@@ -0,0 +1,79 @@
+/*
+Copyright © 2022-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resolver
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"go.yaml.in/yaml/v3"
+
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+// ManifestFile is the path of the release manifest applied to an installed system
+const ManifestFile = "/etc/elemental/release-manifest.yaml"
+
+// WriteManifestFile serializes the resolved release manifest into the manifest file
+// of the given root.
+func WriteManifestFile(s *sys.System, root string, rm *ResolvedManifest) error {
+	path := filepath.Join(root, ManifestFile)
+	err := vfs.MkdirAll(s.FS(), filepath.Dir(path), vfs.DirPerm)
+	if err != nil {
+		return fmt.Errorf("creating elemental directory: %w", err)
+	}
+	if ok, _ := vfs.Exists(s.FS(), path); ok {
+		err = s.FS().Remove(path)
+		if err != nil {
+			return fmt.Errorf("removing previous release manifest file: %w", err)
+		}
+	}
+
+	data, err := yaml.Marshal(rm)
+	if err != nil {
+		return fmt.Errorf("marshalling release manifest: %w", err)
+	}
+	data = append([]byte("# self-generated content, do not edit\n\n"), data...)
+
+	err = s.FS().WriteFile(path, data, 0444)
+	if err != nil {
+		return fmt.Errorf("writing release manifest file '%s': %w", path, err)
+	}
+	return nil
+}
+
+// ParseManifestFile reads the release manifest file of the given root. Returns
+// nil if there is no release manifest file.
+func ParseManifestFile(s *sys.System, root string) (*ResolvedManifest, error) {
+	path := filepath.Join(root, ManifestFile)
+	if ok, err := vfs.Exists(s.FS(), path); !ok {
+		s.Logger().Debug("release manifest file not found '%s'", path)
+		return nil, err
+	}
+	data, err := s.FS().ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading release manifest file '%s': %w", path, err)
+	}
+	rm := &ResolvedManifest{}
+	err = yaml.Unmarshal(data, rm)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshalling release manifest file '%s': %w", path, err)
+	}
+	return rm, nil
+}
@@ -0,0 +1,74 @@
+/*
+Copyright © 2022-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resolver_test
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/manifest/api"
+	"github.com/suse/elemental/v3/pkg/manifest/api/core"
+	"github.com/suse/elemental/v3/pkg/manifest/resolver"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+var _ = Describe("Manifest file", Label("release-manifest"), func() {
+	var s *sys.System
+	var tfs vfs.FS
+	var cleanup func()
+
+	BeforeEach(func() {
+		var err error
+		tfs, cleanup, err = sysmock.TestFS(nil)
+		Expect(err).NotTo(HaveOccurred())
+		s, err = sys.NewSystem(sys.WithFS(tfs), sys.WithLogger(log.New(log.WithBuffer(&bytes.Buffer{}))))
+		Expect(err).NotTo(HaveOccurred())
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+
+	It("writes and parses the release manifest file", func() {
+		rm := &resolver.ResolvedManifest{
+			CorePlatform: &core.ReleaseManifest{
+				Metadata: &api.Metadata{Name: "suse-core", Version: "1.0.0"},
+				Components: core.Components{
+					OperatingSystem: &core.OperatingSystem{Image: core.Image{Base: "registry.org/os:1.0"}},
+				},
+			},
+		}
+		Expect(resolver.WriteManifestFile(s, "/root", rm)).To(Succeed())
+		// the previous file is replaced
+		Expect(resolver.WriteManifestFile(s, "/root", rm)).To(Succeed())
+
+		parsed, err := resolver.ParseManifestFile(s, "/root")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(parsed).To(Equal(rm))
+	})
+
+	It("returns nil if there is no release manifest file", func() {
+		parsed, err := resolver.ParseManifestFile(s, "/root")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(parsed).To(BeNil())
+	})
+})
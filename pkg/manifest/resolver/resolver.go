@@ -21,6 +21,7 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/suse/elemental/v3/pkg/manifest/api"
 	"github.com/suse/elemental/v3/pkg/manifest/api/core"
 	"github.com/suse/elemental/v3/pkg/manifest/api/solution"
 	"github.com/suse/elemental/v3/pkg/manifest/source"
@@ -28,9 +29,21 @@ import (
 
 type ResolvedManifest struct {
 	// Release manifest for the core platform
-	CorePlatform *core.ReleaseManifest
+	CorePlatform *core.ReleaseManifest `yaml:"corePlatform,omitempty"`
 	// Solution release manifest that extends the core platform
-	SolutionExtension *solution.ReleaseManifest
+	SolutionExtension *solution.ReleaseManifest `yaml:"solutionExtension,omitempty"`
+}
+
+// Metadata returns the metadata of the release, this is the solution metadata if the
+// manifest extends the core platform. Returns nil if the manifest has no metadata.
+func (r *ResolvedManifest) Metadata() *api.Metadata {
+	if r.SolutionExtension != nil {
+		return r.SolutionExtension.Metadata
+	}
+	if r.CorePlatform != nil {
+		return r.CorePlatform.Metadata
+	}
+	return nil
 }
 
 type SourceReader interface {
@@ -66,6 +66,7 @@ var _ = Describe("Resolver", Label("release-manifest"), func() {
 		Expect(err).ToNot(HaveOccurred())
 		Expect(resolvedManifest).ToNot(BeNil())
 		validateResolvedManifest(resolvedManifest, false)
+		Expect(resolvedManifest.Metadata().Name).To(Equal("suse-edge"))
 	})
 
 	It("resolves a 'core' release manifest correctly", func() {
@@ -82,6 +83,7 @@ var _ = Describe("Resolver", Label("release-manifest"), func() {
 		Expect(err).ToNot(HaveOccurred())
 		Expect(resolvedManifest).ToNot(BeNil())
 		validateResolvedManifest(resolvedManifest, true)
+		Expect(resolvedManifest.Metadata().Name).To(Equal("suse-core"))
 	})
 
 	It("fails to convert to a manifest source", func() {
@@ -0,0 +1,218 @@
+/*
+Copyright © 2022-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package raid
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/suse/elemental/v3/pkg/block"
+	"github.com/suse/elemental/v3/pkg/cleanstack"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/filesystem"
+	"github.com/suse/elemental/v3/pkg/repart"
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+const (
+	MDAdmConfFile = "/etc/mdadm.conf"
+
+	arrayDir   = "/dev/md"
+	uuidDir    = "/dev/disk/by-id"
+	uuidPrefix = "md-uuid-"
+	metadata   = "1.2"
+)
+
+// DevicePath returns the device path to mount for the given partition. For RAID partitions
+// this is the path of the assembled array, otherwise it is the given device.
+func DevicePath(part *deployment.Partition, device string) string {
+	if part.IsRAID() && part.RAID.UUID != "" {
+		return filepath.Join(uuidDir, uuidPrefix+part.RAID.UUID)
+	}
+	return device
+}
+
+// ArrayName returns the array device path of the given RAID partition
+func ArrayName(part *deployment.Partition) string {
+	return filepath.Join(arrayDir, part.Label)
+}
+
+// ReadUUID returns the UUID of the given array device
+func ReadUUID(s *sys.System, device string) (string, error) {
+	out, err := s.Runner().Run("mdadm", "--detail", "--export", device)
+	if err != nil {
+		return "", fmt.Errorf("reading details of array '%s': %w", device, err)
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		if uuid, ok := strings.CutPrefix(strings.TrimSpace(line), "MD_UUID="); ok {
+			return uuid, nil
+		}
+	}
+	return "", fmt.Errorf("no UUID found for array '%s'", device)
+}
+
+// Create creates a new array with the given name, RAID level and member devices
+func Create(s *sys.System, name string, level int, devices []string) error {
+	args := []string{
+		"--create", name, "--run", fmt.Sprintf("--metadata=%s", metadata),
+		fmt.Sprintf("--level=%d", level), fmt.Sprintf("--raid-devices=%d", len(devices)),
+	}
+	args = append(args, devices...)
+	_, err := s.Runner().Run("mdadm", args...)
+	if err != nil {
+		return fmt.Errorf("creating array '%s': %w", name, err)
+	}
+	_, _ = s.Runner().Run("udevadm", "settle")
+	return nil
+}
+
+// Assemble assembles the array with the given UUID from any of the available member devices
+func Assemble(s *sys.System, uuid string) error {
+	_, err := s.Runner().Run("mdadm", "--assemble", "--scan", fmt.Sprintf("--uuid=%s", uuid))
+	if err != nil {
+		return fmt.Errorf("assembling array '%s': %w", uuid, err)
+	}
+	_, _ = s.Runner().Run("udevadm", "settle")
+	return nil
+}
+
+// Stop stops the given array device
+func Stop(s *sys.System, device string) error {
+	_, err := s.Runner().Run("mdadm", "--stop", device)
+	if err != nil {
+		return fmt.Errorf("stopping array '%s': %w", device, err)
+	}
+	return nil
+}
+
+// Setup prepares the RAID partitions of the given disk once it is partitioned. New arrays
+// are created from the disk partition and equivalent partitions created in each member device,
+// then the array is formatted with the partition filesystem. Pre-existing arrays are assembled
+// if not running already. Stopping the arrays is pushed to the given cleanstack.
+func Setup(s *sys.System, cleanStack *cleanstack.CleanStack, bDev block.Device, disk *deployment.Disk) error {
+	members, err := partitionMembers(s, disk)
+	if err != nil {
+		return err
+	}
+
+	for _, part := range disk.Partitions {
+		if part == nil || !part.IsRAID() {
+			continue
+		}
+		if part.RAID.UUID != "" {
+			device := DevicePath(part, "")
+			if ok, _ := vfs.Exists(s.FS(), device); ok {
+				continue
+			}
+			err = Assemble(s, part.RAID.UUID)
+			if err != nil {
+				return err
+			}
+			cleanStack.Push(func() error { return Stop(s, device) })
+			continue
+		}
+
+		var devices []string
+		for _, p := range append(deployment.Partitions{part}, members[part]...) {
+			bPart, err := block.GetPartitionByUUID(s, bDev, p.UUID, 4)
+			if err != nil {
+				return fmt.Errorf("finding partition '%s': %w", p.UUID, err)
+			}
+			devices = append(devices, bPart.Path)
+		}
+		name := ArrayName(part)
+		err = Create(s, name, part.RAID.Level, devices)
+		if err != nil {
+			return err
+		}
+		cleanStack.Push(func() error { return Stop(s, name) })
+
+		part.RAID.UUID, err = ReadUUID(s, name)
+		if err != nil {
+			return err
+		}
+		err = filesystem.NewMkfsCall(s, name, part.FileSystem.String(), part.Label, "").Apply()
+		if err != nil {
+			return fmt.Errorf("formatting array '%s': %w", name, err)
+		}
+	}
+	return nil
+}
+
+// partitionMembers creates the member partitions of the new RAID partitions of the given disk in
+// their member devices. Member partitions are created in the same order they have in the given disk.
+// Returns a map of RAID partitions to their member partitions.
+func partitionMembers(s *sys.System, disk *deployment.Disk) (map[*deployment.Partition]deployment.Partitions, error) {
+	members := map[*deployment.Partition]deployment.Partitions{}
+	memberDisks := map[string]*deployment.Disk{}
+	var devices []string
+
+	for _, part := range disk.Partitions {
+		if part == nil || !part.IsRAID() || part.RAID.UUID != "" {
+			continue
+		}
+		for _, device := range part.RAID.Devices {
+			if _, ok := memberDisks[device]; !ok {
+				memberDisks[device] = &deployment.Disk{Device: device}
+				devices = append(devices, device)
+			}
+			member := &deployment.Partition{
+				Role: part.Role, Label: part.Label, Size: part.Size, RAID: part.RAID,
+			}
+			memberDisks[device].Partitions = append(memberDisks[device].Partitions, member)
+			members[part] = append(members[part], member)
+		}
+	}
+
+	for _, device := range devices {
+		err := repart.PartitionAndFormatDevice(s, memberDisks[device])
+		if err != nil {
+			return nil, fmt.Errorf("partitioning RAID member device '%s': %w", device, err)
+		}
+	}
+	return members, nil
+}
+
+// WriteMDAdmConf writes the mdadm.conf file within the given root including all the
+// RAID partitions of the given partitions list, so arrays are consistently assembled at boot.
+func WriteMDAdmConf(s *sys.System, root string, parts deployment.Partitions) error {
+	var lines []string
+	for _, part := range parts {
+		if !part.IsRAID() {
+			continue
+		}
+		if part.RAID.UUID == "" {
+			return fmt.Errorf("RAID partition '%s' has no array UUID", part.Label)
+		}
+		lines = append(lines, fmt.Sprintf(
+			"ARRAY %s metadata=%s UUID=%s", ArrayName(part), metadata, part.RAID.UUID,
+		))
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+
+	data := "# self-generated content, do not edit\n\n" + strings.Join(lines, "\n") + "\n"
+	err := s.FS().WriteFile(filepath.Join(root, MDAdmConfFile), []byte(data), vfs.FilePerm)
+	if err != nil {
+		return fmt.Errorf("writing mdadm.conf file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+/*
+Copyright © 2022-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package raid_test
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/block"
+	blockmock "github.com/suse/elemental/v3/pkg/block/mock"
+	"github.com/suse/elemental/v3/pkg/cleanstack"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/raid"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+const memberRepartJson = `[
+	{"uuid" : "mirror-uuid", "file" : "/tmp/elemental-repart.d/0-system.conf"}
+]`
+
+func TestRAIDSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "RAID test suite")
+}
+
+var _ = Describe("RAID", Label("raid"), func() {
+	var tfs vfs.FS
+	var s *sys.System
+	var cleanup func()
+	var err error
+	var runner *sysmock.Runner
+	var d *deployment.Deployment
+	var bDev *blockmock.Device
+	BeforeEach(func() {
+		runner = sysmock.NewRunner()
+		tfs, cleanup, err = sysmock.TestFS(nil)
+		Expect(err).NotTo(HaveOccurred())
+		s, err = sys.NewSystem(
+			sys.WithFS(tfs), sys.WithLogger(log.New(log.WithDiscardAll())),
+			sys.WithRunner(runner),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			switch cmd {
+			case "systemd-repart":
+				return []byte(memberRepartJson), runner.ReturnError
+			case "mdadm":
+				if args[0] == "--detail" {
+					return []byte("MD_LEVEL=raid1\nMD_DEVICES=2\nMD_UUID=a1b2c3d4:a1b2c3d4:a1b2c3d4:a1b2c3d4\n"), nil
+				}
+			}
+			return []byte{}, runner.ReturnError
+		}
+		d = deployment.DefaultDeployment()
+		d.Disks[0].Device = "/dev/sda"
+		sysPart := d.GetSystemPartition()
+		sysPart.UUID = "sys-uuid"
+		sysPart.RAID = &deployment.RAID{Level: deployment.RAID1, Devices: []string{"/dev/sdb"}}
+		bDev = blockmock.NewBlockDevice(
+			&block.Partition{UUID: "sys-uuid", Path: "/dev/sda2"},
+			&block.Partition{UUID: "mirror-uuid", Path: "/dev/sdb1"},
+		)
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+	It("sets up the RAID partitions of a disk", func() {
+		cStack := cleanstack.NewCleanStack()
+		Expect(raid.Setup(s, cStack, bDev, d.Disks[0])).To(Succeed())
+		Expect(runner.MatchMilestones([][]string{
+			{"systemd-repart", "--json=pretty", "--definitions=/tmp/elemental-repart.d", "--dry-run=no", "--empty=force", "/dev/sdb"},
+			{
+				"mdadm", "--create", "/dev/md/SYSTEM", "--run", "--metadata=1.2", "--level=1",
+				"--raid-devices=2", "/dev/sda2", "/dev/sdb1",
+			},
+			{"mdadm", "--detail", "--export", "/dev/md/SYSTEM"},
+			{"mkfs.btrfs", "-L", "SYSTEM", "-f", "/dev/md/SYSTEM"},
+		})).To(Succeed())
+
+		sysPart := d.GetSystemPartition()
+		Expect(sysPart.RAID.UUID).To(Equal("a1b2c3d4:a1b2c3d4:a1b2c3d4:a1b2c3d4"))
+		Expect(raid.DevicePath(sysPart, "/dev/sda2")).To(Equal(
+			"/dev/disk/by-id/md-uuid-a1b2c3d4:a1b2c3d4:a1b2c3d4:a1b2c3d4",
+		))
+
+		runner.ClearCmds()
+		Expect(cStack.Cleanup(nil)).To(Succeed())
+		Expect(runner.CmdsMatch([][]string{{"mdadm", "--stop", "/dev/md/SYSTEM"}})).To(Succeed())
+	})
+	It("assembles pre-existing arrays", func() {
+		d.GetSystemPartition().RAID.UUID = "md-uuid"
+		cStack := cleanstack.NewCleanStack()
+		Expect(raid.Setup(s, cStack, bDev, d.Disks[0])).To(Succeed())
+		Expect(runner.IncludesCmds([][]string{{"systemd-repart"}})).NotTo(Succeed())
+		Expect(runner.IncludesCmds([][]string{{"mdadm", "--create"}})).NotTo(Succeed())
+		Expect(runner.IncludesCmds([][]string{{"mdadm", "--assemble", "--scan", "--uuid=md-uuid"}})).To(Succeed())
+
+		runner.ClearCmds()
+		Expect(cStack.Cleanup(nil)).To(Succeed())
+		Expect(runner.CmdsMatch([][]string{{"mdadm", "--stop", "/dev/disk/by-id/md-uuid-md-uuid"}})).To(Succeed())
+	})
+	It("fails to set up RAID partitions if the array can't be created", func() {
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			switch cmd {
+			case "systemd-repart":
+				return []byte(memberRepartJson), nil
+			case "mdadm":
+				return []byte{}, runner.ReturnError
+			}
+			return []byte{}, nil
+		}
+		runner.ReturnError = fmt.Errorf("mdadm failed")
+		Expect(raid.Setup(s, cleanstack.NewCleanStack(), bDev, d.Disks[0])).To(
+			MatchError(ContainSubstring("creating array '/dev/md/SYSTEM'")),
+		)
+	})
+	It("writes the mdadm.conf file", func() {
+		d.GetSystemPartition().RAID.UUID = "md-uuid"
+		Expect(vfs.MkdirAll(tfs, "/root/etc", vfs.DirPerm)).To(Succeed())
+		Expect(raid.WriteMDAdmConf(s, "/root", d.Disks[0].Partitions)).To(Succeed())
+		data, err := tfs.ReadFile("/root/etc/mdadm.conf")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(ContainSubstring("ARRAY /dev/md/SYSTEM metadata=1.2 UUID=md-uuid\n"))
+	})
+	It("does not write a mdadm.conf file if there are no RAID partitions", func() {
+		Expect(raid.WriteMDAdmConf(s, "/root", deployment.DefaultDeployment().Disks[0].Partitions)).To(Succeed())
+		Expect(vfs.Exists(tfs, "/root/etc/mdadm.conf")).To(BeFalse())
+	})
+})
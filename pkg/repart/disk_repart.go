@@ -45,6 +45,15 @@ const (
 	// Do not change these values as this could break backward compatibility on already installed systems (e.g. reseting a system)
 	configType   = "2ecf8b13-6846-4e8a-9bc3-284ff5e2ac22"
 	recoveryType = "3265f37b-3105-4777-bd97-cfcd9cc7cf99"
+
+	// Linux RAID partition type as defined in the GPT partition types
+	raidType = "a19d880f-05fc-4d3b-a006-743f0f84911e"
+
+	// BIOS boot partition type as defined in the GPT partition types, it holds the grub core image
+	biosType = "21686148-6449-6e6f-744e-656564454649"
+
+	// systemd-repart configuration directory of deployed systems
+	confDir = "/etc/repart.d"
 )
 
 //go:embed templates/partition.conf.tpl
@@ -117,6 +126,28 @@ func CreatePartitionConfFile(s *sys.System, filename string, p Partition) error
 	return nil
 }
 
+// WriteGrowConfig writes into the given root tree a systemd-repart configuration to grow the given
+// partition up to all the available space of its disk on next boot. This is meant for the last
+// partition of disk images, so they can be written into disks bigger than the image itself.
+func WriteGrowConfig(s *sys.System, root string, part *deployment.Partition) error {
+	if part.IsEncrypted() || part.IsRAID() {
+		return fmt.Errorf("growing encrypted or RAID partitions is not supported")
+	}
+
+	dir := filepath.Join(root, confDir)
+	err := vfs.MkdirAll(s.FS(), dir, vfs.DirPerm)
+	if err != nil {
+		return fmt.Errorf("failed creating systemd-repart configuration directory '%s': %w", dir, err)
+	}
+
+	// Partitions without size boundaries are grown to fill all the available space. Format and
+	// content settings have no effect on already existing partitions.
+	grow := *part
+	grow.Size = deployment.AllAvailableSize
+	filename := filepath.Join(dir, fmt.Sprintf("50-%s.conf", part.Role.String()))
+	return CreatePartitionConfFile(s, filename, Partition{Partition: &grow})
+}
+
 // CreatePartitionConf writes a partition configuration for systemd-repart for the given partition into the given io.Writer
 func CreatePartitionConf(s *sys.System, wr io.Writer, p Partition) error {
 	pType := roleToType(s, p.Partition.Role)
@@ -124,6 +155,13 @@ func CreatePartitionConf(s *sys.System, wr io.Writer, p Partition) error {
 		return fmt.Errorf("invalid partition role: %s", p.Partition.Role.String())
 	}
 
+	// RAID partitions are array members, the array is formatted once created
+	format := fileSystemToFormat(p.Partition.FileSystem)
+	if p.Partition.IsRAID() {
+		pType = raidType
+		format = ""
+	}
+
 	for _, copy := range p.CopyFiles {
 		path := strings.Split(copy, ":")[0]
 		if path != "" && !filepath.IsAbs(path) {
@@ -140,15 +178,17 @@ func CreatePartitionConf(s *sys.System, wr io.Writer, p Partition) error {
 		CopyFiles []string
 		Excludes  []string
 		ReadOnly  string
+		Encrypt   string
 	}{
 		Type:      pType,
-		Format:    fileSystemToFormat(p.Partition.FileSystem),
+		Format:    format,
 		Size:      p.Partition.Size,
 		Label:     p.Partition.Label,
 		UUID:      p.Partition.UUID,
 		CopyFiles: p.CopyFiles,
 		Excludes:  p.Excludes,
 		ReadOnly:  readOnlyPart(p.Partition),
+		Encrypt:   encryptPart(p.Partition),
 	}
 
 	partCfg := template.New("partition")
@@ -171,12 +211,23 @@ func notifyKernel(s *sys.System, device string) {
 // repartDisk generates the systemd-repart configuration according to the given disk and runs systemd-repart with the given
 // empty flag.
 func repartDisk(s *sys.System, d *deployment.Disk, empty string) (err error) {
+	var keyFile string
+
 	parts := make([]Partition, len(d.Partitions))
 	for i, part := range d.Partitions {
 		parts[i] = Partition{Partition: part}
+		if part != nil && part.IsEncrypted() && part.Encryption.KeyFile != "" {
+			keyFile = part.Encryption.KeyFile
+		}
 	}
 
-	return runSystemdRepart(s, d.Device, parts, fmt.Sprintf("--empty=%s", empty))
+	flags := []string{fmt.Sprintf("--empty=%s", empty)}
+	if keyFile != "" {
+		// All encrypted partitions within a disk share the same key file, this is
+		// the initial key, other key sources are enrolled afterwards
+		flags = append(flags, fmt.Sprintf("--key-file=%s", keyFile))
+	}
+	return runSystemdRepart(s, d.Device, parts, flags...)
 }
 
 // runSystemdRepart runs systemd-repart for the given partitions and target device. It appends to the generated command the
@@ -262,6 +313,8 @@ func roleToType(s *sys.System, role deployment.PartRole) string {
 		return recoveryType
 	case deployment.Config:
 		return configType
+	case deployment.BIOS:
+		return biosType
 	default:
 		return deployment.Unknown
 	}
@@ -285,6 +338,13 @@ func readOnlyPart(part *deployment.Partition) string {
 	return ""
 }
 
+func encryptPart(part *deployment.Partition) string {
+	if part.IsEncrypted() {
+		return "key-file"
+	}
+	return ""
+}
+
 // setupLoopDeviceNodes creates 4 loop device nodes for systemd-repart to use at runtime. This is only necessary on arm64
 // podman containers as, on first run, the container does not have enough loop device nodes available for systemd-repart to work.
 // This is not an issue in amd64 containers because enough loop device nodes are automatically available.
@@ -128,6 +128,31 @@ var _ = Describe("Systemd-repart tests", Label("systemd-repart"), func() {
 		Expect(vfs.Exists(fs, configFile)).To(BeTrue())
 	})
 
+	It("writes a configuration to grow the given partition on next boot", func() {
+		part := &deployment.Partition{
+			Label:      "SYSTEM",
+			Role:       deployment.System,
+			FileSystem: deployment.Btrfs,
+			Size:       2048,
+			UUID:       "ddb334a8-48a2-c4de-ddb3-849eb2443e92",
+		}
+		s.Platform().Arch = "x86_64"
+		Expect(repart.WriteGrowConfig(s, "/root", part)).To(Succeed())
+
+		data, err := fs.ReadFile("/root/etc/repart.d/50-system.conf")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(ContainSubstring("Type=root-x86_64"))
+		Expect(string(data)).To(ContainSubstring("Label=SYSTEM"))
+		Expect(string(data)).To(ContainSubstring("UUID=ddb334a8-48a2-c4de-ddb3-849eb2443e92"))
+		Expect(string(data)).ToNot(ContainSubstring("SizeMaxBytes"))
+
+		// The given partition is not modified
+		Expect(part.Size).To(Equal(deployment.MiB(2048)))
+
+		part.Encryption = &deployment.Encryption{KeySource: deployment.KeyPassphrase}
+		Expect(repart.WriteGrowConfig(s, "/root", part)).NotTo(Succeed())
+	})
+
 	It("creates a disk image with the given partitions", func() {
 		diskImg := filepath.Join(tempDir, "image.raw")
 		parts := []repart.Partition{
@@ -175,6 +200,48 @@ var _ = Describe("Systemd-repart tests", Label("systemd-repart"), func() {
 		}}))
 	})
 
+	It("reparts a disk including encrypted partitions", func() {
+		var buffer bytes.Buffer
+
+		d := deployment.DefaultDeployment()
+		d.Disks[0].Device = "/dev/device"
+		d.Disks[0].Partitions[1].Encryption = &deployment.Encryption{
+			KeySource: deployment.KeyTPM2, KeyFile: "/path/to/key",
+		}
+		Expect(repart.CreatePartitionConf(s, &buffer, repart.Partition{Partition: d.Disks[0].Partitions[1]})).To(Succeed())
+		Expect(buffer.String()).To(ContainSubstring("Encrypt=key-file"))
+
+		buffer.Reset()
+		Expect(repart.CreatePartitionConf(s, &buffer, repart.Partition{Partition: d.Disks[0].Partitions[0]})).To(Succeed())
+		Expect(buffer.String()).ToNot(ContainSubstring("Encrypt"))
+
+		Expect(repart.PartitionAndFormatDevice(s, d.Disks[0])).To(Succeed())
+		Expect(runner.MatchMilestones([][]string{{
+			"systemd-repart", "--json=pretty", "--definitions=/tmp/elemental-repart.d",
+			"--dry-run=no", "--empty=force", "--key-file=/path/to/key", "/dev/device",
+		}})).To(Succeed())
+	})
+
+	It("creates unformatted Linux RAID partitions for RAID partitions", func() {
+		var buffer bytes.Buffer
+
+		d := deployment.DefaultDeployment()
+		d.Disks[0].Partitions[1].RAID = &deployment.RAID{Level: deployment.RAID1}
+		Expect(repart.CreatePartitionConf(s, &buffer, repart.Partition{Partition: d.Disks[0].Partitions[1]})).To(Succeed())
+		Expect(buffer.String()).To(ContainSubstring("Type=a19d880f-05fc-4d3b-a006-743f0f84911e"))
+		Expect(buffer.String()).ToNot(ContainSubstring("Format="))
+	})
+
+	It("creates unformatted BIOS boot partitions", func() {
+		var buffer bytes.Buffer
+
+		part := &deployment.Partition{Role: deployment.BIOS, Label: deployment.BiosLabel, Size: deployment.BiosSize}
+		Expect(repart.CreatePartitionConf(s, &buffer, repart.Partition{Partition: part})).To(Succeed())
+		Expect(buffer.String()).To(ContainSubstring("Type=21686148-6449-6e6f-744e-656564454649"))
+		Expect(buffer.String()).To(ContainSubstring("SizeMaxBytes=2M"))
+		Expect(buffer.String()).ToNot(ContainSubstring("Format="))
+	})
+
 	It("fails if systemd-repart reports partitions not matching the deployment", func() {
 		d := deployment.DefaultDeployment()
 		deployment.WithConfigPartition(0)(d)
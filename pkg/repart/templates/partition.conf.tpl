@@ -19,6 +19,9 @@ CopyFiles={{ $cpy }}
 {{- range $excl := .Excludes }}
 ExcludeFiles={{ $excl }}
 {{- end }}
+{{- if .Encrypt }}
+Encrypt={{ .Encrypt }}
+{{- end }}
 {{- if .ReadOnly }}
 ReadOnly={{ .ReadOnly }}
 {{- end }}
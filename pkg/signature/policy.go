@@ -0,0 +1,138 @@
+/*
+Copyright © 2022-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package signature
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"go.yaml.in/yaml/v3"
+
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+// PolicyFile is the default location of the signature policy
+const PolicyFile = "/etc/elemental/signature-policy.yaml"
+
+// Policy defines which image repositories require a valid signature
+type Policy struct {
+	Requirements []Requirement `yaml:"requirements"`
+}
+
+// Requirement defines the signature and attestations required for all the images within
+// the given scope. Images are only verified against the most specific matching scope.
+type Requirement struct {
+	// Scope is a registry, a namespace or a repository, e.g. registry.example.com/edge
+	Scope string `yaml:"scope"`
+	// PublicKey is the path to the PEM encoded public key the images are signed with
+	PublicKey string `yaml:"publicKey,omitempty"`
+	// Keyless defines the identity of keyless signatures
+	Keyless *Keyless `yaml:"keyless,omitempty"`
+	// Attestations is the list of predicate types of the required attestations
+	Attestations []string `yaml:"attestations,omitempty"`
+}
+
+// Keyless defines the certificate identity expected for keyless signatures
+type Keyless struct {
+	// Issuer is the OIDC issuer of the signing certificate
+	Issuer string `yaml:"issuer"`
+	// Identity is the subject, either an email or a URI, of the signing certificate
+	Identity string `yaml:"identity"`
+	// RootCertificate is the path to the PEM encoded certificate authority bundle
+	// the signing certificates are issued by
+	RootCertificate string `yaml:"rootCertificate"`
+	// RekorPublicKey is the path to the PEM encoded public key of the transparency log
+	// the signatures are recorded in
+	RekorPublicKey string `yaml:"rekorPublicKey"`
+}
+
+// LoadPolicy reads and validates the policy at the given path. Relative key and certificate
+// paths are resolved from the policy file directory.
+func LoadPolicy(fs vfs.FS, path string) (*Policy, error) {
+	data, err := fs.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading signature policy '%s': %w", path, err)
+	}
+
+	policy := &Policy{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err = decoder.Decode(policy); err != nil {
+		return nil, fmt.Errorf("unmarshalling signature policy '%s': %w", path, err)
+	}
+
+	dir := filepath.Dir(path)
+	for i := range policy.Requirements {
+		req := &policy.Requirements[i]
+		if err = req.validate(); err != nil {
+			return nil, fmt.Errorf("invalid signature policy '%s': %w", path, err)
+		}
+		req.Scope = strings.TrimSuffix(req.Scope, "/")
+		if req.PublicKey != "" && !filepath.IsAbs(req.PublicKey) {
+			req.PublicKey = filepath.Join(dir, req.PublicKey)
+		}
+		if req.Keyless != nil && !filepath.IsAbs(req.Keyless.RootCertificate) {
+			req.Keyless.RootCertificate = filepath.Join(dir, req.Keyless.RootCertificate)
+		}
+		if req.Keyless != nil && !filepath.IsAbs(req.Keyless.RekorPublicKey) {
+			req.Keyless.RekorPublicKey = filepath.Join(dir, req.Keyless.RekorPublicKey)
+		}
+	}
+	return policy, nil
+}
+
+func (r Requirement) validate() error {
+	switch {
+	case r.Scope == "":
+		return fmt.Errorf("requirement without scope")
+	case r.PublicKey == "" && r.Keyless == nil:
+		return fmt.Errorf("scope '%s' requires either a public key or a keyless identity", r.Scope)
+	case r.PublicKey != "" && r.Keyless != nil:
+		return fmt.Errorf("scope '%s' defines both a public key and a keyless identity", r.Scope)
+	case r.Keyless != nil && (r.Keyless.Issuer == "" || r.Keyless.Identity == "" || r.Keyless.RootCertificate == ""):
+		return fmt.Errorf("scope '%s' requires the issuer, identity and root certificate of keyless signatures", r.Scope)
+	case r.Keyless != nil && r.Keyless.RekorPublicKey == "":
+		return fmt.Errorf("scope '%s' requires the transparency log public key of keyless signatures", r.Scope)
+	}
+	return nil
+}
+
+// Requirement returns the most specific requirement matching the given repository or
+// nil if no signature is required for it
+func (p Policy) Requirement(repo name.Repository) *Requirement {
+	names := []string{repo.Name()}
+	if repo.RegistryStr() == name.DefaultRegistry {
+		names = append(names, "docker.io/"+repo.RepositoryStr())
+	}
+
+	var match *Requirement
+	for i, req := range p.Requirements {
+		for _, n := range names {
+			if n != req.Scope && !strings.HasPrefix(n, req.Scope+"/") {
+				continue
+			}
+			if match == nil || len(req.Scope) > len(match.Scope) {
+				match = &p.Requirements[i]
+			}
+		}
+	}
+	return match
+}
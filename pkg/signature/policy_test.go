@@ -0,0 +1,114 @@
+/*
+Copyright © 2022-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package signature_test
+
+import (
+	"github.com/google/go-containerregistry/pkg/name"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/signature"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+var _ = Describe("Policy", Label("signature"), func() {
+	var tfs vfs.FS
+	var cleanup func()
+	BeforeEach(func() {
+		var err error
+		tfs, cleanup, err = sysmock.TestFS(map[string]any{
+			"/etc/elemental/signature-policy.yaml": `requirements:
+  - scope: registry.example.com/edge/
+    publicKey: keys/edge.pub
+  - scope: registry.example.com/edge/os
+    keyless:
+      issuer: https://issuer.example.com
+      identity: release@example.com
+      rootCertificate: /etc/pki/root.pem
+      rekorPublicKey: /etc/pki/rekor.pub
+    attestations:
+      - https://slsa.dev/provenance/v1
+  - scope: docker.io/library
+    publicKey: /etc/pki/library.pub
+`,
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+	It("loads a policy file", func() {
+		policy, err := signature.LoadPolicy(tfs, signature.PolicyFile)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(policy.Requirements).To(HaveLen(3))
+		Expect(policy.Requirements[0].Scope).To(Equal("registry.example.com/edge"))
+		Expect(policy.Requirements[0].PublicKey).To(Equal("/etc/elemental/keys/edge.pub"))
+		Expect(policy.Requirements[1].Keyless.RootCertificate).To(Equal("/etc/pki/root.pem"))
+		Expect(policy.Requirements[1].Keyless.RekorPublicKey).To(Equal("/etc/pki/rekor.pub"))
+		Expect(policy.Requirements[1].Attestations).To(Equal([]string{"https://slsa.dev/provenance/v1"}))
+	})
+	It("matches the most specific requirement", func() {
+		policy, err := signature.LoadPolicy(tfs, signature.PolicyFile)
+		Expect(err).NotTo(HaveOccurred())
+
+		repo, err := name.NewRepository("registry.example.com/edge/os")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(policy.Requirement(repo).Keyless).NotTo(BeNil())
+
+		repo, err = name.NewRepository("registry.example.com/edge/os-extensions")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(policy.Requirement(repo).PublicKey).To(Equal("/etc/elemental/keys/edge.pub"))
+
+		repo, err = name.NewRepository("alpine")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(policy.Requirement(repo).PublicKey).To(Equal("/etc/pki/library.pub"))
+
+		repo, err = name.NewRepository("registry.example.com/other")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(policy.Requirement(repo)).To(BeNil())
+	})
+	It("fails on requirements with both a public key and a keyless identity", func() {
+		Expect(tfs.WriteFile("/policy.yaml", []byte(`requirements:
+  - scope: registry.example.com
+    publicKey: /key.pub
+    keyless:
+      issuer: https://issuer.example.com
+      identity: release@example.com
+      rootCertificate: /root.pem
+`), vfs.FilePerm)).To(Succeed())
+		_, err := signature.LoadPolicy(tfs, "/policy.yaml")
+		Expect(err).To(MatchError(ContainSubstring("defines both a public key and a keyless identity")))
+	})
+	It("fails on keyless requirements without the transparency log public key", func() {
+		Expect(tfs.WriteFile("/policy.yaml", []byte(`requirements:
+  - scope: registry.example.com
+    keyless:
+      issuer: https://issuer.example.com
+      identity: release@example.com
+      rootCertificate: /root.pem
+`), vfs.FilePerm)).To(Succeed())
+		_, err := signature.LoadPolicy(tfs, "/policy.yaml")
+		Expect(err).To(MatchError(ContainSubstring("requires the transparency log public key")))
+	})
+	It("fails on unknown fields", func() {
+		Expect(tfs.WriteFile("/policy.yaml", []byte("requirements:\n  - scope: registry.example.com\n    key: /key.pub\n"), vfs.FilePerm)).To(Succeed())
+		_, err := signature.LoadPolicy(tfs, "/policy.yaml")
+		Expect(err).To(MatchError(ContainSubstring("field key not found")))
+	})
+})
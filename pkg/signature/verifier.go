@@ -0,0 +1,648 @@
+/*
+Copyright © 2022-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package signature
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	containerregistry "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+
+	"github.com/suse/elemental/v3/pkg/sys"
+)
+
+const (
+	// Annotations and media types of cosign signatures and attestations
+	SignatureAnnotation   = "dev.cosignproject.cosign/signature"
+	CertificateAnnotation = "dev.sigstore.cosign/certificate"
+	ChainAnnotation       = "dev.sigstore.cosign/chain"
+	BundleAnnotation      = "dev.sigstore.cosign/bundle"
+	SimpleSigningType     = "cosign container image signature"
+	DSSEMediaType         = "application/vnd.dsse.envelope.v1+json"
+	InTotoPayloadType     = "application/vnd.in-toto+json"
+
+	signatureSuffix   = ".sig"
+	attestationSuffix = ".att"
+)
+
+var (
+	// OIDC issuer extensions of signing certificates
+	issuerV1OID = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 1}
+	issuerV2OID = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 8}
+)
+
+// Verifier verifies the cosign signatures and attestations of OCI images according to a policy
+type Verifier struct {
+	s        *sys.System
+	policy   *Policy
+	insecure bool
+	registry Registry
+	keys     map[string]crypto.PublicKey
+	roots    map[string]*x509.CertPool
+}
+
+// Registry provides the manifests of the verified images and the cosign artifacts attached to them
+type Registry interface {
+	// Manifest returns the raw manifest the given reference resolves to
+	Manifest(ctx context.Context, ref name.Reference) ([]byte, error)
+	// Image returns the image of the given reference
+	Image(ctx context.Context, ref name.Reference) (containerregistry.Image, error)
+}
+
+type Opt func(*Verifier)
+
+// WithInsecure allows plain HTTP connections to registries
+func WithInsecure(insecure bool) Opt {
+	return func(v *Verifier) {
+		v.insecure = insecure
+	}
+}
+
+// WithRegistry sets the registry images are verified from, remote registries are used by default
+func WithRegistry(r Registry) Opt {
+	return func(v *Verifier) {
+		v.registry = r
+	}
+}
+
+// NewRemoteRegistry returns a Registry fetching from remote registries with the credentials of
+// the default keychain
+func NewRemoteRegistry() Registry {
+	return remoteRegistry{}
+}
+
+type remoteRegistry struct{}
+
+func (remoteRegistry) options(ctx context.Context) []remote.Option {
+	return []remote.Option{
+		remote.WithTransport(http.DefaultTransport),
+		remote.WithAuthFromKeychain(authn.DefaultKeychain),
+		remote.WithContext(ctx),
+	}
+}
+
+func (r remoteRegistry) Manifest(ctx context.Context, ref name.Reference) ([]byte, error) {
+	desc, err := remote.Get(ref, r.options(ctx)...)
+	if err != nil {
+		return nil, err
+	}
+	return desc.Manifest, nil
+}
+
+func (r remoteRegistry) Image(ctx context.Context, ref name.Reference) (containerregistry.Image, error) {
+	return remote.Image(ref, r.options(ctx)...)
+}
+
+// NewVerifier loads the policy at the given path and all the keys and certificates it refers to
+func NewVerifier(s *sys.System, policyFile string, opts ...Opt) (*Verifier, error) {
+	policy, err := LoadPolicy(s.FS(), policyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &Verifier{
+		s:        s,
+		policy:   policy,
+		registry: NewRemoteRegistry(),
+		keys:     map[string]crypto.PublicKey{},
+		roots:    map[string]*x509.CertPool{},
+	}
+	for _, o := range opts {
+		o(v)
+	}
+
+	for _, req := range policy.Requirements {
+		switch {
+		case req.PublicKey != "":
+			if _, ok := v.keys[req.PublicKey]; ok {
+				continue
+			}
+			key, err := v.loadPublicKey(req.PublicKey)
+			if err != nil {
+				return nil, err
+			}
+			v.keys[req.PublicKey] = key
+		case req.Keyless != nil:
+			if _, ok := v.keys[req.Keyless.RekorPublicKey]; !ok {
+				key, err := v.loadPublicKey(req.Keyless.RekorPublicKey)
+				if err != nil {
+					return nil, err
+				}
+				v.keys[req.Keyless.RekorPublicKey] = key
+			}
+			if _, ok := v.roots[req.Keyless.RootCertificate]; ok {
+				continue
+			}
+			data, err := s.FS().ReadFile(req.Keyless.RootCertificate)
+			if err != nil {
+				return nil, fmt.Errorf("reading root certificate: %w", err)
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(data) {
+				return nil, fmt.Errorf("no valid certificate found in '%s'", req.Keyless.RootCertificate)
+			}
+			v.roots[req.Keyless.RootCertificate] = pool
+		}
+	}
+	return v, nil
+}
+
+// Verify checks the given image reference is signed, and attested if required, according to the
+// policy and returns the verified manifest digest. Images out of the scope of any policy requirement
+// are not verified and an empty digest is returned.
+func (v Verifier) Verify(ctx context.Context, imageRef string, local bool) (string, error) {
+	ref, err := name.ParseReference(imageRef, v.nameOpts()...)
+	if err != nil {
+		return "", fmt.Errorf("parsing image reference '%s': %w", imageRef, err)
+	}
+
+	req := v.policy.Requirement(ref.Context())
+	if req == nil {
+		v.s.Logger().Debug("No signature required for image '%s'", imageRef)
+		return "", nil
+	}
+
+	if local {
+		return "", fmt.Errorf("signature verification of local image '%s' is not supported", imageRef)
+	}
+
+	manifest, err := v.registry.Manifest(ctx, ref)
+	if err != nil {
+		return "", fmt.Errorf("resolving digest of image '%s': %w", imageRef, err)
+	}
+	digest, _, err := containerregistry.SHA256(bytes.NewReader(manifest))
+	if err != nil {
+		return "", fmt.Errorf("computing digest of image '%s': %w", imageRef, err)
+	}
+
+	v.s.Logger().Info("Verifying signature of image '%s' (%s)", imageRef, digest)
+	err = v.verifySignatures(ctx, ref, digest, req)
+	if err != nil {
+		return "", fmt.Errorf("verifying signature of image '%s': %w", imageRef, err)
+	}
+
+	for _, predicateType := range req.Attestations {
+		err = v.verifyAttestation(ctx, ref, digest, req, predicateType)
+		if err != nil {
+			return "", fmt.Errorf("verifying '%s' attestation of image '%s': %w", predicateType, imageRef, err)
+		}
+	}
+	return digest.String(), nil
+}
+
+func (v Verifier) nameOpts() []name.Option {
+	if v.insecure {
+		return []name.Option{name.Insecure}
+	}
+	return nil
+}
+
+// verifySignatures succeeds if any of the signatures attached to the given digest is valid
+func (v Verifier) verifySignatures(ctx context.Context, ref name.Reference, digest containerregistry.Hash, req *Requirement) error {
+	layers, err := v.fetchAttached(ctx, ref, digest, signatureSuffix)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, layer := range layers {
+		err = v.verifySignature(layer, digest, req)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err)
+	}
+	return fmt.Errorf("no valid signature found: %w", errors.Join(errs...))
+}
+
+func (v Verifier) verifySignature(layer attachedLayer, digest containerregistry.Hash, req *Requirement) error {
+	sig, err := base64.StdEncoding.DecodeString(layer.annotations[SignatureAnnotation])
+	if err != nil || len(sig) == 0 {
+		return fmt.Errorf("invalid or missing signature annotation")
+	}
+
+	key, err := v.signerKey(layer.annotations, req, layer.data)
+	if err != nil {
+		return err
+	}
+
+	if err = verifyBlob(key, layer.data, sig); err != nil {
+		return err
+	}
+
+	payload := struct {
+		Critical struct {
+			Image struct {
+				Digest string `json:"docker-manifest-digest"`
+			} `json:"image"`
+			Type string `json:"type"`
+		} `json:"critical"`
+	}{}
+	if err = json.Unmarshal(layer.data, &payload); err != nil {
+		return fmt.Errorf("unmarshalling signature payload: %w", err)
+	}
+	if payload.Critical.Type != SimpleSigningType {
+		return fmt.Errorf("unexpected signature payload type '%s'", payload.Critical.Type)
+	}
+	if payload.Critical.Image.Digest != digest.String() {
+		return fmt.Errorf("signature payload refers to a different image digest '%s'", payload.Critical.Image.Digest)
+	}
+	return nil
+}
+
+// verifyAttestation succeeds if any of the attestations attached to the given digest is valid
+// and of the given predicate type
+func (v Verifier) verifyAttestation(
+	ctx context.Context, ref name.Reference, digest containerregistry.Hash, req *Requirement, predicateType string,
+) error {
+	layers, err := v.fetchAttached(ctx, ref, digest, attestationSuffix)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, layer := range layers {
+		err = v.verifyEnvelope(layer, digest, req, predicateType)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err)
+	}
+	return fmt.Errorf("no valid attestation found: %w", errors.Join(errs...))
+}
+
+func (v Verifier) verifyEnvelope(layer attachedLayer, digest containerregistry.Hash, req *Requirement, predicateType string) error {
+	envelope := struct {
+		PayloadType string `json:"payloadType"`
+		Payload     string `json:"payload"`
+		Signatures  []struct {
+			Sig string `json:"sig"`
+		} `json:"signatures"`
+	}{}
+	if err := json.Unmarshal(layer.data, &envelope); err != nil {
+		return fmt.Errorf("unmarshalling attestation envelope: %w", err)
+	}
+	if envelope.PayloadType != InTotoPayloadType {
+		return fmt.Errorf("unexpected attestation payload type '%s'", envelope.PayloadType)
+	}
+
+	payload, err := base64.StdEncoding.DecodeString(envelope.Payload)
+	if err != nil {
+		return fmt.Errorf("decoding attestation payload: %w", err)
+	}
+
+	key, err := v.signerKey(layer.annotations, req, payload)
+	if err != nil {
+		return err
+	}
+
+	verified := false
+	for _, s := range envelope.Signatures {
+		sig, err := base64.StdEncoding.DecodeString(s.Sig)
+		if err != nil {
+			continue
+		}
+		if verifyBlob(key, PAE(envelope.PayloadType, payload), sig) == nil {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return fmt.Errorf("invalid attestation signature")
+	}
+
+	statement := inTotoStatement{}
+	if err = json.Unmarshal(payload, &statement); err != nil {
+		return fmt.Errorf("unmarshalling attestation statement: %w", err)
+	}
+	if statement.PredicateType != predicateType {
+		return fmt.Errorf("attestation of predicate type '%s'", statement.PredicateType)
+	}
+	if !slices.ContainsFunc(statement.Subject, func(s inTotoSubject) bool {
+		return s.Digest[digest.Algorithm] == digest.Hex
+	}) {
+		return fmt.Errorf("attestation subject does not include image digest '%s'", digest)
+	}
+	return nil
+}
+
+// signerKey returns the public key to verify the given signed content with. For keyless signatures
+// it verifies the transparency log entry of the signature and that the signing certificate was valid
+// at the time the entry was integrated, issued by the configured root and matching the configured
+// identity.
+func (v Verifier) signerKey(annotations map[string]string, req *Requirement, signed []byte) (crypto.PublicKey, error) {
+	if req.Keyless == nil {
+		return v.keys[req.PublicKey], nil
+	}
+
+	cert, err := parseCertificate([]byte(annotations[CertificateAnnotation]))
+	if err != nil {
+		return nil, fmt.Errorf("parsing signing certificate: %w", err)
+	}
+
+	integrated, err := verifyRekorBundle(annotations[BundleAnnotation], v.keys[req.Keyless.RekorPublicKey], cert, signed)
+	if err != nil {
+		return nil, fmt.Errorf("verifying transparency log entry: %w", err)
+	}
+
+	intermediates := x509.NewCertPool()
+	if chain := annotations[ChainAnnotation]; chain != "" {
+		intermediates.AppendCertsFromPEM([]byte(chain))
+	}
+
+	_, err = cert.Verify(x509.VerifyOptions{
+		Roots:         v.roots[req.Keyless.RootCertificate],
+		Intermediates: intermediates,
+		CurrentTime:   integrated,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("verifying signing certificate: %w", err)
+	}
+
+	identities := slices.Clone(cert.EmailAddresses)
+	for _, uri := range cert.URIs {
+		identities = append(identities, uri.String())
+	}
+	if !slices.Contains(identities, req.Keyless.Identity) {
+		return nil, fmt.Errorf("signing certificate identities %v do not match '%s'", identities, req.Keyless.Identity)
+	}
+
+	if issuer := certificateIssuer(cert); issuer != req.Keyless.Issuer {
+		return nil, fmt.Errorf("signing certificate issuer '%s' does not match '%s'", issuer, req.Keyless.Issuer)
+	}
+	return cert.PublicKey, nil
+}
+
+// rekorBundle is the transparency log entry attached by cosign to keyless signatures. The signed
+// entry timestamp is the signature of the transparency log over the canonical JSON encoding of the
+// payload.
+type rekorBundle struct {
+	SignedEntryTimestamp []byte       `json:"SignedEntryTimestamp"`
+	Payload              rekorPayload `json:"Payload"`
+}
+
+// rekorPayload fields are sorted by their JSON keys, so its JSON encoding is canonical
+type rekorPayload struct {
+	Body           string `json:"body"`
+	IntegratedTime int64  `json:"integratedTime"`
+	LogID          string `json:"logID"`
+	LogIndex       int64  `json:"logIndex"`
+}
+
+type rekorHash struct {
+	Algorithm string `json:"algorithm"`
+	Value     string `json:"value"`
+}
+
+// rekorEntry is the body of a transparency log entry. Only the fields binding the entry to the
+// signing certificate and to the signed content of 'hashedrekord', 'dsse' and 'intoto' entries
+// are included.
+type rekorEntry struct {
+	Kind string `json:"kind"`
+	Spec struct {
+		Data struct {
+			Hash rekorHash `json:"hash"`
+		} `json:"data"`
+		Signature struct {
+			PublicKey struct {
+				Content string `json:"content"`
+			} `json:"publicKey"`
+		} `json:"signature"`
+		PayloadHash rekorHash `json:"payloadHash"`
+		Signatures  []struct {
+			Verifier string `json:"verifier"`
+		} `json:"signatures"`
+		Content struct {
+			PayloadHash rekorHash `json:"payloadHash"`
+			Envelope    struct {
+				Signatures []struct {
+					PublicKey string `json:"publicKey"`
+				} `json:"signatures"`
+			} `json:"envelope"`
+		} `json:"content"`
+	} `json:"spec"`
+}
+
+// verifyRekorBundle verifies the given transparency log bundle is signed by the given transparency
+// log key and that its entry records the given signing certificate and signed content. Returns the
+// time the entry was integrated in the transparency log.
+func verifyRekorBundle(data string, key crypto.PublicKey, cert *x509.Certificate, signed []byte) (time.Time, error) {
+	if data == "" {
+		return time.Time{}, fmt.Errorf("missing transparency log bundle")
+	}
+
+	bundle := rekorBundle{}
+	if err := json.Unmarshal([]byte(data), &bundle); err != nil {
+		return time.Time{}, fmt.Errorf("unmarshalling transparency log bundle: %w", err)
+	}
+
+	payload, err := json.Marshal(bundle.Payload)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("marshalling transparency log bundle payload: %w", err)
+	}
+	if err = verifyBlob(key, payload, bundle.SignedEntryTimestamp); err != nil {
+		return time.Time{}, fmt.Errorf("invalid signed entry timestamp: %w", err)
+	}
+
+	body, err := base64.StdEncoding.DecodeString(bundle.Payload.Body)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("decoding transparency log entry: %w", err)
+	}
+	entry := rekorEntry{}
+	if err = json.Unmarshal(body, &entry); err != nil {
+		return time.Time{}, fmt.Errorf("unmarshalling transparency log entry: %w", err)
+	}
+
+	var hash rekorHash
+	var certs []string
+	switch entry.Kind {
+	case "hashedrekord":
+		hash = entry.Spec.Data.Hash
+		certs = append(certs, entry.Spec.Signature.PublicKey.Content)
+	case "dsse":
+		hash = entry.Spec.PayloadHash
+		for _, sig := range entry.Spec.Signatures {
+			certs = append(certs, sig.Verifier)
+		}
+	case "intoto":
+		hash = entry.Spec.Content.PayloadHash
+		for _, sig := range entry.Spec.Content.Envelope.Signatures {
+			certs = append(certs, sig.PublicKey)
+		}
+	default:
+		return time.Time{}, fmt.Errorf("unsupported transparency log entry kind '%s'", entry.Kind)
+	}
+
+	digest := sha256.Sum256(signed)
+	if hash.Algorithm != "sha256" || hash.Value != hex.EncodeToString(digest[:]) {
+		return time.Time{}, fmt.Errorf("transparency log entry refers to different content")
+	}
+
+	if !slices.ContainsFunc(certs, func(c string) bool {
+		data, err := base64.StdEncoding.DecodeString(c)
+		if err != nil {
+			return false
+		}
+		entryCert, err := parseCertificate(data)
+		return err == nil && entryCert.Equal(cert)
+	}) {
+		return time.Time{}, fmt.Errorf("transparency log entry refers to a different signing certificate")
+	}
+
+	return time.Unix(bundle.Payload.IntegratedTime, 0), nil
+}
+
+// PAE returns the DSSE pre-authentication encoding of the given payload
+func PAE(payloadType string, payload []byte) []byte {
+	return fmt.Appendf(nil, "DSSEv1 %d %s %d %s", len(payloadType), payloadType, len(payload), payload)
+}
+
+// verifyBlob verifies the signature of the given data, ECDSA and RSA signatures are
+// computed over the SHA256 digest of the data
+func verifyBlob(key crypto.PublicKey, data, sig []byte) error {
+	digest := sha256.Sum256(data)
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(k, digest[:], sig) {
+			return fmt.Errorf("invalid ECDSA signature")
+		}
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], sig); err != nil {
+			return fmt.Errorf("invalid RSA signature: %w", err)
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(k, data, sig) {
+			return fmt.Errorf("invalid ed25519 signature")
+		}
+	default:
+		return fmt.Errorf("unsupported public key type %T", key)
+	}
+	return nil
+}
+
+func (v Verifier) loadPublicKey(path string) (crypto.PublicKey, error) {
+	data, err := v.s.FS().ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading public key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found in public key '%s'", path)
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing public key '%s': %w", path, err)
+	}
+	return key, nil
+}
+
+func parseCertificate(data []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM certificate found")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// certificateIssuer returns the OIDC issuer stored in the signing certificate extensions
+func certificateIssuer(cert *x509.Certificate) string {
+	for _, ext := range cert.Extensions {
+		switch {
+		case ext.Id.Equal(issuerV2OID):
+			var issuer string
+			if _, err := asn1.Unmarshal(ext.Value, &issuer); err == nil {
+				return issuer
+			}
+		case ext.Id.Equal(issuerV1OID):
+			return string(ext.Value)
+		}
+	}
+	return ""
+}
+
+type inTotoSubject struct {
+	Digest map[string]string `json:"digest"`
+}
+
+type inTotoStatement struct {
+	PredicateType string          `json:"predicateType"`
+	Subject       []inTotoSubject `json:"subject"`
+}
+
+type attachedLayer struct {
+	data        []byte
+	annotations map[string]string
+}
+
+// fetchAttached fetches the layers of the cosign artifact attached to the given digest
+// with the given tag suffix, e.g. 'sha256-<hex>.sig'
+func (v Verifier) fetchAttached(ctx context.Context, ref name.Reference, digest containerregistry.Hash, suffix string) ([]attachedLayer, error) {
+	tag := ref.Context().Tag(fmt.Sprintf("%s-%s%s", digest.Algorithm, digest.Hex, suffix))
+	img, err := v.registry.Image(ctx, tag)
+	if err != nil {
+		return nil, fmt.Errorf("fetching '%s': %w", tag, err)
+	}
+
+	manifest, err := img.Manifest()
+	if err != nil {
+		return nil, fmt.Errorf("reading '%s' manifest: %w", tag, err)
+	}
+
+	var layers []attachedLayer
+	for _, desc := range manifest.Layers {
+		layer, err := img.LayerByDigest(desc.Digest)
+		if err != nil {
+			return nil, fmt.Errorf("fetching '%s' layer %s: %w", tag, desc.Digest, err)
+		}
+		rc, err := layer.Compressed()
+		if err != nil {
+			return nil, fmt.Errorf("fetching '%s' layer %s: %w", tag, desc.Digest, err)
+		}
+		data, err := io.ReadAll(rc)
+		_ = rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("reading '%s' layer %s: %w", tag, desc.Digest, err)
+		}
+		layers = append(layers, attachedLayer{data: data, annotations: desc.Annotations})
+	}
+	if len(layers) == 0 {
+		return nil, fmt.Errorf("'%s' has no layers", tag)
+	}
+	return layers, nil
+}
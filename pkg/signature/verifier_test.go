@@ -0,0 +1,368 @@
+/*
+Copyright © 2022-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package signature_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	stdlog "log"
+	"math/big"
+	"net/http/httptest"
+	"net/url"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	containerregistry "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/signature"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+)
+
+const provenance = "https://slsa.dev/provenance/v1"
+
+func publicKeyPEM(key *ecdsa.PrivateKey) []byte {
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	Expect(err).NotTo(HaveOccurred())
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+func sign(key *ecdsa.PrivateKey, data []byte) string {
+	digest := sha256.Sum256(data)
+	sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	Expect(err).NotTo(HaveOccurred())
+	return base64.StdEncoding.EncodeToString(sig)
+}
+
+// attach pushes a cosign like artifact with a single layer to the given digest tag
+func attach(ref name.Reference, digest containerregistry.Hash, suffix string, data []byte, annotations map[string]string) {
+	img, err := mutate.Append(empty.Image, mutate.Addendum{
+		Layer:       static.NewLayer(data, "application/octet-stream"),
+		Annotations: annotations,
+	})
+	Expect(err).NotTo(HaveOccurred())
+	tag := ref.Context().Tag(fmt.Sprintf("%s-%s%s", digest.Algorithm, digest.Hex, suffix))
+	Expect(remote.Write(tag, img)).To(Succeed())
+}
+
+func signingPayload(ref name.Reference, digest containerregistry.Hash) []byte {
+	return fmt.Appendf(nil,
+		`{"critical":{"identity":{"docker-reference":"%s"},"image":{"docker-manifest-digest":"%s"},"type":"%s"},"optional":null}`,
+		ref.Context().Name(), digest, signature.SimpleSigningType,
+	)
+}
+
+// rekorBundle returns a transparency log bundle of a 'hashedrekord' entry for the given signed
+// content and signing certificate, integrated at the given time
+func rekorBundle(key *ecdsa.PrivateKey, cert string, signed []byte, integrated time.Time) string {
+	digest := sha256.Sum256(signed)
+	body, err := json.Marshal(map[string]any{
+		"apiVersion": "0.0.1",
+		"kind":       "hashedrekord",
+		"spec": map[string]any{
+			"data": map[string]any{
+				"hash": map[string]string{"algorithm": "sha256", "value": hex.EncodeToString(digest[:])},
+			},
+			"signature": map[string]any{
+				"content":   "",
+				"publicKey": map[string]string{"content": base64.StdEncoding.EncodeToString([]byte(cert))},
+			},
+		},
+	})
+	Expect(err).NotTo(HaveOccurred())
+
+	payload := fmt.Appendf(nil, `{"body":"%s","integratedTime":%d,"logID":"c0d23d6a","logIndex":42}`,
+		base64.StdEncoding.EncodeToString(body), integrated.Unix(),
+	)
+	return fmt.Sprintf(`{"SignedEntryTimestamp":"%s","Payload":%s}`, sign(key, payload), payload)
+}
+
+func signImage(ref name.Reference, digest containerregistry.Hash, key *ecdsa.PrivateKey, annotations map[string]string) {
+	payload := signingPayload(ref, digest)
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[signature.SignatureAnnotation] = sign(key, payload)
+	attach(ref, digest, ".sig", payload, annotations)
+}
+
+func attestImage(ref name.Reference, digest containerregistry.Hash, key *ecdsa.PrivateKey, predicateType string) {
+	statement := fmt.Appendf(nil,
+		`{"_type":"https://in-toto.io/Statement/v1","predicateType":"%s","subject":[{"name":"%s","digest":{"sha256":"%s"}}],"predicate":{}}`,
+		predicateType, ref.Context().Name(), digest.Hex,
+	)
+	envelope, err := json.Marshal(map[string]any{
+		"payloadType": signature.InTotoPayloadType,
+		"payload":     base64.StdEncoding.EncodeToString(statement),
+		"signatures":  []map[string]string{{"sig": sign(key, signature.PAE(signature.InTotoPayloadType, statement))}},
+	})
+	Expect(err).NotTo(HaveOccurred())
+	attach(ref, digest, ".att", envelope, map[string]string{"predicateType": predicateType})
+}
+
+var _ = Describe("Verifier", Label("signature"), func() {
+	var tfs vfs.FS
+	var s *sys.System
+	var cleanup func()
+	var server *httptest.Server
+	var key *ecdsa.PrivateKey
+	var ref name.Reference
+	var digest containerregistry.Hash
+	var host string
+	BeforeEach(func() {
+		var err error
+		server = httptest.NewServer(registry.New(registry.Logger(stdlog.New(io.Discard, "", 0))))
+		u, err := url.Parse(server.URL)
+		Expect(err).NotTo(HaveOccurred())
+		host = u.Host
+
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+
+		tfs, cleanup, err = sysmock.TestFS(map[string]any{
+			"/etc/elemental/keys/edge.pub": string(publicKeyPEM(key)),
+			"/etc/elemental/signature-policy.yaml": fmt.Sprintf(`requirements:
+  - scope: %s/edge
+    publicKey: keys/edge.pub
+  - scope: %s/edge/attested
+    publicKey: keys/edge.pub
+    attestations:
+      - %s
+`, host, host, provenance),
+		})
+		Expect(err).NotTo(HaveOccurred())
+		s, err = sys.NewSystem(sys.WithFS(tfs), sys.WithLogger(log.New(log.WithDiscardAll())))
+		Expect(err).NotTo(HaveOccurred())
+
+		ref, err = name.ParseReference(host + "/edge/os:1.0")
+		Expect(err).NotTo(HaveOccurred())
+		img, err := random.Image(256, 1)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(remote.Write(ref, img)).To(Succeed())
+		digest, err = img.Digest()
+		Expect(err).NotTo(HaveOccurred())
+	})
+	AfterEach(func() {
+		server.Close()
+		cleanup()
+	})
+	It("verifies a signed image", func() {
+		signImage(ref, digest, key, nil)
+		v, err := signature.NewVerifier(s, signature.PolicyFile)
+		Expect(err).NotTo(HaveOccurred())
+		verified, err := v.Verify(context.Background(), ref.String(), false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(verified).To(Equal(digest.String()))
+	})
+	It("skips images out of the policy scope", func() {
+		other, err := name.ParseReference(host + "/other/os:1.0")
+		Expect(err).NotTo(HaveOccurred())
+		v, err := signature.NewVerifier(s, signature.PolicyFile)
+		Expect(err).NotTo(HaveOccurred())
+		verified, err := v.Verify(context.Background(), other.String(), true)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(verified).To(BeEmpty())
+	})
+	It("fails on unsigned images", func() {
+		v, err := signature.NewVerifier(s, signature.PolicyFile)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = v.Verify(context.Background(), ref.String(), false)
+		Expect(err).To(MatchError(ContainSubstring("verifying signature of image")))
+	})
+	It("fails on images signed with a different key", func() {
+		otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+		signImage(ref, digest, otherKey, nil)
+		v, err := signature.NewVerifier(s, signature.PolicyFile)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = v.Verify(context.Background(), ref.String(), false)
+		Expect(err).To(MatchError(ContainSubstring("invalid ECDSA signature")))
+	})
+	It("fails on local images requiring a signature", func() {
+		v, err := signature.NewVerifier(s, signature.PolicyFile)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = v.Verify(context.Background(), ref.String(), true)
+		Expect(err).To(MatchError(ContainSubstring("not supported")))
+	})
+	It("verifies the required attestations", func() {
+		attested, err := name.ParseReference(host + "/edge/attested:1.0")
+		Expect(err).NotTo(HaveOccurred())
+		img, err := random.Image(256, 1)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(remote.Write(attested, img)).To(Succeed())
+		d, err := img.Digest()
+		Expect(err).NotTo(HaveOccurred())
+		signImage(attested, d, key, nil)
+
+		v, err := signature.NewVerifier(s, signature.PolicyFile)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = v.Verify(context.Background(), attested.String(), false)
+		Expect(err).To(MatchError(ContainSubstring("attestation")))
+
+		attestImage(attested, d, key, provenance)
+		_, err = v.Verify(context.Background(), attested.String(), false)
+		Expect(err).NotTo(HaveOccurred())
+	})
+	Describe("keyless signatures", func() {
+		var caPEM []byte
+		var caCert *x509.Certificate
+		var caKey *ecdsa.PrivateKey
+		var rekorKey *ecdsa.PrivateKey
+		BeforeEach(func() {
+			var err error
+			caKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			Expect(err).NotTo(HaveOccurred())
+			rekorKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			Expect(err).NotTo(HaveOccurred())
+			tmpl := &x509.Certificate{
+				SerialNumber:          big.NewInt(1),
+				Subject:               pkix.Name{CommonName: "test root"},
+				NotBefore:             time.Now().Add(-time.Hour),
+				NotAfter:              time.Now().Add(time.Hour),
+				IsCA:                  true,
+				BasicConstraintsValid: true,
+				KeyUsage:              x509.KeyUsageCertSign,
+			}
+			der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &caKey.PublicKey, caKey)
+			Expect(err).NotTo(HaveOccurred())
+			caCert, err = x509.ParseCertificate(der)
+			Expect(err).NotTo(HaveOccurred())
+			caPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+
+			Expect(tfs.WriteFile("/etc/elemental/root.pem", caPEM, vfs.FilePerm)).To(Succeed())
+			Expect(tfs.WriteFile("/etc/elemental/rekor.pub", publicKeyPEM(rekorKey), vfs.FilePerm)).To(Succeed())
+			Expect(tfs.WriteFile(signature.PolicyFile, fmt.Appendf(nil, `requirements:
+  - scope: %s/edge
+    keyless:
+      issuer: https://issuer.example.com
+      identity: https://ci.example.com/release
+      rootCertificate: root.pem
+      rekorPublicKey: rekor.pub
+`, host), vfs.FilePerm)).To(Succeed())
+		})
+		signingCert := func(identity string) string {
+			issuer, err := asn1.Marshal("https://issuer.example.com")
+			Expect(err).NotTo(HaveOccurred())
+			uri, err := url.Parse(identity)
+			Expect(err).NotTo(HaveOccurred())
+			tmpl := &x509.Certificate{
+				SerialNumber: big.NewInt(2),
+				NotBefore:    time.Now().Add(-time.Minute),
+				NotAfter:     time.Now().Add(10 * time.Minute),
+				KeyUsage:     x509.KeyUsageDigitalSignature,
+				ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+				URIs:         []*url.URL{uri},
+				ExtraExtensions: []pkix.Extension{
+					{Id: asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 8}, Value: issuer},
+				},
+			}
+			der, err := x509.CreateCertificate(rand.Reader, tmpl, caCert, &key.PublicKey, caKey)
+			Expect(err).NotTo(HaveOccurred())
+			return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+		}
+		It("verifies the signing certificate identity", func() {
+			cert := signingCert("https://ci.example.com/release")
+			signImage(ref, digest, key, map[string]string{
+				signature.CertificateAnnotation: cert,
+				signature.BundleAnnotation:      rekorBundle(rekorKey, cert, signingPayload(ref, digest), time.Now()),
+			})
+			v, err := signature.NewVerifier(s, signature.PolicyFile)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = v.Verify(context.Background(), ref.String(), false)
+			Expect(err).NotTo(HaveOccurred())
+		})
+		It("fails on signatures not recorded in the transparency log", func() {
+			signImage(ref, digest, key, map[string]string{
+				signature.CertificateAnnotation: signingCert("https://ci.example.com/release"),
+			})
+			v, err := signature.NewVerifier(s, signature.PolicyFile)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = v.Verify(context.Background(), ref.String(), false)
+			Expect(err).To(MatchError(ContainSubstring("missing transparency log bundle")))
+		})
+		It("fails on transparency log bundles signed with a different key", func() {
+			otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			Expect(err).NotTo(HaveOccurred())
+			cert := signingCert("https://ci.example.com/release")
+			signImage(ref, digest, key, map[string]string{
+				signature.CertificateAnnotation: cert,
+				signature.BundleAnnotation:      rekorBundle(otherKey, cert, signingPayload(ref, digest), time.Now()),
+			})
+			v, err := signature.NewVerifier(s, signature.PolicyFile)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = v.Verify(context.Background(), ref.String(), false)
+			Expect(err).To(MatchError(ContainSubstring("invalid signed entry timestamp")))
+		})
+		It("fails on transparency log entries of a different content", func() {
+			cert := signingCert("https://ci.example.com/release")
+			signImage(ref, digest, key, map[string]string{
+				signature.CertificateAnnotation: cert,
+				signature.BundleAnnotation:      rekorBundle(rekorKey, cert, []byte("other"), time.Now()),
+			})
+			v, err := signature.NewVerifier(s, signature.PolicyFile)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = v.Verify(context.Background(), ref.String(), false)
+			Expect(err).To(MatchError(ContainSubstring("refers to different content")))
+		})
+		It("fails on signatures recorded once the signing certificate expired", func() {
+			cert := signingCert("https://ci.example.com/release")
+			signImage(ref, digest, key, map[string]string{
+				signature.CertificateAnnotation: cert,
+				signature.BundleAnnotation:      rekorBundle(rekorKey, cert, signingPayload(ref, digest), time.Now().Add(time.Hour)),
+			})
+			v, err := signature.NewVerifier(s, signature.PolicyFile)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = v.Verify(context.Background(), ref.String(), false)
+			Expect(err).To(MatchError(ContainSubstring("verifying signing certificate")))
+		})
+		It("fails on a different identity", func() {
+			cert := signingCert("https://ci.example.com/nightly")
+			signImage(ref, digest, key, map[string]string{
+				signature.CertificateAnnotation: cert,
+				signature.BundleAnnotation:      rekorBundle(rekorKey, cert, signingPayload(ref, digest), time.Now()),
+			})
+			v, err := signature.NewVerifier(s, signature.PolicyFile)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = v.Verify(context.Background(), ref.String(), false)
+			Expect(err).To(MatchError(ContainSubstring("do not match 'https://ci.example.com/release'")))
+		})
+	})
+})
@@ -45,10 +45,12 @@ type Snapper struct {
 }
 
 type Snapshot struct {
-	Number   int      `json:"number"`
-	Default  bool     `json:"default"`
-	Active   bool     `json:"active"`
-	UserData Metadata `json:"userdata,omitempty"`
+	Number      int      `json:"number"`
+	Default     bool     `json:"default"`
+	Active      bool     `json:"active"`
+	Date        string   `json:"date,omitempty"`
+	Description string   `json:"description,omitempty"`
+	UserData    Metadata `json:"userdata,omitempty"`
 }
 
 type Metadata map[string]string
@@ -125,7 +127,7 @@ func (sn Snapper) ListSnapshots(root string, config string) (Snapshots, error) {
 	if config == "" {
 		config = root
 	}
-	args = append(args, "-c", config, "--jsonout", "list", "--columns", "number,default,active,userdata")
+	args = append(args, "-c", config, "--jsonout", "list", "--columns", "number,default,active,userdata,date,description")
 	cmdOut, err := sn.s.Runner().Run("snapper", args...)
 	if err != nil {
 		return nil, fmt.Errorf("collecting snapshots: %s: %w", string(cmdOut), err)
@@ -31,6 +31,11 @@ type Transactioner struct {
 	Trans             *transaction.Transaction
 	UpgradeHelper     UpgradeHelper
 	SrcDigest         string
+	Snapshots         []*transaction.Snapshot
+	SetDefaultErr     error
+	DeleteErr         error
+	DefaultID         int
+	DeletedIDs        []int
 	rollbackCalled    bool
 	activeSnapshotIDs []int
 }
@@ -94,3 +99,23 @@ func (t Transactioner) RollbackCalled() bool {
 func (t Transactioner) GetActiveSnapshotIDs() ([]int, error) {
 	return t.activeSnapshotIDs, nil
 }
+
+func (t Transactioner) ListSnapshots() ([]*transaction.Snapshot, error) {
+	return t.Snapshots, nil
+}
+
+func (t *Transactioner) SetDefaultSnapshot(id int) error {
+	if t.SetDefaultErr != nil {
+		return t.SetDefaultErr
+	}
+	t.DefaultID = id
+	return nil
+}
+
+func (t *Transactioner) DeleteSnapshot(id int) error {
+	if t.DeleteErr != nil {
+		return t.DeleteErr
+	}
+	t.DeletedIDs = append(t.DeletedIDs, id)
+	return nil
+}
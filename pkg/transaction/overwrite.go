@@ -26,6 +26,8 @@ import (
 	"github.com/suse/elemental/v3/pkg/cleanstack"
 	"github.com/suse/elemental/v3/pkg/deployment"
 	"github.com/suse/elemental/v3/pkg/fstab"
+	"github.com/suse/elemental/v3/pkg/luks"
+	"github.com/suse/elemental/v3/pkg/raid"
 	"github.com/suse/elemental/v3/pkg/sys"
 	"github.com/suse/elemental/v3/pkg/sys/vfs"
 	"github.com/suse/elemental/v3/pkg/unpack"
@@ -113,7 +115,7 @@ func (n Overwrite) MountPartition(root string, hwParts block.PartitionList, p *d
 		return fmt.Errorf("failed creating mountpoint %s: %w", target, err)
 	}
 
-	err := n.s.Mounter().Mount(dev.Path, target, p.FileSystem.String(), p.MountOpts)
+	err := n.s.Mounter().Mount(raid.DevicePath(p, luks.DevicePath(p, dev.Path)), target, p.FileSystem.String(), p.MountOpts)
 	if err != nil {
 		return fmt.Errorf("failed mounting partition '%s': %w", p.Label, err)
 	}
@@ -134,6 +136,18 @@ func (n Overwrite) GetActiveSnapshotIDs() ([]int, error) {
 	return []int{0}, nil
 }
 
+func (n Overwrite) ListSnapshots() ([]*Snapshot, error) {
+	return []*Snapshot{{ID: 0, Default: true, Active: true}}, nil
+}
+
+func (n Overwrite) SetDefaultSnapshot(int) error {
+	return fmt.Errorf("cannot set default snapshots using 'overwrite' snapshotter")
+}
+
+func (n Overwrite) DeleteSnapshot(int) error {
+	return fmt.Errorf("cannot delete snapshots using 'overwrite' snapshotter")
+}
+
 func (n Overwrite) SyncImageContent(imgSrc *deployment.ImageSource, trans *Transaction, opts ...unpack.Opt) (err error) {
 	if trans.status != started {
 		return fmt.Errorf("given transaction '%d' is not started", trans.ID)
@@ -167,13 +181,21 @@ func (n Overwrite) UpdateFstab(trans *Transaction) error {
 
 	for _, part := range sysDisk.Partitions {
 		lines = append(lines, fstab.Line{
-			Device:     fmt.Sprintf("PARTUUID=%s", part.UUID),
+			Device:     fstabDevice(part),
 			MountPoint: part.MountPoint,
 			Options:    part.MountOpts,
 			FileSystem: part.FileSystem.String(),
 		})
 
 	}
+	err := luks.WriteCrypttab(n.s, trans.Path, sysDisk.Partitions)
+	if err != nil {
+		return fmt.Errorf("writing crypttab: %w", err)
+	}
+	err = raid.WriteMDAdmConf(n.s, trans.Path, sysDisk.Partitions)
+	if err != nil {
+		return fmt.Errorf("writing mdadm.conf: %w", err)
+	}
 	fstabFile := filepath.Join(trans.Path, fstab.File)
 	return fstab.Write(n.s, fstabFile, lines)
 }
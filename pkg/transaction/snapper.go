@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strconv"
 
 	"github.com/suse/elemental/v3/pkg/block"
@@ -30,6 +31,8 @@ import (
 	"github.com/suse/elemental/v3/pkg/btrfs"
 	"github.com/suse/elemental/v3/pkg/cleanstack"
 	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/luks"
+	"github.com/suse/elemental/v3/pkg/raid"
 	"github.com/suse/elemental/v3/pkg/snapper"
 	"github.com/suse/elemental/v3/pkg/sys"
 	"github.com/suse/elemental/v3/pkg/sys/vfs"
@@ -208,11 +211,16 @@ func (sn *snapperT) probe(d deployment.Deployment) (err error) {
 		return fmt.Errorf("system partition not found: %+v", sysPart)
 	}
 
-	mountPoints, err := sn.s.Mounter().GetMountPoints(part.Path)
+	device := raid.DevicePath(sysPart, luks.DevicePath(sysPart, part.Path))
+	if sysPart.IsRAID() {
+		// Mounted arrays are listed by their kernel device name, not by the udev symlink
+		device, _ = vfs.ResolveLink(sn.s.FS(), device, "/", 4)
+	}
+	mountPoints, err := sn.s.Mounter().GetMountPoints(device)
 	if err != nil {
 		return fmt.Errorf("getting mount points: %w", err)
 	} else if len(mountPoints) == 0 {
-		return fmt.Errorf("no mountpoints found for device '%s'", part.Path)
+		return fmt.Errorf("no mountpoints found for device '%s'", device)
 	}
 
 	r := regexp.MustCompile(fmt.Sprintf(`%s/.snapshots/\d+/snapshot$`, btrfs.TopSubVol))
@@ -255,6 +263,68 @@ func (sn snapperT) GetActiveSnapshotIDs() ([]int, error) {
 	return snapIDs, nil
 }
 
+// ListSnapshots returns all the root snapshots of the current system
+func (sn snapperT) ListSnapshots() ([]*Snapshot, error) {
+	snaps, err := sn.snap.ListSnapshots(sn.rootDir, "root")
+	if err != nil {
+		return nil, fmt.Errorf("listing snapshots: %w", err)
+	}
+	snapshots := make([]*Snapshot, len(snaps))
+	for i, snap := range snaps {
+		snapshots[i] = &Snapshot{
+			ID:          snap.Number,
+			Default:     snap.Default,
+			Active:      snap.Active,
+			Date:        snap.Date,
+			Description: snap.Description,
+			Metadata:    snap.UserData,
+		}
+	}
+	return snapshots, nil
+}
+
+// SetDefaultSnapshot sets the given snapshot as the default one for the following boots
+func (sn snapperT) SetDefaultSnapshot(id int) error {
+	snaps, err := sn.snap.ListSnapshots(sn.rootDir, "root")
+	if err != nil {
+		return fmt.Errorf("listing snapshots: %w", err)
+	}
+	if !slices.ContainsFunc(snaps, func(snap *snapper.Snapshot) bool { return snap.Number == id }) {
+		return fmt.Errorf("snapshot '%d' not found", id)
+	}
+	sn.s.Logger().Info("Setting snapshot %d as default", id)
+	err = sn.snap.SetDefault(sn.rootDir, id, nil)
+	if err != nil {
+		return fmt.Errorf("setting default snapshot: %w", err)
+	}
+	return nil
+}
+
+// DeleteSnapshot removes the given snapshot. Neither the active nor the default
+// snapshots can be deleted.
+func (sn snapperT) DeleteSnapshot(id int) error {
+	snaps, err := sn.snap.ListSnapshots(sn.rootDir, "root")
+	if err != nil {
+		return fmt.Errorf("listing snapshots: %w", err)
+	}
+	idx := slices.IndexFunc(snaps, func(snap *snapper.Snapshot) bool { return snap.Number == id })
+	switch {
+	case idx < 0:
+		return fmt.Errorf("snapshot '%d' not found", id)
+	case snaps[idx].Active:
+		return fmt.Errorf("cannot delete the active snapshot '%d'", id)
+	case snaps[idx].Default:
+		return fmt.Errorf("cannot delete the default snapshot '%d'", id)
+	}
+	sn.s.Logger().Info("Deleting snapshot %d", id)
+	path := filepath.Join(sn.rootDir, fmt.Sprintf(snapshotPathTmpl, id))
+	err = sn.snap.DeleteByPath(path)
+	if err != nil {
+		return fmt.Errorf("deleting snapshot '%d': %w", id, err)
+	}
+	return nil
+}
+
 // mountPartition mounts the given partition to the given mount point. In addition it also
 // sets the umount cleanup task.
 func (sn snapperT) mountPartition(part *deployment.Partition, mountPoint string) error {
@@ -266,7 +336,7 @@ func (sn snapperT) mountPartition(part *deployment.Partition, mountPoint string)
 	if bPart == nil {
 		return fmt.Errorf("partition '%s' not found", part.UUID)
 	}
-	err = sn.s.Mounter().Mount(bPart.Path, mountPoint, "", []string{"rw"})
+	err = sn.s.Mounter().Mount(raid.DevicePath(part, luks.DevicePath(part, bPart.Path)), mountPoint, "", []string{"rw"})
 	if err != nil {
 		return fmt.Errorf("mounting partition at '%s': %w", mountPoint, err)
 	}
@@ -303,7 +373,7 @@ func (sn snapperT) mountVol(part *deployment.Partition, volumePath, mountPoint s
 		return fmt.Errorf("creating mountpoint at '%s': %w", mountPoint, err)
 	}
 	err = sn.s.Mounter().Mount(
-		bPart.Path, mountPoint, "",
+		raid.DevicePath(part, luks.DevicePath(part, bPart.Path)), mountPoint, "",
 		[]string{"rw", fmt.Sprintf("subvol=%s", filepath.Join(btrfs.TopSubVol, volumePath))},
 	)
 	if err != nil {
@@ -436,7 +506,7 @@ func (sn snapperT) preparePartitions(baseID int, trans *Transaction) error {
 // assumes it will be creating the first snapshot.
 func (sn snapperT) createNewSnapshot(baseID int) (*Transaction, error) {
 	var newID int
-	var path string
+	var path, basePath string
 	var err error
 
 	if baseID == 0 {
@@ -452,11 +522,13 @@ func (sn snapperT) createNewSnapshot(baseID int) (*Transaction, error) {
 			return nil, err
 		}
 		path = filepath.Join(sn.rootDir, fmt.Sprintf(snapshotPathTmpl, newID))
+		basePath = filepath.Join(sn.rootDir, fmt.Sprintf(snapshotPathTmpl, baseID))
 	}
 
 	return &Transaction{
 		ID:     newID,
 		Path:   path,
+		Base:   basePath,
 		Merges: map[string]*Merge{},
 		status: started,
 	}, nil
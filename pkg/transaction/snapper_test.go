@@ -178,6 +178,35 @@ var _ = Describe("SnapperTransaction", Label("transaction"), func() {
 				})).To(Succeed())
 			})
 		})
+		It("lists current snapshots", func() {
+			snaps, err := sn.ListSnapshots()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(len(snaps)).To(Equal(4))
+			Expect(snaps[3].ID).To(Equal(4))
+			Expect(snaps[3].Default).To(BeTrue())
+			Expect(snaps[3].Active).To(BeTrue())
+		})
+		It("sets a new default snapshot", func() {
+			runner.ClearCmds()
+			Expect(sn.SetDefaultSnapshot(2)).To(Succeed())
+			Expect(runner.MatchMilestones([][]string{
+				{"snapper", "--no-dbus", "modify", "--default", "2"},
+			})).To(Succeed())
+		})
+		It("fails to set an unknown snapshot as default", func() {
+			Expect(sn.SetDefaultSnapshot(7)).To(MatchError("snapshot '7' not found"))
+		})
+		It("deletes a snapshot", func() {
+			runner.ClearCmds()
+			Expect(sn.DeleteSnapshot(2)).To(Succeed())
+			Expect(runner.MatchMilestones([][]string{
+				{"btrfs", "property", "set", "-ts", "/.snapshots/2/snapshot", "ro", "false"},
+				{"btrfs", "subvolume", "delete", "-c", "-R", "/.snapshots/2/snapshot"},
+			})).To(Succeed())
+		})
+		It("fails to delete the active snapshot", func() {
+			Expect(sn.DeleteSnapshot(4)).To(MatchError("cannot delete the active snapshot '4'"))
+		})
 		It("it fails to start a transaction if it does not find previous snapshotted volumes", func() {
 			sideEffects["snapper"] = func(args ...string) ([]byte, error) {
 				if slices.Contains(args, "create") {
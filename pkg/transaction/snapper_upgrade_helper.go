@@ -19,6 +19,8 @@ package transaction
 
 import (
 	"bufio"
+	"bytes"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -26,16 +28,23 @@ import (
 	"strconv"
 	"strings"
 
+	"go.yaml.in/yaml/v3"
+
 	"github.com/suse/elemental/v3/pkg/btrfs"
 	"github.com/suse/elemental/v3/pkg/chroot"
 	"github.com/suse/elemental/v3/pkg/deployment"
 	"github.com/suse/elemental/v3/pkg/fstab"
+	"github.com/suse/elemental/v3/pkg/luks"
+	"github.com/suse/elemental/v3/pkg/raid"
 	"github.com/suse/elemental/v3/pkg/rsync"
 	"github.com/suse/elemental/v3/pkg/snapper"
 	"github.com/suse/elemental/v3/pkg/sys/vfs"
 	"github.com/suse/elemental/v3/pkg/unpack"
 )
 
+// sidecarSuffix is appended to the new image version of conflicting files kept along the local version
+const sidecarSuffix = ".rpmnew"
+
 // SyncImageContent syncs the given image tree to given transaction. For the first transaction all content
 // is synced regardless if some paths are under a persistent path or not. On upgrades it only syncs the immutable
 // content and snapshotted paths.
@@ -90,6 +99,16 @@ func (sc snapperContext) UpdateFstab(trans *Transaction) (err error) {
 		return fmt.Errorf("transaction '%d' is not started", trans.ID)
 	}
 
+	err = luks.WriteCrypttab(sc.s, trans.Path, sc.partitions)
+	if err != nil {
+		return fmt.Errorf("writing crypttab: %w", err)
+	}
+
+	err = raid.WriteMDAdmConf(sc.s, trans.Path, sc.partitions)
+	if err != nil {
+		return fmt.Errorf("writing mdadm.conf: %w", err)
+	}
+
 	sc.s.Logger().Info("Updating fstab")
 	if ok, _ := vfs.Exists(sc.s.FS(), filepath.Join(trans.Path, fstab.File)); ok {
 		return sc.updateFstab(trans)
@@ -211,9 +230,9 @@ func (sc snapperContext) configureRWVolumes(trans *Transaction) error {
 	return chroot.ChrootedCallback(sc.s, trans.Path, nil, callback, chroot.WithoutDefaultBinds())
 }
 
-// merge runs a 3 way merge for snapshotted RW volumes.
-// Current implementation solves potential conflicts by always keeping
-// custom changes over changes coming from the OS image.
+// merge runs a 3 way merge for snapshotted RW volumes. Conflicts, files changed both locally
+// and in the new OS image, are solved according to the merge policy of each volume and
+// reported in the new snapshot.
 func (sc snapperContext) merge(trans *Transaction) (err error) {
 	var status, tmpDir string
 
@@ -240,11 +259,32 @@ func (sc snapperContext) merge(trans *Transaction) (err error) {
 			return err
 		}
 
-		err = sc.applyCustomChanges(status, rwVol.Path, m)
+		err = sc.applyCustomChanges(status, rwVol, m, trans)
 		if err != nil {
 			return err
 		}
 	}
+	if len(trans.Conflicts) == 0 {
+		return nil
+	}
+	return sc.writeConflictsReport(trans)
+}
+
+// writeConflictsReport writes the conflicts found during the merge into the transaction
+func (sc snapperContext) writeConflictsReport(trans *Transaction) error {
+	data, err := yaml.Marshal(trans.Conflicts)
+	if err != nil {
+		return fmt.Errorf("marshalling merge conflicts: %w", err)
+	}
+	report := filepath.Join(trans.Path, ConflictsFile)
+	err = vfs.MkdirAll(sc.s.FS(), filepath.Dir(report), vfs.DirPerm)
+	if err != nil {
+		return fmt.Errorf("creating merge conflicts report directory: %w", err)
+	}
+	err = sc.s.FS().WriteFile(report, append([]byte("# self-generated content, do not edit\n\n"), data...), vfs.FilePerm)
+	if err != nil {
+		return fmt.Errorf("writing merge conflicts report: %w", err)
+	}
 	return nil
 }
 
@@ -275,8 +315,10 @@ func (sc snapperContext) customChangesStatus(volPath string, merge *Merge, outpu
 }
 
 // applyCustomChanges reads the given status file and applies reported changes in to the target destination.
-// This method is the responsible of applying customizations to the new volume
-func (sc snapperContext) applyCustomChanges(status, rwVolPath string, merge *Merge) (err error) {
+// This method is the responsible of applying customizations to the new volume. Changes to files also changed
+// in the new OS image are applied according to the volume merge policy.
+func (sc snapperContext) applyCustomChanges(status string, rwVol deployment.RWVolume, merge *Merge, trans *Transaction) (err error) {
+	rwVolPath := rwVol.Path
 	sc.s.Logger().Debug("rw volume path: %s", rwVolPath)
 	statusF, err := sc.s.FS().OpenFile(status, os.O_RDONLY, vfs.FilePerm)
 	if err != nil {
@@ -297,27 +339,41 @@ func (sc snapperContext) applyCustomChanges(status, rwVolPath string, merge *Mer
 
 	r := regexp.MustCompile(`(([-+ct.])[p.][u.][g.][x.][a.])\s+(.*)`)
 
+	policy := rwVol.GetMergePolicy()
+	var conflicts []string
+
 	scanner := bufio.NewScanner(statusF)
 	for scanner.Scan() {
 		line := scanner.Text()
 		match := r.FindStringSubmatch(line)
 
-		switch {
-		case len(match) == 0:
-			continue
-		case strings.HasPrefix(match[1], "...."):
+		if len(match) == 0 || strings.HasPrefix(match[1], "....") {
 			// Ignore extended attributes changes because the stock snapshot used for
 			// comparison was taken before SELINUX relabelling, hence this is likely to
 			// list almost every single file.
 			continue
+		}
+
+		path := strings.TrimPrefix(match[3], rwVolPath)
+		apply, e := sc.resolveConflict(policy, match[3], path, merge, trans)
+		if e != nil {
+			_ = syncF.Close()
+			return e
+		}
+		switch {
+		case !apply:
+			if policy == deployment.MergeFailOnConflict {
+				conflicts = append(conflicts, match[3])
+			}
+			continue
 		case match[2] == "-":
-			err = sc.s.FS().RemoveAll(filepath.Join(merge.New, strings.TrimPrefix(match[3], rwVolPath)))
+			err = sc.s.FS().RemoveAll(filepath.Join(merge.New, path))
 			if err != nil {
 				_ = syncF.Close()
 				return err
 			}
 		default:
-			_, err = fmt.Fprintln(syncF, strings.TrimPrefix(match[3], rwVolPath)) // #nosec G705
+			_, err = fmt.Fprintln(syncF, path) // #nosec G705
 			if err != nil {
 				_ = syncF.Close()
 				return err
@@ -329,6 +385,10 @@ func (sc snapperContext) applyCustomChanges(status, rwVolPath string, merge *Mer
 		return fmt.Errorf("failed closing modified files list: %w", err)
 	}
 
+	if len(conflicts) > 0 {
+		return fmt.Errorf("conflicting changes found in '%s': %s", rwVolPath, strings.Join(conflicts, ", "))
+	}
+
 	// Ensure rsync gets the raw path in testing environments
 	if s, e := sc.s.FS().RawPath(syncFiles); e == nil {
 		syncFiles = s
@@ -344,6 +404,82 @@ func (sc snapperContext) applyCustomChanges(status, rwVolPath string, merge *Mer
 	return nil
 }
 
+// resolveConflict checks if the given locally changed path was also changed in the new OS image and, if so,
+// solves the conflict according to the given policy. Returns true if the local change has to be applied.
+func (sc snapperContext) resolveConflict(policy, fullPath, path string, merge *Merge, trans *Transaction) (bool, error) {
+	newPath := filepath.Join(merge.New, path)
+	changed, err := imageChanged(sc.s.FS(), filepath.Join(merge.Old, path), newPath)
+	if err != nil {
+		return false, fmt.Errorf("checking image changes of '%s': %w", fullPath, err)
+	} else if !changed {
+		return true, nil
+	}
+
+	conflict := Conflict{Path: fullPath, Policy: policy}
+	apply := true
+	switch policy {
+	case deployment.MergePreferImage:
+		conflict.Resolution = "kept new image version"
+		apply = false
+	case deployment.MergeKeepBoth:
+		conflict.Resolution = "kept local version"
+		if info, err := sc.s.FS().Lstat(newPath); err == nil && info.Mode().IsRegular() {
+			err = vfs.CopyFile(sc.s.FS(), newPath, newPath+sidecarSuffix)
+			if err != nil {
+				return false, fmt.Errorf("saving new image version of '%s': %w", fullPath, err)
+			}
+			conflict.Resolution = fmt.Sprintf("kept local version, new image version saved to '%s'", fullPath+sidecarSuffix)
+		}
+	case deployment.MergeFailOnConflict:
+		conflict.Resolution = "upgrade aborted"
+		apply = false
+	default:
+		conflict.Resolution = "kept local version"
+	}
+	trans.Conflicts = append(trans.Conflicts, conflict)
+	return apply, nil
+}
+
+// imageChanged returns true if the given paths of the old and new OS image trees differ. Only regular
+// files and symlinks are compared, directories are never considered to be changed.
+func imageChanged(fs vfs.FS, oldPath, newPath string) (bool, error) {
+	oldInfo, oldErr := fs.Lstat(oldPath)
+	newInfo, newErr := fs.Lstat(newPath)
+	switch {
+	case oldErr != nil && !errors.Is(oldErr, os.ErrNotExist):
+		return false, oldErr
+	case newErr != nil && !errors.Is(newErr, os.ErrNotExist):
+		return false, newErr
+	case oldErr != nil || newErr != nil:
+		return (oldErr == nil) != (newErr == nil), nil
+	case oldInfo.Mode().Type() != newInfo.Mode().Type():
+		return true, nil
+	case oldInfo.Mode()&os.ModeSymlink != 0:
+		oldLink, err := fs.Readlink(oldPath)
+		if err != nil {
+			return false, err
+		}
+		newLink, err := fs.Readlink(newPath)
+		if err != nil {
+			return false, err
+		}
+		return oldLink != newLink, nil
+	case !oldInfo.Mode().IsRegular():
+		return false, nil
+	case oldInfo.Size() != newInfo.Size():
+		return true, nil
+	}
+	oldData, err := fs.ReadFile(oldPath)
+	if err != nil {
+		return false, err
+	}
+	newData, err := fs.ReadFile(newPath)
+	if err != nil {
+		return false, err
+	}
+	return !bytes.Equal(oldData, newData), nil
+}
+
 // snapshotIDFromPath determines the snapshot ID form the snapshot root path
 func snapshotIDFromPath(path string) (int, error) {
 	r := regexp.MustCompile(`.*/.snapshots/(\d+)/snapshot$`)
@@ -387,7 +523,7 @@ func (sc snapperContext) updateFstab(trans *Transaction) error {
 			opts := rwVol.MountOpts
 			oldLines = append(oldLines, fstab.Line{MountPoint: rwVol.Path})
 			newLines = append(newLines, fstab.Line{
-				Device:     fmt.Sprintf("PARTUUID=%s", part.UUID),
+				Device:     fstabDevice(part),
 				MountPoint: rwVol.Path,
 				Options:    append(opts, fmt.Sprintf("subvol=%s", subVol)),
 				FileSystem: part.FileSystem.String(),
@@ -417,7 +553,7 @@ func (sc snapperContext) createFstab(trans *Transaction) error {
 			if len(opts) == 0 {
 				opts = []string{"defaults"}
 			}
-			line.Device = fmt.Sprintf("PARTUUID=%s", part.UUID)
+			line.Device = fstabDevice(part)
 			line.MountPoint = part.MountPoint
 			line.Options = opts
 			line.FileSystem = part.FileSystem.String()
@@ -434,7 +570,7 @@ func (sc snapperContext) createFstab(trans *Transaction) error {
 			}
 			opts := rwVol.MountOpts
 			opts = append(opts, fmt.Sprintf("subvol=%s", subVol))
-			line.Device = fmt.Sprintf("PARTUUID=%s", part.UUID)
+			line.Device = fstabDevice(part)
 			line.MountPoint = rwVol.Path
 			line.Options = opts
 			line.FileSystem = part.FileSystem.String()
@@ -443,7 +579,7 @@ func (sc snapperContext) createFstab(trans *Transaction) error {
 		if part.Role == deployment.System {
 			var line fstab.Line
 			subVol := filepath.Join(btrfs.TopSubVol, snapper.SnapshotsPath)
-			line.Device = fmt.Sprintf("PARTUUID=%s", part.UUID)
+			line.Device = fstabDevice(part)
 			line.MountPoint = filepath.Join("/", snapper.SnapshotsPath)
 			line.Options = []string{fmt.Sprintf("subvol=%s", subVol)}
 			line.FileSystem = part.FileSystem.String()
@@ -453,3 +589,9 @@ func (sc snapperContext) createFstab(trans *Transaction) error {
 
 	return fstab.Write(sc.s, filepath.Join(trans.Path, fstab.File), fstabLines)
 }
+
+// fstabDevice returns the fstab device reference of the given partition, encrypted
+// partitions are referenced by their unlocked device and RAID partitions by their array
+func fstabDevice(part *deployment.Partition) string {
+	return raid.DevicePath(part, luks.DevicePath(part, fmt.Sprintf("PARTUUID=%s", part.UUID)))
+}
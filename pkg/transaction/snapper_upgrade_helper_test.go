@@ -21,11 +21,13 @@ import (
 	"fmt"
 	"path/filepath"
 	"slices"
+	"strings"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
 	"github.com/suse/elemental/v3/pkg/btrfs"
+	"github.com/suse/elemental/v3/pkg/deployment"
 	sysrunner "github.com/suse/elemental/v3/pkg/sys/runner"
 	"github.com/suse/elemental/v3/pkg/sys/vfs"
 	"github.com/suse/elemental/v3/pkg/transaction"
@@ -38,6 +40,11 @@ c..... /etc/modifiedFile
 ....x. /etc/relabelledFile
 `
 
+const conflictsStatus = `c..... /etc/modifiedFile
+c..... /etc/localOnlyFile
+-..... /etc/deletedFile
+`
+
 var _ = Describe("SnapperUpgradeHelper", Label("transaction"), func() {
 	var root string
 	var trans *transaction.Transaction
@@ -126,6 +133,37 @@ var _ = Describe("SnapperUpgradeHelper", Label("transaction"), func() {
 			Expect(err).NotTo(HaveOccurred())
 			Expect(string(data)).To(Not(ContainSubstring("PARTUUID=d7dd841f-aeaa-4fe3-a383-8913f4e8d4de")))
 		})
+		It("creates fstab and crypttab for encrypted partitions", func() {
+			path := filepath.Join(root, btrfs.TopSubVol, ".snapshots/1/snapshot/etc")
+			Expect(vfs.MkdirAll(tfs, path, vfs.DirPerm)).To(Succeed())
+			d.Disks[0].Partitions[2].Encryption = &deployment.Encryption{
+				KeySource: deployment.KeyPassphrase, UUID: "8c0ca8b2-8d53-4c8e-b9a0-6a3f2b9b5c1e",
+			}
+
+			Expect(upgradeH.UpdateFstab(trans)).To(Succeed())
+			data, err := tfs.ReadFile(filepath.Join(trans.Path, transaction.FstabFile))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(data)).To(ContainSubstring("/dev/mapper/luks-8c0ca8b2-8d53-4c8e-b9a0-6a3f2b9b5c1e /home"))
+			Expect(string(data)).NotTo(ContainSubstring("PARTUUID=2443e92c-ddb3-48a2-8ecc-34a8abb87510"))
+			data, err = tfs.ReadFile(filepath.Join(trans.Path, "/etc/crypttab"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(data)).To(ContainSubstring(
+				"luks-8c0ca8b2-8d53-4c8e-b9a0-6a3f2b9b5c1e UUID=8c0ca8b2-8d53-4c8e-b9a0-6a3f2b9b5c1e none luks",
+			))
+		})
+		It("creates fstab and mdadm.conf for RAID partitions", func() {
+			path := filepath.Join(root, btrfs.TopSubVol, ".snapshots/1/snapshot/etc")
+			Expect(vfs.MkdirAll(tfs, path, vfs.DirPerm)).To(Succeed())
+			d.Disks[0].Partitions[2].RAID = &deployment.RAID{Level: deployment.RAID1, UUID: "a1b2c3d4:a1b2c3d4:a1b2c3d4:a1b2c3d4"}
+
+			Expect(upgradeH.UpdateFstab(trans)).To(Succeed())
+			data, err := tfs.ReadFile(filepath.Join(trans.Path, transaction.FstabFile))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(data)).To(ContainSubstring("/dev/disk/by-id/md-uuid-a1b2c3d4:a1b2c3d4:a1b2c3d4:a1b2c3d4 /home"))
+			data, err = tfs.ReadFile(filepath.Join(trans.Path, "/etc/mdadm.conf"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(data)).To(ContainSubstring("UUID=a1b2c3d4:a1b2c3d4:a1b2c3d4:a1b2c3d4"))
+		})
 		It("it fails to create fstab file if the path does not exist", func() {
 			err := upgradeH.UpdateFstab(trans)
 			Expect(err).To(HaveOccurred())
@@ -235,6 +273,98 @@ var _ = Describe("SnapperUpgradeHelper", Label("transaction"), func() {
 			// Verify unmodified files are not copied over
 			Expect(tfs.ReadFile(filepath.Join(etcMerge.New, "unmodifiedFile"))).To(Equal([]byte("new defaults non modified file")))
 		})
+		Describe("merge conflicts", func() {
+			var etcMerge *transaction.Merge
+			var synced string
+			BeforeEach(func() {
+				snapshotP := ".snapshots/5/snapshot"
+				newEtc := filepath.Join(root, snapshotP, "etc")
+				template := filepath.Join(root, snapshotP, "/usr/share/snapper/config-templates/default")
+				snSysConf := filepath.Join(root, snapshotP, "/etc/sysconfig/snapper")
+				Expect(vfs.MkdirAll(tfs, filepath.Join(root, snapshotP, "/etc/snapper/configs"), vfs.DirPerm)).To(Succeed())
+				Expect(vfs.MkdirAll(tfs, filepath.Dir(template), vfs.DirPerm)).To(Succeed())
+				Expect(tfs.WriteFile(template, []byte{}, vfs.FilePerm)).To(Succeed())
+				Expect(vfs.MkdirAll(tfs, filepath.Dir(snSysConf), vfs.DirPerm)).To(Succeed())
+				Expect(tfs.WriteFile(snSysConf, []byte{}, vfs.FilePerm)).To(Succeed())
+				Expect(vfs.MkdirAll(tfs, "/tmp/snapStatus", vfs.DirPerm)).To(Succeed())
+				Expect(tfs.WriteFile("/tmp/snapStatus/snap_status_etc", []byte(conflictsStatus), vfs.FilePerm)).To(Succeed())
+				Expect(tfs.WriteFile("/tmp/snapStatus/snap_status_home", []byte{}, vfs.FilePerm)).To(Succeed())
+
+				synced = ""
+				sideEffects["rsync"] = func(args ...string) ([]byte, error) {
+					if slices.ContainsFunc(args, func(arg string) bool { return strings.HasSuffix(arg, "sync_etc") }) {
+						data, err := tfs.ReadFile("/tmp/snapStatus/sync_etc")
+						synced = string(data)
+						return []byte{}, err
+					}
+					return []byte{}, nil
+				}
+
+				etcMerge = trans.Merges["/etc"]
+				Expect(etcMerge).NotTo(BeNil())
+				Expect(vfs.MkdirAll(tfs, trans.Merges["/home"].Modified, vfs.DirPerm)).To(Succeed())
+				Expect(vfs.MkdirAll(tfs, filepath.Join(root, snapshotP, "home"), vfs.DirPerm)).To(Succeed())
+				for path, files := range map[string]map[string]string{
+					etcMerge.Old:      {"modifiedFile": "stock", "localOnlyFile": "stock", "deletedFile": "stock"},
+					etcMerge.Modified: {"modifiedFile": "custom", "localOnlyFile": "custom"},
+					newEtc:            {"modifiedFile": "new", "localOnlyFile": "stock", "deletedFile": "new"},
+				} {
+					Expect(vfs.MkdirAll(tfs, path, vfs.DirPerm)).To(Succeed())
+					for name, data := range files {
+						Expect(tfs.WriteFile(filepath.Join(path, name), []byte(data), vfs.FilePerm)).To(Succeed())
+					}
+				}
+			})
+			It("keeps local changes and reports conflicts by default", func() {
+				Expect(upgradeH.Merge(trans)).To(Succeed())
+				Expect(synced).To(Equal("/modifiedFile\n/localOnlyFile\n"))
+				Expect(vfs.Exists(tfs, filepath.Join(etcMerge.New, "deletedFile"))).To(BeFalse())
+				Expect(trans.Conflicts).To(Equal([]transaction.Conflict{
+					{Path: "/etc/modifiedFile", Policy: deployment.MergePreferLocal, Resolution: "kept local version"},
+					{Path: "/etc/deletedFile", Policy: deployment.MergePreferLocal, Resolution: "kept local version"},
+				}))
+				data, err := tfs.ReadFile(filepath.Join(trans.Path, transaction.ConflictsFile))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(data)).To(ContainSubstring("path: /etc/modifiedFile"))
+				Expect(string(data)).To(ContainSubstring("path: /etc/deletedFile"))
+			})
+			It("does not write a conflicts report if there are no conflicts", func() {
+				Expect(tfs.WriteFile("/tmp/snapStatus/snap_status_etc", []byte{}, vfs.FilePerm)).To(Succeed())
+				Expect(upgradeH.Merge(trans)).To(Succeed())
+				Expect(trans.Conflicts).To(BeEmpty())
+				Expect(vfs.Exists(tfs, filepath.Join(trans.Path, transaction.ConflictsFile))).To(BeFalse())
+			})
+			It("keeps new image changes on conflicts", func() {
+				d.Disks[0].Partitions[1].RWVolumes[2].MergePolicy = deployment.MergePreferImage
+				Expect(upgradeH.Merge(trans)).To(Succeed())
+				Expect(synced).To(Equal("/localOnlyFile\n"))
+				Expect(tfs.ReadFile(filepath.Join(etcMerge.New, "deletedFile"))).To(Equal([]byte("new")))
+				Expect(trans.Conflicts).To(HaveLen(2))
+				Expect(trans.Conflicts[0].Resolution).To(Equal("kept new image version"))
+			})
+			It("keeps both versions on conflicts", func() {
+				d.Disks[0].Partitions[1].RWVolumes[2].MergePolicy = deployment.MergeKeepBoth
+				Expect(upgradeH.Merge(trans)).To(Succeed())
+				Expect(synced).To(Equal("/modifiedFile\n/localOnlyFile\n"))
+				Expect(tfs.ReadFile(filepath.Join(etcMerge.New, "modifiedFile.rpmnew"))).To(Equal([]byte("new")))
+				Expect(tfs.ReadFile(filepath.Join(etcMerge.New, "deletedFile.rpmnew"))).To(Equal([]byte("new")))
+				Expect(vfs.Exists(tfs, filepath.Join(etcMerge.New, "deletedFile"))).To(BeFalse())
+				Expect(vfs.Exists(tfs, filepath.Join(etcMerge.New, "localOnlyFile.rpmnew"))).To(BeFalse())
+				Expect(trans.Conflicts[0].Resolution).To(Equal(
+					"kept local version, new image version saved to '/etc/modifiedFile.rpmnew'",
+				))
+			})
+			It("fails on conflicts", func() {
+				d.Disks[0].Partitions[1].RWVolumes[2].MergePolicy = deployment.MergeFailOnConflict
+				err := upgradeH.Merge(trans)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring(
+					"conflicting changes found in '/etc': /etc/modifiedFile, /etc/deletedFile",
+				))
+				Expect(synced).To(BeEmpty())
+				Expect(tfs.ReadFile(filepath.Join(etcMerge.New, "deletedFile"))).To(Equal([]byte("new")))
+			})
+		})
 		It("updates fstab", func() {
 			fstab := filepath.Join(root, ".snapshots/5/snapshot/etc/fstab")
 			Expect(vfs.MkdirAll(tfs, filepath.Dir(fstab), vfs.DirPerm)).To(Succeed())
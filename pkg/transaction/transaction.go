@@ -31,6 +31,9 @@ type transactionState int
 
 const FstabFile = "/etc/fstab"
 
+// ConflictsFile is the report of the conflicts found merging snapshotted RW volumes
+const ConflictsFile = "/etc/elemental/merge-conflicts.yaml"
+
 const (
 	started transactionState = iota + 1
 	committed
@@ -54,14 +57,33 @@ type Merge struct {
 	Modified string // modified tree on top of the old tree
 }
 
+// Conflict describes a file of a snapshotted RW volume changed both locally and by the new OS image
+type Conflict struct {
+	Path       string `yaml:"path"`
+	Policy     string `yaml:"policy"`
+	Resolution string `yaml:"resolution"`
+}
+
 type Transaction struct {
-	ID     int
-	Path   string
-	Merges map[string]*Merge
+	ID        int
+	Path      string
+	Base      string // path of the snapshot the transaction is based on, empty if none
+	Merges    map[string]*Merge
+	Conflicts []Conflict // conflicts found merging snapshotted RW volumes
 
 	status transactionState
 }
 
+// Snapshot describes an existing snapshot of the system
+type Snapshot struct {
+	ID          int               `json:"id"`
+	Default     bool              `json:"default"`
+	Active      bool              `json:"active"`
+	Date        string            `json:"date,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+}
+
 type Interface interface {
 	Init(deployment.Deployment) (UpgradeHelper, error)
 	Start() (*Transaction, error)
@@ -69,6 +91,9 @@ type Interface interface {
 	Rollback(*Transaction, error) error
 
 	GetActiveSnapshotIDs() ([]int, error)
+	ListSnapshots() ([]*Snapshot, error)
+	SetDefaultSnapshot(id int) error
+	DeleteSnapshot(id int) error
 }
 
 type UpgradeHelper interface {
@@ -23,6 +23,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
 	"time"
 
 	"github.com/schollz/progressbar/v3"
@@ -53,9 +54,12 @@ type OCI struct {
 	local       bool
 	verify      bool
 	imageRef    string
+	baseDigest  string
 	rsyncFlags  []string
 	ctrdSock    string
 	ctrd        containerd.Interface
+	verifier    SignatureVerifier
+	source      ImageSource
 }
 
 type OCIOpt func(*OCI)
@@ -84,6 +88,30 @@ func WithRsyncFlagsOCI(flags ...string) OCIOpt {
 	}
 }
 
+// WithBaseDigestOCI sets the config digest of the image already unpacked at the synched
+// destination. If the given image is based on it only the missing layers are applied.
+func WithBaseDigestOCI(digest string) OCIOpt {
+	return func(o *OCI) {
+		o.baseDigest = digest
+	}
+}
+
+// WithSignatureVerifierOCI sets the verifier of the image signatures, images are verified
+// before any content is fetched
+func WithSignatureVerifierOCI(v SignatureVerifier) OCIOpt {
+	return func(o *OCI) {
+		o.verifier = v
+	}
+}
+
+// WithImageSourceOCI sets the source images are fetched from instead of remote registries
+// or the local daemon
+func WithImageSourceOCI(src ImageSource) OCIOpt {
+	return func(o *OCI) {
+		o.source = src
+	}
+}
+
 func WithContainerd(ctrd containerd.Interface) OCIOpt {
 	return func(o *OCI) {
 		o.ctrd = ctrd
@@ -102,7 +130,7 @@ func NewOCIUnpacker(s *sys.System, imageRef string, opts ...OCIOpt) *OCI {
 		o(unpacker)
 	}
 
-	if unpacker.local {
+	if unpacker.local && unpacker.source == nil {
 		sock := os.Getenv(CtrdSockEnv)
 		if ok, _ := vfs.Exists(unpacker.s.FS(), sock); ok {
 			unpacker.ctrdSock = sock
@@ -113,13 +141,27 @@ func NewOCIUnpacker(s *sys.System, imageRef string, opts ...OCIOpt) *OCI {
 }
 
 func (o OCI) SynchedUnpack(ctx context.Context, destination string, excludes []string, deleteExcludes []string) (digest string, err error) {
+	if o.imageRef, err = o.VerifySignature(ctx); err != nil {
+		return "", err
+	}
 	if o.ctrdSock != "" {
 		return o.synchedUnpackContainerd(ctx, destination, excludes, deleteExcludes)
 	}
+	if o.baseDigest != "" {
+		var applied bool
+		digest, applied, err = o.deltaUnpack(ctx, destination, excludes)
+		if err != nil || applied {
+			return digest, err
+		}
+		o.s.Logger().Info("Falling back to a full image unpack")
+	}
 	return o.synchedUnpack(ctx, destination, excludes, deleteExcludes)
 }
 
 func (o OCI) Unpack(ctx context.Context, destination string, excludes ...string) (digest string, err error) {
+	if o.imageRef, err = o.VerifySignature(ctx); err != nil {
+		return "", err
+	}
 	if o.ctrdSock != "" {
 		return o.unpackContainerd(ctx, destination, excludes...)
 	}
@@ -127,6 +169,74 @@ func (o OCI) Unpack(ctx context.Context, destination string, excludes ...string)
 	return o.unpack(ctx, destination, excludes...)
 }
 
+// Image verifies the image signatures and fetches the image without extracting any content
+func (o OCI) Image(ctx context.Context) (containerregistry.Image, error) {
+	var err error
+	if o.imageRef, err = o.VerifySignature(ctx); err != nil {
+		return nil, err
+	}
+
+	img, _, err := o.fetch(ctx)
+	return img, err
+}
+
+// Resolve fetches the image manifest and config without extracting any content. It returns the
+// image config digest and the size in bytes of the compressed image layers.
+func (o OCI) Resolve(ctx context.Context) (string, int64, error) {
+	var err error
+	if o.imageRef, err = o.VerifySignature(ctx); err != nil {
+		return "", 0, err
+	}
+
+	img, _, err := o.fetch(ctx)
+	if err != nil {
+		return "", 0, err
+	}
+
+	digest, err := img.ConfigName()
+	if err != nil {
+		return "", 0, err
+	}
+
+	layers, err := img.Layers()
+	if err != nil {
+		return "", 0, err
+	}
+
+	var size int64
+	for _, layer := range layers {
+		layerSize, err := layer.Size()
+		if err != nil {
+			return "", 0, err
+		}
+		size += layerSize
+	}
+	return digest.String(), size, nil
+}
+
+// VerifySignature verifies the image signatures with the configured verifier, if any. It returns
+// the image reference pinned to the verified digest, so the verified image is the one fetched.
+func (o OCI) VerifySignature(ctx context.Context) (string, error) {
+	if o.verifier == nil {
+		return o.imageRef, nil
+	}
+
+	digest, err := o.verifier.Verify(ctx, o.imageRef, o.local)
+	if err != nil || digest == "" {
+		return o.imageRef, err
+	}
+
+	opts := []name.Option{}
+	if !o.verify {
+		opts = append(opts, name.Insecure)
+	}
+	ref, err := name.ParseReference(o.imageRef, opts...)
+	if err != nil {
+		return "", err
+	}
+	return ref.Context().Digest(digest).String(), nil
+}
+
 // synchedUnpack for OCI images will extract OCI contents to a destination sibling directory first and
 // after that it will sync it to the destination directory. Ideally the destination path should
 // not be mountpoint to a different filesystem of the sibling directories in order to benefit of
@@ -156,52 +266,178 @@ func (o OCI) synchedUnpack(ctx context.Context, destination string, excludes []s
 }
 
 func (o OCI) unpack(ctx context.Context, destination string, excludes ...string) (string, error) {
-	platform, err := containerregistry.ParsePlatform(o.platformRef)
+	img, _, err := o.fetch(ctx)
 	if err != nil {
 		return "", err
 	}
 
-	opts := []name.Option{}
-	if !o.verify {
-		opts = append(opts, name.Insecure)
+	digest, err := img.ConfigName()
+	if err != nil {
+		return "", err
 	}
 
-	ref, err := name.ParseReference(o.imageRef, opts...)
+	reader := mutate.Extract(img)
+	defer reader.Close()
+
+	destination, err = o.s.FS().RawPath(destination)
 	if err != nil {
 		return "", err
 	}
 
-	var img containerregistry.Image
+	bar := progressbar.DefaultBytes(-1, "Extracting")
+	defer bar.Close()
 
-	err = backoff.Retry(func() error {
-		img, err = fetchImage(ctx, ref, *platform, o.local)
-		return err
-	}, backoff.WithMaxRetries(backoff.NewConstantBackOff(3*time.Second), 3))
+	r := progressbar.NewReader(reader, bar)
+
+	_, err = containerd.Apply(ctx, destination, &r, excludesFilter(destination, excludes...))
+
+	return digest.String(), err
+}
+
+// deltaUnpack applies to destination only the image layers not included in the base image. Destination
+// is expected to already include the base image content. It returns false if the image is not based on
+// the base image or the base image can't be inspected, in that case destination is left untouched.
+func (o OCI) deltaUnpack(ctx context.Context, destination string, excludes []string) (string, bool, error) {
+	if o.local || o.source != nil {
+		o.s.Logger().Info("Delta unpack is only supported for remote images")
+		return "", false, nil
+	}
+
+	img, ref, err := o.fetch(ctx)
 	if err != nil {
-		return "", err
+		return "", false, err
 	}
 
 	digest, err := img.ConfigName()
 	if err != nil {
-		return "", err
+		return "", false, err
 	}
 
-	reader := mutate.Extract(img)
-	defer reader.Close()
+	config, err := img.ConfigFile()
+	if err != nil {
+		return "", false, err
+	}
+
+	base, err := fetchConfig(ctx, ref, o.baseDigest)
+	if err != nil {
+		o.s.Logger().Warn("Could not inspect base image '%s': %v", o.baseDigest, err)
+		return "", false, nil
+	}
+
+	shared := len(base.RootFS.DiffIDs)
+	if shared > len(config.RootFS.DiffIDs) || !slices.Equal(base.RootFS.DiffIDs, config.RootFS.DiffIDs[:shared]) {
+		o.s.Logger().Info("Image '%s' is not based on image '%s'", digest, o.baseDigest)
+		return "", false, nil
+	}
+
+	layers, err := img.Layers()
+	if err != nil {
+		return "", false, err
+	}
 
 	destination, err = o.s.FS().RawPath(destination)
 	if err != nil {
-		return "", err
+		return "", false, err
 	}
 
 	bar := progressbar.DefaultBytes(-1, "Extracting")
 	defer bar.Close()
 
+	var saved, total int64
+	for i, layer := range layers {
+		size, err := layer.Size()
+		if err != nil {
+			return "", false, err
+		}
+		total += size
+		if i < shared {
+			saved += size
+			continue
+		}
+
+		err = applyLayer(ctx, layer, destination, bar, excludes...)
+		if err != nil {
+			return "", false, err
+		}
+	}
+
+	o.s.Logger().Info(
+		"Applied %d out of %d image layers, saved %d out of %d bytes",
+		len(layers)-shared, len(layers), saved, total,
+	)
+	return digest.String(), true, nil
+}
+
+// fetch parses the image reference and fetches the image, it retries on failure
+func (o OCI) fetch(ctx context.Context) (containerregistry.Image, name.Reference, error) {
+	platform, err := containerregistry.ParsePlatform(o.platformRef)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	opts := []name.Option{}
+	if !o.verify {
+		opts = append(opts, name.Insecure)
+	}
+
+	ref, err := name.ParseReference(o.imageRef, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var img containerregistry.Image
+
+	if o.source != nil {
+		img, err = o.source.Image(ref.String())
+		if err != nil {
+			return nil, nil, err
+		}
+		return img, ref, nil
+	}
+
+	err = backoff.Retry(func() error {
+		img, err = fetchImage(ctx, ref, *platform, o.local)
+		return err
+	}, backoff.WithMaxRetries(backoff.NewConstantBackOff(3*time.Second), 3))
+	if err != nil {
+		return nil, nil, err
+	}
+	return img, ref, nil
+}
+
+// applyLayer extracts the given layer on top of the destination
+func applyLayer(ctx context.Context, layer containerregistry.Layer, destination string, bar *progressbar.ProgressBar, excludes ...string) error {
+	reader, err := layer.Uncompressed()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
 	r := progressbar.NewReader(reader, bar)
 
 	_, err = containerd.Apply(ctx, destination, &r, excludesFilter(destination, excludes...))
+	return err
+}
 
-	return digest.String(), err
+// fetchConfig fetches the image config blob with the given digest from the repository of the given reference
+func fetchConfig(ctx context.Context, ref name.Reference, digest string) (*containerregistry.ConfigFile, error) {
+	blob, err := remote.Layer(
+		ref.Context().Digest(digest),
+		remote.WithTransport(http.DefaultTransport),
+		remote.WithAuthFromKeychain(authn.DefaultKeychain),
+		remote.WithContext(ctx),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := blob.Compressed()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return containerregistry.ParseConfigFile(reader)
 }
 
 func fetchImage(ctx context.Context, ref name.Reference, platform containerregistry.Platform, local bool) (containerregistry.Image, error) {
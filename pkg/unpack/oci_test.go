@@ -20,11 +20,23 @@ package unpack_test
 import (
 	"context"
 	"fmt"
+	"io"
+	stdlog "log"
+	"net/http/httptest"
+	"net/url"
 	"os"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	containerregistry "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+
 	ctrdmock "github.com/suse/elemental/v3/pkg/containerd/mock"
 	"github.com/suse/elemental/v3/pkg/log"
 	"github.com/suse/elemental/v3/pkg/sys"
@@ -169,3 +181,66 @@ var _ = Describe("OCIUnpacker", Label("oci", "containerd"), func() {
 		Expect(err).To(MatchError("failed to mount image"))
 	})
 })
+
+var _ = Describe("OCIUnpacker", Label("oci", "delta"), func() {
+	var tfs vfs.FS
+	var s *sys.System
+	var cleanup func()
+	var server *httptest.Server
+	var baseDigest, imageRef string
+	BeforeEach(func() {
+		var err error
+		tfs, cleanup, err = sysmock.TestFS(nil)
+		Expect(err).NotTo(HaveOccurred())
+		s, err = sys.NewSystem(sys.WithFS(tfs), sys.WithLogger(log.New(log.WithDiscardAll())))
+		Expect(err).NotTo(HaveOccurred())
+
+		server = httptest.NewServer(registry.New(registry.Logger(stdlog.New(io.Discard, "", 0))))
+		u, err := url.Parse(server.URL)
+		Expect(err).NotTo(HaveOccurred())
+
+		baseLayer, err := crane.Layer(map[string][]byte{"etc/os-release": []byte("VERSION_ID=1")})
+		Expect(err).NotTo(HaveOccurred())
+		base, err := mutate.AppendLayers(empty.Image, baseLayer)
+		Expect(err).NotTo(HaveOccurred())
+		newLayer, err := crane.Layer(map[string][]byte{"usr/bin/tool": []byte("tool")})
+		Expect(err).NotTo(HaveOccurred())
+		img, err := mutate.AppendLayers(base, newLayer)
+		Expect(err).NotTo(HaveOccurred())
+
+		push := func(tag string, img containerregistry.Image) {
+			ref, err := name.ParseReference(fmt.Sprintf("%s/os:%s", u.Host, tag))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(remote.Write(ref, img)).To(Succeed())
+		}
+		push("base", base)
+		push("new", img)
+		digest, err := base.ConfigName()
+		Expect(err).NotTo(HaveOccurred())
+		baseDigest = digest.String()
+		imageRef = fmt.Sprintf("%s/os:new", u.Host)
+
+		Expect(vfs.MkdirAll(tfs, "/target/root/etc", vfs.DirPerm)).To(Succeed())
+		Expect(tfs.WriteFile("/target/root/etc/os-release", []byte("VERSION_ID=1 unmodified"), vfs.FilePerm)).To(Succeed())
+	})
+	AfterEach(func() {
+		server.Close()
+		cleanup()
+	})
+	It("Applies only the layers not included in the base image", func() {
+		unpacker := unpack.NewOCIUnpacker(s, imageRef, unpack.WithBaseDigestOCI(baseDigest))
+		digest, err := unpacker.SynchedUnpack(context.Background(), "/target/root", []string{"/usr/local"}, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(digest).To(ContainSubstring("sha256:"))
+		Expect(digest).NotTo(Equal(baseDigest))
+
+		data, err := tfs.ReadFile("/target/root/usr/bin/tool")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal("tool"))
+
+		// base image layers are not applied again
+		data, err = tfs.ReadFile("/target/root/etc/os-release")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal("VERSION_ID=1 unmodified"))
+	})
+})
@@ -21,8 +21,11 @@ import (
 	"context"
 	"fmt"
 
+	containerregistry "github.com/google/go-containerregistry/pkg/v1"
+
 	"github.com/suse/elemental/v3/pkg/deployment"
 	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
 )
 
 type Interface interface {
@@ -37,6 +40,20 @@ type Interface interface {
 	SynchedUnpack(ctx context.Context, destination string, excludes []string, deleteExcludes []string) (string, error)
 }
 
+// SignatureVerifier verifies the signatures of OCI images
+type SignatureVerifier interface {
+	// Verify verifies the given image and returns the verified digest, or an empty
+	// digest if the image does not require verification
+	Verify(ctx context.Context, imageRef string, local bool) (digest string, err error)
+}
+
+// ImageSource provides OCI images from a location other than remote registries or the local
+// daemon, such as an offline bundle
+type ImageSource interface {
+	// Image returns the image of the given reference or an error if it is not available
+	Image(ref string) (containerregistry.Image, error)
+}
+
 type options struct {
 	ociOpts []OCIOpt
 	dirOpts []DirectoryOpt
@@ -66,6 +83,28 @@ func WithVerify(verify bool) Opt {
 	}
 }
 
+// WithSignatureVerifier sets the verifier of OCI image signatures
+func WithSignatureVerifier(v SignatureVerifier) Opt {
+	return func(srcType deployment.ImageSrcType, o *options) {
+		switch srcType {
+		case deployment.OCI:
+			o.ociOpts = append(o.ociOpts, WithSignatureVerifierOCI(v))
+		default:
+		}
+	}
+}
+
+// WithImageSource sets the source OCI images are fetched from
+func WithImageSource(src ImageSource) Opt {
+	return func(srcType deployment.ImageSrcType, o *options) {
+		switch srcType {
+		case deployment.OCI:
+			o.ociOpts = append(o.ociOpts, WithImageSourceOCI(src))
+		default:
+		}
+	}
+}
+
 func WithPlatformRef(platform string) Opt {
 	return func(srcType deployment.ImageSrcType, o *options) {
 		switch srcType {
@@ -76,6 +115,18 @@ func WithPlatformRef(platform string) Opt {
 	}
 }
 
+// WithBaseDigest sets the digest of the image already unpacked at the destination
+// of synched unpacks, so only the missing content is applied if possible.
+func WithBaseDigest(digest string) Opt {
+	return func(srcType deployment.ImageSrcType, o *options) {
+		switch srcType {
+		case deployment.OCI:
+			o.ociOpts = append(o.ociOpts, WithBaseDigestOCI(digest))
+		default:
+		}
+	}
+}
+
 func WithRsyncFlags(flags ...string) Opt {
 	return func(srcType deployment.ImageSrcType, o *options) {
 		switch srcType {
@@ -121,3 +172,48 @@ func NewUnpacker(s *sys.System, src *deployment.ImageSource, opts ...Opt) (Inter
 		return nil, fmt.Errorf("unsupported type of image source")
 	}
 }
+
+// Resolve checks the given image source is available without unpacking it. It returns the image
+// digest, if known, and the size in bytes of the image content. For OCI images the size refers to
+// the compressed layers.
+func Resolve(ctx context.Context, s *sys.System, src *deployment.ImageSource, opts ...Opt) (string, int64, error) {
+	o := &options{}
+	switch {
+	case src.IsEmpty():
+		return "", 0, fmt.Errorf("can't resolve an empty source")
+	case src.IsOCI():
+		for _, opt := range opts {
+			opt(deployment.OCI, o)
+		}
+		return NewOCIUnpacker(s, src.URI(), o.ociOpts...).Resolve(ctx)
+	case src.IsDir():
+		size, err := vfs.DirSize(s.FS(), src.URI())
+		if err != nil {
+			return "", 0, fmt.Errorf("reading directory '%s': %w", src.URI(), err)
+		}
+		return "", size, nil
+	case src.IsRaw(), src.IsTar():
+		info, err := s.FS().Stat(src.URI())
+		if err != nil {
+			return "", 0, fmt.Errorf("reading image file '%s': %w", src.URI(), err)
+		}
+		return "", info.Size(), nil
+	default:
+		return "", 0, fmt.Errorf("unsupported type of image source")
+	}
+}
+
+// Verify checks the signatures of the given image source without fetching its content. Only
+// OCI images are verified, and only if a signature verifier is set.
+func Verify(ctx context.Context, s *sys.System, src *deployment.ImageSource, opts ...Opt) error {
+	if src == nil || !src.IsOCI() {
+		return nil
+	}
+
+	o := &options{}
+	for _, opt := range opts {
+		opt(deployment.OCI, o)
+	}
+	_, err := NewOCIUnpacker(s, src.URI(), o.ociOpts...).VerifySignature(ctx)
+	return err
+}
@@ -19,7 +19,9 @@ package unpack_test
 
 import (
 	"context"
+	"fmt"
 	"slices"
+	"strings"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -93,4 +95,41 @@ var _ = Describe("DirectoryUnpacker", Label("directory"), func() {
 		unpacker, err = unpack.NewUnpacker(s, deployment.NewEmptySrc())
 		Expect(err).To(HaveOccurred())
 	})
+	It("verifies the signatures of oci sources only", func() {
+		verifier := &verifierMock{err: fmt.Errorf("no valid signature found")}
+		opts := []unpack.Opt{unpack.WithSignatureVerifier(verifier), unpack.WithLocal(true)}
+
+		Expect(unpack.Verify(context.Background(), s, deployment.NewDirSrc("/some/root"), opts...)).To(Succeed())
+		Expect(verifier.refs).To(BeEmpty())
+
+		err = unpack.Verify(context.Background(), s, deployment.NewOCISrc("domain.org/some/image:tag"), opts...)
+		Expect(err).To(MatchError("no valid signature found"))
+		Expect(verifier.refs).To(Equal([]string{"domain.org/some/image:tag"}))
+		Expect(verifier.local).To(BeTrue())
+
+		unpacker, err = unpack.NewUnpacker(s, deployment.NewOCISrc("domain.org/some/image:tag"), opts...)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = unpacker.Unpack(context.Background(), "/target/dir")
+		Expect(err).To(MatchError("no valid signature found"))
+	})
+	It("pins oci sources to the verified digest", func() {
+		verifier := &verifierMock{digest: "sha256:" + strings.Repeat("a", 64)}
+		unpacker := unpack.NewOCIUnpacker(s, "domain.org/some/image:tag", unpack.WithSignatureVerifierOCI(verifier))
+		ref, err := unpacker.VerifySignature(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ref).To(Equal("domain.org/some/image@sha256:" + strings.Repeat("a", 64)))
+	})
 })
+
+type verifierMock struct {
+	refs   []string
+	local  bool
+	digest string
+	err    error
+}
+
+func (v *verifierMock) Verify(_ context.Context, imageRef string, local bool) (string, error) {
+	v.refs = append(v.refs, imageRef)
+	v.local = local
+	return v.digest, v.err
+}
@@ -0,0 +1,318 @@
+/*
+Copyright © 2025-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package upgrade
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"go.yaml.in/yaml/v3"
+
+	"github.com/suse/elemental/v3/pkg/cleanstack"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/manifest/resolver"
+	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+	"github.com/suse/elemental/v3/pkg/transaction"
+	"github.com/suse/elemental/v3/pkg/unpack"
+)
+
+const (
+	// KubernetesUpgradeFile records the state of the Kubernetes upgrade of the node
+	KubernetesUpgradeFile = "/etc/elemental/kubernetes-upgrade.yaml"
+
+	// KubernetesDrainFile marks the node as drained by an upgrade. It is kept out of the snapshots, so
+	// the node is uncordoned by any snapshot it boots, including the fallback of a failed upgrade.
+	KubernetesDrainFile = "/var/lib/elemental/kubernetes-drain.yaml"
+
+	// KubernetesArtifactsDir holds the Kubernetes artifacts staged in a snapshot by an upgrade
+	KubernetesArtifactsDir = "/usr/lib/elemental/kubernetes"
+
+	// KubernetesUpgradeUnitName is the systemd unit installing staged Kubernetes upgrades on boot, before
+	// the Kubernetes service starts
+	KubernetesUpgradeUnitName = "elemental-kubernetes-upgrade.service"
+
+	// KubernetesUncordonUnitName is the systemd unit uncordoning the drained node once the Kubernetes service is up
+	KubernetesUncordonUnitName = "elemental-kubernetes-uncordon.service"
+
+	kubernetesInstallScript = "install.sh"
+	systemdUnitsDir         = "/etc/systemd/system"
+	multiUserTargetWants    = "multi-user.target.wants"
+)
+
+// States of a Kubernetes upgrade
+const (
+	KubernetesUpgradeStaged    = "staged"
+	KubernetesUpgradeCompleted = "completed"
+	KubernetesUpgradeFailed    = "failed"
+)
+
+//go:embed templates/elemental-kubernetes-upgrade.service
+var kubernetesUpgradeUnit []byte
+
+//go:embed templates/elemental-kubernetes-uncordon.service
+var kubernetesUncordonUnit []byte
+
+// KubernetesDrainer drains the node before rebooting into a snapshot staging a Kubernetes upgrade
+type KubernetesDrainer interface {
+	// Drain drains the node running the given Kubernetes distribution. Returns false if the node
+	// can't be drained.
+	Drain(ctx context.Context, distribution string) (bool, error)
+	// Uncordon uncordons the node running the given Kubernetes distribution
+	Uncordon(ctx context.Context, distribution string) error
+}
+
+// KubernetesDrain is the record of a node drained by an upgrade
+type KubernetesDrain struct {
+	Distribution string `yaml:"distribution"`
+}
+
+// KubernetesUpgrade is the record of a Kubernetes version upgrade of the node
+type KubernetesUpgrade struct {
+	Distribution string `yaml:"distribution" json:"distribution"`
+	FromVersion  string `yaml:"fromVersion" json:"fromVersion"`
+	ToVersion    string `yaml:"toVersion" json:"toVersion"`
+	State        string `yaml:"state" json:"state"`
+	Message      string `yaml:"message,omitempty" json:"message,omitempty"`
+}
+
+// Pending returns true if the upgrade still has to be applied on the node
+func (k KubernetesUpgrade) Pending() bool {
+	return k.State == KubernetesUpgradeStaged || k.State == KubernetesUpgradeFailed
+}
+
+// WithKubernetesDrainer sets the drainer of the node on Kubernetes version upgrades. The node
+// is drained once the upgrade is staged, before rebooting into the new snapshot, and uncordoned
+// again if the upgrade fails.
+func WithKubernetesDrainer(d KubernetesDrainer) Option {
+	return func(u *Upgrader) {
+		u.drainer = d
+	}
+}
+
+// WriteKubernetesUpgrade stores the given Kubernetes upgrade record in the given root
+func WriteKubernetesUpgrade(s *sys.System, root string, k *KubernetesUpgrade) error {
+	data, err := yaml.Marshal(k)
+	if err != nil {
+		return fmt.Errorf("marshalling kubernetes upgrade record: %w", err)
+	}
+
+	path := filepath.Join(root, KubernetesUpgradeFile)
+	if err = vfs.MkdirAll(s.FS(), filepath.Dir(path), vfs.DirPerm); err != nil {
+		return fmt.Errorf("creating kubernetes upgrade record directory: %w", err)
+	}
+
+	if err = s.FS().WriteFile(path, data, vfs.FilePerm); err != nil {
+		return fmt.Errorf("writing kubernetes upgrade record '%s': %w", path, err)
+	}
+	return nil
+}
+
+// ParseKubernetesUpgrade reads the Kubernetes upgrade record of the given root, returns nil
+// if there is no record
+func ParseKubernetesUpgrade(s *sys.System, root string) (*KubernetesUpgrade, error) {
+	path := filepath.Join(root, KubernetesUpgradeFile)
+	if ok, _ := vfs.Exists(s.FS(), path); !ok {
+		return nil, nil
+	}
+
+	data, err := s.FS().ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading kubernetes upgrade record '%s': %w", path, err)
+	}
+
+	k := &KubernetesUpgrade{}
+	if err = yaml.Unmarshal(data, k); err != nil {
+		return nil, fmt.Errorf("unmarshalling kubernetes upgrade record '%s': %w", path, err)
+	}
+	return k, nil
+}
+
+// WriteKubernetesDrain records the node as drained by an upgrade
+func WriteKubernetesDrain(s *sys.System, k *KubernetesDrain) error {
+	data, err := yaml.Marshal(k)
+	if err != nil {
+		return fmt.Errorf("marshalling kubernetes drain record: %w", err)
+	}
+
+	if err = vfs.MkdirAll(s.FS(), filepath.Dir(KubernetesDrainFile), vfs.DirPerm); err != nil {
+		return fmt.Errorf("creating kubernetes drain record directory: %w", err)
+	}
+
+	if err = s.FS().WriteFile(KubernetesDrainFile, data, vfs.FilePerm); err != nil {
+		return fmt.Errorf("writing kubernetes drain record '%s': %w", KubernetesDrainFile, err)
+	}
+	return nil
+}
+
+// ParseKubernetesDrain reads the drain record of the node, returns nil if the node was not drained
+func ParseKubernetesDrain(s *sys.System) (*KubernetesDrain, error) {
+	if ok, _ := vfs.Exists(s.FS(), KubernetesDrainFile); !ok {
+		return nil, nil
+	}
+
+	data, err := s.FS().ReadFile(KubernetesDrainFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading kubernetes drain record '%s': %w", KubernetesDrainFile, err)
+	}
+
+	k := &KubernetesDrain{}
+	if err = yaml.Unmarshal(data, k); err != nil {
+		return nil, fmt.Errorf("unmarshalling kubernetes drain record '%s': %w", KubernetesDrainFile, err)
+	}
+	return k, nil
+}
+
+// RemoveKubernetesDrain removes the drain record of the node, if any
+func RemoveKubernetesDrain(s *sys.System) error {
+	if err := s.FS().Remove(KubernetesDrainFile); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("removing kubernetes drain record '%s': %w", KubernetesDrainFile, err)
+	}
+	return nil
+}
+
+// stageKubernetes stages the Kubernetes artifacts of the target release in the new snapshot if the
+// Kubernetes version of the node changes and drains the node, if requested. The artifacts are
+// installed on boot by a systemd unit before Kubernetes starts, as the node has to run the new
+// snapshot first. The node is uncordoned again if the upgrade fails after the drain.
+func (u Upgrader) stageKubernetes(cleanup *cleanstack.CleanStack, trans *transaction.Transaction) error {
+	if u.manifest == nil || u.manifest.CorePlatform == nil || u.manifest.CorePlatform.Components.Kubernetes == nil {
+		return nil
+	}
+	target := u.manifest.CorePlatform.Components.Kubernetes
+
+	installed, err := resolver.ParseManifestFile(u.s, "/")
+	if err != nil {
+		return fmt.Errorf("parsing installed release manifest: %w", err)
+	}
+	if installed == nil || installed.CorePlatform == nil || installed.CorePlatform.Components.Kubernetes == nil {
+		u.s.Logger().Info("Kubernetes is not part of the installed release, skipping Kubernetes upgrade")
+		return nil
+	}
+	current := installed.CorePlatform.Components.Kubernetes
+
+	if current.Version == target.Version {
+		return nil
+	}
+
+	dist := current.GetDistribution()
+	if !kubernetesConfigured(u.s, trans.Path, dist) {
+		u.s.Logger().Info("Kubernetes is not configured on this node, skipping Kubernetes upgrade")
+		return nil
+	}
+	if target.GetDistribution() != dist {
+		return fmt.Errorf("changing the kubernetes distribution from '%s' to '%s' is not supported", dist, target.GetDistribution())
+	}
+
+	u.s.Logger().Info("Staging Kubernetes upgrade from %s to %s", current.Version, target.Version)
+
+	artifactsDir := filepath.Join(trans.Path, KubernetesArtifactsDir)
+	if err = vfs.MkdirAll(u.s.FS(), artifactsDir, vfs.DirPerm); err != nil {
+		return fmt.Errorf("creating kubernetes artifacts directory: %w", err)
+	}
+
+	src, err := deployment.NewSrcFromURI(target.Image)
+	if err != nil {
+		return fmt.Errorf("parsing kubernetes image '%s': %w", target.Image, err)
+	}
+	unpacker, err := unpack.NewUnpacker(u.s, src, u.unpackOpts...)
+	if err != nil {
+		return fmt.Errorf("initializing unpacker: %w", err)
+	}
+	if _, err = unpacker.Unpack(u.ctx, artifactsDir); err != nil {
+		return fmt.Errorf("unpacking kubernetes image '%s': %w", target.Image, err)
+	}
+	if ok, _ := vfs.Exists(u.s.FS(), filepath.Join(artifactsDir, kubernetesInstallScript)); !ok {
+		return fmt.Errorf("kubernetes install script not found in image '%s'", target.Image)
+	}
+
+	err = WriteKubernetesUpgrade(u.s, trans.Path, &KubernetesUpgrade{
+		Distribution: dist,
+		FromVersion:  current.Version,
+		ToVersion:    target.Version,
+		State:        KubernetesUpgradeStaged,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = installUnit(u.s, trans.Path, KubernetesUpgradeUnitName, kubernetesUpgradeUnit)
+	if err != nil {
+		return err
+	}
+
+	if u.drainer == nil {
+		return nil
+	}
+
+	// A failed drain may leave the node cordoned, hence the node is uncordoned on any failure from now on
+	cleanup.PushErrorOnly(func() error {
+		return errors.Join(u.drainer.Uncordon(u.ctx, dist), RemoveKubernetesDrain(u.s))
+	})
+	drained, err := u.drainer.Drain(u.ctx, dist)
+	if err != nil {
+		return fmt.Errorf("draining node: %w", err)
+	}
+	if !drained {
+		return nil
+	}
+	return WriteKubernetesDrain(u.s, &KubernetesDrain{Distribution: dist})
+}
+
+// kubernetesConfigured returns true if the node was configured to run the given Kubernetes distribution,
+// the first boot installer writes the node configuration to /etc/rancher/<distribution>
+func kubernetesConfigured(s *sys.System, root, dist string) bool {
+	ok, _ := vfs.Exists(s.FS(), filepath.Join(root, "etc", "rancher", dist, "config.yaml"))
+	return ok
+}
+
+// installKubernetesUncordonUnit installs the systemd unit uncordoning the node drained by an upgrade.
+// It is installed in every snapshot, as the node may boot a snapshot not staging any Kubernetes upgrade,
+// such as the fallback of a failed upgrade.
+func installKubernetesUncordonUnit(s *sys.System, rootPath string) error {
+	return installUnit(s, rootPath, KubernetesUncordonUnitName, kubernetesUncordonUnit)
+}
+
+// installUnit installs and enables the given systemd unit
+func installUnit(s *sys.System, rootPath, name string, data []byte) error {
+	unitsDir := filepath.Join(rootPath, systemdUnitsDir)
+	err := vfs.MkdirAll(s.FS(), filepath.Join(unitsDir, multiUserTargetWants), vfs.DirPerm)
+	if err != nil {
+		return fmt.Errorf("creating systemd units dir: %w", err)
+	}
+
+	unitPath := filepath.Join(unitsDir, name)
+	err = s.FS().WriteFile(unitPath, data, vfs.FilePerm)
+	if err != nil {
+		return fmt.Errorf("writing unit '%s': %w", unitPath, err)
+	}
+
+	link := filepath.Join(unitsDir, multiUserTargetWants, name)
+	if ok, _ := vfs.Exists(s.FS(), link); ok {
+		return nil
+	}
+	err = s.FS().Symlink(filepath.Join(systemdUnitsDir, name), link)
+	if err != nil {
+		return fmt.Errorf("enabling unit '%s': %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,221 @@
+/*
+Copyright © 2022-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package upgrade
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/suse/elemental/v3/pkg/bootloader"
+	"github.com/suse/elemental/v3/pkg/btrfs"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+	"github.com/suse/elemental/v3/pkg/unpack"
+)
+
+// snapshotQGroup is the quota group snapper accounts snapshots to
+const snapshotQGroup = "1/0"
+
+// Reason codes of the pre-flight checks an upgrade plan can fail on
+const (
+	ReasonInvalidDeployment     = "InvalidDeployment"
+	ReasonImageUnavailable      = "ImageUnavailable"
+	ReasonInsufficientESPSpace  = "InsufficientESPSpace"
+	ReasonInsufficientDiskSpace = "InsufficientDiskSpace"
+	ReasonQuotaExceeded         = "SnapshotQuotaExceeded"
+	ReasonCheckFailed           = "CheckFailed"
+	ReasonManifestUnavailable   = "ReleaseManifestUnavailable"
+	ReasonUpgradeNotAllowed     = "UpgradeNotAllowed"
+	ReasonMissingSigningKey     = "MissingSigningKey"
+	ReasonBootEntryFailed       = "BootEntryFailed"
+)
+
+// Plan describes what an upgrade would do and the reasons it would fail, if any
+type Plan struct {
+	Image         string      `json:"image"`
+	Digest        string      `json:"digest,omitempty"`
+	ImageSize     int64       `json:"imageSize"`
+	MergedVolumes []string    `json:"mergedVolumes"`
+	ESP           *SpaceCheck `json:"esp,omitempty"`
+	Snapshot      *SpaceCheck `json:"snapshot,omitempty"`
+	Reasons       []Reason    `json:"reasons,omitempty"`
+}
+
+// SpaceCheck reports the space in bytes an upgrade requires in a filesystem. Available
+// space is limited by the quota of the filesystem, if any.
+type SpaceCheck struct {
+	Path      string `json:"path"`
+	Required  int64  `json:"required"`
+	Available int64  `json:"available"`
+}
+
+// Reason is a machine readable cause of an upgrade failure
+type Reason struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Ready returns true if no pre-flight check failed
+func (p Plan) Ready() bool {
+	return len(p.Reasons) == 0
+}
+
+// Error returns an error listing all the failed pre-flight checks, nil if the plan is ready
+func (p Plan) Error() error {
+	if p.Ready() {
+		return nil
+	}
+	msgs := make([]string, 0, len(p.Reasons))
+	for _, r := range p.Reasons {
+		msgs = append(msgs, fmt.Sprintf("%s: %s", r.Code, r.Message))
+	}
+	return fmt.Errorf("upgrade pre-flight checks failed: %s", strings.Join(msgs, "; "))
+}
+
+func (p *Plan) addReason(code, format string, args ...any) {
+	p.Reasons = append(p.Reasons, Reason{Code: code, Message: fmt.Sprintf(format, args...)})
+}
+
+// Plan runs the upgrade pre-flight checks for the given deployment without starting any
+// transaction. It validates the deployment, resolves the OS image and checks there is
+// enough space in the ESP and in the system partition for the new snapshot. Required
+// sizes are estimates based on the OS image size and the kernel of the running system.
+func (u Upgrader) Plan(d *deployment.Deployment, excludeChecks ...deployment.SanitizeDeployment) *Plan {
+	p := &Plan{MergedVolumes: []string{}}
+	if d.SourceOS != nil {
+		p.Image = d.SourceOS.String()
+	}
+
+	err := d.Sanitize(u.s, excludeChecks...)
+	if err != nil {
+		p.addReason(ReasonInvalidDeployment, "%s", err.Error())
+		return p
+	}
+
+	var partitions deployment.Partitions
+	for _, disk := range d.Disks {
+		partitions = append(partitions, disk.Partitions...)
+	}
+	for _, rwVol := range partitions.GetSnapshottedVolumes() {
+		p.MergedVolumes = append(p.MergedVolumes, rwVol.Path)
+	}
+
+	p.Digest, p.ImageSize, err = unpack.Resolve(u.ctx, u.s, d.SourceOS, u.unpackOpts...)
+	if err != nil {
+		p.addReason(ReasonImageUnavailable, "resolving OS image '%s': %s", p.Image, err.Error())
+	}
+
+	if esp := d.GetEfiPartition(); esp != nil {
+		u.checkESP(p, d, esp)
+	}
+
+	if sysPart := d.GetSystemPartition(); sysPart != nil && sysPart.FileSystem == deployment.Btrfs {
+		u.checkSnapshot(p, sysPart)
+	}
+	return p
+}
+
+// checkESP checks the ESP has room for the boot artifacts of the new snapshot. The required
+// space is estimated from the kernel and initrd of the running system.
+func (u Upgrader) checkESP(p *Plan, d *deployment.Deployment, esp *deployment.Partition) {
+	if d.BootConfig == nil || d.BootConfig.Bootloader == bootloader.BootNone {
+		return
+	}
+
+	check := &SpaceCheck{Path: esp.MountPoint}
+	p.ESP = check
+
+	kernel, _, err := vfs.FindKernel(u.s.FS(), "/")
+	if err != nil {
+		p.addReason(ReasonCheckFailed, "finding current kernel: %s", err.Error())
+		return
+	}
+	for _, file := range []string{kernel, filepath.Join(filepath.Dir(kernel), bootloader.Initrd)} {
+		info, err := u.s.FS().Stat(file)
+		if err != nil {
+			p.addReason(ReasonCheckFailed, "reading boot artifact size: %s", err.Error())
+			return
+		}
+		check.Required += info.Size()
+	}
+
+	check.Available, err = u.availableSpace(esp.MountPoint)
+	if err != nil {
+		p.addReason(ReasonCheckFailed, "reading ESP free space: %s", err.Error())
+		return
+	}
+	if check.Available < check.Required {
+		p.addReason(
+			ReasonInsufficientESPSpace, "%d bytes required in '%s' for the new kernel and initrd, only %d available",
+			check.Required, esp.MountPoint, check.Available,
+		)
+	}
+}
+
+// checkSnapshot checks the system partition has room for the new snapshot, considering both the
+// filesystem free space and the snapshots quota group limit. The required space is estimated
+// from the OS image size.
+func (u Upgrader) checkSnapshot(p *Plan, sysPart *deployment.Partition) {
+	check := &SpaceCheck{Path: sysPart.MountPoint, Required: p.ImageSize}
+	p.Snapshot = check
+
+	free, err := btrfs.FreeSpace(u.s, sysPart.MountPoint)
+	if err != nil {
+		p.addReason(ReasonCheckFailed, "reading btrfs free space: %s", err.Error())
+		return
+	}
+	check.Available = int64(free)
+	if check.Available < check.Required {
+		p.addReason(
+			ReasonInsufficientDiskSpace, "%d bytes required in '%s' for the new snapshot, only %d available",
+			check.Required, sysPart.MountPoint, check.Available,
+		)
+		return
+	}
+
+	used, limit, err := btrfs.QuotaGroupUsage(u.s, sysPart.MountPoint, snapshotQGroup)
+	if err != nil {
+		u.s.Logger().Warn("Could not read snapshots quota, skipping quota check: %v", err)
+		return
+	}
+	if limit == 0 {
+		return
+	}
+	check.Available = min(check.Available, int64(limit)-int64(used))
+	if check.Available < check.Required {
+		p.addReason(
+			ReasonQuotaExceeded, "%d bytes required for the new snapshot, only %d left in quota group %s",
+			check.Required, check.Available, snapshotQGroup,
+		)
+	}
+}
+
+// availableSpace returns the available space in bytes of the filesystem mounted at the given path
+func (u Upgrader) availableSpace(path string) (int64, error) {
+	out, err := u.s.Runner().Run("df", "--output=avail", "-B1", path)
+	if err != nil {
+		return 0, fmt.Errorf("running df on '%s': %s: %w", path, string(out), err)
+	}
+	fields := strings.Fields(string(out))
+	if len(fields) < 2 {
+		return 0, fmt.Errorf("unexpected df output for '%s': %s", path, string(out))
+	}
+	return strconv.ParseInt(fields[len(fields)-1], 10, 64)
+}
@@ -0,0 +1,145 @@
+/*
+Copyright © 2022-2026 SUSE LLC
+SPDX-License-Identifier: Apache-2.0
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package upgrade_test
+
+import (
+	"context"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/suse/elemental/v3/pkg/bootloader"
+	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/sys"
+	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
+	transmock "github.com/suse/elemental/v3/pkg/transaction/mock"
+	"github.com/suse/elemental/v3/pkg/upgrade"
+)
+
+const btrfsUsage = `Overall:
+    Device size:                 10737418240
+    Free (estimated):             %d      (min: 1024)
+`
+
+const btrfsQGroups = `qgroupid         rfer         excl     max_rfer     max_excl
+--------         ----         ----     --------     --------
+0/5             16384        16384         none         none
+1/0              4096         4096         %s         none
+`
+
+var _ = Describe("Upgrade plan", Label("upgrade", "plan"), func() {
+	var runner *sysmock.Runner
+	var fs vfs.FS
+	var cleanup func()
+	var s *sys.System
+	var d *deployment.Deployment
+	var u *upgrade.Upgrader
+	var espAvail, btrfsFree int
+	var qgroupLimit string
+
+	BeforeEach(func() {
+		var err error
+		runner = sysmock.NewRunner()
+		fs, cleanup, err = sysmock.TestFS(map[string]any{
+			"/some/dir/file":                     make([]byte, 1000),
+			"/usr/lib/modules/6.4/vmlinuz":       make([]byte, 100),
+			"/usr/lib/modules/6.4/initrd":        make([]byte, 200),
+			"/usr/lib/modules/6.4/.vmlinuz.hmac": []byte{},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		s, err = sys.NewSystem(
+			sys.WithRunner(runner), sys.WithFS(fs),
+			sys.WithLogger(log.New(log.WithDiscardAll())),
+		)
+		Expect(err).NotTo(HaveOccurred())
+
+		espAvail, btrfsFree, qgroupLimit = 4096, 8192, "none"
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			switch {
+			case cmd == "df":
+				return []byte(fmt.Sprintf("Avail\n%d\n", espAvail)), nil
+			case cmd == "btrfs" && args[0] == "filesystem":
+				return []byte(fmt.Sprintf(btrfsUsage, btrfsFree)), nil
+			case cmd == "btrfs" && args[0] == "qgroup":
+				return []byte(fmt.Sprintf(btrfsQGroups, qgroupLimit)), nil
+			}
+			return []byte{}, nil
+		}
+
+		d = deployment.DefaultDeployment()
+		d.SourceOS = deployment.NewDirSrc("/some/dir")
+		d.BootConfig.Bootloader = bootloader.BootGrub
+
+		// Planning never starts a transaction
+		t := &transmock.Transactioner{
+			InitErr: fmt.Errorf("init not expected"), StartErr: fmt.Errorf("start not expected"),
+		}
+		u = upgrade.New(context.Background(), s, upgrade.WithTransaction(t))
+	})
+	AfterEach(func() {
+		cleanup()
+	})
+	It("plans an upgrade", func() {
+		p := u.Plan(d, deployment.CheckDiskDevice)
+		Expect(p.Reasons).To(BeEmpty())
+		Expect(p.Ready()).To(BeTrue())
+		Expect(p.Error()).NotTo(HaveOccurred())
+		Expect(p.Image).To(Equal("dir:///some/dir"))
+		Expect(p.ImageSize).To(Equal(int64(1000)))
+		Expect(p.MergedVolumes).To(Equal([]string{"/etc"}))
+		Expect(*p.ESP).To(Equal(upgrade.SpaceCheck{Path: deployment.EfiMnt, Required: 300, Available: 4096}))
+		Expect(*p.Snapshot).To(Equal(upgrade.SpaceCheck{Path: deployment.SystemMnt, Required: 1000, Available: 8192}))
+		Expect(runner.IncludesCmds([][]string{
+			{"df", "--output=avail", "-B1", deployment.EfiMnt},
+			{"btrfs", "filesystem", "usage", "-b", deployment.SystemMnt},
+			{"btrfs", "qgroup", "show", "-re", "--raw", deployment.SystemMnt},
+		})).To(Succeed())
+	})
+	It("fails on an inconsistent deployment", func() {
+		d.Disks[0].Partitions[1].RWVolumes = append(d.Disks[0].Partitions[1].RWVolumes, deployment.RWVolume{Path: "/etc"})
+		p := u.Plan(d, deployment.CheckDiskDevice)
+		Expect(p.Ready()).To(BeFalse())
+		Expect(p.Reasons).To(HaveLen(1))
+		Expect(p.Reasons[0].Code).To(Equal(upgrade.ReasonInvalidDeployment))
+		Expect(runner.GetCmds()).To(BeEmpty())
+	})
+	It("fails if the image is not available", func() {
+		d.SourceOS = deployment.NewDirSrc("/missing/dir")
+		p := u.Plan(d, deployment.CheckDiskDevice)
+		Expect(p.Reasons).To(HaveLen(1))
+		Expect(p.Reasons[0].Code).To(Equal(upgrade.ReasonImageUnavailable))
+		Expect(p.Error()).To(MatchError(ContainSubstring("ImageUnavailable: resolving OS image 'dir:///missing/dir'")))
+	})
+	It("fails if there is not enough space", func() {
+		espAvail, btrfsFree = 100, 500
+		p := u.Plan(d, deployment.CheckDiskDevice)
+		Expect(p.Reasons).To(HaveLen(2))
+		Expect(p.Reasons[0].Code).To(Equal(upgrade.ReasonInsufficientESPSpace))
+		Expect(p.Reasons[1].Code).To(Equal(upgrade.ReasonInsufficientDiskSpace))
+	})
+	It("fails if the snapshots quota is exceeded", func() {
+		qgroupLimit = "5000"
+		p := u.Plan(d, deployment.CheckDiskDevice)
+		Expect(p.Reasons).To(HaveLen(1))
+		Expect(p.Reasons[0].Code).To(Equal(upgrade.ReasonQuotaExceeded))
+		Expect(p.Snapshot.Available).To(Equal(int64(904)))
+	})
+})
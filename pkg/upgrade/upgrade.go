@@ -19,21 +19,30 @@ package upgrade
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"path/filepath"
 	"slices"
 	"strconv"
 	"strings"
 
+	"github.com/suse/elemental/v3/pkg/block"
+	"github.com/suse/elemental/v3/pkg/block/lsblk"
 	"github.com/suse/elemental/v3/pkg/bootloader"
 	"github.com/suse/elemental/v3/pkg/chroot"
 	"github.com/suse/elemental/v3/pkg/cleanstack"
 	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/extensions"
 	"github.com/suse/elemental/v3/pkg/fips"
 	"github.com/suse/elemental/v3/pkg/firmware"
+	"github.com/suse/elemental/v3/pkg/luks"
+	"github.com/suse/elemental/v3/pkg/manifest/api"
+	"github.com/suse/elemental/v3/pkg/manifest/resolver"
+	"github.com/suse/elemental/v3/pkg/repart"
 	"github.com/suse/elemental/v3/pkg/rsync"
 	"github.com/suse/elemental/v3/pkg/selinux"
 	"github.com/suse/elemental/v3/pkg/sys"
+	"github.com/suse/elemental/v3/pkg/sys/vfs"
 	"github.com/suse/elemental/v3/pkg/transaction"
 	"github.com/suse/elemental/v3/pkg/unpack"
 )
@@ -53,6 +62,10 @@ type Upgrader struct {
 	bm         *firmware.EfiBootManager
 	b          bootloader.Bootloader
 	unpackOpts []unpack.Opt
+	delta      bool
+	grow       bool
+	drainer    KubernetesDrainer
+	manifest   *resolver.ResolvedManifest
 }
 
 func WithTransaction(t transaction.Interface) Option {
@@ -85,6 +98,30 @@ func WithUnpackOpts(opts ...unpack.Opt) Option {
 	}
 }
 
+// WithDelta enables delta upgrades, only the OS image content not already present in
+// the snapshot the upgrade is based on is fetched and applied, if possible.
+func WithDelta(delta bool) Option {
+	return func(u *Upgrader) {
+		u.delta = delta
+	}
+}
+
+// WithReleaseManifest stores the given release manifest in the new snapshot as the record
+// of the release it runs.
+func WithReleaseManifest(rm *resolver.ResolvedManifest) Option {
+	return func(u *Upgrader) {
+		u.manifest = rm
+	}
+}
+
+// WithFirstBootGrowth configures the deployed system to grow the last partition of the system
+// disk up to all the available space on first boot, this is relevant for disk images.
+func WithFirstBootGrowth(grow bool) Option {
+	return func(u *Upgrader) {
+		u.grow = grow
+	}
+}
+
 func New(ctx context.Context, s *sys.System, opts ...Option) *Upgrader {
 	up := &Upgrader{
 		s:   s,
@@ -114,6 +151,11 @@ func (u Upgrader) Upgrade(d *deployment.Deployment) (err error) {
 		return fmt.Errorf("no %s partition defined in deployment", deployment.EfiLabel)
 	}
 
+	err = unpack.Verify(u.ctx, u.s, d.SourceOS, u.unpackOpts...)
+	if err != nil {
+		return fmt.Errorf("verifying OS image: %w", err)
+	}
+
 	uh, err = u.t.Init(*d)
 	if err != nil {
 		return fmt.Errorf("initializing transaction: %w", err)
@@ -125,7 +167,7 @@ func (u Upgrader) Upgrade(d *deployment.Deployment) (err error) {
 	}
 	cleanup.PushErrorOnly(func() error { return u.t.Rollback(trans, err) })
 
-	err = uh.SyncImageContent(d.SourceOS, trans, u.unpackOpts...)
+	err = uh.SyncImageContent(d.SourceOS, trans, u.syncUnpackOpts(trans)...)
 	if err != nil {
 		return fmt.Errorf("syncing OS image content: %w", err)
 	}
@@ -140,6 +182,26 @@ func (u Upgrader) Upgrade(d *deployment.Deployment) (err error) {
 		return fmt.Errorf("updating fstab: %w", err)
 	}
 
+	err = u.carryOverExtensions(trans)
+	if err != nil {
+		return fmt.Errorf("checking systemd extensions: %w", err)
+	}
+
+	err = u.checkKernelModules(trans)
+	if err != nil {
+		return fmt.Errorf("checking kernel modules: %w", err)
+	}
+
+	err = installKubernetesUncordonUnit(u.s, trans.Path)
+	if err != nil {
+		return fmt.Errorf("installing kubernetes uncordon unit: %w", err)
+	}
+
+	err = u.stageKubernetes(cleanup, trans)
+	if err != nil {
+		return fmt.Errorf("staging kubernetes upgrade: %w", err)
+	}
+
 	if d.IsFipsEnabled() {
 		err = fips.ChrootedEnable(u.ctx, u.s, trans.Path)
 		if err != nil {
@@ -147,15 +209,23 @@ func (u Upgrader) Upgrade(d *deployment.Deployment) (err error) {
 		}
 	}
 
+	if u.grow {
+		parts := d.GetSystemDisk().Partitions
+		err = repart.WriteGrowConfig(u.s, trans.Path, parts[len(parts)-1])
+		if err != nil {
+			return fmt.Errorf("configuring partition growth: %w", err)
+		}
+	}
+
 	shared, snapshotted := parsePersistentPaths(d)
 	err = selinux.ChrootedSystemRelabel(u.ctx, u.s, trans.Path, snapshotted, shared)
 	if err != nil {
 		return fmt.Errorf("relabelling snapshot path '%s': %w", trans.Path, err)
 	}
 
-	err = d.WriteDeploymentFile(u.s, trans.Path)
+	err = u.recordRelease(d, trans.Path)
 	if err != nil {
-		return fmt.Errorf("writing deployment file: %w", err)
+		return err
 	}
 
 	err = uh.Lock(trans)
@@ -200,6 +270,18 @@ func (u Upgrader) Upgrade(d *deployment.Deployment) (err error) {
 		return fmt.Errorf("installing bootloader: %w", err)
 	}
 
+	if d.BootConfig.IsBIOSEnabled() {
+		err = u.installBIOSBoot(d, trans.Path, espDir, esp.Label)
+		if err != nil {
+			return fmt.Errorf("installing bootloader for legacy BIOS: %w", err)
+		}
+	}
+
+	err = u.sealTPM2Keys(d, trans, espDir, kernelCmdline)
+	if err != nil {
+		return fmt.Errorf("sealing TPM2 keys: %w", err)
+	}
+
 	if d.Firmware != nil {
 		err = u.bm.CreateBootEntries(d.Firmware.BootEntries)
 		if err != nil {
@@ -213,6 +295,13 @@ func (u Upgrader) Upgrade(d *deployment.Deployment) (err error) {
 			return fmt.Errorf("get active snapshots: %w", err)
 		}
 
+		if usesPCRLock(d) {
+			err = luks.PrunePCRLock(u.s, trans.Path, snapshots)
+			if err != nil {
+				return fmt.Errorf("pruning PCR predictions: %w", err)
+			}
+		}
+
 		return u.b.Prune(trans.Path, filepath.Join(trans.Path, esp.MountPoint), snapshots)
 	}
 
@@ -221,9 +310,254 @@ func (u Upgrader) Upgrade(d *deployment.Deployment) (err error) {
 		return fmt.Errorf("committing transaction: %w", err)
 	}
 
+	u.reportConflicts(trans)
+
+	return nil
+}
+
+// carryOverExtensions keeps enabled in the new snapshot only the installed systemd extensions which are
+// compatible with the os-release of the new OS image. Incompatible extensions are reported and disabled,
+// their artifacts are kept as the previous snapshots may still use them.
+func (u Upgrader) carryOverExtensions(trans *transaction.Transaction) error {
+	if ok, _ := vfs.Exists(u.s.FS(), filepath.Join(trans.Path, extensions.File)); !ok {
+		return nil
+	}
+
+	exts, err := extensions.Parse(u.s, trans.Path)
+	if err != nil {
+		return err
+	} else if len(exts) == 0 {
+		return nil
+	}
+
+	osRelease, err := extensions.ReadOSRelease(u.s, trans.Path)
+	if err != nil {
+		return err
+	}
+	arch := extensions.Architecture(u.s.Platform())
+
+	compatible := make([]api.SystemdExtension, 0, len(exts))
+	for _, ext := range exts {
+		paths, err := extensions.Paths(u.s.FS(), extensions.Dir, ext)
+		if err != nil {
+			return err
+		}
+		if err = extensions.Check(u.s, paths, osRelease, arch); err != nil {
+			u.s.Logger().Warn("Disabling systemd extension '%s' for the upgraded system: %v", ext.Name, err)
+			continue
+		}
+		compatible = append(compatible, ext)
+	}
+
+	if len(compatible) == len(exts) {
+		return nil
+	}
+	return extensions.Write(u.s, trans.Path, compatible)
+}
+
+// checkKernelModules verifies the kernel modules of the enabled systemd extensions resolve for the kernel of the
+// new snapshot and records the outcome within it. Missing modules of required extensions fail the upgrade, others
+// are only reported.
+func (u Upgrader) checkKernelModules(trans *transaction.Transaction) error {
+	if ok, _ := vfs.Exists(u.s.FS(), filepath.Join(trans.Path, extensions.File)); !ok {
+		return nil
+	}
+
+	exts, err := extensions.Parse(u.s, trans.Path)
+	if err != nil {
+		return err
+	} else if !slices.ContainsFunc(exts, func(e api.SystemdExtension) bool { return len(e.KernelModules) > 0 }) {
+		return nil
+	}
+
+	_, kernelVersion, err := vfs.FindKernel(u.s.FS(), trans.Path)
+	if err != nil {
+		return fmt.Errorf("finding kernel version: %w", err)
+	}
+
+	report, err := extensions.CheckKernelModules(u.s, trans.Path, kernelVersion, exts)
+	if err != nil {
+		return err
+	}
+
+	err = extensions.WriteModulesReport(u.s, trans.Path, report)
+	if err != nil {
+		return err
+	}
+
+	required := u.requiredExtensions(trans.Path)
+
+	var errs []error
+	for _, m := range report.Missing() {
+		if slices.Contains(required, m.Extension) {
+			errs = append(errs, fmt.Errorf("module '%s' of required extension '%s' not found", m.Module, m.Extension))
+			continue
+		}
+		u.s.Logger().Warn("Kernel module '%s' of extension '%s' not found for kernel %s", m.Module, m.Extension, kernelVersion)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("kernel %s: %w", kernelVersion, errors.Join(errs...))
+	}
 	return nil
 }
 
+// recordRelease writes the deployment file and the release manifest of the target release to the
+// given root. Without a target release manifest the release carried over from the previous snapshot
+// no longer describes the upgraded OS, hence it is dropped from both.
+func (u Upgrader) recordRelease(d *deployment.Deployment, root string) error {
+	manifestFile := filepath.Join(root, resolver.ManifestFile)
+	if u.manifest == nil {
+		if d.Release != nil {
+			u.s.Logger().Warn("No release manifest given, the upgraded OS is no longer tracked as '%s' %s", d.Release.Name, d.Release.Version)
+			d.Release = nil
+		}
+		if ok, _ := vfs.Exists(u.s.FS(), manifestFile); ok {
+			err := u.s.FS().Remove(manifestFile)
+			if err != nil {
+				return fmt.Errorf("removing previous release manifest file: %w", err)
+			}
+		}
+	}
+
+	err := d.WriteDeploymentFile(u.s, root)
+	if err != nil {
+		return fmt.Errorf("writing deployment file: %w", err)
+	}
+
+	if u.manifest != nil {
+		err = resolver.WriteManifestFile(u.s, root, u.manifest)
+		if err != nil {
+			return fmt.Errorf("writing release manifest file: %w", err)
+		}
+	}
+	return nil
+}
+
+// requiredExtensions returns the names of the extensions required by the target release manifest, or by
+// the installed release manifest if no target release was given
+func (u Upgrader) requiredExtensions(root string) []string {
+	rm := u.manifest
+	if rm == nil {
+		rm, _ = resolver.ParseManifestFile(u.s, root)
+	}
+	if rm == nil || rm.CorePlatform == nil {
+		return nil
+	}
+
+	all := rm.CorePlatform.Components.Systemd.Extensions
+	if rm.SolutionExtension != nil {
+		all = append(slices.Clone(all), rm.SolutionExtension.Components.Systemd.Extensions...)
+	}
+
+	var required []string
+	for _, e := range all {
+		if e.Required {
+			required = append(required, e.Name)
+		}
+	}
+	return required
+}
+
+// installBIOSBoot installs the bootloader core image to the disk including the BIOS boot partition
+func (u Upgrader) installBIOSBoot(d *deployment.Deployment, root, espDir, espLabel string) error {
+	bl, ok := u.b.(bootloader.BIOSBootloader)
+	if !ok {
+		return fmt.Errorf("configured bootloader does not support legacy BIOS boot")
+	}
+
+	biosPart := d.GetBIOSPartition()
+	if biosPart == nil {
+		return fmt.Errorf("no %s partition defined in deployment", deployment.BiosLabel)
+	}
+
+	bPart, err := block.GetPartitionByUUID(u.s, lsblk.NewLsDevice(u.s), biosPart.UUID, 4)
+	if err != nil {
+		return fmt.Errorf("finding partition '%s': %w", biosPart.UUID, err)
+	}
+	return bl.InstallBIOS(root, espDir, espLabel, bPart.Disk)
+}
+
+// reportConflicts prints a summary of the conflicts found merging snapshotted RW volumes
+func (u Upgrader) reportConflicts(trans *transaction.Transaction) {
+	if len(trans.Conflicts) == 0 {
+		return
+	}
+	u.s.Logger().Warn(
+		"%d merge conflicts found, the report is available at '%s'", len(trans.Conflicts), transaction.ConflictsFile,
+	)
+	for _, c := range trans.Conflicts {
+		u.s.Logger().Warn("  %s (%s): %s", c.Path, c.Policy, c.Resolution)
+	}
+}
+
+// syncUnpackOpts returns the unpack options to sync the OS image into the given transaction. For delta
+// upgrades it includes the digest of the OS image the transaction is based on.
+func (u Upgrader) syncUnpackOpts(trans *transaction.Transaction) []unpack.Opt {
+	if !u.delta {
+		return u.unpackOpts
+	}
+	if trans.Base == "" {
+		u.s.Logger().Info("No base snapshot to compute a delta upgrade from")
+		return u.unpackOpts
+	}
+	base, err := deployment.Parse(u.s, trans.Base)
+	if err != nil || base == nil || base.SourceOS == nil || base.SourceOS.GetDigest() == "" {
+		u.s.Logger().Warn("Could not determine the OS image digest of the base snapshot")
+		return u.unpackOpts
+	}
+	return append(slices.Clone(u.unpackOpts), unpack.WithBaseDigest(base.SourceOS.GetDigest()))
+}
+
+// sealTPM2Keys keeps the TPM2 bound keys of encrypted partitions valid for the new boot
+// entry. systemd-pcrlock predictions are updated on each upgrade, signed and static
+// policies do not require any update. Keys are only enrolled on installation, as this is
+// the only time the key file to unlock the devices is available.
+func (u Upgrader) sealTPM2Keys(d *deployment.Deployment, trans *transaction.Transaction, espDir, cmdline string) error {
+	if usesPCRLock(d) {
+		uki := d.BootConfig.IsUKIEnabled()
+		bootImage := bootloader.UKIPath(espDir, strconv.Itoa(trans.ID))
+		if !uki {
+			kernel, _, err := vfs.FindKernel(u.s.FS(), trans.Path)
+			if err != nil {
+				return fmt.Errorf("finding kernel: %w", err)
+			}
+			bootImage = kernel
+		}
+		err := luks.UpdatePCRLock(u.s, trans.Path, strconv.Itoa(trans.ID), bootImage, cmdline, uki)
+		if err != nil {
+			return fmt.Errorf("updating PCR policy: %w", err)
+		}
+	}
+
+	for _, part := range d.GetEncryptedPartitions() {
+		if part.Encryption.KeySource != deployment.KeyTPM2 || part.Encryption.KeyFile == "" {
+			continue
+		}
+		var policy luks.TPM2Policy
+		switch part.Encryption.GetPCRPolicy() {
+		case deployment.PCRSigned:
+			policy.PublicKey = d.BootConfig.UKI.PCRPublicKey
+		case deployment.PCRLock:
+			policy.PCRLock = filepath.Join(trans.Path, luks.PCRLockPolicyFile)
+		default:
+			policy.PCRs = part.Encryption.PCRs
+		}
+		err := luks.EnrollTPM2(u.s, luks.LUKSDevicePath(part), part.Encryption.KeyFile, policy)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// usesPCRLock returns true if any encrypted partition is bound to a systemd-pcrlock policy
+func usesPCRLock(d *deployment.Deployment) bool {
+	return slices.ContainsFunc(d.GetEncryptedPartitions(), func(part *deployment.Partition) bool {
+		return part.Encryption.KeySource == deployment.KeyTPM2 && part.Encryption.GetPCRPolicy() == deployment.PCRLock
+	})
+}
+
 func (u Upgrader) configHook(config string, root string) error {
 	u.s.Logger().Info("Running transaction hook")
 	callback := func() error {
@@ -20,15 +20,22 @@ package upgrade_test
 import (
 	"context"
 	"fmt"
+	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	"github.com/suse/elemental/v3/pkg/bootloader"
 	"github.com/suse/elemental/v3/pkg/deployment"
+	"github.com/suse/elemental/v3/pkg/extensions"
 	"github.com/suse/elemental/v3/pkg/firmware"
 	"github.com/suse/elemental/v3/pkg/log"
+	"github.com/suse/elemental/v3/pkg/manifest/api"
+	"github.com/suse/elemental/v3/pkg/manifest/api/core"
+	"github.com/suse/elemental/v3/pkg/manifest/resolver"
 	"github.com/suse/elemental/v3/pkg/sys"
 	sysmock "github.com/suse/elemental/v3/pkg/sys/mock"
 	"github.com/suse/elemental/v3/pkg/sys/vfs"
@@ -42,6 +49,21 @@ func TestUpgradeSuite(t *testing.T) {
 	RunSpecs(t, "Upgrade test suite")
 }
 
+// biosBootloader is a bootloader supporting legacy BIOS boot which records the target device
+type biosBootloader struct {
+	*bootloader.None
+	device string
+}
+
+func (b *biosBootloader) InstallBIOS(_, _, _, device string) error {
+	b.device = device
+	return nil
+}
+
+func (b *biosBootloader) InstallLiveBIOS(_, _ string) error {
+	return nil
+}
+
 var _ = Describe("Upgrade", Label("upgrade"), func() {
 	var runner *sysmock.Runner
 	var mounter *sysmock.Mounter
@@ -101,6 +123,281 @@ var _ = Describe("Upgrade", Label("upgrade"), func() {
 			{"/etc/elemental/config.sh"},
 		}))
 	})
+	It("configures the growth of the last partition on first boot", func() {
+		u = upgrade.New(
+			context.Background(), s, upgrade.WithTransaction(t),
+			upgrade.WithBootManager(firmware.NewEfiBootManager(s)), upgrade.WithFirstBootGrowth(true),
+		)
+		Expect(u.Upgrade(d)).To(Succeed())
+		Expect(vfs.Exists(fs, "/snapshot/path/etc/repart.d/50-system.conf")).To(BeTrue())
+	})
+	It("stores the release manifest in the new snapshot", func() {
+		rm := &resolver.ResolvedManifest{CorePlatform: &core.ReleaseManifest{
+			Metadata: &api.Metadata{Name: "suse-core", Version: "1.0.0"},
+		}}
+		u = upgrade.New(
+			context.Background(), s, upgrade.WithTransaction(t),
+			upgrade.WithBootManager(firmware.NewEfiBootManager(s)), upgrade.WithReleaseManifest(rm),
+		)
+		Expect(u.Upgrade(d)).To(Succeed())
+		stored, err := resolver.ParseManifestFile(s, "/snapshot/path")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stored.Metadata()).To(Equal(rm.Metadata()))
+	})
+	It("drops the release carried over from the previous snapshot if no release manifest is given", func() {
+		Expect(resolver.WriteManifestFile(s, "/snapshot/path", &resolver.ResolvedManifest{})).To(Succeed())
+		d.Release = &deployment.Release{Name: "suse-core", Version: "1.0.0"}
+
+		Expect(u.Upgrade(d)).To(Succeed())
+		Expect(vfs.Exists(fs, "/snapshot/path"+resolver.ManifestFile)).To(BeFalse())
+
+		stored, err := deployment.Parse(s, "/snapshot/path")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stored.Release).To(BeNil())
+	})
+	It("carries over only the systemd extensions compatible with the new OS", func() {
+		exts := []api.SystemdExtension{
+			{Name: "foo", Image: "registry.example.com/foo:1.0"},
+			{Name: "bar", Image: "https://example.com/bar.raw"},
+		}
+		Expect(extensions.Write(s, "/snapshot/path", exts)).To(Succeed())
+		Expect(vfs.MkdirAll(fs, "/snapshot/path/usr/lib", vfs.DirPerm)).To(Succeed())
+		Expect(fs.WriteFile("/snapshot/path/usr/lib/os-release", []byte("ID=sl-micro\nVERSION_ID=6.2\n"), vfs.FilePerm)).To(Succeed())
+
+		for name, release := range map[string]string{"foo": "VERSION_ID=6.2", "bar": "VERSION_ID=6.1"} {
+			dir := filepath.Join(extensions.Dir, name, extensions.ReleaseDir)
+			Expect(vfs.MkdirAll(fs, dir, vfs.DirPerm)).To(Succeed())
+			data := []byte("ID=sl-micro\n" + release + "\n")
+			Expect(fs.WriteFile(filepath.Join(dir, "extension-release."+name), data, vfs.FilePerm)).To(Succeed())
+		}
+
+		Expect(u.Upgrade(d)).To(Succeed())
+		Expect(extensions.Parse(s, "/snapshot/path")).To(Equal(exts[:1]))
+		Expect(vfs.Exists(fs, filepath.Join(extensions.Dir, "bar"))).To(BeTrue())
+	})
+	Describe("kernel modules of systemd extensions", func() {
+		exts := []api.SystemdExtension{{Name: "nvidia", Image: "https://example.com/nvidia.raw", KernelModules: []string{"nvidia"}}}
+
+		BeforeEach(func() {
+			Expect(extensions.Write(s, "/snapshot/path", exts)).To(Succeed())
+			Expect(vfs.MkdirAll(fs, "/snapshot/path/usr/lib/modules/6.12.0-default", vfs.DirPerm)).To(Succeed())
+			Expect(fs.WriteFile("/snapshot/path/usr/lib/modules/6.12.0-default/vmlinuz", []byte{}, vfs.FilePerm)).To(Succeed())
+			Expect(fs.WriteFile("/snapshot/path/usr/lib/os-release", []byte("ID=sl-micro\nVERSION_ID=6.2\n"), vfs.FilePerm)).To(Succeed())
+
+			dir := filepath.Join(extensions.Dir, "nvidia", extensions.ReleaseDir)
+			Expect(vfs.MkdirAll(fs, dir, vfs.DirPerm)).To(Succeed())
+			Expect(fs.WriteFile(filepath.Join(dir, "extension-release.nvidia"), []byte("ID=_any\n"), vfs.FilePerm)).To(Succeed())
+
+			runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+				if cmd == "modinfo" {
+					return nil, fmt.Errorf("module not found")
+				}
+				return []byte{}, nil
+			}
+		})
+		It("records the modules not found for the new kernel", func() {
+			Expect(u.Upgrade(d)).To(Succeed())
+
+			report, err := extensions.ParseModulesReport(s, "/snapshot/path")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(report.KernelVersion).To(Equal("6.12.0-default"))
+			Expect(report.Missing()).To(Equal([]extensions.ModuleStatus{{Extension: "nvidia", Module: "nvidia"}}))
+		})
+		It("fails if modules of required extensions are not found", func() {
+			required := slices.Clone(exts)
+			required[0].Required = true
+			rm := &resolver.ResolvedManifest{CorePlatform: &core.ReleaseManifest{
+				Components: core.Components{Systemd: api.Systemd{Extensions: required}},
+			}}
+			u = upgrade.New(
+				context.Background(), s, upgrade.WithTransaction(t),
+				upgrade.WithBootManager(firmware.NewEfiBootManager(s)), upgrade.WithReleaseManifest(rm),
+			)
+
+			err := u.Upgrade(d)
+			Expect(err).To(MatchError(
+				"checking kernel modules: kernel 6.12.0-default: module 'nvidia' of required extension 'nvidia' not found",
+			))
+		})
+	})
+	Describe("kubernetes upgrade", func() {
+		installed := &resolver.ResolvedManifest{CorePlatform: &core.ReleaseManifest{
+			Metadata: &api.Metadata{Name: "suse-core", Version: "1.0.0"},
+			Components: core.Components{
+				Kubernetes: &core.Kubernetes{Version: "v1.34.2+rke2r1", Image: "dir:///opt/k8s-1.34"},
+			},
+		}}
+		var target *resolver.ResolvedManifest
+
+		BeforeEach(func() {
+			target = &resolver.ResolvedManifest{CorePlatform: &core.ReleaseManifest{
+				Metadata: &api.Metadata{Name: "suse-core", Version: "1.1.0"},
+				Components: core.Components{
+					Kubernetes: &core.Kubernetes{Version: "v1.35.0+rke2r1", Image: "dir:///opt/k8s-1.35"},
+				},
+			}}
+			Expect(resolver.WriteManifestFile(s, "/", installed)).To(Succeed())
+			Expect(vfs.MkdirAll(fs, "/snapshot/path/etc/rancher/rke2", vfs.DirPerm)).To(Succeed())
+			Expect(fs.WriteFile("/snapshot/path/etc/rancher/rke2/config.yaml", []byte{}, vfs.FilePerm)).To(Succeed())
+			Expect(vfs.MkdirAll(fs, "/opt/k8s-1.35", vfs.DirPerm)).To(Succeed())
+
+			runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+				if cmd == "rsync" && strings.Contains(args[len(args)-1], "/snapshot/path"+upgrade.KubernetesArtifactsDir) {
+					return []byte{}, fs.WriteFile("/snapshot/path/usr/lib/elemental/kubernetes/install.sh", []byte{}, vfs.FilePerm)
+				}
+				return []byte{}, nil
+			}
+		})
+		It("stages the new kubernetes version in the new snapshot", func() {
+			drainer := &kubernetesDrainer{drained: true}
+			u = upgrade.New(
+				context.Background(), s, upgrade.WithTransaction(t), upgrade.WithBootManager(firmware.NewEfiBootManager(s)),
+				upgrade.WithReleaseManifest(target), upgrade.WithKubernetesDrainer(drainer),
+			)
+			Expect(u.Upgrade(d)).To(Succeed())
+			Expect(drainer.distribution).To(Equal("rke2"))
+
+			record, err := upgrade.ParseKubernetesUpgrade(s, "/snapshot/path")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*record).To(Equal(upgrade.KubernetesUpgrade{
+				Distribution: "rke2", FromVersion: "v1.34.2+rke2r1", ToVersion: "v1.35.0+rke2r1",
+				State: upgrade.KubernetesUpgradeStaged,
+			}))
+			Expect(record.Pending()).To(BeTrue())
+			drain, err := upgrade.ParseKubernetesDrain(s)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*drain).To(Equal(upgrade.KubernetesDrain{Distribution: "rke2"}))
+			Expect(drainer.uncordoned).To(BeFalse())
+			Expect(vfs.Exists(fs, "/snapshot/path/etc/systemd/system/elemental-kubernetes-upgrade.service")).To(BeTrue())
+			link, err := vfs.ReadLink(fs, "/snapshot/path/etc/systemd/system/multi-user.target.wants/elemental-kubernetes-upgrade.service")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(link).To(Equal("/etc/systemd/system/elemental-kubernetes-upgrade.service"))
+			link, err = vfs.ReadLink(fs, "/snapshot/path/etc/systemd/system/multi-user.target.wants/elemental-kubernetes-uncordon.service")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(link).To(Equal("/etc/systemd/system/elemental-kubernetes-uncordon.service"))
+		})
+		It("does not record a drain if the node can't be drained", func() {
+			u = upgrade.New(
+				context.Background(), s, upgrade.WithTransaction(t), upgrade.WithBootManager(firmware.NewEfiBootManager(s)),
+				upgrade.WithReleaseManifest(target), upgrade.WithKubernetesDrainer(&kubernetesDrainer{}),
+			)
+			Expect(u.Upgrade(d)).To(Succeed())
+			Expect(upgrade.ParseKubernetesDrain(s)).To(BeNil())
+		})
+		It("uncordons the node if the drain fails", func() {
+			drainer := &kubernetesDrainer{err: fmt.Errorf("timeout")}
+			u = upgrade.New(
+				context.Background(), s, upgrade.WithTransaction(t), upgrade.WithBootManager(firmware.NewEfiBootManager(s)),
+				upgrade.WithReleaseManifest(target), upgrade.WithKubernetesDrainer(drainer),
+			)
+			Expect(u.Upgrade(d)).To(MatchError("staging kubernetes upgrade: draining node: timeout"))
+			Expect(t.RollbackCalled()).To(BeTrue())
+			Expect(drainer.uncordoned).To(BeTrue())
+		})
+		It("uncordons the drained node if the upgrade fails after staging kubernetes", func() {
+			drainer := &kubernetesDrainer{drained: true}
+			t.CommitErr = fmt.Errorf("commit failed")
+			u = upgrade.New(
+				context.Background(), s, upgrade.WithTransaction(t), upgrade.WithBootManager(firmware.NewEfiBootManager(s)),
+				upgrade.WithReleaseManifest(target), upgrade.WithKubernetesDrainer(drainer),
+			)
+			Expect(u.Upgrade(d)).To(MatchError(ContainSubstring("commit failed")))
+			Expect(t.RollbackCalled()).To(BeTrue())
+			Expect(drainer.uncordoned).To(BeTrue())
+			Expect(upgrade.ParseKubernetesDrain(s)).To(BeNil())
+		})
+		It("does nothing if the kubernetes version does not change", func() {
+			target.CorePlatform.Components.Kubernetes.Version = "v1.34.2+rke2r1"
+			u = upgrade.New(
+				context.Background(), s, upgrade.WithTransaction(t),
+				upgrade.WithBootManager(firmware.NewEfiBootManager(s)), upgrade.WithReleaseManifest(target),
+			)
+			Expect(u.Upgrade(d)).To(Succeed())
+			Expect(upgrade.ParseKubernetesUpgrade(s, "/snapshot/path")).To(BeNil())
+			Expect(vfs.Exists(fs, "/snapshot/path"+upgrade.KubernetesArtifactsDir)).To(BeFalse())
+			Expect(vfs.Exists(fs, "/snapshot/path/etc/systemd/system/elemental-kubernetes-upgrade.service")).To(BeFalse())
+
+			// Any snapshot uncordons the node drained by a failed upgrade falling back to it
+			Expect(vfs.Exists(fs, "/snapshot/path/etc/systemd/system/multi-user.target.wants/elemental-kubernetes-uncordon.service")).To(BeTrue())
+		})
+		It("does nothing if kubernetes is not configured on the node", func() {
+			Expect(fs.Remove("/snapshot/path/etc/rancher/rke2/config.yaml")).To(Succeed())
+			u = upgrade.New(
+				context.Background(), s, upgrade.WithTransaction(t),
+				upgrade.WithBootManager(firmware.NewEfiBootManager(s)), upgrade.WithReleaseManifest(target),
+			)
+			Expect(u.Upgrade(d)).To(Succeed())
+			Expect(upgrade.ParseKubernetesUpgrade(s, "/snapshot/path")).To(BeNil())
+		})
+		It("fails if the kubernetes distribution changes", func() {
+			target.CorePlatform.Components.Kubernetes.Distribution = core.DistributionK3s
+			u = upgrade.New(
+				context.Background(), s, upgrade.WithTransaction(t),
+				upgrade.WithBootManager(firmware.NewEfiBootManager(s)), upgrade.WithReleaseManifest(target),
+			)
+			Expect(u.Upgrade(d)).To(MatchError(
+				"staging kubernetes upgrade: changing the kubernetes distribution from 'rke2' to 'k3s' is not supported",
+			))
+			Expect(t.RollbackCalled()).To(BeTrue())
+		})
+		It("fails if the kubernetes image has no install script", func() {
+			runner.SideEffect = nil
+			u = upgrade.New(
+				context.Background(), s, upgrade.WithTransaction(t),
+				upgrade.WithBootManager(firmware.NewEfiBootManager(s)), upgrade.WithReleaseManifest(target),
+			)
+			Expect(u.Upgrade(d)).To(MatchError(
+				"staging kubernetes upgrade: kubernetes install script not found in image 'dir:///opt/k8s-1.35'",
+			))
+		})
+	})
+	It("installs the bootloader for legacy BIOS to the disk of the BIOS partition", func() {
+		deployment.WithBIOSPartition()(d)
+		d.GetBIOSPartition().UUID = "bios-uuid"
+		d.BootConfig.BIOS = true
+		runner.SideEffect = func(cmd string, args ...string) ([]byte, error) {
+			if cmd == "lsblk" {
+				return []byte(`{"blockdevices": [{"partuuid": "bios-uuid", "path": "/dev/sda1", "pkname": "/dev/sda", "type": "part"}]}`), nil
+			}
+			return runner.ReturnValue, runner.ReturnError
+		}
+
+		// The default bootloader does not support legacy BIOS boot
+		Expect(u.Upgrade(d)).To(MatchError(ContainSubstring("does not support legacy BIOS boot")))
+
+		bl := &biosBootloader{None: bootloader.NewNone(s)}
+		u = upgrade.New(
+			context.Background(), s, upgrade.WithTransaction(t),
+			upgrade.WithBootManager(firmware.NewEfiBootManager(s)), upgrade.WithBootloader(bl),
+		)
+		Expect(u.Upgrade(d)).To(Succeed())
+		Expect(bl.device).To(Equal("/dev/sda"))
+	})
+	It("seals TPM2 keys bound to a pcrlock policy", func() {
+		Expect(vfs.MkdirAll(fs, "/snapshot/path/usr/lib/modules/6.4", vfs.DirPerm)).To(Succeed())
+		Expect(fs.WriteFile("/snapshot/path/usr/lib/modules/6.4/vmlinuz", []byte{}, vfs.FilePerm)).To(Succeed())
+		d.GetSystemPartition().Encryption = &deployment.Encryption{
+			KeySource: deployment.KeyTPM2, KeyFile: "/etc/keys/luks.key",
+			UUID: "sys-luks", PCRPolicy: deployment.PCRLock,
+		}
+
+		Expect(u.Upgrade(d)).To(Succeed())
+		Expect(runner.MatchMilestones([][]string{
+			{"/usr/lib/systemd/systemd-pcrlock", "lock-pe", "--pcrlock=/snapshot/path/var/lib/pcrlock.d/650-elemental-kernel.pcrlock.d/2.pcrlock"},
+			{"/usr/lib/systemd/systemd-pcrlock", "make-policy"},
+			{
+				"systemd-cryptenroll", "--unlock-key-file=/etc/keys/luks.key", "--tpm2-device=auto",
+				"--tpm2-pcrlock=/snapshot/path/var/lib/systemd/pcrlock.json", "/dev/disk/by-uuid/sys-luks",
+			},
+		})).To(Succeed())
+
+		// Keys are not enrolled again on upgrades, only the policy is updated
+		runner.ClearCmds()
+		d.GetSystemPartition().Encryption.KeyFile = ""
+		Expect(u.Upgrade(d)).To(Succeed())
+		Expect(runner.MatchMilestones([][]string{{"/usr/lib/systemd/systemd-pcrlock", "make-policy"}})).To(Succeed())
+		Expect(runner.MatchMilestones([][]string{{"systemd-cryptenroll"}})).NotTo(Succeed())
+	})
 	It("fails on transaction initialization", func() {
 		t.InitErr = fmt.Errorf("init failed")
 		err := u.Upgrade(d)
@@ -205,3 +502,21 @@ var _ = Describe("Upgrade", Label("upgrade"), func() {
 		Expect(efiBootMgrCalled).To(BeTrue())
 	})
 })
+
+// kubernetesDrainer records the drained distribution and returns the configured outcome
+type kubernetesDrainer struct {
+	distribution string
+	drained      bool
+	uncordoned   bool
+	err          error
+}
+
+func (k *kubernetesDrainer) Drain(_ context.Context, distribution string) (bool, error) {
+	k.distribution = distribution
+	return k.drained, k.err
+}
+
+func (k *kubernetesDrainer) Uncordon(_ context.Context, _ string) error {
+	k.uncordoned = true
+	return nil
+}
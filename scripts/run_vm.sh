@@ -25,6 +25,8 @@ TESTS_PATH=$(realpath -s "${SCRIPTS_PATH}/../tests")
 : "${ELMNTL_DEBUG:=false}"
 : "${ELMNTL_BRIDGE:=}"
 : "${ELMNTL_MAC:=52:54:00:12:34:56}"
+: "${ELMNTL_TPM:=false}"
+: "${ELMNTL_TPMSTATE:=${TESTS_PATH}/${ELMNTL_PREFIX}tpmstate}"
 
 function _abort {
   echo "$@" && exit 1
@@ -48,6 +50,7 @@ function start {
   local smp_arg
   local vmpid
   local kvm_arg
+  local tpm_arg
   local out
 
   if [[ -f "${ELMNTL_PIDFILE}" ]]; then
@@ -104,10 +107,19 @@ function start {
 
   [[ "${ELMNTL_SMP}" != "" ]] && smp_arg="-smp ${ELMNTL_SMP}"
 
+  # Software TPM2 emulator standing in for a hardware TPM2, it terminates with the VM
+  if [[ "${ELMNTL_TPM}" == "true" ]]; then
+    mkdir -p "${ELMNTL_TPMSTATE}"
+    swtpm socket --tpm2 --daemon --terminate \
+      --tpmstate dir="${ELMNTL_TPMSTATE}" \
+      --ctrl type=unixio,path="${ELMNTL_TPMSTATE}/swtpm-sock"
+    tpm_arg="-chardev socket,id=chrtpm,path=${ELMNTL_TPMSTATE}/swtpm-sock -tpmdev emulator,id=tpm0,chardev=chrtpm -device tpm-tis,tpmdev=tpm0"
+  fi
+
   # Generate the command line
   cmdline="qemu-system-${ELMNTL_TARGETARCH} ${kvm_arg} ${disk_arg} ${cdrom_arg} ${firmware_arg} \
              ${net_arg} ${memory_arg} ${serial_arg} ${pidfile_arg} \
-             ${display_arg} ${machine_arg} ${accel_arg} ${rngdev_arg} ${cpu_arg} ${smp_arg}"
+             ${display_arg} ${machine_arg} ${accel_arg} ${rngdev_arg} ${cpu_arg} ${smp_arg} ${tpm_arg}"
 
   # Start the VM
   eval ${cmdline} ${out}
@@ -135,6 +147,7 @@ function clean {
   ([[ -f "${ELMNTL_LOGFILE}" ]] && rm -f "${ELMNTL_LOGFILE}") || true
   ([[ -f "${ELMNTL_TESTDISK}" ]] && rm -f "${ELMNTL_TESTDISK}") || true
   ([[ -f "${ELMNTL_VMSTDOUT}" ]] && rm -f "${ELMNTL_VMSTDOUT}") || true
+  ([[ -d "${ELMNTL_TPMSTATE}" ]] && rm -rf "${ELMNTL_TPMSTATE}") || true
 }
 
 function vmpid {